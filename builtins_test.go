@@ -8,6 +8,9 @@
 package golisp
 
 import (
+	"runtime"
+	"time"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -620,3 +623,58 @@ func (s *BuiltinsSuite) TestQuoteShortcut(c *C) {
 
 	c.Assert(NilP(Cddr(result)), Equals, true)
 }
+
+// Command line arguments
+
+func (s *BuiltinsSuite) TestCommandLineArgs(c *C) {
+	commandLineArgs.Mutex.RLock()
+	original := commandLineArgs.Args
+	commandLineArgs.Mutex.RUnlock()
+	defer SetCommandLineArgs(original)
+
+	SetCommandLineArgs([]string{"host", "--verbose"})
+	code, _ := Parse("(command-line-args)")
+	result, err := Eval(code, Global)
+	c.Assert(err, IsNil)
+	c.Assert(String(result), Equals, `("host" "--verbose")`)
+}
+
+// Weak references
+
+func (s *BuiltinsSuite) TestWeakRefLetsGoOfItsValue(c *C) {
+	ref := NewWeakRef(InternalMakeList(IntegerWithValue(1), IntegerWithValue(2)))
+	for i := 0; i < 100 && ref.Value() != nil; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(ref.Value(), IsNil)
+}
+
+func (s *BuiltinsSuite) TestWeakRefKeepsAValueInUse(c *C) {
+	value := InternalMakeList(IntegerWithValue(1), IntegerWithValue(2))
+	ref, other := NewWeakRef(value), NewWeakRef(value)
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(ref.Value(), Equals, value)
+	c.Assert(other.Value(), Equals, value)
+	runtime.KeepAlive(value)
+}
+
+// Generators
+
+func (s *BuiltinsSuite) TestAbandonedGeneratorsEnd(c *C) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		code, _ := Parse("((make-generator (lambda (yield) (yield 1) (yield 2))))")
+		result, err := Eval(code, Global)
+		c.Assert(err, IsNil)
+		c.Assert(IntegerValue(result), Equals, int64(1))
+	}
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(runtime.NumGoroutine() <= before, Equals, true)
+}
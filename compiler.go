@@ -0,0 +1,546 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file implements the bytecode compiler for function bodies.
+
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+type opcode int
+
+const (
+	opLoadConst     opcode = iota // push Constants[A]
+	opLoadVar                     // push the value of the symbol Constants[A]
+	opLoadFunc                    // push the function of the call Constants[A], or if it isn't one evaluate the call and jump to B
+	opLoadFuncTail                // opLoadFunc in tail position, returning the evaluated call
+	opCall                        // call the function under the top B values with them
+	opTailCall                    // opCall in tail position, returning a tail call for user functions
+	opJump                        // continue at A
+	opBranchIfFalse               // pop a value and continue at A if it is false
+	opPop                         // drop the top value
+	opReturn                      // return the top value
+	opEval                        // push the value of Constants[A], evaluated by the interpreter
+	opEvalTail                    // opEval in tail position, returning the value
+	opLoadLocal                   // push argument A
+	opLoadPrimitive               // push the primitive Constants[C] for the call Constants[A], unless a frame slot overrides it
+)
+
+var opcodeNames = []string{"load-const", "load-var", "load-func", "load-func-tail", "call", "tail-call", "jump", "branch-if-false", "pop", "return", "eval", "eval-tail", "load-local", "load-primitive"}
+
+type instruction struct {
+	Op opcode
+	A  int
+	B  int
+	C  int
+}
+
+// CompiledCode is a function body compiled to instructions for a stack
+// machine. Calls keep their whole form as a constant so that errors read as
+// they do in the interpreter, and forms the compiler doesn't handle are
+// kept as constants for the interpreter to evaluate. Code that never hands
+// its environment to the interpreter is Frameless: it reads its arguments
+// from a slice instead of a symbol table frame.
+type CompiledCode struct {
+	Code      []instruction
+	Constants []*Data
+	Frameless bool
+}
+
+// activation is a running call of compiled code. Env is where its variables
+// are looked up; for frameless code that is the function's environment, and
+// Locals holds the arguments.
+type activation struct {
+	Function *Function
+	Env      *SymbolTableFrame
+	Locals   []*Data
+	framed   bool
+}
+
+// foldablePrimitives are the primitives whose calls with constant arguments
+// are evaluated at compile time.
+var foldablePrimitives = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "quotient": true, "modulo": true,
+	"<": true, ">": true, "<=": true, ">=": true, "==": true, "!=": true,
+	"not": true, "min": true, "max": true, "abs": true, "zero?": true, "even?": true, "odd?": true,
+}
+
+// environmentPrimitives are the primitives that work with the environment
+// they are called in, so code calling them needs its arguments in a frame.
+var environmentPrimitives = map[string]bool{
+	"bound?":               true,
+	"break":                true,
+	"debug":                true,
+	"dump":                 true,
+	"environment-bindings": true,
+	"eval":                 true,
+	"the-environment":      true,
+}
+
+// compiler compiles one function body. Calls to the protected global
+// primitives are bound when compiling, unless the function is defined in a
+// frame, whose slots could shadow them.
+type compiler struct {
+	Code      *CompiledCode
+	Env       *SymbolTableFrame
+	Params    map[*Data]bool
+	Locals    map[*Data]int
+	Integrate bool
+	Framed    bool
+	constants map[*Data]int
+}
+
+// Compile compiles f's body, returning a function that runs the compiled
+// code but otherwise is f: it has the same parameters and closes over the
+// same environment.
+func Compile(f *Function) *Function {
+	c := &compiler{Code: &CompiledCode{}, Env: f.Env, Params: make(map[*Data]bool), Locals: make(map[*Data]int), constants: make(map[*Data]int)}
+	c.Integrate = !f.Env.HasFrame()
+	// Defaults are evaluated in the frame the parameters are bound in.
+	c.Framed = !c.Integrate || f.extended != nil
+	for _, param := range parameterSymbols(f) {
+		c.Params[param] = true
+		c.Locals[param] = len(c.Locals)
+	}
+	c.Params[Intern("self")] = true
+	c.Params[Intern("parentProcess")] = true
+
+	c.compileBody(f.Body, true)
+	if c.Framed {
+		// A definition in the body's frame could override a primitive.
+		for i, ins := range c.Code.Code {
+			if ins.Op == opLoadPrimitive {
+				c.Code.Code[i].Op = opLoadFunc
+				if c.Code.Code[ins.B-1].Op == opTailCall {
+					c.Code.Code[i].Op = opLoadFuncTail
+				}
+			}
+		}
+	} else {
+		c.Code.Frameless = true
+		for i, ins := range c.Code.Code {
+			if ins.Op != opLoadVar {
+				continue
+			}
+			if local, ok := c.Locals[c.Code.Constants[ins.A]]; ok {
+				c.Code.Code[i] = instruction{Op: opLoadLocal, A: local}
+			}
+		}
+	}
+	compiled := MakeFunction(f.Name, f.Params, f.Body, f.Env)
+	compiled.SlotFunction = atomic.LoadInt32(&f.SlotFunction)
+	compiled.ParentProcess = f.ParentProcess
+	compiled.Compiled = c.Code
+	return compiled
+}
+
+// parameterSymbols lists f's parameters, the rest parameter last.
+func parameterSymbols(f *Function) []*Data {
+	if f.extended != nil {
+		return f.extended.symbols()
+	}
+	params := make([]*Data, 0, f.RequiredArgCount+1)
+	p := f.Params
+	for ; PairP(p) && NotNilP(p); p = Cdr(p) {
+		params = append(params, Car(p))
+	}
+	if SymbolP(p) {
+		params = append(params, p)
+	}
+	return params
+}
+
+func (self *compiler) emit(op opcode, a int, b int) int {
+	if op == opEval || op == opEvalTail {
+		self.Framed = true
+	}
+	self.Code.Code = append(self.Code.Code, instruction{Op: op, A: a, B: b, C: -1})
+	return len(self.Code.Code) - 1
+}
+
+func (self *compiler) constant(d *Data) int {
+	if i, ok := self.constants[d]; ok && d != nil {
+		return i
+	}
+	self.Code.Constants = append(self.Code.Constants, d)
+	self.constants[d] = len(self.Code.Constants) - 1
+	return len(self.Code.Constants) - 1
+}
+
+// here is the address of the next instruction.
+func (self *compiler) here() int {
+	return len(self.Code.Code)
+}
+
+// builtin is the primitive sym names where f is defined, if sym isn't a
+// parameter.
+func (self *compiler) builtin(sym *Data) *PrimitiveFunction {
+	if self.Params[sym] {
+		return nil
+	}
+	value := self.Env.ValueOf(sym)
+	if !PrimitiveP(value) {
+		return nil
+	}
+	return PrimitiveValue(value)
+}
+
+// integrated is the protected global primitive sym names, if calls to it
+// can be bound when compiling.
+func (self *compiler) integrated(sym *Data) *Data {
+	if !self.Integrate || self.Params[sym] {
+		return nil
+	}
+	binding, found := self.Env.FindBindingFor(sym)
+	if !found || !binding.Protected || !PrimitiveP(binding.Val) || PrimitiveValue(binding.Val).Special {
+		return nil
+	}
+	if global, found := Global.bindingOf(sym); !found || global != binding {
+		return nil
+	}
+	return binding.Val
+}
+
+// specialForm is the name of the built-in special form that form calls, or
+// "" if it calls something else.
+func (self *compiler) specialForm(form *Data) string {
+	if !SymbolP(Car(form)) {
+		return ""
+	}
+	p := self.builtin(Car(form))
+	if p == nil || !p.Special || p.Name != StringValue(Car(form)) {
+		return ""
+	}
+	return p.Name
+}
+
+// constantValue is the value of d if the compiler knows it without running
+// anything.
+func (self *compiler) constantValue(d *Data) (value *Data, ok bool) {
+	switch {
+	case d == nil:
+		return nil, true
+	case PairP(d):
+		if NilP(d) {
+			return d, true
+		}
+		if self.specialForm(d) == "quote" && Length(d) == 2 {
+			return Cadr(d), true
+		}
+		return self.fold(d)
+	case SymbolP(d):
+		if NakedP(d) {
+			return d, true
+		}
+		return nil, false
+	}
+	return d, true
+}
+
+// fold evaluates a call to a foldable primitive whose arguments are all
+// constant. A call that fails is left to fail when it runs.
+func (self *compiler) fold(form *Data) (value *Data, ok bool) {
+	if !SymbolP(Car(form)) {
+		return nil, false
+	}
+	p := self.builtin(Car(form))
+	if p == nil || p.Special || !foldablePrimitives[p.Name] || p.Name != StringValue(Car(form)) || !p.checkArgumentCount(Length(Cdr(form))) {
+		return nil, false
+	}
+	args := make([]*Data, 0, Length(Cdr(form)))
+	for a := Cdr(form); NotNilP(a); a = Cdr(a) {
+		arg, ok := self.constantValue(Car(a))
+		if !ok {
+			return nil, false
+		}
+		args = append(args, arg)
+	}
+	value, err := p.Body(ArrayToList(args), self.Env)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (self *compiler) compileBody(body *Data, tail bool) {
+	if NilP(body) {
+		self.emit(opLoadConst, self.constant(nil), 0)
+	}
+	for b := body; NotNilP(b); b = Cdr(b) {
+		last := NilP(Cdr(b))
+		self.compileExpression(Car(b), tail && last)
+		if !last {
+			self.emit(opPop, 0, 0)
+		}
+	}
+	if tail {
+		self.emit(opReturn, 0, 0)
+	}
+}
+
+// compileExpression compiles code leaving d's value on the stack or, in
+// tail position, returning it.
+func (self *compiler) compileExpression(d *Data, tail bool) {
+	if value, ok := self.constantValue(d); ok {
+		self.emit(opLoadConst, self.constant(value), 0)
+		return
+	}
+	if SymbolP(d) {
+		self.emit(opLoadVar, self.constant(d), 0)
+		return
+	}
+
+	switch self.specialForm(d) {
+	case "if":
+		if n := Length(d); n == 3 || n == 4 {
+			self.compileIf(Cadr(d), Cons(Caddr(d), nil), Cons(Car(Cdddr(d)), nil), tail)
+			return
+		}
+	case "when":
+		if Length(d) >= 3 {
+			self.compileIf(Cadr(d), Cddr(d), nil, tail)
+			return
+		}
+	case "unless":
+		if Length(d) >= 3 {
+			self.compileIf(Cadr(d), nil, Cddr(d), tail)
+			return
+		}
+	case "begin":
+		self.compileBody(Cdr(d), tail)
+		if tail {
+			self.Code.Code = self.Code.Code[:len(self.Code.Code)-1]
+		}
+		return
+	case "":
+		if SymbolP(Car(d)) && postProcessShortcuts(d) == d && properListP(d) {
+			if p := self.builtin(Car(d)); p == nil || !p.Special {
+				if MacroP(self.Env.ValueOf(Car(d))) && !self.Params[Car(d)] {
+					break
+				}
+				self.compileCall(d, tail)
+				return
+			}
+		}
+	}
+
+	if tail {
+		self.emit(opEvalTail, self.constant(d), 0)
+	} else {
+		self.emit(opEval, self.constant(d), 0)
+	}
+}
+
+// compileIf compiles a conditional; then and otherwise are bodies.
+func (self *compiler) compileIf(test *Data, then *Data, otherwise *Data, tail bool) {
+	if value, ok := self.constantValue(test); ok {
+		if BooleanValue(value) {
+			self.compileIfBranch(then, tail)
+		} else {
+			self.compileIfBranch(otherwise, tail)
+		}
+		return
+	}
+
+	self.compileExpression(test, false)
+	branch := self.emit(opBranchIfFalse, 0, 0)
+	self.compileIfBranch(then, tail)
+	jump := -1
+	if !tail {
+		jump = self.emit(opJump, 0, 0)
+	}
+	self.Code.Code[branch].A = self.here()
+	self.compileIfBranch(otherwise, tail)
+	if jump != -1 {
+		self.Code.Code[jump].A = self.here()
+	}
+}
+
+func (self *compiler) compileIfBranch(body *Data, tail bool) {
+	if NilP(body) {
+		self.compileExpression(nil, tail)
+		if tail {
+			self.emit(opReturn, 0, 0)
+		}
+		return
+	}
+	self.compileBody(body, tail)
+}
+
+func (self *compiler) compileCall(form *Data, tail bool) {
+	formIndex := self.constant(form)
+	var loadFunc int
+	if self.Params[Car(form)] || (SymbolP(Car(form)) && environmentPrimitives[StringValue(Car(form))]) {
+		self.Framed = true
+	}
+	if primitive := self.integrated(Car(form)); primitive != nil {
+		loadFunc = self.emit(opLoadPrimitive, formIndex, 0)
+		self.Code.Code[loadFunc].C = self.constant(primitive)
+	} else if tail {
+		loadFunc = self.emit(opLoadFuncTail, formIndex, 0)
+	} else {
+		loadFunc = self.emit(opLoadFunc, formIndex, 0)
+	}
+	for a := Cdr(form); NotNilP(a); a = Cdr(a) {
+		self.compileExpression(Car(a), false)
+	}
+	if tail {
+		self.emit(opTailCall, formIndex, Length(Cdr(form)))
+	} else {
+		self.emit(opCall, formIndex, Length(Cdr(form)))
+	}
+	self.Code.Code[loadFunc].B = self.here()
+}
+
+// String lists the instructions, one per line.
+func (self *CompiledCode) String() string {
+	lines := make([]string, len(self.Code))
+	for i, ins := range self.Code {
+		switch ins.Op {
+		case opLoadConst, opLoadVar, opEval, opEvalTail:
+			lines[i] = fmt.Sprintf("%3d %s %s", i, opcodeNames[ins.Op], String(self.Constants[ins.A]))
+		case opLoadFunc, opLoadFuncTail, opLoadPrimitive:
+			lines[i] = fmt.Sprintf("%3d %s %s %d", i, opcodeNames[ins.Op], String(Car(self.Constants[ins.A])), ins.B)
+		case opCall, opTailCall:
+			lines[i] = fmt.Sprintf("%3d %s %d", i, opcodeNames[ins.Op], ins.B)
+		case opJump, opBranchIfFalse, opLoadLocal:
+			lines[i] = fmt.Sprintf("%3d %s %d", i, opcodeNames[ins.Op], ins.A)
+		default:
+			lines[i] = fmt.Sprintf("%3d %s", i, opcodeNames[ins.Op])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// argumentList makes a list of values for a call, allocating its cells
+// together. Each Data is allocated on its own since a weak reference needs
+// to set a finalizer on it.
+func argumentList(values []*Data) *Data {
+	if len(values) == 0 {
+		return nil
+	}
+	cells := make([]ConsCell, len(values))
+	var list *Data
+	for i := len(values) - 1; i >= 0; i-- {
+		value := SingleValue(values[i])
+		if value == nil {
+			value = EmptyCons()
+		}
+		cells[i].Car = value
+		cells[i].Cdr = list
+		list = &Data{Type: ConsCellType, Value: unsafe.Pointer(&cells[i])}
+	}
+	return list
+}
+
+// frame gives a frameless activation a symbol table frame holding its
+// arguments, for the interpreter to evaluate a form in. The arguments can't
+// have changed, as frameless code doesn't assign to them.
+func (self *activation) frame() *SymbolTableFrame {
+	if self.Locals != nil && !self.framed {
+		localEnv := NewSymbolTableFrameBelowWithFrame(self.Function.Env, nil, self.Function.Name)
+		for i, param := range parameterSymbols(self.Function) {
+			localEnv.BindLocallyTo(param, self.Locals[i])
+		}
+		self.Env = localEnv
+		self.framed = true
+	}
+	return self.Env
+}
+
+// run executes the code for a. Like a body run by the interpreter, it may
+// return a tail call.
+func (self *CompiledCode) run(a *activation) (result *Data, err error) {
+	env := a.Env
+	stack := make([]*Data, 0, 8)
+	for pc := 0; ; {
+		ins := self.Code[pc]
+		pc++
+		switch ins.Op {
+		case opLoadConst:
+			stack = append(stack, self.Constants[ins.A])
+		case opLoadVar:
+			stack = append(stack, env.ValueOf(self.Constants[ins.A]))
+		case opLoadLocal:
+			stack = append(stack, a.Locals[ins.A])
+		case opLoadPrimitive:
+			if env.HasFrame() {
+				stack = append(stack, env.ValueOfWithFunctionSlotCheck(Car(self.Constants[ins.A]), true))
+			} else {
+				stack = append(stack, self.Constants[ins.C])
+			}
+		case opLoadFunc, opLoadFuncTail:
+			form := self.Constants[ins.A]
+			function := env.ValueOfWithFunctionSlotCheck(Car(form), true)
+			if NilP(function) {
+				err = errors.New(fmt.Sprintf("Nil when function or macro expected for %s.", String(Car(form))))
+				return
+			}
+			if TypeOf(function) == FunctionType || (PrimitiveP(function) && !PrimitiveValue(function).Special) {
+				stack = append(stack, function)
+				continue
+			}
+			env = a.frame()
+			if ins.Op == opLoadFuncTail {
+				return evalTail(form, env)
+			}
+			result, err = Eval(form, env)
+			if err != nil {
+				return
+			}
+			stack = append(stack, result)
+			pc = ins.B
+		case opCall, opTailCall:
+			function := stack[len(stack)-ins.B-1]
+			args := argumentList(stack[len(stack)-ins.B:])
+			stack = stack[:len(stack)-ins.B-1]
+			if ins.Op == opTailCall && TypeOf(function) == FunctionType {
+				if f := FunctionValue(function); atomic.LoadInt32(&f.SlotFunction) != 1 || !env.HasFrame() {
+					return TailCallWithFunctionAndArgs(f, args, env), nil
+				}
+			}
+			if TypeOf(function) == FunctionType {
+				result, err = ApplyWithoutEval(function, args, env)
+			} else {
+				result, err = PrimitiveValue(function).applyToValues(args, env)
+			}
+			if err != nil {
+				form := self.Constants[ins.A]
+				err = fmt.Errorf("\nEvaling %s. %w", String(form), locateError(err, form))
+				return
+			}
+			if ins.Op == opTailCall {
+				return result, nil
+			}
+			stack = append(stack, result)
+		case opJump:
+			pc = ins.A
+		case opBranchIfFalse:
+			test := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !BooleanValue(SingleValue(test)) {
+				pc = ins.A
+			}
+		case opPop:
+			stack = stack[:len(stack)-1]
+		case opReturn:
+			return stack[len(stack)-1], nil
+		case opEval:
+			result, err = Eval(self.Constants[ins.A], env)
+			if err != nil {
+				return
+			}
+			stack = append(stack, result)
+		case opEvalTail:
+			return evalTail(self.Constants[ins.A], env)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file tests the bytecode compiler.
+
+package golisp
+
+import (
+	"unsafe"
+
+	. "gopkg.in/check.v1"
+)
+
+type CompilerSuite struct {
+}
+
+var _ = Suite(&CompilerSuite{})
+
+func (s *CompilerSuite) SetUpSuite(c *C) {
+	InitLisp()
+}
+
+func (s *CompilerSuite) compile(c *C, code string) *Function {
+	f, err := ParseAndEval(code)
+	c.Assert(err, IsNil)
+	c.Assert(FunctionP(f), Equals, true)
+	return Compile(FunctionValue(f))
+}
+
+func (s *CompilerSuite) TestConstantFolding(c *C) {
+	f := s.compile(c, "(lambda () (+ 1 (* 2 3)))")
+	c.Assert(f.Compiled.String(), Equals, "  0 load-const 7\n  1 return")
+}
+
+func (s *CompilerSuite) TestFramelessArguments(c *C) {
+	f := s.compile(c, "(lambda (x) (+ x 1))")
+	c.Assert(f.Compiled.Frameless, Equals, true)
+	result, err := Apply(&Data{Type: FunctionType, Value: unsafe.Pointer(f)}, InternalMakeList(IntegerWithValue(41)), Global)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(42))
+}
+
+func (s *CompilerSuite) TestFallbackNeedsFrame(c *C) {
+	f := s.compile(c, "(lambda (x) (let ((y x)) y))")
+	c.Assert(f.Compiled.Frameless, Equals, false)
+}
@@ -11,8 +11,10 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"unsafe"
@@ -37,11 +39,17 @@ const (
 	FrameType
 	EnvironmentType
 	PortType
+	BignumType
+	RationalType
+	ComplexType
+	CharacterType
+	TailCallType
 )
 
 type ConsCell struct {
-	Car *Data
-	Cdr *Data
+	Car      *Data
+	Cdr      *Data
+	Location *SourceLocation
 }
 
 type BoxedObject struct {
@@ -120,6 +128,16 @@ func TypeName(t uint8) string {
 		return "Environment"
 	case PortType:
 		return "Port"
+	case BignumType:
+		return "Bignum"
+	case RationalType:
+		return "Rational"
+	case ComplexType:
+		return "Complex"
+	case CharacterType:
+		return "Character"
+	case TailCallType:
+		return "TailCall"
 	default:
 		return "Unknown"
 	}
@@ -154,6 +172,17 @@ func ListP(d *Data) bool {
 	return PairP(d) || AlistP(d)
 }
 
+// ProperListP is whether d is a list that ends in nil rather than in some
+// other value, as (1 . 2) does.
+func ProperListP(d *Data) bool {
+	for c := d; NotNilP(c); c = Cdr(c) {
+		if !ListP(c) {
+			return false
+		}
+	}
+	return true
+}
+
 func DottedPairP(d *Data) bool {
 	return d == nil || TypeOf(d) == AlistCellType
 }
@@ -186,8 +215,35 @@ func FloatP(d *Data) bool {
 	return d != nil && TypeOf(d) == FloatType
 }
 
+func BignumP(d *Data) bool {
+	return d != nil && TypeOf(d) == BignumType
+}
+
+// ExactIntegerP is true for both fixnums and bignums.
+func ExactIntegerP(d *Data) bool {
+	return IntegerP(d) || BignumP(d)
+}
+
+func RationalP(d *Data) bool {
+	return d != nil && TypeOf(d) == RationalType
+}
+
+// ExactP is true for numbers with no rounding error: integers and rationals.
+func ExactP(d *Data) bool {
+	return ExactIntegerP(d) || RationalP(d)
+}
+
+// NumberP is true for the real numbers. Complex numbers are ComplexP.
 func NumberP(d *Data) bool {
-	return IntegerP(d) || FloatP(d)
+	return IntegerP(d) || FloatP(d) || BignumP(d) || RationalP(d)
+}
+
+func ComplexP(d *Data) bool {
+	return d != nil && TypeOf(d) == ComplexType
+}
+
+func CharacterP(d *Data) bool {
+	return d != nil && TypeOf(d) == CharacterType
 }
 
 func ObjectP(d *Data) bool {
@@ -306,13 +362,19 @@ func RemoveFromListBang(l *Data, item *Data) (result *Data) {
 	return
 }
 
+// aconsCell puts a new (car . cdr) pair on the front of alist, without
+// looking for one with the same key as Acons does.
+func aconsCell(car *Data, cdr *Data, alist *Data) *Data {
+	p := ConsCell{Car: car, Cdr: cdr}
+	cell := Data{Type: AlistCellType, Value: unsafe.Pointer(&p)}
+	conscell := ConsCell{Car: &cell, Cdr: alist}
+	return &Data{Type: AlistType, Value: unsafe.Pointer(&conscell)}
+}
+
 func Acons(car *Data, cdr *Data, alist *Data) *Data {
 	pair, _ := Assoc(car, alist)
 	if NilP(pair) {
-		p := ConsCell{Car: car, Cdr: cdr}
-		cell := Data{Type: AlistCellType, Value: unsafe.Pointer(&p)}
-		conscell := ConsCell{Car: &cell, Cdr: alist}
-		return &Data{Type: AlistType, Value: unsafe.Pointer(&conscell)}
+		return aconsCell(car, cdr, alist)
 	} else {
 		((*ConsCell)(pair.Value)).Cdr = cdr
 		return alist
@@ -348,6 +410,40 @@ func IntegerWithValue(n int64) *Data {
 	return &Data{Type: IntegerType, Value: unsafe.Pointer(&n)}
 }
 
+func BignumWithValue(n *big.Int) *Data {
+	return &Data{Type: BignumType, Value: unsafe.Pointer(n)}
+}
+
+// ExactIntegerWithValue demotes n to a fixnum when it fits in an int64 and
+// keeps it as a bignum otherwise.
+func ExactIntegerWithValue(n *big.Int) *Data {
+	if n.IsInt64() {
+		return IntegerWithValue(n.Int64())
+	}
+	return BignumWithValue(n)
+}
+
+// RationalWithValue reduces r to an integer when its denominator is 1.
+// big.Rat always keeps itself in lowest terms.
+func RationalWithValue(r *big.Rat) *Data {
+	if r.IsInt() {
+		return ExactIntegerWithValue(new(big.Int).Set(r.Num()))
+	}
+	return &Data{Type: RationalType, Value: unsafe.Pointer(r)}
+}
+
+// ComplexWithValue reduces c to a float when its imaginary part is 0.
+func ComplexWithValue(c complex128) *Data {
+	if imag(c) == 0 {
+		return FloatWithValue(float32(real(c)))
+	}
+	return &Data{Type: ComplexType, Value: unsafe.Pointer(&c)}
+}
+
+func CharacterWithValue(ch rune) *Data {
+	return &Data{Type: CharacterType, Value: unsafe.Pointer(&ch)}
+}
+
 func FloatWithValue(n float32) *Data {
 	return &Data{Type: FloatType, Value: unsafe.Pointer(&n)}
 }
@@ -373,7 +469,14 @@ func SetStringValue(d *Data, s string) *Data {
 	}
 }
 
+// SymbolWithName is the interned symbol called s.
 func SymbolWithName(s string) *Data {
+	return Intern(s)
+}
+
+// UninternedSymbolWithName makes a new symbol called s that is distinct
+// from every other symbol, including the interned one with the same name.
+func UninternedSymbolWithName(s string) *Data {
 	return &Data{Type: SymbolType, Value: unsafe.Pointer(&s)}
 }
 
@@ -469,9 +572,58 @@ func IntegerValue(d *Data) int64 {
 		return int64(*((*float32)(d.Value)))
 	}
 
+	if BignumP(d) {
+		return (*big.Int)(d.Value).Int64()
+	}
+
+	if RationalP(d) {
+		r := (*big.Rat)(d.Value)
+		return new(big.Int).Quo(r.Num(), r.Denom()).Int64()
+	}
+
 	return 0
 }
 
+// RationalValue returns the value of any exact number as a big.Rat.
+// Rationals return their own value so callers must not modify it.
+func RationalValue(d *Data) *big.Rat {
+	if RationalP(d) {
+		return (*big.Rat)(d.Value)
+	}
+
+	if ExactIntegerP(d) {
+		return new(big.Rat).SetInt(BignumValue(d))
+	}
+
+	if FloatP(d) {
+		r := new(big.Rat)
+		if r.SetFloat64(float64(FloatValue(d))) != nil {
+			return r
+		}
+	}
+
+	return new(big.Rat)
+}
+
+// BignumValue returns the value of any exact integer as a big.Int. Bignums
+// return their own value so callers must not modify it.
+func BignumValue(d *Data) *big.Int {
+	if BignumP(d) {
+		return (*big.Int)(d.Value)
+	}
+
+	if IntegerP(d) {
+		return big.NewInt(IntegerValue(d))
+	}
+
+	if FloatP(d) {
+		n, _ := big.NewFloat(float64(FloatValue(d))).Int(nil)
+		return n
+	}
+
+	return new(big.Int)
+}
+
 func FloatValue(d *Data) float32 {
 	if d == nil {
 		return 0
@@ -485,6 +637,60 @@ func FloatValue(d *Data) float32 {
 		return float32(*((*int64)(d.Value)))
 	}
 
+	if BignumP(d) {
+		f, _ := new(big.Float).SetInt((*big.Int)(d.Value)).Float32()
+		return f
+	}
+
+	if RationalP(d) {
+		f, _ := (*big.Rat)(d.Value).Float32()
+		return f
+	}
+
+	return 0
+}
+
+// ComplexValue returns the value of any number as a complex128.
+func ComplexValue(d *Data) complex128 {
+	if ComplexP(d) {
+		return *((*complex128)(d.Value))
+	}
+
+	if IntegerP(d) {
+		return complex(float64(IntegerValue(d)), 0)
+	}
+
+	if FloatP(d) {
+		return complex(float64(FloatValue(d)), 0)
+	}
+
+	if ExactP(d) {
+		f, _ := RationalValue(d).Float64()
+		return complex(f, 0)
+	}
+
+	return 0
+}
+
+// complexString writes c the way the reader reads it, as in 3+4i. The parts
+// are written without exponents since the reader doesn't accept them.
+func complexString(c complex128) string {
+	sign := "+"
+	if math.Signbit(imag(c)) {
+		sign = ""
+	}
+	re := real(c)
+	if re == 0 {
+		// Written as 0 even when it is -0, which arithmetic on i can give.
+		re = 0
+	}
+	return fmt.Sprintf("%s%s%si", strconv.FormatFloat(re, 'f', -1, 64), sign, strconv.FormatFloat(imag(c), 'f', -1, 64))
+}
+
+func CharacterValue(d *Data) rune {
+	if CharacterP(d) {
+		return *((*rune)(d.Value))
+	}
 	return 0
 }
 
@@ -639,6 +845,10 @@ func Length(d *Data) int {
 		return len(dBytes)
 	}
 
+	if VectorP(d) {
+		return len(VectorValue(d).Elements)
+	}
+
 	if FrameP(d) {
 		frame := FrameValue(d)
 		frame.Mutex.RLock()
@@ -667,55 +877,61 @@ func Reverse(d *Data) (result *Data) {
 	return l
 }
 
+// Flatten splices the elements of any lists in d into it, one level deep.
 func Flatten(d *Data) (result *Data, err error) {
-	if d == nil {
-		return nil, nil
-	}
+	return FlattenToDepth(d, 1)
+}
 
-	if !ListP(d) {
+// RecursiveFlatten splices the elements of lists in d into it at every level.
+func RecursiveFlatten(d *Data) (result *Data, err error) {
+	return FlattenToDepth(d, -1)
+}
+
+// FlattenToDepth splices the elements of lists in d into it, going depth
+// levels deep, or all the way down if depth is negative. Empty lists
+// disappear and the tail of an improper list is kept as an element. A cyclic
+// list is an error rather than a hang. Anything but a list is returned as is.
+func FlattenToDepth(d *Data, depth int) (result *Data, err error) {
+	if d == nil || !ListP(d) {
 		return d, nil
 	}
 
-	var l []*Data = make([]*Data, 0, 10)
-	for c := d; NotNilP(c); c = Cdr(c) {
-		if ListP(Car(c)) {
-			for i := Car(c); i != nil; i = Cdr(i) {
-				l = append(l, Car(i))
-			}
-		} else {
-			l = append(l, Car(c))
-		}
+	l, ok := flattenInto(make([]*Data, 0, 10), d, depth, make(map[*Data]bool))
+	if !ok {
+		return nil, errors.New("Can't flatten a cyclic list.")
 	}
-
 	return ArrayToList(l), nil
 }
 
-func RecursiveFlatten(d *Data) (result *Data, err error) {
-	if d == nil {
-		return nil, nil
-	}
-
-	if !ListP(d) {
-		return d, nil
-	}
+// flattenInto appends the flattened elements of d to l. visiting holds the
+// cells of the lists being flattened, so coming back to one means a cycle.
+func flattenInto(l []*Data, d *Data, depth int, visiting map[*Data]bool) (result []*Data, ok bool) {
+	var cells []*Data
+	defer func() {
+		for _, c := range cells {
+			delete(visiting, c)
+		}
+	}()
 
-	var l []*Data = make([]*Data, 0, 10)
-	var elem *Data
 	for c := d; NotNilP(c); c = Cdr(c) {
-		if ListP(Car(c)) {
-			elem, err = RecursiveFlatten(Car(c))
-			if err != nil {
+		if !ListP(c) {
+			return append(l, c), true
+		}
+		if visiting[c] {
+			return nil, false
+		}
+		visiting[c] = true
+		cells = append(cells, c)
+
+		if depth != 0 && ListP(Car(c)) {
+			if l, ok = flattenInto(l, Car(c), depth-1, visiting); !ok {
 				return
 			}
-			for i := elem; i != nil; i = Cdr(i) {
-				l = append(l, Car(i))
-			}
 		} else {
 			l = append(l, Car(c))
 		}
 	}
-
-	return ArrayToList(l), nil
+	return l, true
 }
 
 func QuoteIt(value *Data) (result *Data) {
@@ -728,12 +944,8 @@ func QuoteIt(value *Data) (result *Data) {
 
 func QuoteAll(d *Data) (result *Data) {
 	var l []*Data = make([]*Data, 0, 10)
-	if d == nil {
-		l = append(l, QuoteIt(nil))
-	} else {
-		for c := d; c != nil; c = Cdr(c) {
-			l = append(l, QuoteIt(Car(c)))
-		}
+	for c := d; NotNilP(c); c = Cdr(c) {
+		l = append(l, QuoteIt(Car(c)))
 	}
 	return ArrayToList(l)
 }
@@ -815,7 +1027,126 @@ func Copy(d *Data) *Data {
 	return d
 }
 
+// DeepCopy copies d all the way down: lists, frames, vectors, hash tables,
+// sets, structures and byte arrays are copied, and so is everything in them,
+// so changing the copy never changes d. Structure shared within d, cycles
+// included, is shared the same way in the copy. Everything else, numbers,
+// strings and symbols among it, is not copied.
+func DeepCopy(d *Data) *Data {
+	return deepCopy(d, make(map[unsafe.Pointer]*Data))
+}
+
+// deepCopy does the work of DeepCopy; copies maps each compound value
+// already copied to its copy.
+func deepCopy(d *Data, copies map[unsafe.Pointer]*Data) *Data {
+	if d == nil || d.Value == nil {
+		return d
+	}
+	if copied, found := copies[d.Value]; found {
+		return copied
+	}
+
+	switch d.Type {
+	case ConsCellType, AlistType, AlistCellType:
+		if NilP(d) {
+			return d
+		}
+		cell := &ConsCell{}
+		copied := &Data{Type: d.Type, Value: unsafe.Pointer(cell)}
+		copies[d.Value] = copied
+		cell.Car = deepCopy(Car(d), copies)
+		cell.Cdr = deepCopy(Cdr(d), copies)
+		return copied
+	case FrameType:
+		m := &FrameMap{Data: make(FrameMapData)}
+		copied := FrameWithValue(m)
+		copies[d.Value] = copied
+		frame := FrameValue(d)
+		frame.Mutex.RLock()
+		slots := make(FrameMapData, len(frame.Data))
+		for k, v := range frame.Data {
+			slots[k] = v
+		}
+		frame.Mutex.RUnlock()
+		for k, v := range slots {
+			m.Data[k] = deepCopy(v, copies)
+		}
+		return copied
+	case BoxedObjectType:
+		return deepCopyObject(d, copies)
+	}
+	return d
+}
+
+func deepCopyObject(d *Data, copies map[unsafe.Pointer]*Data) *Data {
+	switch ObjectType(d) {
+	case "[]byte":
+		bytes := append([]byte{}, *(*[]byte)(ObjectValue(d))...)
+		return ObjectWithTypeAndValue("[]byte", unsafe.Pointer(&bytes))
+	case "Vector":
+		vector := VectorValue(d)
+		v := &Vector{Elements: make([]*Data, len(vector.Elements)), Immutable: vector.Immutable}
+		copied := ObjectWithTypeAndValue("Vector", unsafe.Pointer(v))
+		copies[d.Value] = copied
+		for i, element := range vector.Elements {
+			v.Elements[i] = deepCopy(element, copies)
+		}
+		return copied
+	case "HashTable":
+		table := &HashTable{Entries: make(map[string]hashEntry)}
+		copied := ObjectWithTypeAndValue("HashTable", unsafe.Pointer(table))
+		copies[d.Value] = copied
+		original := (*HashTable)(ObjectValue(d))
+		original.Mutex.RLock()
+		entries := make(map[string]hashEntry, len(original.Entries))
+		for k, entry := range original.Entries {
+			entries[k] = entry
+		}
+		original.Mutex.RUnlock()
+		for k, entry := range entries {
+			table.Entries[k] = hashEntry{Key: deepCopy(entry.Key, copies), Value: deepCopy(entry.Value, copies)}
+		}
+		return copied
+	case "Set":
+		elements := SetValue(d).snapshot()
+		set := &Set{Elements: make(map[string]*Data, len(elements))}
+		copied := ObjectWithTypeAndValue("Set", unsafe.Pointer(set))
+		copies[d.Value] = copied
+		for k, element := range elements {
+			set.Elements[k] = deepCopy(element, copies)
+		}
+		return copied
+	case "Structure":
+		original := StructureValue(d)
+		s := &Structure{Type: original.Type}
+		copied := ObjectWithTypeAndValue("Structure", unsafe.Pointer(s))
+		copies[d.Value] = copied
+		values := original.snapshot()
+		s.Values = make([]*Data, len(values))
+		for i, value := range values {
+			s.Values[i] = deepCopy(value, copies)
+		}
+		return copied
+	}
+	return d
+}
+
+// IsEqual is equal?: structures are compared element by element and
+// atoms by value. Circular structures are handled by treating a pair of
+// cells that is already being compared as equal. Exact and inexact numbers
+// are never equal, so (equal? 1 1.0) is false, but exact numbers compare
+// by value whatever their representation.
 func IsEqual(d *Data, o *Data) bool {
+	return isEqual(d, o, nil)
+}
+
+// eqvPair identifies a pair of compound values that isEqual has started
+// comparing.
+type eqvPair struct {
+	d, o unsafe.Pointer
+}
+
+func isEqual(d *Data, o *Data, visited map[eqvPair]bool) bool {
 	if d == o && !FloatP(d) {
 		return true
 	}
@@ -836,6 +1167,12 @@ func IsEqual(d *Data, o *Data) bool {
 		if !PairP(o) && !DottedPairP(o) {
 			return false
 		}
+	} else if (BignumP(d) || BignumP(o)) && ExactIntegerP(d) && ExactIntegerP(o) {
+		return BignumValue(d).Cmp(BignumValue(o)) == 0
+	} else if RationalP(d) && RationalP(o) {
+		return RationalValue(d).Cmp(RationalValue(o)) == 0
+	} else if ComplexP(d) && ComplexP(o) {
+		return ComplexValue(d) == ComplexValue(o)
 	} else if TypeOf(o) != TypeOf(d) {
 		return false
 	}
@@ -846,7 +1183,7 @@ func IsEqual(d *Data, o *Data) bool {
 		}
 		for c := d; NotNilP(c); c = Cdr(c) {
 			otherPair, err := Assoc(Caar(c), o)
-			if err != nil || NilP(otherPair) || !IsEqual(Cdar(c), Cdr(otherPair)) {
+			if err != nil || NilP(otherPair) || !isEqual(Cdar(c), Cdr(otherPair), visited) {
 				return false
 			}
 		}
@@ -854,19 +1191,28 @@ func IsEqual(d *Data, o *Data) bool {
 	}
 
 	if DottedPairP(d) {
-		return IsEqual(Car(d), Car(o)) && IsEqual(Cdr(d), Cdr(o))
+		return isEqual(Car(d), Car(o), visited) && isEqual(Cdr(d), Cdr(o), visited)
 	}
 
 	if ListP(d) {
-		if Length(d) != Length(o) {
-			return false
+		if visited == nil {
+			visited = make(map[eqvPair]bool)
 		}
-		for a1, a2 := d, o; NotNilP(a1); a1, a2 = Cdr(a1), Cdr(a2) {
-			if !IsEqual(Car(a1), Car(a2)) {
+		a1, a2 := d, o
+		for ; PairP(a1) && NotNilP(a1) && PairP(a2) && NotNilP(a2); a1, a2 = Cdr(a1), Cdr(a2) {
+			cells := eqvPair{a1.Value, a2.Value}
+			if visited[cells] {
+				return true
+			}
+			visited[cells] = true
+			if !isEqual(Car(a1), Car(a2), visited) {
 				return false
 			}
 		}
-		return true
+		if NilP(a1) || NilP(a2) {
+			return NilP(a1) && NilP(a2)
+		}
+		return isEqual(a1, a2, visited)
 	}
 
 	if FrameP(d) {
@@ -880,7 +1226,7 @@ func IsEqual(d *Data, o *Data) bool {
 			return false
 		}
 		for k, v := range frameD.Data {
-			if !IsEqual(v, frameO.Data[k]) {
+			if !isEqual(v, frameO.Data[k], visited) {
 				frameO.Mutex.RUnlock()
 				frameD.Mutex.RUnlock()
 				return false
@@ -908,6 +1254,45 @@ func IsEqual(d *Data, o *Data) bool {
 		return true
 	}
 
+	// vectors compare element by element
+	if VectorP(d) && VectorP(o) {
+		if visited == nil {
+			visited = make(map[eqvPair]bool)
+		}
+		vectors := eqvPair{ObjectValue(d), ObjectValue(o)}
+		if visited[vectors] {
+			return true
+		}
+		visited[vectors] = true
+		dElements := VectorValue(d).Elements
+		oElements := VectorValue(o).Elements
+		if len(dElements) != len(oElements) {
+			return false
+		}
+		for i := range dElements {
+			if !isEqual(dElements[i], oElements[i], visited) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if SetP(d) && SetP(o) {
+		return setsEqual(SetValue(d), SetValue(o))
+	}
+
+	if StructureP(d) && StructureP(o) {
+		if visited == nil {
+			visited = make(map[eqvPair]bool)
+		}
+		structures := eqvPair{ObjectValue(d), ObjectValue(o)}
+		if visited[structures] {
+			return true
+		}
+		visited[structures] = true
+		return structuresEqual(StructureValue(d), StructureValue(o), visited)
+	}
+
 	switch TypeOf(d) {
 	case IntegerType:
 		return IntegerValue(d) == IntegerValue(o)
@@ -915,8 +1300,12 @@ func IsEqual(d *Data, o *Data) bool {
 		return FloatValue(d) == FloatValue(o)
 	case BooleanType:
 		return BooleanValue(d) == BooleanValue(o)
-	case StringType, SymbolType: // check symbols not generated using intern (aka: gensym and gensym-naked)
+	case StringType:
 		return StringValue(d) == StringValue(o)
+	case CharacterType:
+		return CharacterValue(d) == CharacterValue(o)
+	case SymbolType:
+		return d == o
 	case FunctionType:
 		return FunctionValue(d) == FunctionValue(o)
 	case MacroType:
@@ -930,22 +1319,129 @@ func IsEqual(d *Data, o *Data) bool {
 	return *d == *o
 }
 
-func escapeQuotes(str string) string {
+// IsEqv is eqv?: numbers of the same exactness compare by value, as do
+// booleans, symbols and empty lists. Everything else, including strings,
+// lists and vectors, is only eqv? to itself.
+func IsEqv(d *Data, o *Data) bool {
+	if NilP(d) || NilP(o) {
+		return NilP(d) && NilP(o)
+	}
+
+	if NumberP(d) && NumberP(o) {
+		return FloatP(d) == FloatP(o) && IsEqual(d, o)
+	}
+
+	if ComplexP(d) && ComplexP(o) {
+		return IsEqual(d, o)
+	}
+
+	if TypeOf(d) != TypeOf(o) {
+		return false
+	}
+
+	switch TypeOf(d) {
+	case BooleanType, CharacterType, SymbolType, FunctionType, MacroType, PrimitiveType:
+		return IsEqual(d, o)
+	case BoxedObjectType:
+		return ObjectType(d) == ObjectType(o) && ObjectValue(d) == ObjectValue(o)
+	case ConsCellType, AlistType, AlistCellType, FrameType:
+		return d.Value == o.Value
+	}
+
+	return d == o
+}
+
+// IsEq is eq?, identity. Symbols are interned, so those with the same name
+// are eq? (uninterned ones only to themselves), and fixnums, booleans,
+// characters and empty lists are eq? when they have the same value, as if
+// there were only one of each. Bignums, rationals, floats and complex
+// numbers are only eq? to themselves, even if they are =, as are strings and
+// everything else; eqv? and equal? compare those by value.
+func IsEq(d *Data, o *Data) bool {
+	if IntegerP(d) && IntegerP(o) {
+		return IntegerValue(d) == IntegerValue(o)
+	}
+	if NumberP(d) || ComplexP(d) || NumberP(o) || ComplexP(o) {
+		return d == o
+	}
+	return IsEqv(d, o)
+}
+
+// escapeString escapes what the reader needs escaped inside a string
+// literal: quotes, backslashes and newlines.
+func escapeString(str string) string {
 	buffer := make([]rune, 0, 10)
 	for _, ch := range str {
-		if rune(ch) == '"' {
-			buffer = append(buffer, '\\')
+		switch ch {
+		case '"', '\\':
+			buffer = append(buffer, '\\', ch)
+		case '\n':
+			buffer = append(buffer, '\\', 'n')
+		default:
+			buffer = append(buffer, ch)
 		}
-		buffer = append(buffer, rune(ch))
 	}
 	return string(buffer)
 }
 
+// String writes d the way the reader would read it back. It honours the
+// *print-depth* and *print-length* limits, and labels any structure that
+// contains itself with #N= and #N# so that circular data can be printed.
+// The limits are the ones outside any parameterize.
 func String(d *Data) string {
+	return StringInEnv(d, nil)
+}
+
+// StringInEnv is String with the print limits in effect in env.
+func StringInEnv(d *Data, env *SymbolTableFrame) string {
+	return newPrinter(d, false, env).write(d, 0)
+}
+
+// SharedString is String but labels all shared structure, not only cycles.
+func SharedString(d *Data) string {
+	return SharedStringInEnv(d, nil)
+}
+
+// SharedStringInEnv is SharedString with the print limits in effect in env.
+func SharedStringInEnv(d *Data, env *SymbolTableFrame) string {
+	return newPrinter(d, true, env).write(d, 0)
+}
+
+// DisplayString is String for people rather than the reader: strings, even
+// nested ones, are written without quotes or escapes.
+func DisplayString(d *Data) string {
+	return DisplayStringInEnv(d, nil)
+}
+
+// DisplayStringInEnv is DisplayString with the print limits in effect in env.
+func DisplayStringInEnv(d *Data, env *SymbolTableFrame) string {
+	p := newPrinter(d, false, env)
+	p.Readable = false
+	return p.write(d, 0)
+}
+
+func (self *printer) write(d *Data, level int) string {
 	if d == nil {
 		return "()"
 	}
 
+	if compoundP(d) {
+		if self.Depth >= 0 && level >= self.Depth {
+			return "..."
+		}
+		label, reference := self.label(d)
+		if reference {
+			return label
+		}
+		if label != "" {
+			return label + self.writeContents(d, level)
+		}
+	}
+	return self.writeContents(d, level)
+}
+
+func (self *printer) writeContents(d *Data, level int) string {
+
 	switch d.Type {
 	case ConsCellType:
 		{
@@ -954,11 +1450,22 @@ func String(d *Data) string {
 			}
 			var c *Data = d
 
-			contents := make([]string, 0, Length(d))
+			contents := make([]string, 0)
+			truncated := false
 			for NotNilP(c) && PairP(c) {
-				contents = append(contents, String(Car(c)))
+				if len(contents) > 0 && self.labelled(c) {
+					break
+				}
+				if self.Length >= 0 && len(contents) == self.Length {
+					truncated = true
+					break
+				}
+				contents = append(contents, self.write(Car(c), level+1))
 				c = Cdr(c)
 			}
+			if truncated {
+				return fmt.Sprintf("(%s)", strings.Join(append(contents, "..."), " "))
+			}
 			if NilP(c) {
 				if SymbolP(Car(d)) && StringValue(Car(d)) == "quote" {
 					if len(contents) == 1 {
@@ -970,7 +1477,7 @@ func String(d *Data) string {
 					return fmt.Sprintf("(%s)", strings.Join(contents, " "))
 				}
 			} else {
-				return fmt.Sprintf("(%s . %s)", strings.Join(contents, " "), String(c))
+				return fmt.Sprintf("(%s . %s)", strings.Join(contents, " "), self.write(c, level))
 			}
 		}
 	case AlistType:
@@ -980,14 +1487,24 @@ func String(d *Data) string {
 			}
 			contents := make([]string, 0, Length(d))
 			for c := d; NotNilP(c); c = Cdr(c) {
-				contents = append(contents, String(Car(c)))
+				if self.Length >= 0 && len(contents) == self.Length {
+					contents = append(contents, "...")
+					break
+				}
+				contents = append(contents, self.write(Car(c), level+1))
 			}
 			return fmt.Sprintf("(%s)", strings.Join(contents, " "))
 		}
 	case AlistCellType:
-		return fmt.Sprintf("(%s . %s)", String(Car(d)), String(Cdr(d)))
+		return fmt.Sprintf("(%s . %s)", self.write(Car(d), level+1), self.write(Cdr(d), level+1))
 	case IntegerType:
 		return fmt.Sprintf("%d", IntegerValue(d))
+	case BignumType:
+		return BignumValue(d).String()
+	case RationalType:
+		return RationalValue(d).RatString()
+	case ComplexType:
+		return complexString(ComplexValue(d))
 	case FloatType:
 		{
 			v := FloatValue(d)
@@ -1014,7 +1531,15 @@ func String(d *Data) string {
 			return "#f"
 		}
 	case StringType:
-		return fmt.Sprintf(`"%s"`, escapeQuotes(StringValue(d)))
+		if !self.Readable {
+			return StringValue(d)
+		}
+		return fmt.Sprintf(`"%s"`, escapeString(StringValue(d)))
+	case CharacterType:
+		if !self.Readable {
+			return string(CharacterValue(d))
+		}
+		return characterLiteral(CharacterValue(d))
 	case SymbolType:
 		return StringValue(d)
 	case FunctionType:
@@ -1031,6 +1556,54 @@ func String(d *Data) string {
 				contents = append(contents, fmt.Sprintf("%d", b))
 			}
 			return fmt.Sprintf("[%s]", strings.Join(contents, " "))
+		} else if ObjectType(d) == "Vector" {
+			elements := VectorValue(d).Elements
+			contents := make([]string, 0, len(elements))
+			for _, element := range elements {
+				if self.Length >= 0 && len(contents) == self.Length {
+					contents = append(contents, "...")
+					break
+				}
+				contents = append(contents, self.write(element, level+1))
+			}
+			return fmt.Sprintf("#(%s)", strings.Join(contents, " "))
+		} else if ObjectType(d) == "Values" {
+			values := ValuesValue(d)
+			contents := make([]string, 0, len(values))
+			for _, value := range values {
+				contents = append(contents, self.write(value, level))
+			}
+			return fmt.Sprintf("<values: %s>", strings.Join(contents, " "))
+		} else if ObjectType(d) == "Set" {
+			elements := SetValue(d).snapshot()
+			keys := make([]string, 0, len(elements))
+			for k := range elements {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			contents := make([]string, 0, len(keys))
+			for _, k := range keys {
+				if self.Length >= 0 && len(contents) == self.Length {
+					contents = append(contents, "...")
+					break
+				}
+				contents = append(contents, self.write(elements[k], level+1))
+			}
+			return fmt.Sprintf("<set: %s>", strings.Join(contents, " "))
+		} else if ObjectType(d) == "Structure" {
+			return structureString(StructureValue(d), func(value *Data) string {
+				return self.write(value, level+1)
+			})
+		} else if ObjectType(d) == "Condition" {
+			return fmt.Sprintf("<condition: %s>", ConditionValue(d).Type.Name)
+		} else if ObjectType(d) == "GoObject" {
+			return fmt.Sprintf("<go object: %s>", GoObjectValue(d).Value.Type())
+		} else if ObjectType(d) == "WeakRef" {
+			return "<weak ref>"
+		} else if ObjectType(d) == "Promise" {
+			return "<promise>"
+		} else if ObjectType(d) == "StringPort" {
+			return "<port: string>"
 		} else {
 			return fmt.Sprintf("<opaque Go object of type %s : 0x%x>", ObjectType(d), (*uint64)(ObjectValue(d)))
 		}
@@ -1045,8 +1618,12 @@ func String(d *Data) string {
 
 		pairs := make([]string, 0, len(frame.Data))
 		for _, key := range keys {
+			if self.Length >= 0 && len(pairs) == self.Length {
+				pairs = append(pairs, "...")
+				break
+			}
 			val := frame.Data[key]
-			var valString string = String(val)
+			var valString string = self.write(val, level+1)
 			pairs = append(pairs, fmt.Sprintf("%s %s", key, valString))
 		}
 		frame.Mutex.RUnlock()
@@ -1061,10 +1638,15 @@ func String(d *Data) string {
 }
 
 func PrintString(d *Data) string {
+	return PrintStringInEnv(d, nil)
+}
+
+// PrintStringInEnv is PrintString with the print limits in effect in env.
+func PrintStringInEnv(d *Data, env *SymbolTableFrame) string {
 	if StringP(d) {
 		return StringValue(d)
 	} else {
-		return String(d)
+		return StringInEnv(d, env)
 	}
 }
 
@@ -1126,6 +1708,20 @@ func logResult(result *Data, env *SymbolTableFrame) {
 }
 
 func evalHelper(d *Data, env *SymbolTableFrame, needFunction bool) (result *Data, err error) {
+	result, err = evalUnforced(d, env, needFunction, false)
+	if err == nil && TailCallP(result) {
+		result, err = forceTailCall(result, nil)
+		if err != nil {
+			err = fmt.Errorf("\nEvaling %s. %w", String(d), err)
+		}
+	}
+	return
+}
+
+// evalUnforced is Eval except that when d is a special form whose tail
+// expression is a call to a user function, or with tail, when d is such a
+// call itself, it returns a tail call.
+func evalUnforced(d *Data, env *SymbolTableFrame, needFunction bool, tail bool) (result *Data, err error) {
 	if IsInteractive && !DebugEvalInDebugRepl {
 		env.CurrentCode.PushFront(fmt.Sprintf("Eval %s", String(d)))
 	}
@@ -1146,6 +1742,7 @@ func evalHelper(d *Data, env *SymbolTableFrame, needFunction bool) (result *Data
 		switch d.Type {
 		case ConsCellType:
 			{
+				form := d
 				d = postProcessShortcuts(d)
 
 				// catch empty cons cell
@@ -1170,9 +1767,15 @@ func evalHelper(d *Data, env *SymbolTableFrame, needFunction bool) (result *Data
 
 				args := Cdr(d)
 
-				result, err = Apply(function, args, env)
+				if tail {
+					result, err = applyTail(function, args, env)
+				} else if PrimitiveP(function) {
+					result, err = PrimitiveValue(function).applyUnforced(args, env)
+				} else {
+					result, err = Apply(function, args, env)
+				}
 				if err != nil {
-					err = fmt.Errorf("\nEvaling %s. %w", String(d), err)
+					err = fmt.Errorf("\nEvaling %s. %w", String(d), locateError(err, form))
 					return
 				} else if DebugReturnValue != nil {
 					result = DebugReturnValue
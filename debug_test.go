@@ -0,0 +1,79 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file tests breakpoints in the debugger.
+
+package golisp
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type DebugSuite struct {
+	Input []string
+	Reads int
+}
+
+var _ = Suite(&DebugSuite{})
+
+func (s *DebugSuite) SetUpSuite(c *C) {
+	InitLisp()
+	debugReadLine = func(prompt *string) *string {
+		s.Reads++
+		if len(s.Input) == 0 {
+			return nil
+		}
+		line := s.Input[0]
+		s.Input = s.Input[1:]
+		return &line
+	}
+}
+
+func (s *DebugSuite) TearDownSuite(c *C) {
+	debugReadLine = ReadLine
+}
+
+func (s *DebugSuite) SetUpTest(c *C) {
+	s.Input = nil
+	s.Reads = 0
+}
+
+func (s *DebugSuite) TestBreakSeesLocals(c *C) {
+	s.Input = []string{"(set! x (* x 2))", "(continue)", "(set! x 0)"}
+	result, err := ParseAndEval(`((lambda (x) (break "here") x) 21)`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(42))
+	c.Assert(s.Reads, Equals, 2)
+}
+
+func (s *DebugSuite) TestBreakInCompiledCode(c *C) {
+	s.Input = []string{"(set! x (* x 2))", "(continue)"}
+	result, err := ParseAndEval(`((compile (lambda (x) (break) x)) 21)`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(42))
+}
+
+func (s *DebugSuite) TestStep(c *C) {
+	s.Input = []string{"(step)", "(continue)"}
+	result, err := ParseAndEval(`((lambda (x) (break) (+ x 1)) 1)`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(2))
+	c.Assert(s.Reads, Equals, 2)
+	c.Assert(DebugSingleStep, Equals, false)
+}
+
+func (s *DebugSuite) TestEndOfInputContinues(c *C) {
+	result, err := ParseAndEval(`(begin (break) 'done)`)
+	c.Assert(err, IsNil)
+	c.Assert(StringValue(result), Equals, "done")
+	c.Assert(s.Reads, Equals, 1)
+}
+
+func (s *DebugSuite) TestResumingOutsideTheDebugger(c *C) {
+	_, err := ParseAndEval(`(continue)`)
+	c.Assert(err, ErrorMatches, "(?s).*continue can only be used in the debugger.*")
+	_, err = ParseAndEval(`(step)`)
+	c.Assert(err, ErrorMatches, "(?s).*step can only be used in the debugger.*")
+}
@@ -8,7 +8,12 @@
 package golisp
 
 import (
+	"errors"
 	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
 )
 
 type EvalSuite struct {
@@ -26,3 +31,81 @@ func (s *EvalSuite) TestEvalWithNilFunction(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(result, IsNil)
 }
+
+func (s *EvalSuite) TestDeepTailRecursion(c *C) {
+	if testing.Short() {
+		c.Skip("takes too long for -short")
+	}
+	_, err := ParseAndEval("(define (count-up n acc) (if (== n 0) acc (count-up (- n 1) (+ acc 1))))")
+	c.Assert(err, IsNil)
+	result, err := ParseAndEval("(count-up 10000000 0)")
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(10000000))
+}
+
+func (s *EvalSuite) TestMutualTailRecursion(c *C) {
+	if testing.Short() {
+		c.Skip("takes too long for -short")
+	}
+	_, err := ParseAndEval("(define (tail-even? n) (cond ((== n 0) #t) (else (tail-odd? (- n 1)))))")
+	c.Assert(err, IsNil)
+	_, err = ParseAndEval("(define (tail-odd? n) (and (!= n 0) (tail-even? (- n 1))))")
+	c.Assert(err, IsNil)
+	result, err := ParseAndEval("(tail-even? 1000001)")
+	c.Assert(err, IsNil)
+	c.Assert(BooleanValue(result), Equals, false)
+}
+
+func (s *EvalSuite) TestNamedLetTailRecursion(c *C) {
+	if testing.Short() {
+		c.Skip("takes too long for -short")
+	}
+	result, err := ParseAndEval("(let loop ((i 0)) (if (< i 1000000) (loop (+ i 1)) i))")
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(1000000))
+}
+
+// BenchmarkFib makes the calls a doubly recursive function does, none of
+// which are in tail position.
+func BenchmarkFib(b *testing.B) {
+	InitLisp()
+	_, err := ParseAndEval("(define (benchmark-fib n) (if (< n 2) n (+ (benchmark-fib (- n 1)) (benchmark-fib (- n 2)))))")
+	if err != nil {
+		b.Fatal(err)
+	}
+	code, _ := Parse("(benchmark-fib 20)")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Eval(code, Global)
+	}
+}
+
+// BenchmarkTailLoop makes tail calls, in a named let.
+func BenchmarkTailLoop(b *testing.B) {
+	InitLisp()
+	code, _ := Parse("(let loop ((i 0)) (if (< i 10000) (loop (+ i 1)) i))")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Eval(code, Global)
+	}
+}
+
+func (s *EvalSuite) TestErrorsNameTheirSourceLocation(c *C) {
+	filename := filepath.Join(c.MkDir(), "located.lsp")
+	source := "(define (located-g)\n  (let ((y 2))\n    (vector-ref y 0)))\n\n   (located-g)\n"
+	c.Assert(ioutil.WriteFile(filename, []byte(source), 0644), IsNil)
+
+	_, err := ProcessFile(filename)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "error at "+filename+":3:5: vector-ref expects a vector"), Equals, true)
+
+	var located *LocatedError
+	c.Assert(errors.As(err, &located), Equals, true)
+	c.Assert(*located.Location, Equals, SourceLocation{File: filename, Line: 3, Column: 5})
+}
+
+func (s *EvalSuite) TestStringSourcesHaveNoLocation(c *C) {
+	_, err := ParseAndEval("(vector-ref 2 0)")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "error at"), Equals, false)
+}
@@ -24,6 +24,8 @@ type Function struct {
 	DebugOnEntry     bool
 	SlotFunction     int32
 	ParentProcess    *Process
+	Compiled         *CompiledCode
+	extended         *lambdaList
 }
 
 func computeRequiredArgumentCount(args *Data) (requiredArgumentCount int, varArgs bool) {
@@ -41,6 +43,11 @@ func computeRequiredArgumentCount(args *Data) (requiredArgumentCount int, varArg
 }
 
 func MakeFunction(name string, params *Data, body *Data, parentEnv *SymbolTableFrame) *Function {
+	if extendedParamsP(params) {
+		if ll, err := parseLambdaList(params); err == nil {
+			return &Function{Name: name, Params: params, VarArgs: true, RequiredArgCount: len(ll.Required), Body: body, Env: parentEnv, SlotFunction: 0, extended: ll}
+		}
+	}
 	requiredArgs, varArgs := computeRequiredArgumentCount(params)
 	return &Function{Name: name, Params: params, VarArgs: varArgs, RequiredArgCount: requiredArgs, Body: body, Env: parentEnv, SlotFunction: 0}
 }
@@ -50,6 +57,10 @@ func (self *Function) String() string {
 }
 
 func (self *Function) makeLocalBindings(args *Data, argEnv *SymbolTableFrame, localEnv *SymbolTableFrame, eval bool) (err error) {
+	if self.extended != nil {
+		return self.bindExtended(args, argEnv, localEnv, eval)
+	}
+
 	if self.VarArgs {
 		if Length(args) < self.RequiredArgCount {
 			return errors.New(fmt.Sprintf("%s expected at least %d parameters, received %d.", self.Name, self.RequiredArgCount, Length(args)))
@@ -62,6 +73,10 @@ func (self *Function) makeLocalBindings(args *Data, argEnv *SymbolTableFrame, lo
 
 	var argValue *Data
 	var accumulatingParam *Data = nil
+	if SymbolP(self.Params) {
+		// (lambda args ...) collects all the arguments
+		accumulatingParam = self.Params
+	}
 	accumulatedArgs := make([]*Data, 0)
 	for p, a := self.Params, args; NotNilP(a); a = Cdr(a) {
 		if eval {
@@ -69,6 +84,7 @@ func (self *Function) makeLocalBindings(args *Data, argEnv *SymbolTableFrame, lo
 			if err != nil {
 				return
 			}
+			argValue = SingleValue(argValue)
 		} else {
 			argValue = Car(a)
 		}
@@ -97,9 +113,52 @@ func (self *Function) makeLocalBindings(args *Data, argEnv *SymbolTableFrame, lo
 	return nil
 }
 
+// bindExtended evaluates the arguments, if they need it, before binding a
+// parameter list with optional or keyword parameters.
+func (self *Function) bindExtended(args *Data, argEnv *SymbolTableFrame, localEnv *SymbolTableFrame, eval bool) (err error) {
+	values := make([]*Data, 0, Length(args))
+	for a := args; NotNilP(a); a = Cdr(a) {
+		argValue := Car(a)
+		if eval {
+			argValue, err = Eval(argValue, argEnv)
+			if err != nil {
+				return
+			}
+			argValue = SingleValue(argValue)
+		}
+		values = append(values, argValue)
+	}
+	return self.extended.bind(self.Name, values, localEnv)
+}
+
+// internalApply runs the function, then any tail call its body ends with, and
+// so on, in a loop rather than recursively.
 func (self *Function) internalApply(args *Data, argEnv *SymbolTableFrame, frame *FrameMap, eval bool) (result *Data, err error) {
+	f := self
+	previous := argEnv
+	for {
+		result, err = f.applyOnce(args, argEnv, previous, frame, eval)
+		if err != nil || !TailCallP(result) {
+			return
+		}
+		call := TailCallValue(result)
+		f, args, argEnv, frame, eval = call.Function, call.Args, call.Env, nil, false
+	}
+}
+
+// applyOnce runs the function's body, returning a tail call if it ends with
+// one. The new frame's Previous is the env that made the first call, so a
+// long chain of tail calls doesn't keep every frame alive. Its parameter
+// bindings are those of the caller; frameless code, which calls out from
+// the function's own environment, only runs when those are the same.
+func (self *Function) applyOnce(args *Data, argEnv *SymbolTableFrame, previous *SymbolTableFrame, frame *FrameMap, eval bool) (result *Data, err error) {
+	if self.Compiled != nil && self.Compiled.Frameless && frame == nil && self.ParentProcess == nil && !self.inheritsSelf(argEnv) && parametersIn(argEnv) == parametersIn(self.Env) {
+		return self.applyFrameless(args, argEnv, eval)
+	}
+
 	localEnv := NewSymbolTableFrameBelowWithFrame(self.Env, frame, self.Name)
-	localEnv.Previous = argEnv
+	localEnv.Previous = previous
+	localEnv.parameters = parametersIn(argEnv)
 	selfSym := Intern("self")
 	if frame != nil {
 		_, err = localEnv.BindLocallyTo(selfSym, FrameWithValue(frame))
@@ -134,10 +193,24 @@ func (self *Function) internalApply(args *Data, argEnv *SymbolTableFrame, frame
 
 	ProfileEnter("func", self.Name, localGuid)
 
-	for s := self.Body; NotNilP(s); s = Cdr(s) {
-		result, err = Eval(Car(s), localEnv)
+	if self.Compiled != nil {
+		a := &activation{Function: self, Env: localEnv, framed: true}
+		if self.Compiled.Frameless {
+			a.Locals = localArguments(self, localEnv)
+		}
+		result, err = self.Compiled.run(a)
 		if err != nil {
-			result, err = nil, errors.New(fmt.Sprintf("In '%s': %s", self.Name, err))
+			result, err = nil, fmt.Errorf("In '%s': %w", self.Name, err)
+		}
+	}
+	for s := self.Body; self.Compiled == nil && NotNilP(s); s = Cdr(s) {
+		if NilP(Cdr(s)) {
+			result, err = evalTail(Car(s), localEnv)
+		} else {
+			result, err = Eval(Car(s), localEnv)
+		}
+		if err != nil {
+			result, err = nil, fmt.Errorf("In '%s': %w", self.Name, err)
 			break
 		}
 	}
@@ -147,6 +220,83 @@ func (self *Function) internalApply(args *Data, argEnv *SymbolTableFrame, frame
 	return
 }
 
+// localArguments collects the arguments bound in localEnv for compiled code
+// that reads them from a slice.
+func localArguments(f *Function, localEnv *SymbolTableFrame) []*Data {
+	params := parameterSymbols(f)
+	locals := make([]*Data, len(params))
+	for i, param := range params {
+		if binding, found := localEnv.findBindingInLocalFrameFor(param); found {
+			locals[i] = binding.Val
+		}
+	}
+	return locals
+}
+
+// inheritsSelf is whether a call from argEnv binds self to argEnv's self,
+// as a slot function called from another does.
+func (self *Function) inheritsSelf(argEnv *SymbolTableFrame) bool {
+	if atomic.LoadInt32(&self.SlotFunction) != 1 {
+		return false
+	}
+	_, found := argEnv.findBindingInLocalFrameFor(Intern("self"))
+	return found
+}
+
+// applyFrameless runs frameless compiled code, its arguments in a slice
+// rather than a new symbol table frame.
+func (self *Function) applyFrameless(args *Data, argEnv *SymbolTableFrame, eval bool) (result *Data, err error) {
+	count := Length(args)
+	if self.VarArgs {
+		if count < self.RequiredArgCount {
+			return nil, errors.New(fmt.Sprintf("%s expected at least %d parameters, received %d.", self.Name, self.RequiredArgCount, count))
+		}
+	} else if count != self.RequiredArgCount {
+		return nil, errors.New(fmt.Sprintf("%s expected %d parameters, received %d.", self.Name, self.RequiredArgCount, count))
+	}
+
+	locals := make([]*Data, 0, self.RequiredArgCount+1)
+	a := args
+	for ; NotNilP(a); a = Cdr(a) {
+		if self.VarArgs && len(locals) == self.RequiredArgCount {
+			break
+		}
+		argValue := Car(a)
+		if eval {
+			argValue, err = Eval(argValue, argEnv)
+			if err != nil {
+				return
+			}
+			argValue = SingleValue(argValue)
+		}
+		locals = append(locals, argValue)
+	}
+	if self.VarArgs {
+		rest := make([]*Data, 0, count-self.RequiredArgCount)
+		for ; NotNilP(a); a = Cdr(a) {
+			argValue := Car(a)
+			if eval {
+				argValue, err = Eval(argValue, argEnv)
+				if err != nil {
+					return
+				}
+				argValue = SingleValue(argValue)
+			}
+			rest = append(rest, argValue)
+		}
+		locals = append(locals, ArrayToList(rest))
+	}
+
+	localGuid := atomic.AddInt64(&ProfileGUID, 1) - 1
+	ProfileEnter("func", self.Name, localGuid)
+	result, err = self.Compiled.run(&activation{Function: self, Env: self.Env, Locals: locals})
+	if err != nil {
+		result, err = nil, fmt.Errorf("In '%s': %w", self.Name, err)
+	}
+	ProfileExit("func", self.Name, localGuid)
+	return
+}
+
 func (self *Function) Apply(args *Data, argEnv *SymbolTableFrame) (result *Data, err error) {
 	return self.internalApply(args, argEnv, nil, true)
 }
@@ -177,7 +327,7 @@ func (self *Function) ApplyOveriddingEnvironment(args *Data, argEnv *SymbolTable
 	for s := self.Body; NotNilP(s); s = Cdr(s) {
 		result, err = Eval(Car(s), localEnv)
 		if err != nil {
-			result, err = nil, errors.New(fmt.Sprintf("In '%s': %s", self.Name, err))
+			result, err = nil, fmt.Errorf("In '%s': %w", self.Name, err)
 			break
 		}
 	}
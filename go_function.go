@@ -0,0 +1,230 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains support for binding ordinary Go functions as primitives.
+
+package golisp
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+var (
+	dataType  = reflect.TypeOf((*Data)(nil))
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterGoFunc binds name to a primitive that calls fn, which must be a
+// Go function. Arguments are converted to fn's parameter types, which may
+// be integer, float, string and bool types, *Data, slices of those
+// converted from lists or vectors, and structs or pointers to structs,
+// which go back and forth as go objects. A variadic fn takes any number of
+// trailing arguments. Results are converted back the same way; a final
+// error result is raised as a Lisp error, and several other results are
+// returned as multiple values. RegisterGoFunc panics if fn can't be bound.
+func RegisterGoFunc(name string, fn interface{}) {
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func {
+		panic(fmt.Sprintf("RegisterGoFunc: %s needs a function but was given %T", name, fn))
+	}
+	t := f.Type()
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+		if !goFuncTypeSupported(in) {
+			panic(fmt.Sprintf("RegisterGoFunc: %s takes an unsupported %s", name, in))
+		}
+	}
+	for i := 0; i < t.NumOut(); i++ {
+		if out := t.Out(i); !goFuncTypeSupported(out) && !(out == errorType && i == t.NumOut()-1) {
+			panic(fmt.Sprintf("RegisterGoFunc: %s returns an unsupported %s", name, out))
+		}
+	}
+
+	argCount := fmt.Sprintf("%d", t.NumIn())
+	if t.IsVariadic() {
+		argCount = fmt.Sprintf(">=%d", t.NumIn()-1)
+	}
+	MakePrimitiveFunction(name, argCount, func(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+		return callGoFunc(name, f, args, env)
+	})
+}
+
+func goFuncTypeSupported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	case reflect.Slice:
+		return goFuncTypeSupported(t.Elem())
+	case reflect.Struct:
+		return true
+	case reflect.Ptr:
+		return t == dataType || t.Elem().Kind() == reflect.Struct
+	}
+	return false
+}
+
+func callGoFunc(name string, f reflect.Value, args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	t := f.Type()
+	in := make([]reflect.Value, 0, Length(args))
+	for i, arg := 0, args; NotNilP(arg); i, arg = i+1, Cdr(arg) {
+		var paramType reflect.Type
+		if t.IsVariadic() && i >= t.NumIn()-1 {
+			paramType = t.In(t.NumIn() - 1).Elem()
+		} else {
+			paramType = t.In(i)
+		}
+		v, ok := lispToGo(Car(arg), paramType)
+		if !ok {
+			err = ProcessError(fmt.Sprintf("%s expects argument %d to be %s but received %s.", name, i+1, goTypeDescription(paramType), String(Car(arg))), env)
+			return
+		}
+		in = append(in, v)
+	}
+
+	out := f.Call(in)
+	if len(out) > 0 && t.Out(len(out)-1) == errorType {
+		if e := out[len(out)-1]; !e.IsNil() {
+			err = ProcessError(fmt.Sprintf("%s: %s.", name, e.Interface().(error)), env)
+			return
+		}
+		out = out[:len(out)-1]
+	}
+
+	switch len(out) {
+	case 0:
+		return nil, nil
+	case 1:
+		return goToLisp(out[0]), nil
+	}
+	values := make([]*Data, len(out))
+	for i, v := range out {
+		values[i] = goToLisp(v)
+	}
+	return ValuesWithArray(values), nil
+}
+
+func goTypeDescription(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice:
+		return "a list or vector"
+	case reflect.Struct, reflect.Ptr:
+		return fmt.Sprintf("a go object of type %s", t)
+	}
+	return "a value"
+}
+
+// lispToGo converts d to a value of type t, failing if d is the wrong
+// type or doesn't fit.
+func lispToGo(d *Data, t reflect.Type) (v reflect.Value, ok bool) {
+	if t == dataType {
+		return reflect.ValueOf(d), true
+	}
+	v = reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !IntegerP(d) || v.OverflowInt(IntegerValue(d)) {
+			return v, false
+		}
+		v.SetInt(IntegerValue(d))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !IntegerP(d) || IntegerValue(d) < 0 || v.OverflowUint(uint64(IntegerValue(d))) {
+			return v, false
+		}
+		v.SetUint(uint64(IntegerValue(d)))
+	case reflect.Float32, reflect.Float64:
+		if IntegerP(d) {
+			v.SetFloat(float64(IntegerValue(d)))
+		} else if FloatP(d) {
+			v.SetFloat(float64(FloatValue(d)))
+		} else {
+			return v, false
+		}
+	case reflect.String:
+		if !StringP(d) {
+			return v, false
+		}
+		v.SetString(StringValue(d))
+	case reflect.Bool:
+		if !BooleanP(d) {
+			return v, false
+		}
+		v.SetBool(BooleanValue(d))
+	case reflect.Slice:
+		var elements []*Data
+		if VectorP(d) {
+			elements = VectorValue(d).Elements
+		} else if ListP(d) {
+			elements = ToArray(d)
+		} else {
+			return v, false
+		}
+		v = reflect.MakeSlice(t, len(elements), len(elements))
+		for i, element := range elements {
+			e, ok := lispToGo(element, t.Elem())
+			if !ok {
+				return v, false
+			}
+			v.Index(i).Set(e)
+		}
+	default:
+		if GoObjectP(d) && GoObjectValue(d).Value.Type().AssignableTo(t) {
+			return GoObjectValue(d).Value, true
+		}
+		if NilP(d) && t.Kind() == reflect.Ptr {
+			return v, true
+		}
+		return v, false
+	}
+	return v, true
+}
+
+func goToLisp(v reflect.Value) *Data {
+	if v.Type() == dataType {
+		return v.Interface().(*Data)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return IntegerWithValue(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return BignumWithValue(new(big.Int).SetUint64(v.Uint()))
+		}
+		return IntegerWithValue(int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return FloatWithValue(float32(v.Float()))
+	case reflect.String:
+		return StringWithValue(v.String())
+	case reflect.Bool:
+		return BooleanWithValue(v.Bool())
+	case reflect.Slice:
+		elements := make([]*Data, v.Len())
+		for i := range elements {
+			elements[i] = goToLisp(v.Index(i))
+		}
+		return ArrayToList(elements)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	return GoObjectWithValue(v.Interface())
+}
@@ -0,0 +1,118 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file tests binding Go functions as primitives.
+
+package golisp
+
+import (
+	"errors"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type GoFunctionSuite struct {
+}
+
+var _ = Suite(&GoFunctionSuite{})
+
+func (s *GoFunctionSuite) SetUpSuite(c *C) {
+	InitLisp()
+	RegisterGoFunc("go-add", func(a int64, b float64) float64 { return float64(a) + b })
+	RegisterGoFunc("go-repeat", strings.Repeat)
+	RegisterGoFunc("go-not", func(b bool) bool { return !b })
+	RegisterGoFunc("go-sum", func(start int, ns ...int) int {
+		for _, n := range ns {
+			start += n
+		}
+		return start
+	})
+	RegisterGoFunc("go-reverse", func(ss []string) []string {
+		reversed := make([]string, len(ss))
+		for i, s := range ss {
+			reversed[len(ss)-1-i] = s
+		}
+		return reversed
+	})
+	RegisterGoFunc("go-divide", func(a, b int) (int, int, error) {
+		if b == 0 {
+			return 0, 0, errors.New("division by zero")
+		}
+		return a / b, a % b, nil
+	})
+	RegisterGoFunc("go-identity", func(d *Data) *Data { return d })
+	RegisterGoFunc("go-nothing", func() {})
+}
+
+func (s *GoFunctionSuite) TestConversions(c *C) {
+	result, err := ParseAndEval(`(go-add 1 2.5)`)
+	c.Assert(err, IsNil)
+	c.Assert(FloatValue(result), Equals, float32(3.5))
+
+	result, err = ParseAndEval(`(go-repeat "ab" 3)`)
+	c.Assert(err, IsNil)
+	c.Assert(StringValue(result), Equals, "ababab")
+
+	result, err = ParseAndEval(`(go-not #f)`)
+	c.Assert(err, IsNil)
+	c.Assert(BooleanValue(result), Equals, true)
+
+	result, err = ParseAndEval(`(go-identity '(a b))`)
+	c.Assert(err, IsNil)
+	c.Assert(String(result), Equals, "(a b)")
+
+	result, err = ParseAndEval(`(go-nothing)`)
+	c.Assert(err, IsNil)
+	c.Assert(NilP(result), Equals, true)
+}
+
+func (s *GoFunctionSuite) TestSlices(c *C) {
+	result, err := ParseAndEval(`(go-reverse '("a" "b" "c"))`)
+	c.Assert(err, IsNil)
+	c.Assert(String(result), Equals, `("c" "b" "a")`)
+
+	result, err = ParseAndEval(`(go-reverse #("a" "b"))`)
+	c.Assert(err, IsNil)
+	c.Assert(String(result), Equals, `("b" "a")`)
+}
+
+func (s *GoFunctionSuite) TestVariadic(c *C) {
+	result, err := ParseAndEval(`(go-sum 1)`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(1))
+
+	result, err = ParseAndEval(`(go-sum 1 2 3)`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(6))
+}
+
+func (s *GoFunctionSuite) TestResultsAndErrors(c *C) {
+	result, err := ParseAndEval(`(receive (q r) (go-divide 7 2) (list q r))`)
+	c.Assert(err, IsNil)
+	c.Assert(String(result), Equals, "(3 1)")
+
+	_, err = ParseAndEval(`(go-divide 7 0)`)
+	c.Assert(err, ErrorMatches, "(?s).*go-divide: division by zero.")
+}
+
+func (s *GoFunctionSuite) TestMismatches(c *C) {
+	_, err := ParseAndEval(`(go-repeat "ab")`)
+	c.Assert(err, NotNil)
+
+	_, err = ParseAndEval(`(go-repeat 1 2)`)
+	c.Assert(err, ErrorMatches, "(?s).*go-repeat expects argument 1 to be a string but received 1.")
+
+	_, err = ParseAndEval(`(go-sum 1 2 "3")`)
+	c.Assert(err, ErrorMatches, "(?s).*go-sum expects argument 3 to be an integer but received \"3\".")
+
+	_, err = ParseAndEval(`(go-reverse '("a" 1))`)
+	c.Assert(err, ErrorMatches, "(?s).*go-reverse expects argument 1 to be a list or vector .*")
+}
+
+func (s *GoFunctionSuite) TestUnsupportedFunctions(c *C) {
+	c.Assert(func() { RegisterGoFunc("go-bad", 1) }, PanicMatches, "RegisterGoFunc: go-bad needs a function but was given int")
+	c.Assert(func() { RegisterGoFunc("go-bad", func(m map[string]int) {}) }, PanicMatches, "RegisterGoFunc: go-bad takes an unsupported map.*")
+}
@@ -0,0 +1,130 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file tests using Go structs from Lisp.
+
+package golisp
+
+import (
+	"errors"
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+type GoObjectSuite struct {
+	Counter *testCounter
+}
+
+var _ = Suite(&GoObjectSuite{})
+
+type testPoint struct {
+	X, Y int
+}
+
+type testCounter struct {
+	Name   string
+	Count  int64
+	Origin testPoint
+	Tags   []string
+	secret int
+}
+
+func (self *testCounter) Add(n int64) int64 {
+	self.Count += n
+	return self.Count
+}
+
+func (self *testCounter) Describe(prefix string, more ...string) string {
+	return fmt.Sprintf("%s %s %v", prefix, self.Name, more)
+}
+
+func (self *testCounter) Fail() error {
+	return errors.New("failed on purpose")
+}
+
+func (self testPoint) Sum() int {
+	return self.X + self.Y
+}
+
+func (s *GoObjectSuite) SetUpTest(c *C) {
+	InitLisp()
+	s.Counter = &testCounter{Name: "clicks", Origin: testPoint{X: 1, Y: 2}, Tags: []string{"a"}}
+	RegisterGoObject("counter", s.Counter)
+	RegisterGoObject("point", testPoint{X: 3, Y: 4})
+}
+
+func (s *GoObjectSuite) TestReadFields(c *C) {
+	result, err := ParseAndEval(`(go-slot counter "Name")`)
+	c.Assert(err, IsNil)
+	c.Assert(StringValue(result), Equals, "clicks")
+
+	result, err = ParseAndEval(`(go-slot counter "Tags")`)
+	c.Assert(err, IsNil)
+	c.Assert(String(result), Equals, `("a")`)
+
+	result, err = ParseAndEval(`(go-slot (go-slot counter "Origin") "Y")`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(2))
+
+	result, err = ParseAndEval(`(go-object? counter)`)
+	c.Assert(err, IsNil)
+	c.Assert(BooleanValue(result), Equals, true)
+}
+
+func (s *GoObjectSuite) TestWriteFields(c *C) {
+	_, err := ParseAndEval(`(go-set-slot! counter "Count" 5)`)
+	c.Assert(err, IsNil)
+	c.Assert(s.Counter.Count, Equals, int64(5))
+
+	_, err = ParseAndEval(`(go-set-slot! counter "Tags" '("x" "y"))`)
+	c.Assert(err, IsNil)
+	c.Assert(s.Counter.Tags, DeepEquals, []string{"x", "y"})
+
+	_, err = ParseAndEval(`(go-set-slot! counter "Origin" point)`)
+	c.Assert(err, IsNil)
+	c.Assert(s.Counter.Origin, Equals, testPoint{X: 3, Y: 4})
+}
+
+func (s *GoObjectSuite) TestInvokeMethods(c *C) {
+	result, err := ParseAndEval(`(go-invoke counter "Add" 3)`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(3))
+	c.Assert(s.Counter.Count, Equals, int64(3))
+
+	result, err = ParseAndEval(`(go-invoke counter "Describe" "counter" "x" "y")`)
+	c.Assert(err, IsNil)
+	c.Assert(StringValue(result), Equals, "counter clicks [x y]")
+
+	result, err = ParseAndEval(`(go-invoke point "Sum")`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(result), Equals, int64(7))
+
+	_, err = ParseAndEval(`(go-invoke counter "Fail")`)
+	c.Assert(err, ErrorMatches, "(?s).*go-invoke Fail: failed on purpose.")
+}
+
+func (s *GoObjectSuite) TestErrors(c *C) {
+	_, err := ParseAndEval(`(go-slot counter "Missing")`)
+	c.Assert(err, ErrorMatches, "(?s).*go-slot: golisp.testCounter has no field Missing.")
+
+	_, err = ParseAndEval(`(go-slot counter "secret")`)
+	c.Assert(err, ErrorMatches, "(?s).*go-slot: field secret of golisp.testCounter is unexported.")
+
+	_, err = ParseAndEval(`(go-set-slot! counter "Count" "many")`)
+	c.Assert(err, ErrorMatches, "(?s).*go-set-slot! expects Count to be set to an integer but received \"many\".")
+
+	_, err = ParseAndEval(`(go-set-slot! point "X" 1)`)
+	c.Assert(err, ErrorMatches, "(?s).*go-set-slot! can't set X of a golisp.testPoint.*")
+
+	_, err = ParseAndEval(`(go-invoke counter "Missing")`)
+	c.Assert(err, ErrorMatches, "(?s).*go-invoke: \\*golisp.testCounter has no exported method Missing.")
+
+	_, err = ParseAndEval(`(go-invoke counter "Add")`)
+	c.Assert(err, ErrorMatches, "(?s).*go-invoke: Add takes 1 arguments but received 0.")
+
+	_, err = ParseAndEval(`(go-slot 1 "Name")`)
+	c.Assert(err, ErrorMatches, "(?s).*go-slot expects a go object but received 1.")
+}
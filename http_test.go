@@ -0,0 +1,101 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file tests the http client primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type HttpSuite struct {
+	Server *httptest.Server
+}
+
+var _ = Suite(&HttpSuite{})
+
+func (s *HttpSuite) SetUpSuite(c *C) {
+	InitLisp()
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/echo":
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+			fmt.Fprintf(w, "%s %s", r.Method, body)
+			return
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Header().Set("X-Greeting", r.Header.Get("X-Name"))
+		fmt.Fprint(w, "hello")
+	}))
+	Global.BindTo(Intern("server"), StringWithValue(s.Server.URL))
+}
+
+func (s *HttpSuite) TearDownSuite(c *C) {
+	s.Server.Close()
+}
+
+func (s *HttpSuite) TestGet(c *C) {
+	result, err := ParseAndEval(`(http-get (str server "/hello") '(("X-Name" . "lisp")))`)
+	c.Assert(err, IsNil)
+	c.Assert(FrameP(result), Equals, true)
+	c.Assert(IntegerValue(FrameValue(result).Get("status:")), Equals, int64(200))
+	c.Assert(StringValue(FrameValue(result).Get("body:")), Equals, "hello")
+
+	greeting, err := Assoc(StringWithValue("X-Greeting"), FrameValue(result).Get("headers:"))
+	c.Assert(err, IsNil)
+	c.Assert(StringValue(Cdr(greeting)), Equals, "lisp")
+}
+
+func (s *HttpSuite) TestGetReportsStatus(c *C) {
+	result, err := ParseAndEval(`(http-get (str server "/missing"))`)
+	c.Assert(err, IsNil)
+	c.Assert(IntegerValue(FrameValue(result).Get("status:")), Equals, int64(404))
+}
+
+func (s *HttpSuite) TestPost(c *C) {
+	result, err := ParseAndEval(`(http-post (str server "/echo") "{}" "application/json")`)
+	c.Assert(err, IsNil)
+	c.Assert(StringValue(FrameValue(result).Get("body:")), Equals, "POST {}")
+
+	contentType, err := Assoc(StringWithValue("X-Content-Type"), FrameValue(result).Get("headers:"))
+	c.Assert(err, IsNil)
+	c.Assert(StringValue(Cdr(contentType)), Equals, "application/json")
+}
+
+func (s *HttpSuite) TestTimeout(c *C) {
+	_, err := ParseAndEval(`(http-get (str server "/slow") '() 50)`)
+	c.Assert(err, ErrorMatches, "(?s).*http-get: .*Timeout.*")
+
+	_, err = ParseAndEval(`(http-get (str server "/slow") '() 1000)`)
+	c.Assert(err, IsNil)
+}
+
+func (s *HttpSuite) TestBadArguments(c *C) {
+	_, err := ParseAndEval(`(http-get 1)`)
+	c.Assert(err, ErrorMatches, "(?s).*http-get expects a url string .*")
+
+	_, err = ParseAndEval(`(http-get server '(1))`)
+	c.Assert(err, ErrorMatches, "(?s).*http-get expects headers .*")
+
+	_, err = ParseAndEval(`(http-get server '() 0)`)
+	c.Assert(err, ErrorMatches, "(?s).*http-get expects a timeout .*")
+
+	_, err = ParseAndEval(`(http-post server 1 "text/plain")`)
+	c.Assert(err, ErrorMatches, "(?s).*http-post expects its body .*")
+
+	_, err = ParseAndEval(`(http-get "http://127.0.0.1:0/")`)
+	c.Assert(err, ErrorMatches, "(?s).*http-get: .*")
+}
@@ -11,7 +11,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
+	"strings"
+	"unsafe"
 )
 
 func JsonToLisp(json interface{}) (result *Data) {
@@ -126,3 +130,229 @@ func TransformJson(xform *Data, jsonNode *Data, parentNode *Data) (xformedJson *
 	xformedJson = newData
 	return
 }
+
+// JsonStringToLispWithObjects parses jsonData the way json->lisp does.
+// Objects become frames, alists or hash tables as objects is "frame",
+// "alist" or "hash-table"; arrays become lists, null becomes nil, and
+// numbers without a fraction or exponent become integers. An empty array,
+// like null, becomes nil, so it is written back as null.
+func JsonStringToLispWithObjects(jsonData string, objects string) (result *Data, err error) {
+	decoder := json.NewDecoder(strings.NewReader(jsonData))
+	decoder.UseNumber()
+	result, err = decodeJsonValue(decoder, objects)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			err = fmt.Errorf("badly formed json at byte offset %d: %s", syntaxErr.Offset, syntaxErr)
+		}
+		return
+	}
+	if _, trailing := decoder.Token(); trailing != io.EOF {
+		err = errors.New("badly formed json: extra data after the value")
+	}
+	return
+}
+
+func decodeJsonValue(decoder *json.Decoder, objects string) (result *Data, err error) {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		err = errors.New("badly formed json: unexpected end of input")
+	}
+	if err != nil {
+		return
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		if value == '[' {
+			elements := make([]*Data, 0)
+			for decoder.More() {
+				var element *Data
+				element, err = decodeJsonValue(decoder, objects)
+				if err != nil {
+					return
+				}
+				elements = append(elements, element)
+			}
+			_, err = decoder.Token()
+			return ArrayToList(elements), err
+		}
+
+		keys := make([]string, 0)
+		values := make([]*Data, 0)
+		for decoder.More() {
+			var key json.Token
+			key, err = decoder.Token()
+			if err != nil {
+				return
+			}
+			var element *Data
+			element, err = decodeJsonValue(decoder, objects)
+			if err != nil {
+				return
+			}
+			keys = append(keys, key.(string))
+			values = append(values, element)
+		}
+		if _, err = decoder.Token(); err != nil {
+			return
+		}
+		return jsonObjectToLisp(keys, values, objects), nil
+	case json.Number:
+		return jsonNumberToLisp(value)
+	case string:
+		return StringWithValue(value), nil
+	case bool:
+		return BooleanWithValue(value), nil
+	}
+	return nil, nil
+}
+
+func jsonObjectToLisp(keys []string, values []*Data, objects string) *Data {
+	switch objects {
+	case "alist":
+		var alist *Data
+		for i := len(keys) - 1; i >= 0; i-- {
+			alist = Acons(StringWithValue(keys[i]), values[i], alist)
+		}
+		return alist
+	case "hash-table":
+		table := &HashTable{Entries: make(map[string]hashEntry, len(keys))}
+		for i, key := range keys {
+			k := StringWithValue(key)
+			table.Entries[hashKey(k)] = hashEntry{Key: k, Value: values[i]}
+		}
+		return ObjectWithTypeAndValue("HashTable", unsafe.Pointer(table))
+	}
+	m := &FrameMap{Data: make(FrameMapData, len(keys))}
+	for i, key := range keys {
+		m.Data[fmt.Sprintf("%s:", key)] = values[i]
+	}
+	return FrameWithValue(m)
+}
+
+func jsonNumberToLisp(n json.Number) (*Data, error) {
+	if !strings.ContainsAny(n.String(), ".eE") {
+		if i, err := n.Int64(); err == nil {
+			return IntegerWithValue(i), nil
+		}
+		if b, ok := new(big.Int).SetString(n.String(), 10); ok {
+			return BignumWithValue(b), nil
+		}
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("badly formed json number %s", n)
+	}
+	return FloatWithValue(float32(f)), nil
+}
+
+// LispToJsonStringWithObjects writes d as JSON the way lisp->json does.
+// Frames, hash tables and alists become objects, other lists arrays and nil
+// null. With objects "alist", a list of pairs whose cars are strings or
+// symbols is taken to be an alist too. Since nil is also the empty list, an
+// empty array read by json->lisp doesn't round trip: it is written as null.
+func LispToJsonStringWithObjects(d *Data, objects string) (result string, err error) {
+	value, err := lispToJsonValue(d, objects == "alist")
+	if err != nil {
+		return
+	}
+	j, err := json.Marshal(value)
+	return string(j), err
+}
+
+func jsonKey(key *Data) string {
+	if NakedP(key) {
+		return strings.TrimSuffix(StringValue(key), ":")
+	}
+	return PrintString(key)
+}
+
+// looseAlistP is whether d is a non-empty list of pairs keyed by strings
+// or symbols.
+func looseAlistP(d *Data) bool {
+	if !PairP(d) || NilP(d) {
+		return false
+	}
+	for c := d; NotNilP(c); c = Cdr(c) {
+		pair := Car(c)
+		if !(PairP(pair) || DottedPairP(pair)) || NilP(pair) || !(StringP(Car(pair)) || SymbolP(Car(pair))) {
+			return false
+		}
+	}
+	return true
+}
+
+func lispToJsonValue(d *Data, alists bool) (result interface{}, err error) {
+	switch {
+	case NilP(d):
+		return nil, nil
+	case IntegerP(d):
+		return IntegerValue(d), nil
+	case BignumP(d):
+		return json.Number(BignumValue(d).String()), nil
+	case RationalP(d):
+		f, _ := RationalValue(d).Float64()
+		return f, nil
+	case FloatP(d):
+		return FloatValue(d), nil
+	case StringP(d), SymbolP(d):
+		return StringValue(d), nil
+	case BooleanP(d):
+		return BooleanValue(d), nil
+	case AlistP(d) || (alists && looseAlistP(d)):
+		dict := make(map[string]interface{}, Length(d))
+		for c := d; NotNilP(c); c = Cdr(c) {
+			if dict[jsonKey(Caar(c))], err = lispToJsonValue(Cdar(c), alists); err != nil {
+				return
+			}
+		}
+		return dict, nil
+	case PairP(d) && properListP(d):
+		ary := make([]interface{}, 0, Length(d))
+		for c := d; NotNilP(c); c = Cdr(c) {
+			var element interface{}
+			if element, err = lispToJsonValue(Car(c), alists); err != nil {
+				return
+			}
+			ary = append(ary, element)
+		}
+		return ary, nil
+	case VectorP(d):
+		ary := make([]interface{}, 0, len(VectorValue(d).Elements))
+		for _, e := range VectorValue(d).Elements {
+			var element interface{}
+			if element, err = lispToJsonValue(e, alists); err != nil {
+				return
+			}
+			ary = append(ary, element)
+		}
+		return ary, nil
+	case ObjectP(d) && ObjectType(d) == "HashTable":
+		table := (*HashTable)(ObjectValue(d))
+		table.Mutex.RLock()
+		defer table.Mutex.RUnlock()
+		dict := make(map[string]interface{}, len(table.Entries))
+		for _, entry := range table.Entries {
+			if dict[jsonKey(entry.Key)], err = lispToJsonValue(entry.Value, alists); err != nil {
+				return
+			}
+		}
+		return dict, nil
+	case FrameP(d):
+		frame := FrameValue(d)
+		frame.Mutex.RLock()
+		defer frame.Mutex.RUnlock()
+		dict := make(map[string]interface{}, len(frame.Data))
+		for k, v := range frame.Data {
+			if FunctionP(v) {
+				continue
+			}
+			if dict[strings.TrimSuffix(k, ":")], err = lispToJsonValue(v, alists); err != nil {
+				return
+			}
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("%s can't be represented in json", String(d))
+}
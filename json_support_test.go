@@ -72,6 +72,34 @@ func (s *JsonLispSuite) TestJsonToLispIllegal(c *C) {
 	c.Assert(func() { JsonStringToLisp("hello") }, PanicMatches, `Badly formed json: 'hello'`)
 }
 
+func (s *JsonLispSuite) TestJsonToLispWithObjectsKeepsKeyOrder(c *C) {
+	sexpr, err := JsonStringToLispWithObjects(`{"b": 1, "a": [2, null]}`, "alist")
+	c.Assert(err, IsNil)
+	expected := Acons(StringWithValue("b"), IntegerWithValue(1),
+		Acons(StringWithValue("a"), InternalMakeList(IntegerWithValue(2), nil), nil))
+	c.Assert(IsEqual(sexpr, expected), Equals, true)
+}
+
+func (s *JsonLispSuite) TestJsonToLispWithObjectsNumbers(c *C) {
+	sexpr, err := JsonStringToLispWithObjects(`[1, 1.0, 123456789012345678901234567890]`, "alist")
+	c.Assert(err, IsNil)
+	c.Assert(IntegerP(First(sexpr)), Equals, true)
+	c.Assert(FloatP(Second(sexpr)), Equals, true)
+	c.Assert(BignumP(Third(sexpr)), Equals, true)
+}
+
+func (s *JsonLispSuite) TestJsonToLispWithObjectsReportsOffset(c *C) {
+	_, err := JsonStringToLispWithObjects(`{"a": }`, "alist")
+	c.Assert(err, ErrorMatches, `badly formed json at byte offset 7: .*`)
+}
+
+func (s *JsonLispSuite) TestLispToJsonWithObjectsAlist(c *C) {
+	alist := Acons(StringWithValue("map"), InternalMakeList(IntegerWithValue(1), nil), nil)
+	data, err := LispToJsonStringWithObjects(alist, "alist")
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, `{"map":[1,null]}`)
+}
+
 func (s *JsonLispSuite) TestLispToJsonMap(c *C) {
 	alist := Acons(StringWithValue("map"), IntegerWithValue(1), nil)
 	data := LispToJsonString(alist)
@@ -0,0 +1,232 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file implements optional and keyword parameters.
+//
+// A parameter list can name, after its required parameters, optional
+// parameters, a rest parameter and keyword parameters:
+//
+//   (a b #!optional c (d 10) #!rest more #!key e (f 20))
+//
+// An optional or keyword parameter is a symbol or a (symbol default) list.
+// The default is evaluated, after the parameters before it are bound, only
+// when the argument is left out; one without a default is bound to nil. A
+// rest parameter can be written as a dotted tail instead of with #!rest.
+// Keyword arguments follow the positional ones as name: value pairs, and the
+// first keyword of the function's ends the positional arguments.
+
+package golisp
+
+import (
+	"errors"
+	"fmt"
+)
+
+type defaultedParam struct {
+	Name    *Data
+	Default *Data
+}
+
+type lambdaList struct {
+	Required  []*Data
+	Optionals []defaultedParam
+	Rest      *Data
+	Keys      []defaultedParam
+}
+
+var (
+	optionalMarker = Intern("#!optional")
+	restMarker     = Intern("#!rest")
+	keyMarker      = Intern("#!key")
+)
+
+// extendedParamsP is whether params uses #!optional, #!rest or #!key.
+func extendedParamsP(params *Data) bool {
+	for p := params; PairP(p) && NotNilP(p); p = Cdr(p) {
+		if Car(p) == optionalMarker || Car(p) == restMarker || Car(p) == keyMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLambdaList takes params apart, checking that it is well formed.
+func parseLambdaList(params *Data) (ll *lambdaList, err error) {
+	ll = &lambdaList{}
+	section := "required"
+	p := params
+	for ; PairP(p) && NotNilP(p); p = Cdr(p) {
+		param := Car(p)
+		switch {
+		case param == optionalMarker:
+			if section != "required" {
+				return nil, errors.New("#!optional has to come before #!rest and #!key")
+			}
+			section = "optional"
+		case param == restMarker:
+			if section == "rest" || section == "key" || ll.Rest != nil {
+				return nil, errors.New("#!rest has to come before #!key and only once")
+			}
+			p = Cdr(p)
+			if !PairP(p) || NilP(p) || !SymbolP(Car(p)) {
+				return nil, errors.New("#!rest has to be followed by a symbol")
+			}
+			ll.Rest = Car(p)
+			section = "rest"
+		case param == keyMarker:
+			if section == "key" {
+				return nil, errors.New("#!key can only be given once")
+			}
+			section = "key"
+		case section == "required":
+			if !SymbolP(param) {
+				return nil, fmt.Errorf("parameters must be symbols but received %s", String(param))
+			}
+			ll.Required = append(ll.Required, param)
+		case section == "rest":
+			return nil, fmt.Errorf("only #!key can follow the rest parameter but received %s", String(param))
+		default:
+			dp, dpErr := parseDefaultedParam(param)
+			if dpErr != nil {
+				return nil, dpErr
+			}
+			if section == "optional" {
+				ll.Optionals = append(ll.Optionals, dp)
+			} else {
+				ll.Keys = append(ll.Keys, dp)
+			}
+		}
+	}
+	if NotNilP(p) {
+		if !SymbolP(p) || ll.Rest != nil || section == "key" {
+			return nil, fmt.Errorf("can't have a rest parameter of %s there", String(p))
+		}
+		ll.Rest = p
+	}
+	return
+}
+
+func parseDefaultedParam(param *Data) (dp defaultedParam, err error) {
+	if SymbolP(param) {
+		return defaultedParam{Name: param}, nil
+	}
+	if PairP(param) && Length(param) == 2 && SymbolP(Car(param)) {
+		return defaultedParam{Name: Car(param), Default: Cadr(param)}, nil
+	}
+	err = fmt.Errorf("optional and keyword parameters must be a symbol or (symbol default) but received %s", String(param))
+	return
+}
+
+// symbols is all the parameters, in the order they're bound.
+func (self *lambdaList) symbols() []*Data {
+	symbols := append([]*Data{}, self.Required...)
+	for _, dp := range self.Optionals {
+		symbols = append(symbols, dp.Name)
+	}
+	if self.Rest != nil {
+		symbols = append(symbols, self.Rest)
+	}
+	for _, dp := range self.Keys {
+		symbols = append(symbols, dp.Name)
+	}
+	return symbols
+}
+
+func (self *lambdaList) keyNamed(keyword *Data) (index int, found bool) {
+	for i, dp := range self.Keys {
+		if NakedSymbolFrom(dp.Name) == keyword {
+			return i, true
+		}
+	}
+	return
+}
+
+// bind binds the parameters in localEnv to the already evaluated args of
+// the function called name.
+func (self *lambdaList) bind(name string, args []*Data, localEnv *SymbolTableFrame) (err error) {
+	if len(args) < len(self.Required) {
+		return fmt.Errorf("%s expected at least %d parameters, received %d.", name, len(self.Required), len(args))
+	}
+
+	positional := len(args)
+	if len(self.Keys) > 0 {
+		for i := len(self.Required); i < len(args); i++ {
+			if _, found := self.keyNamed(args[i]); found {
+				positional = i
+				break
+			}
+		}
+	}
+
+	i := 0
+	for _, param := range self.Required {
+		if _, err = localEnv.BindLocallyTo(param, args[i]); err != nil {
+			return
+		}
+		i++
+	}
+	for _, dp := range self.Optionals {
+		if i < positional {
+			_, err = localEnv.BindLocallyTo(dp.Name, args[i])
+			i++
+		} else {
+			err = dp.bindDefault(localEnv)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if self.Rest != nil {
+		if _, err = localEnv.BindLocallyTo(self.Rest, ArrayToList(args[i:positional])); err != nil {
+			return
+		}
+		i = positional
+	} else if i < positional {
+		if len(self.Keys) > 0 {
+			return fmt.Errorf("%s expected a keyword argument but received %s.", name, String(args[i]))
+		}
+		return fmt.Errorf("%s expected at most %d parameters, received %d.", name, len(self.Required)+len(self.Optionals), len(args))
+	}
+
+	given := make([]bool, len(self.Keys))
+	for ; i < len(args); i += 2 {
+		k, found := self.keyNamed(args[i])
+		if !found {
+			return fmt.Errorf("%s has no keyword parameter %s.", name, String(args[i]))
+		}
+		if i+1 == len(args) {
+			return fmt.Errorf("%s was given no value for keyword %s.", name, String(args[i]))
+		}
+		if given[k] {
+			return fmt.Errorf("%s was given keyword %s more than once.", name, String(args[i]))
+		}
+		given[k] = true
+		if _, err = localEnv.BindLocallyTo(self.Keys[k].Name, args[i+1]); err != nil {
+			return
+		}
+	}
+	for k, dp := range self.Keys {
+		if !given[k] {
+			if err = dp.bindDefault(localEnv); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (self defaultedParam) bindDefault(localEnv *SymbolTableFrame) (err error) {
+	var value *Data
+	if self.Default != nil {
+		value, err = Eval(self.Default, localEnv)
+		if err != nil {
+			return
+		}
+		value = SingleValue(value)
+	}
+	_, err = localEnv.BindLocallyTo(self.Name, value)
+	return
+}
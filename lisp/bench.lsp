@@ -114,9 +114,6 @@
 ;;; ================================================================================
 ;;; Running benchmarks
 
-(define (display string)
-    (format #t "~A" string))
-
 (define (run-bench name count run)
     (let loop ((i count)
                (result '(undefined)))
@@ -129,7 +126,8 @@
   (let* ((loop-count 10)
          (run (apply run-maker args))
          (results (map (lambda (ignored)
-                         (time (run-bench name count run)))
+                         (receive (elapsed value) (time (run-bench name count run))
+                           elapsed))
                        (interval loop-count))))
      (list name count loop-count (min results) (max results) (/ (apply + results) loop-count))))
 
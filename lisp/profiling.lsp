@@ -66,7 +66,7 @@
 
   (define (add-to-function fname time data)
     (let ((existing (assoc fname data)))
-      (if (null? existing)
+      (if (not existing)
           (acons fname (list 1 time) data)
           (acons fname (list (+ (cadr existing) 1) (+ time (caddr existing))) (dissoc fname data)))))
   
@@ -2,36 +2,8 @@
 
 ;;; Streams from SICP
 
-(define (memo-proc proc)
-  (let ((already-run? #f)
-        (result #f))
-    (lambda ()
-      (if (not already-run?)
-          (begin (set! result (proc))
-                 (set! already-run? #t)
-                 result)
-          result))))
-
-(defmacro (delay **expr**)
-  `(memo-proc (lambda ()
-                ,**expr**)))
-
-(define (force p)
-  (p))
-
-(defmacro (stream-cons **a** **b**)
-  `(cons ,**a** (delay ,**b**)))
-
-(define (stream-car s)
-  (car s))
-
-(define (stream-cdr s)
-  (force (cdr s)))
-
-(define (stream-null? s)
-  (null? s))
-
-(define the-empty-stream '())
+;;; delay, force, stream-cons, stream-car, stream-cdr, stream-null?,
+;;; stream-take, stream-tail and the-empty-stream are built in.
 
 ;;; stream-ref returns the nth element of a stream (where the first
 ;;; element of the stream is counted as the 0th)
@@ -41,15 +13,6 @@
         ((eq? n 0) (stream-car s))
         (else (stream-ref (stream-cdr s) (- n 1)))))
 
-;;; take some number of elements from a stream
-
-(define (stream-take s n)
-   (cond ((stream-null? s) the-empty-stream)
-	((eq? n 0) 'done)
-	(else (write-line (stream-car s))
-	      (print-stream (stream-cdr s) (- n 1)))))
-
-
 ;;; filter a stream by pred
 
 (define (stream-filter pred stream)
@@ -103,7 +103,7 @@
   `(let* ((actual ,sexpr)
           (expected ,expected-sexpr)
           (msg (format #f "(assert-eq ~A ~A)" ',sexpr ',expected-sexpr)))
-     (if (eq? actual expected)
+     (if (equal? actual expected)
          (log-pass msg)
          (log-failure msg (format #f "expected ~A, but was ~A" expected actual)))))
 
@@ -112,7 +112,7 @@
   `(let* ((actual ,sexpr)
           (expected ,expected-sexpr)
           (msg (format #f "(assert-neq ~A ~A)" ',sexpr ',expected-sexpr)))
-     (if (neq? actual expected)
+     (if (not (equal? actual expected))
          (log-pass msg)
          (log-failure msg (format #f "did not expect ~A, but it was" expected)))))
 
@@ -134,7 +134,7 @@
 
 (defmacro (assert-memq sexpr object)
   `(let* ((searched-for ,object)
-          (result (memq ,object ,sexpr))
+          (result (member ,object ,sexpr))
           (msg (format #f "(assert-memq ~A ~S)" ',sexpr ',object)))
      (if result
          (log-pass msg)
@@ -80,6 +80,7 @@ func (self *Macro) makeLocalBindings(args *Data, argEnv *SymbolTableFrame, local
 
 func (self *Macro) Expand(args *Data, argEnv *SymbolTableFrame) (result *Data, err error) {
 	localEnv := NewSymbolTableFrameBelow(self.Env, self.Name)
+	localEnv.parameters = parametersIn(argEnv)
 	err = self.makeLocalBindings(args, argEnv, localEnv, false)
 	if err != nil {
 		return
@@ -0,0 +1,85 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file tests the package system.
+
+package golisp
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type PackageSuite struct {
+}
+
+var _ = Suite(&PackageSuite{})
+
+func (s *PackageSuite) SetUpSuite(c *C) {
+	InitLisp()
+	_, err := ParseAndEvalAll(`
+(define-package test-util (export twice))
+(in-package test-util)
+(define (twice x) (* 2 x))
+(define (hidden x) x)
+
+(define-package test-math (export quadruple collide) (import test-util))
+(in-package test-math)
+(define (quadruple x) (twice (twice x)))
+(define collide 'math)
+(define helper (lambda () (hidden 1)))
+`)
+	c.Assert(err, IsNil)
+	_, err = ParseAndEvalAll(`(define collide 'global)`)
+	c.Assert(err, IsNil)
+}
+
+func (s *PackageSuite) eval(c *C, code string) *Data {
+	result, err := ParseAndEvalAll(code)
+	c.Assert(err, IsNil)
+	return result
+}
+
+func (s *PackageSuite) TestQualifiedReferences(c *C) {
+	c.Assert(IntegerValue(s.eval(c, "(test-math:quadruple 3)")), Equals, int64(12))
+	c.Assert(IntegerValue(s.eval(c, "(test-util:twice 3)")), Equals, int64(6))
+	c.Assert(String(s.eval(c, "test-math:collide")), Equals, "math")
+}
+
+func (s *PackageSuite) TestDefinitionsStayInTheirPackage(c *C) {
+	c.Assert(String(s.eval(c, "collide")), Equals, "global")
+	c.Assert(String(s.eval(c, "(bound? 'quadruple)")), Equals, "#f")
+	c.Assert(String(s.eval(c, "(bound? 'twice)")), Equals, "#f")
+}
+
+func (s *PackageSuite) TestExportsControlVisibility(c *C) {
+	c.Assert(String(s.eval(c, "(bound? 'test-util:hidden)")), Equals, "#f")
+	c.Assert(String(s.eval(c, "(bound? 'test-math:helper)")), Equals, "#f")
+	_, err := ParseAndEvalAll("(test-util:hidden 1)")
+	c.Assert(err, NotNil)
+	c.Assert(String(s.eval(c, "(package-exports 'test-math)")), Equals, "(collide quadruple)")
+}
+
+func (s *PackageSuite) TestImportsOnlySeeExports(c *C) {
+	c.Assert(IntegerValue(s.eval(c, "(in-package test-math) (twice 5)")), Equals, int64(10))
+	_, err := ParseAndEvalAll("(in-package test-math) (helper)")
+	c.Assert(err, NotNil)
+}
+
+func (s *PackageSuite) TestInPackageLastsToTheEndOfTheSource(c *C) {
+	c.Assert(String(s.eval(c, "(in-package test-math) (current-package)")), Equals, "test-math")
+	c.Assert(NilP(s.eval(c, "(current-package)")), Equals, true)
+	c.Assert(NilP(s.eval(c, "(in-package test-math) (in-package) (current-package)")), Equals, true)
+}
+
+func (s *PackageSuite) TestErrors(c *C) {
+	_, err := ParseAndEvalAll("(in-package no-such-package)")
+	c.Assert(err, ErrorMatches, "(?s).*in-package: there is no package no-such-package.*")
+	_, err = ParseAndEvalAll("(define-package bad (import no-such-package))")
+	c.Assert(err, ErrorMatches, "(?s).*define-package: there is no package no-such-package to import.*")
+	_, err = ParseAndEvalAll("(define-package bad (exports a))")
+	c.Assert(err, ErrorMatches, "(?s).*define-package expects export and import clauses.*")
+	_, err = ParseAndEvalAll(`(define-package "bad")`)
+	c.Assert(err, ErrorMatches, "(?s).*define-package expects a package name.*")
+}
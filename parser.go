@@ -11,7 +11,11 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -21,6 +25,9 @@ func makeInteger(str string) (n *Data, err error) {
 	var i int64
 	_, err = fmt.Sscanf(str, "%d", &i)
 	if err != nil {
+		if b, ok := new(big.Int).SetString(str, 10); ok {
+			return BignumWithValue(b), nil
+		}
 		return
 	}
 	n = IntegerWithValue(i)
@@ -31,6 +38,9 @@ func makeBinaryInteger(str string) (n *Data, err error) {
 	var i int64
 	_, err = fmt.Sscanf(str, "%b", &i)
 	if err != nil {
+		if b, ok := new(big.Int).SetString(str, 2); ok {
+			return BignumWithValue(b), nil
+		}
 		return
 	}
 	n = IntegerWithValue(i)
@@ -41,6 +51,9 @@ func makeHexInteger(str string) (n *Data, err error) {
 	var i int64
 	_, err = fmt.Sscanf(str, "%x", &i)
 	if err != nil {
+		if b, ok := new(big.Int).SetString(str, 16); ok {
+			return BignumWithValue(b), nil
+		}
 		return
 	}
 	n = IntegerWithValue(i)
@@ -57,6 +70,62 @@ func makeFloat(str string) (n *Data, err error) {
 	return
 }
 
+func makeRational(str string) (n *Data, err error) {
+	r, ok := new(big.Rat).SetString(str)
+	if !ok {
+		err = errors.New(fmt.Sprintf("Bad rational literal: %s", str))
+		return
+	}
+	n = RationalWithValue(r)
+	return
+}
+
+// makeComplex reads a literal like 3+4i, 1/2-i or 4i.
+func makeComplex(str string) (n *Data, err error) {
+	if !strings.HasSuffix(str, "i") {
+		err = errors.New(fmt.Sprintf("Bad complex literal: %s", str))
+		return
+	}
+	body := strings.TrimSuffix(str, "i")
+	split := strings.LastIndexAny(body, "+-")
+	realPart, imagPart := "0", body
+	if split > 0 {
+		realPart, imagPart = body[:split], body[split:]
+	}
+	if imagPart == "+" || imagPart == "-" {
+		imagPart += "1"
+	}
+	re, ok := new(big.Rat).SetString(realPart)
+	im, ok2 := new(big.Rat).SetString(imagPart)
+	if !ok || !ok2 {
+		err = errors.New(fmt.Sprintf("Bad complex literal: %s", str))
+		return
+	}
+	r, _ := re.Float64()
+	i, _ := im.Float64()
+	n = ComplexWithValue(complex(r, i))
+	return
+}
+
+// makeCharacter reads what followed #\ in a character literal.
+func makeCharacter(str string) (c *Data, err error) {
+	if utf8.RuneCountInString(str) == 1 {
+		ch, _ := utf8.DecodeRuneInString(str)
+		return CharacterWithValue(ch), nil
+	}
+	if ch, found := characterNames[strings.ToLower(str)]; found {
+		return CharacterWithValue(ch), nil
+	}
+	if str[0] == 'x' || str[0] == 'U' || str[0] == 'u' {
+		code, parseErr := strconv.ParseUint(str[1:], 16, 32)
+		if parseErr == nil && utf8.ValidRune(rune(code)) {
+			return CharacterWithValue(rune(code)), nil
+		}
+	}
+	err = errors.New(fmt.Sprintf("Bad character literal: #\\%s", str))
+	return
+}
+
 func makeString(str string) (s *Data, err error) {
 	s = StringWithValue(str)
 	return
@@ -166,6 +235,29 @@ func parseBytearray(s *Tokenizer) (sexpr *Data, eof bool, err error) {
 	return
 }
 
+func parseVector(s *Tokenizer) (sexpr *Data, eof bool, err error) {
+	tok, _ := s.NextToken()
+
+	var element *Data
+	cells := make([]*Data, 0, 10)
+	for tok != RPAREN {
+		element, eof, err = parseExpression(s)
+		if eof {
+			err = errors.New("Unexpected EOF (expected closing parenthesis)")
+			return
+		}
+		if err != nil {
+			return
+		}
+		cells = append(cells, element)
+		tok, _ = s.NextToken()
+	}
+
+	s.ConsumeToken()
+	sexpr = VectorWithValue(cells, true)
+	return
+}
+
 func parseFrame(s *Tokenizer) (sexpr *Data, eof bool, err error) {
 	tok, _ := s.NextToken()
 	if tok == RBRACKET {
@@ -223,13 +315,25 @@ func parseExpression(s *Tokenizer) (sexpr *Data, eof bool, err error) {
 			s.ConsumeToken()
 			sexpr, err = makeFloat(lit)
 			return
+		case RATIONAL:
+			s.ConsumeToken()
+			sexpr, err = makeRational(lit)
+			return
+		case COMPLEX:
+			s.ConsumeToken()
+			sexpr, err = makeComplex(lit)
+			return
 		case STRING:
 			s.ConsumeToken()
 			sexpr, err = makeString(lit)
 			return
 		case LPAREN:
+			location := lookaheadLocation(s)
 			s.ConsumeToken()
 			sexpr, eof, err = parseConsCell(s)
+			if location != nil && err == nil && PairP(sexpr) && NotNilP(sexpr) {
+				ConsValue(sexpr).Location = location
+			}
 			return
 		case LBRACKET:
 			s.ConsumeToken()
@@ -243,41 +347,13 @@ func parseExpression(s *Tokenizer) (sexpr *Data, eof bool, err error) {
 			s.ConsumeToken()
 			sexpr, err = makeSymbol(lit)
 			return
-		case FALSE:
-			s.ConsumeToken()
-			sexpr = LispFalse
-			return
-		case TRUE:
-			s.ConsumeToken()
-			sexpr = LispTrue
-			return
-		case QUOTE:
-			s.ConsumeToken()
-			sexpr, eof, err = parseExpression(s)
-			if sexpr != nil {
-				sexpr = Cons(Intern("quote"), Cons(sexpr, nil))
-			}
-			return
-		case BACKQUOTE:
-			s.ConsumeToken()
-			sexpr, eof, err = parseExpression(s)
-			if sexpr != nil {
-				sexpr = Cons(Intern("quasiquote"), Cons(sexpr, nil))
-			}
-			return
-		case COMMA:
-			s.ConsumeToken()
-			sexpr, eof, err = parseExpression(s)
-			if sexpr != nil {
-				sexpr = Cons(Intern("unquote"), Cons(sexpr, nil))
-			}
-			return
-		case COMMAAT:
-			s.ConsumeToken()
-			sexpr, eof, err = parseExpression(s)
-			if sexpr != nil {
-				sexpr = Cons(Intern("unquote-splicing"), Cons(sexpr, nil))
-			}
+		case QUOTE, BACKQUOTE, COMMA, COMMAAT, READERMACRO:
+			ch, _ := utf8.DecodeRuneInString(lit)
+			return readMacro(ch, s)
+		case TRUE, FALSE, HASHLPAREN, CHARACTER:
+			return readMacro('#', s)
+		case RPAREN, RBRACKET, RBRACE:
+			err = errors.New(fmt.Sprintf("Unexpected %s", lit))
 			return
 		case ILLEGAL:
 			err = errors.New(fmt.Sprintf("Illegal character: %s", lit))
@@ -337,15 +413,24 @@ func ProcessFileInEnvironment(filename string, env *SymbolTableFrame) (result *D
 	if err != nil {
 		return
 	}
-	result, err = ParseAndEvalAllInEnvironment(src, env)
+	s := NewTokenizerFromString(src)
+	s.File = filename
+	result, err = evalAll(s, env)
 	return
 }
 
 func ParseAndEvalAllInEnvironment(src string, env *SymbolTableFrame) (result *Data, err error) {
-	s := NewTokenizerFromString(src)
+	return evalAll(NewTokenizerFromString(src), env)
+}
+
+// evalAll evaluates the forms s reads. Forms evaluated in the global
+// environment go to the current package, which is put back at the end.
+// Errors evaluating a file's forms say which form failed.
+func evalAll(s *Tokenizer, env *SymbolTableFrame) (result *Data, err error) {
+	defer setCurrentPackage(currentPackage())
 	var sexpr *Data
 	var eof bool
-	for {
+	for form := 1; ; form++ {
 		sexpr, eof, err = parseExpression(s)
 		if err != nil {
 			return
@@ -356,10 +441,14 @@ func ParseAndEvalAllInEnvironment(src string, env *SymbolTableFrame) (result *Da
 		if NilP(sexpr) {
 			return
 		}
-		result, err = Eval(sexpr, env)
+		result, err = Eval(sexpr, packageEnvironment(env))
 		if err != nil {
+			if s.File != "" {
+				err = fmt.Errorf("In form %d of %s: %w", form, s.File, err)
+			}
 			return
 		}
+		s.Relex()
 	}
 }
 
@@ -372,7 +461,7 @@ func ParseAndEvalInEnvironment(src string, env *SymbolTableFrame) (result *Data,
 	if NilP(sexpr) {
 		return
 	}
-	result, err = Eval(sexpr, env)
+	result, err = Eval(sexpr, packageEnvironment(env))
 	if err != nil {
 		return
 	}
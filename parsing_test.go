@@ -68,6 +68,22 @@ func (s *ParsingSuite) TestBinaryInteger(c *C) {
 	c.Assert(IntegerValue(sexpr), Equals, int64(10))
 }
 
+func (s *ParsingSuite) TestBignum(c *C) {
+	sexpr, err := Parse("123456789012345678901234567890")
+	c.Assert(err, IsNil)
+	c.Assert(sexpr, NotNil)
+	c.Assert(int(TypeOf(sexpr)), Equals, BignumType)
+	c.Assert(BignumValue(sexpr).String(), Equals, "123456789012345678901234567890")
+}
+
+func (s *ParsingSuite) TestHexBignum(c *C) {
+	sexpr, err := Parse("#x10000000000000000")
+	c.Assert(err, IsNil)
+	c.Assert(sexpr, NotNil)
+	c.Assert(int(TypeOf(sexpr)), Equals, BignumType)
+	c.Assert(BignumValue(sexpr).String(), Equals, "18446744073709551616")
+}
+
 func (s *ParsingSuite) TestFloat(c *C) {
 	sexpr, err := Parse("12.345")
 	c.Assert(err, IsNil)
@@ -272,6 +288,14 @@ func (s *ParsingSuite) TestEmptyByteArray(c *C) {
 	c.Assert(len(*bytes), Equals, 0)
 }
 
+func (s *ParsingSuite) TestVector(c *C) {
+	sexpr, err := Parse("#(1 a (b))")
+	c.Assert(err, IsNil)
+	c.Assert(VectorP(sexpr), Equals, true)
+	c.Assert(VectorValue(sexpr).Immutable, Equals, true)
+	c.Assert(String(sexpr), Equals, "#(1 a (b))")
+}
+
 func (s *ParsingSuite) TestQuote(c *C) {
 	sexpr, err := Parse("'a")
 	c.Assert(err, IsNil)
@@ -347,3 +371,18 @@ func (s *ParsingSuite) BenchmarkParse(c *C) {
 		_, _ = ParseAndEval(src)
 	}
 }
+
+func (s *ParsingSuite) TestFileListsCarryTheirLocation(c *C) {
+	t := NewTokenizerFromString("(a\n  (b c)\n 'd)")
+	t.File = "source.lsp"
+	sexpr, _, err := parseExpression(t)
+	c.Assert(err, IsNil)
+	c.Assert(*LocationOf(sexpr), Equals, SourceLocation{File: "source.lsp", Line: 1, Column: 1})
+	c.Assert(*LocationOf(Cadr(sexpr)), Equals, SourceLocation{File: "source.lsp", Line: 2, Column: 3})
+}
+
+func (s *ParsingSuite) TestStringListsHaveNoLocation(c *C) {
+	sexpr, err := Parse("(a (b c))")
+	c.Assert(err, IsNil)
+	c.Assert(LocationOf(sexpr), IsNil)
+}
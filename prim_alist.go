@@ -7,17 +7,81 @@
 
 package golisp
 
-import ()
+import (
+	"fmt"
+)
 
 func RegisterAListPrimitives() {
 	MakePrimitiveFunction("acons", "2|3", AconsImpl)
 	MakePrimitiveFunction("pairlis", "2|3", PairlisImpl)
-	MakePrimitiveFunction("assq", "2", AssocImpl)
-	MakePrimitiveFunction("assv", "2", AssocImpl)
-	MakePrimitiveFunction("assoc", "2", AssocImpl)
+	MakePrimitiveFunction("assq", "2|3", AssqImpl)
+	MakePrimitiveFunction("assv", "2|3", AssvImpl)
+	MakePrimitiveFunction("assoc", "2|3", AssocImpl)
 	MakePrimitiveFunction("dissoc", "2", DissocImpl)
 	MakePrimitiveFunction("rassoc", "2", RassocImpl)
 	MakePrimitiveFunction("alist", "1", AlistImpl)
+	MakePrimitiveFunction("alist->list", "1", AlistToListImpl)
+	MakePrimitiveFunction("alist-keys", "1", AlistKeysImpl)
+	MakePrimitiveFunction("alist-values", "1", AlistValuesImpl)
+	MakePrimitiveFunction("alist-update", "3|4", AlistUpdateImpl)
+	MakePrimitiveFunction("alist-delete", "2|3", AlistDeleteImpl)
+}
+
+// keyMatcher compares alist keys with the predicate given as the argument at
+// index, or with same if there isn't one.
+func keyMatcher(name string, args *Data, index int, same func(*Data, *Data) bool, env *SymbolTableFrame) (match func(*Data, *Data) (bool, error), err error) {
+	if Length(args) <= index {
+		return func(key *Data, other *Data) (bool, error) { return same(key, other), nil }, nil
+	}
+	predicate := Nth(args, index+1)
+	if !FunctionOrPrimitiveP(predicate) {
+		err = ProcessError(fmt.Sprintf("%s expects a predicate function but received %s.", name, String(predicate)), env)
+		return
+	}
+	return func(key *Data, other *Data) (bool, error) {
+		b, err := ApplyWithoutEval(predicate, InternalMakeList(key, other), env)
+		return BooleanValue(b), err
+	}, nil
+}
+
+// alistPairs is the pairs of alist, checking that it is made of pairs.
+func alistPairs(name string, alist *Data, env *SymbolTableFrame) (pairs []*Data, err error) {
+	if !ListP(alist) {
+		err = ProcessError(fmt.Sprintf("%s expects an alist but received %s.", name, String(alist)), env)
+		return
+	}
+	for c := alist; NotNilP(c); c = Cdr(c) {
+		pair := Car(c)
+		if !PairP(pair) && !DottedPairP(pair) {
+			err = ProcessError(fmt.Sprintf("%s expects an alist of pairs but found %s.", name, String(pair)), env)
+			return
+		}
+		pairs = append(pairs, pair)
+	}
+	return
+}
+
+// findPair is the first pair in alist whose key matches key, or #f.
+func findPair(name string, args *Data, same func(*Data, *Data) bool, env *SymbolTableFrame) (result *Data, err error) {
+	match, err := keyMatcher(name, args, 2, same, env)
+	if err != nil {
+		return
+	}
+	pairs, err := alistPairs(name, Cadr(args), env)
+	if err != nil {
+		return
+	}
+	key := Car(args)
+	for _, pair := range pairs {
+		found, matchErr := match(key, Car(pair))
+		if matchErr != nil {
+			return nil, matchErr
+		}
+		if found {
+			return pair, nil
+		}
+	}
+	return LispFalse, nil
 }
 
 func AlistImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -26,6 +90,9 @@ func AlistImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return
 }
 
+// (acons key value [alist]) puts a (key . value) pair on the front of alist.
+// If key is already in alist its pair is changed in place instead, which
+// anything sharing alist sees; alist-update makes a new alist.
 func AconsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	key := First(args)
 	if PairP(key) {
@@ -83,10 +150,119 @@ func PairlisImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return
 }
 
+// (assoc key alist [predicate]) is the first pair in alist whose key is
+// equal? to key, or #f if there isn't one. A predicate of two arguments, key
+// and the pair's key, can be given to compare with instead.
 func AssocImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	key := Car(args)
-	list := Cadr(args)
-	return Assoc(key, list)
+	return findPair("assoc", args, IsEqual, env)
+}
+
+// (assq key alist [predicate]) is assoc comparing keys with eq?, so that
+// strings and lists only match themselves.
+func AssqImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return findPair("assq", args, IsEq, env)
+}
+
+// (assv key alist [predicate]) is assoc comparing keys with eqv?.
+func AssvImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return findPair("assv", args, IsEqv, env)
+}
+
+// (alist->list alist) is a plain list of the pairs of alist, copied.
+func AlistToListImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	pairs, err := alistPairs("alist->list", Car(args), env)
+	if err != nil {
+		return
+	}
+	copies := make([]*Data, len(pairs))
+	for i, pair := range pairs {
+		copies[i] = Cons(Car(pair), Cdr(pair))
+	}
+	return ArrayToList(copies), nil
+}
+
+func AlistKeysImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	pairs, err := alistPairs("alist-keys", Car(args), env)
+	if err != nil {
+		return
+	}
+	keys := make([]*Data, len(pairs))
+	for i, pair := range pairs {
+		keys[i] = Car(pair)
+	}
+	return ArrayToList(keys), nil
+}
+
+func AlistValuesImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	pairs, err := alistPairs("alist-values", Car(args), env)
+	if err != nil {
+		return
+	}
+	values := make([]*Data, len(pairs))
+	for i, pair := range pairs {
+		values[i] = Cdr(pair)
+	}
+	return ArrayToList(values), nil
+}
+
+// (alist-update key value alist [predicate]) is a new alist with key's value
+// set to value: in place of the first pair with that key, or in a new pair
+// at the front if there isn't one. alist itself is left alone.
+func AlistUpdateImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	match, err := keyMatcher("alist-update", args, 3, IsEqual, env)
+	if err != nil {
+		return
+	}
+	pairs, err := alistPairs("alist-update", Third(args), env)
+	if err != nil {
+		return
+	}
+	key := First(args)
+	index := -1
+	for i, pair := range pairs {
+		found, matchErr := match(key, Car(pair))
+		if matchErr != nil {
+			return nil, matchErr
+		}
+		if found {
+			index = i
+			break
+		}
+	}
+	for i := len(pairs) - 1; i >= 0; i-- {
+		if i == index {
+			result = aconsCell(Car(pairs[i]), Second(args), result)
+		} else {
+			result = aconsCell(Car(pairs[i]), Cdr(pairs[i]), result)
+		}
+	}
+	if index == -1 {
+		result = aconsCell(key, Second(args), result)
+	}
+	return
+}
+
+// (alist-delete key alist [predicate]) is a new alist without the pairs
+// whose key matches key.
+func AlistDeleteImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	match, err := keyMatcher("alist-delete", args, 2, IsEqual, env)
+	if err != nil {
+		return
+	}
+	pairs, err := alistPairs("alist-delete", Cadr(args), env)
+	if err != nil {
+		return
+	}
+	for i := len(pairs) - 1; i >= 0; i-- {
+		found, matchErr := match(First(args), Car(pairs[i]))
+		if matchErr != nil {
+			return nil, matchErr
+		}
+		if !found {
+			result = aconsCell(Car(pairs[i]), Cdr(pairs[i]), result)
+		}
+	}
+	return
 }
 
 func RassocImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -9,6 +9,8 @@ package golisp
 
 import (
 	"fmt"
+	"math/big"
+	"math/bits"
 )
 
 func RegisterBinaryPrimitives() {
@@ -17,6 +19,13 @@ func RegisterBinaryPrimitives() {
 	MakePrimitiveFunction("binary-not", "1", BinaryNotImpl)
 	MakePrimitiveFunction("left-shift", "2", LeftShiftImpl)
 	MakePrimitiveFunction("right-shift", "2", RightShiftImpl)
+
+	MakePrimitiveFunction("bit-and", "*", BitAndImpl)
+	MakePrimitiveFunction("bit-or", "*", BitOrImpl)
+	MakePrimitiveFunction("bit-xor", "*", BitXorImpl)
+	MakePrimitiveFunction("bit-not", "1", BitNotImpl)
+	MakePrimitiveFunction("arithmetic-shift", "2", ArithmeticShiftImpl)
+	MakePrimitiveFunction("bit-count", "1", BitCountImpl)
 }
 
 func BinaryAndImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -101,3 +110,93 @@ func RightShiftImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 
 	return IntegerWithValue(int64(b1 >> b2)), nil
 }
+
+// The bit- primitives treat integers, bignums included, as infinitely sign
+// extended two's complement numbers, unlike the older binary- ones which work
+// on unsigned 64 bit values.
+
+func integerArgs(name string, args *Data, env *SymbolTableFrame) (values []*big.Int, err error) {
+	for c := args; NotNilP(c); c = Cdr(c) {
+		arg := Car(c)
+		if !ExactIntegerP(arg) {
+			err = ProcessError(fmt.Sprintf("%s expects integer arguments, received %s %s", name, TypeName(TypeOf(arg)), String(arg)), env)
+			return
+		}
+		values = append(values, BignumValue(arg))
+	}
+	return
+}
+
+func foldBits(name string, identity int64, op func(*big.Int, *big.Int, *big.Int) *big.Int, args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	values, err := integerArgs(name, args, env)
+	if err != nil {
+		return
+	}
+
+	acc := big.NewInt(identity)
+	for _, v := range values {
+		op(acc, acc, v)
+	}
+	return ExactIntegerWithValue(acc), nil
+}
+
+func BitAndImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return foldBits("bit-and", -1, (*big.Int).And, args, env)
+}
+
+func BitOrImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return foldBits("bit-or", 0, (*big.Int).Or, args, env)
+}
+
+func BitXorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return foldBits("bit-xor", 0, (*big.Int).Xor, args, env)
+}
+
+func BitNotImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	values, err := integerArgs("bit-not", args, env)
+	if err != nil {
+		return
+	}
+	return ExactIntegerWithValue(new(big.Int).Not(values[0])), nil
+}
+
+// (arithmetic-shift n count) shifts left for a positive count and right, sign
+// preserving, for a negative one.
+func ArithmeticShiftImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	values, err := integerArgs("arithmetic-shift", args, env)
+	if err != nil {
+		return
+	}
+
+	count := Second(args)
+	if !IntegerP(count) {
+		err = ProcessError(fmt.Sprintf("arithmetic-shift expects a fixnum shift count, received %s", String(count)), env)
+		return
+	}
+
+	shift := IntegerValue(count)
+	if shift >= 0 {
+		return ExactIntegerWithValue(new(big.Int).Lsh(values[0], uint(shift))), nil
+	}
+	return ExactIntegerWithValue(new(big.Int).Rsh(values[0], uint(-shift))), nil
+}
+
+// bit-count counts the 1 bits of a non-negative integer, and the 0 bits of a
+// negative one.
+func BitCountImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	values, err := integerArgs("bit-count", args, env)
+	if err != nil {
+		return
+	}
+
+	n := values[0]
+	if n.Sign() < 0 {
+		n = new(big.Int).Not(n)
+	}
+
+	count := 0
+	for _, word := range n.Bits() {
+		count += bits.OnesCount(uint(word))
+	}
+	return IntegerWithValue(int64(count)), nil
+}
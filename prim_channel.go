@@ -9,6 +9,7 @@ package golisp
 
 import (
 	"fmt"
+	"reflect"
 	"unsafe"
 )
 
@@ -21,6 +22,12 @@ func RegisterChannelPrimitives() {
 	MakePrimitiveFunction("channel-try-write", "2", ChannelTryWriteImpl)
 	MakePrimitiveFunction("channel-try-read", "1", ChannelTryReadImpl)
 	MakePrimitiveFunction("close-channel", "1", CloseChannelImpl)
+
+	MakePrimitiveFunction("channel-send", "2", ChannelSendImpl)
+	MakePrimitiveFunction("channel-receive", "1", ChannelReceiveImpl)
+	MakePrimitiveFunction("channel-close", "1", ChannelCloseImpl)
+
+	MakeSpecialForm("channel-select", ">=1", ChannelSelectImpl)
 }
 
 func MakeChannelImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -55,10 +62,10 @@ func MakeChannelImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 	return ObjectWithTypeAndValue("Channel", unsafe.Pointer(&c)), nil
 }
 
-func ChannelWriteImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+func channelWrite(name string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	channelObj := Car(args)
 	if !ObjectP(channelObj) || ObjectType(channelObj) != "Channel" {
-		err = ProcessError(fmt.Sprintf("channel<- expects an Channel object but received %s.", ObjectType(channelObj)), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Channel object but received %s.", name, ObjectType(channelObj)), env)
 		return
 	}
 
@@ -68,7 +75,7 @@ func ChannelWriteImpl(args *Data, env *SymbolTableFrame) (result *Data, err erro
 	func() {
 		defer func() {
 			if e := recover(); e != nil {
-				err = ProcessError("channel<- tried to write to a closed channel.", env)
+				err = ProcessError(fmt.Sprintf("%s tried to write to a closed channel.", name), env)
 			}
 		}()
 		c <- obj
@@ -81,10 +88,19 @@ func ChannelWriteImpl(args *Data, env *SymbolTableFrame) (result *Data, err erro
 	return
 }
 
-func ChannelReadImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+func ChannelWriteImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return channelWrite("channel<-", args, env)
+}
+
+func ChannelSendImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return channelWrite("channel-send", args, env)
+}
+
+// Reading returns (value more?); more? is #f once the channel is closed and drained.
+func channelRead(name string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	channelObj := Car(args)
 	if !ObjectP(channelObj) || ObjectType(channelObj) != "Channel" {
-		err = ProcessError(fmt.Sprintf("<-channel expects an Channel object but received %s.", ObjectType(channelObj)), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Channel object but received %s.", name, ObjectType(channelObj)), env)
 		return
 	}
 
@@ -95,6 +111,14 @@ func ChannelReadImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 	return ArrayToList([]*Data{obj, BooleanWithValue(more)}), nil
 }
 
+func ChannelReadImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return channelRead("<-channel", args, env)
+}
+
+func ChannelReceiveImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return channelRead("channel-receive", args, env)
+}
+
 func ChannelTryWriteImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	channelObj := Car(args)
 	if !ObjectP(channelObj) || ObjectType(channelObj) != "Channel" {
@@ -148,10 +172,10 @@ func ChannelTryReadImpl(args *Data, env *SymbolTableFrame) (result *Data, err er
 	return ArrayToList([]*Data{BooleanWithValue(readSucceed), obj, BooleanWithValue(more)}), nil
 }
 
-func CloseChannelImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+func closeChannel(name string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	channelObj := Car(args)
 	if !ObjectP(channelObj) || ObjectType(channelObj) != "Channel" {
-		err = ProcessError(fmt.Sprintf("<-channel expects an Channel object but received %s.", ObjectType(channelObj)), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Channel object but received %s.", name, ObjectType(channelObj)), env)
 		return
 	}
 
@@ -160,7 +184,7 @@ func CloseChannelImpl(args *Data, env *SymbolTableFrame) (result *Data, err erro
 	func() {
 		defer func() {
 			if e := recover(); e != nil {
-				err = ProcessError("channel-close tried to close a channel twice.", env)
+				err = ProcessError(fmt.Sprintf("%s tried to close a channel twice.", name), env)
 			}
 		}()
 		close(c)
@@ -168,3 +192,87 @@ func CloseChannelImpl(args *Data, env *SymbolTableFrame) (result *Data, err erro
 
 	return
 }
+
+func CloseChannelImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return closeChannel("close-channel", args, env)
+}
+
+func ChannelCloseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return closeChannel("channel-close", args, env)
+}
+
+// channel-select waits on any number of channels at once. Each clause is
+// (channel handler); handler is called with the value received. A handler
+// that takes a second parameter is also passed more?, which like channel-read
+// is #f when the channel was closed, telling closure apart from a received nil.
+// An optional (else handler) clause makes the select non-blocking, its handler
+// is called with no arguments. When more than one channel is ready, one is
+// chosen uniformly at random, just like Go's select statement.
+func ChannelSelectImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	cases := make([]reflect.SelectCase, 0, Length(args))
+	handlers := make([]*Data, 0, Length(args))
+	var elseHandler *Data
+
+	for c := args; NotNilP(c); c = Cdr(c) {
+		clause := Car(c)
+		if !PairP(clause) || Length(clause) != 2 {
+			err = ProcessError(fmt.Sprintf("channel-select expects clauses of the form (channel handler) but received %s.", String(clause)), env)
+			return
+		}
+
+		var handler *Data
+		handler, err = Eval(Cadr(clause), env)
+		if err != nil {
+			return
+		}
+		if !FunctionOrPrimitiveP(handler) {
+			err = ProcessError(fmt.Sprintf("channel-select expects a function as a clause handler but received %s.", String(handler)), env)
+			return
+		}
+
+		if IsEqual(Car(clause), Intern("else")) {
+			if elseHandler != nil {
+				err = ProcessError("channel-select accepts only one else clause.", env)
+				return
+			}
+			elseHandler = handler
+			continue
+		}
+
+		var channelObj *Data
+		channelObj, err = Eval(Car(clause), env)
+		if err != nil {
+			return
+		}
+		if !ObjectP(channelObj) || ObjectType(channelObj) != "Channel" {
+			err = ProcessError(fmt.Sprintf("channel-select expects a Channel object but received %s.", String(channelObj)), env)
+			return
+		}
+
+		ch := *(*Channel)(ObjectValue(channelObj))
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)})
+		handlers = append(handlers, handler)
+	}
+
+	if elseHandler != nil {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+
+	chosen, value, more := reflect.Select(cases)
+
+	if chosen == len(handlers) {
+		return ApplyWithoutEval(elseHandler, nil, env)
+	}
+
+	var obj *Data
+	if more {
+		obj = value.Interface().(*Data)
+	}
+
+	handler := handlers[chosen]
+	handlerArgs := InternalMakeList(obj)
+	if FunctionP(handler) && (FunctionValue(handler).RequiredArgCount == 2 || FunctionValue(handler).VarArgs) {
+		handlerArgs = InternalMakeList(obj, BooleanWithValue(more))
+	}
+	return ApplyWithoutEval(handler, handlerArgs, env)
+}
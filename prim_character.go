@@ -0,0 +1,140 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the character primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// characterNames are the names the reader accepts after #\, besides a
+// single character or a hex code point like x41.
+var characterNames = map[string]rune{
+	"space":     ' ',
+	"newline":   '\n',
+	"linefeed":  '\n',
+	"tab":       '\t',
+	"return":    '\r',
+	"null":      0,
+	"nul":       0,
+	"alarm":     7,
+	"backspace": 8,
+	"page":      12,
+	"escape":    27,
+	"altmode":   27,
+	"delete":    127,
+	"rubout":    127,
+}
+
+// characterLiteralNames are the names characters are written with.
+var characterLiteralNames = map[rune]string{
+	' ':  "space",
+	'\n': "newline",
+	'\t': "tab",
+	'\r': "return",
+	0:    "null",
+	7:    "alarm",
+	8:    "backspace",
+	12:   "page",
+	27:   "escape",
+	127:  "delete",
+}
+
+func RegisterCharacterPrimitives() {
+	MakePrimitiveFunction("char?", "1", CharPImpl)
+	MakePrimitiveFunction("char->integer", "1", CharToIntegerImpl)
+	MakePrimitiveFunction("integer->char", "1", IntegerToCharImpl)
+	MakePrimitiveFunction("char-upcase", "1", CharUpcaseImpl)
+	MakePrimitiveFunction("char-downcase", "1", CharDowncaseImpl)
+	MakePrimitiveFunction("char-alphabetic?", "1", CharAlphabeticPImpl)
+	MakePrimitiveFunction("char-numeric?", "1", CharNumericPImpl)
+	MakePrimitiveFunction("char-whitespace?", "1", CharWhitespacePImpl)
+}
+
+// characterLiteral writes ch the way the reader reads it.
+func characterLiteral(ch rune) string {
+	if name, found := characterLiteralNames[ch]; found {
+		return fmt.Sprintf("#\\%s", name)
+	}
+	if !unicode.IsGraphic(ch) {
+		return fmt.Sprintf("#\\x%x", ch)
+	}
+	return fmt.Sprintf("#\\%c", ch)
+}
+
+func characterArg(name string, args *Data, env *SymbolTableFrame) (ch rune, err error) {
+	c := Car(args)
+	if !CharacterP(c) {
+		err = ProcessError(fmt.Sprintf("%s expects a character but received %s.", name, String(c)), env)
+		return
+	}
+	return CharacterValue(c), nil
+}
+
+func CharPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(CharacterP(Car(args))), nil
+}
+
+// (char->integer ch) is the unicode code point of ch.
+func CharToIntegerImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ch, err := characterArg("char->integer", args, env)
+	if err != nil {
+		return
+	}
+	return IntegerWithValue(int64(ch)), nil
+}
+
+func IntegerToCharImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if !IntegerP(n) || IntegerValue(n) > utf8.MaxRune || !utf8.ValidRune(rune(IntegerValue(n))) {
+		err = ProcessError(fmt.Sprintf("integer->char expects a unicode code point but received %s.", String(n)), env)
+		return
+	}
+	return CharacterWithValue(rune(IntegerValue(n))), nil
+}
+
+func CharUpcaseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ch, err := characterArg("char-upcase", args, env)
+	if err != nil {
+		return
+	}
+	return CharacterWithValue(unicode.ToUpper(ch)), nil
+}
+
+func CharDowncaseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ch, err := characterArg("char-downcase", args, env)
+	if err != nil {
+		return
+	}
+	return CharacterWithValue(unicode.ToLower(ch)), nil
+}
+
+func CharAlphabeticPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ch, err := characterArg("char-alphabetic?", args, env)
+	if err != nil {
+		return
+	}
+	return BooleanWithValue(unicode.IsLetter(ch)), nil
+}
+
+func CharNumericPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ch, err := characterArg("char-numeric?", args, env)
+	if err != nil {
+		return
+	}
+	return BooleanWithValue(unicode.IsDigit(ch)), nil
+}
+
+func CharWhitespacePImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ch, err := characterArg("char-whitespace?", args, env)
+	if err != nil {
+		return
+	}
+	return BooleanWithValue(unicode.IsSpace(ch)), nil
+}
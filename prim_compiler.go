@@ -0,0 +1,38 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the compiler primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+func RegisterCompilerPrimitives() {
+	MakePrimitiveFunction("compile", "1", CompileImpl)
+	MakePrimitiveFunction("compiled-function?", "1", CompiledFunctionPImpl)
+}
+
+// (compile f) returns f with its body compiled to bytecode. The result is
+// called like f; since f's recursive calls go through its name, rebind the
+// name to the result to compile them too: (set! f (compile f)).
+func CompileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	f := Car(args)
+	if !FunctionP(f) {
+		err = ProcessError(fmt.Sprintf("compile expects a function but received %s.", String(f)), env)
+		return
+	}
+	if FunctionValue(f).Compiled != nil {
+		return f, nil
+	}
+	return &Data{Type: FunctionType, Value: unsafe.Pointer(Compile(FunctionValue(f)))}, nil
+}
+
+func CompiledFunctionPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	f := Car(args)
+	return BooleanWithValue(FunctionP(f) && FunctionValue(f).Compiled != nil), nil
+}
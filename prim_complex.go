@@ -0,0 +1,194 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the complex number primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+func RegisterComplexPrimitives() {
+	MakePrimitiveFunction("complex?", "1", ComplexPImpl)
+	MakePrimitiveFunction("real?", "1", RealPImpl)
+	MakePrimitiveFunction("make-rectangular", "2", MakeRectangularImpl)
+	MakePrimitiveFunction("make-polar", "2", MakePolarImpl)
+	MakePrimitiveFunction("real-part", "1", RealPartImpl)
+	MakePrimitiveFunction("imag-part", "1", ImagPartImpl)
+	MakePrimitiveFunction("magnitude", "1", MagnitudeImpl)
+	MakePrimitiveFunction("angle", "1", AngleImpl)
+}
+
+// anyComplexes is whether any of args is complex. Once one is, the rest
+// only have to be numbers of some kind.
+func anyComplexes(args *Data, env *SymbolTableFrame) (result bool, err error) {
+	for c := args; NotNilP(c); c = Cdr(c) {
+		if ComplexP(Car(c)) {
+			result = true
+		} else if !NumberP(Car(c)) {
+			err = ProcessError(fmt.Sprintf("Number expected, received %s", String(Car(c))), env)
+			return
+		}
+	}
+	return
+}
+
+func addComplexes(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	var acc complex128
+	for c := args; NotNilP(c); c = Cdr(c) {
+		acc += ComplexValue(Car(c))
+	}
+	return ComplexWithValue(acc), nil
+}
+
+func subtractComplexes(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	acc := ComplexValue(Car(args))
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		acc -= ComplexValue(Car(c))
+	}
+	return ComplexWithValue(acc), nil
+}
+
+func multiplyComplexes(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	var acc complex128 = 1
+	for c := args; NotNilP(c); c = Cdr(c) {
+		acc *= ComplexValue(Car(c))
+	}
+	return ComplexWithValue(acc), nil
+}
+
+func quotientComplexes(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	acc := ComplexValue(Car(args))
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		v := ComplexValue(Car(c))
+		if v == 0 {
+			err = ProcessError(fmt.Sprintf("Quotent: %s -> Divide by zero.", String(args)), env)
+			return
+		}
+		acc /= v
+	}
+	return ComplexWithValue(acc), nil
+}
+
+func complexParts(name string, args *Data, env *SymbolTableFrame) (a float64, b float64, err error) {
+	for c := args; NotNilP(c); c = Cdr(c) {
+		if !NumberP(Car(c)) {
+			err = ProcessError(fmt.Sprintf("%s expects real numbers but received %s.", name, String(Car(c))), env)
+			return
+		}
+	}
+	return real(ComplexValue(Car(args))), real(ComplexValue(Cadr(args))), nil
+}
+
+// powComplexes is base raised to exponent as a complex number. Integer
+// exponents are applied by multiplying, so that (expt 1+i 2) is exactly 2i.
+func powComplexes(base *Data, exponent *Data, env *SymbolTableFrame) (result *Data, err error) {
+	b := ComplexValue(base)
+	if !IntegerP(exponent) {
+		return ComplexWithValue(cmplx.Pow(b, ComplexValue(exponent))), nil
+	}
+
+	e := IntegerValue(exponent)
+	if e < 0 && b == 0 {
+		err = ProcessError("expt: zero can't be raised to a negative power.", env)
+		return
+	}
+	var acc complex128 = 1
+	for n := e; n != 0; n /= 2 {
+		if n%2 != 0 {
+			acc *= b
+		}
+		b *= b
+	}
+	if e < 0 {
+		acc = 1 / acc
+	}
+	return ComplexWithValue(acc), nil
+}
+
+// complex? is true for every number, since the real numbers are complex
+// numbers too.
+func ComplexPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(NumberP(Car(args)) || ComplexP(Car(args))), nil
+}
+
+func RealPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(NumberP(Car(args))), nil
+}
+
+// (make-rectangular x y) is x+yi.
+func MakeRectangularImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	x, y, err := complexParts("make-rectangular", args, env)
+	if err != nil {
+		return
+	}
+	return ComplexWithValue(complex(x, y)), nil
+}
+
+// (make-polar magnitude angle) is the complex number with that magnitude
+// and angle in radians.
+func MakePolarImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	r, theta, err := complexParts("make-polar", args, env)
+	if err != nil {
+		return
+	}
+	return ComplexWithValue(cmplx.Rect(r, theta)), nil
+}
+
+func RealPartImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	z := Car(args)
+	if NumberP(z) {
+		return z, nil
+	}
+	if !ComplexP(z) {
+		err = ProcessError(fmt.Sprintf("real-part expects a number but received %s.", String(z)), env)
+		return
+	}
+	return FloatWithValue(float32(real(ComplexValue(z)))), nil
+}
+
+func ImagPartImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	z := Car(args)
+	if NumberP(z) {
+		return IntegerWithValue(0), nil
+	}
+	if !ComplexP(z) {
+		err = ProcessError(fmt.Sprintf("imag-part expects a number but received %s.", String(z)), env)
+		return
+	}
+	return FloatWithValue(float32(imag(ComplexValue(z)))), nil
+}
+
+func MagnitudeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	z := Car(args)
+	if NumberP(z) {
+		return AbsImpl(args, env)
+	}
+	if !ComplexP(z) {
+		err = ProcessError(fmt.Sprintf("magnitude expects a number but received %s.", String(z)), env)
+		return
+	}
+	return FloatWithValue(float32(cmplx.Abs(ComplexValue(z)))), nil
+}
+
+// (angle z) is the angle of z in radians, from -pi to pi. The angle of a
+// positive real number is an exact 0.
+func AngleImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	z := Car(args)
+	if !NumberP(z) && !ComplexP(z) {
+		err = ProcessError(fmt.Sprintf("angle expects a number but received %s.", String(z)), env)
+		return
+	}
+	if NumberP(z) {
+		if real(ComplexValue(z)) < 0 {
+			return FloatWithValue(math.Pi), nil
+		}
+		return IntegerWithValue(0), nil
+	}
+	return FloatWithValue(float32(cmplx.Phase(ComplexValue(z)))), nil
+}
@@ -8,9 +8,12 @@
 package golisp
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -18,6 +21,13 @@ import (
 
 type empty struct{}
 
+// Mutex tracks whether it is held so that unlocking an unlocked mutex can be
+// reported as a lisp error instead of killing the runtime.
+type Mutex struct {
+	sync.Mutex
+	locked int32
+}
+
 type Process struct {
 	Env           *SymbolTableFrame
 	Code          *Data
@@ -27,16 +37,70 @@ type Process struct {
 	ReturnValue   chan *Data
 	Joined        int32
 	ScheduleTimer *time.Timer
+	Done          chan *Data
+	Result        *Data
+	Error         error
+	Status        int32
+	Retries       int32
+	ctx           context.Context
+	cancel        context.CancelFunc
+}
+
+// Process states, updated atomically in Process.Status and reported by
+// proc-status.
+const (
+	ProcessPending = iota
+	ProcessRunning
+	ProcessSleeping
+	ProcessCompleted
+	ProcessAbandoned
+)
+
+// WaitGroup keeps its own counter alongside the sync.WaitGroup so a negative
+// count is reported as a lisp error rather than a Go panic.
+type WaitGroup struct {
+	sync.WaitGroup
+	mutex sync.Mutex
+	count int64
+}
+
+// TimeoutObject is what with-timeout returns when the deadline passes first.
+// It is uninterned so that no symbol read in can be mistaken for it.
+var TimeoutObject *Data = UninternedSymbolWithName("__TIMEOUT__")
+
+var processStatusNames = []string{"pending", "running", "sleeping", "completed", "abandoned"}
+
+// finish records the outcome of a process and releases everyone waiting on
+// it in proc-join. Done is closed rather than sent on so any number of
+// joiners see it, and later joins return the cached Result immediately.
+func (proc *Process) finish(result *Data, err error) {
+	proc.Result = result
+	proc.Error = err
+	if atomic.LoadInt32(&proc.Status) != ProcessAbandoned {
+		atomic.StoreInt32(&proc.Status, ProcessCompleted)
+	}
+	close(proc.Done)
+	proc.ReturnValue <- result
 }
 
 func RegisterConcurrencyPrimitives() {
 	MakePrimitiveFunction("fork", ">=1", ForkImpl)
 	MakePrimitiveFunction("proc-sleep", "2", ProcSleepImpl)
+	MakePrimitiveFunction("proc-sleep-remaining", "2", ProcSleepRemainingImpl)
 	MakePrimitiveFunction("wake", "1", WakeImpl)
 	MakePrimitiveFunction("schedule", ">=2", ScheduleImpl)
+	MakePrimitiveFunction("supervise", "3|4", SuperviseImpl)
 	MakePrimitiveFunction("reset-timeout", "1", ResetTimeoutImpl)
 	MakePrimitiveFunction("abandon", "1", AbandonImpl)
+	MakePrimitiveFunction("proc-cancel", "1", ProcCancelImpl)
+	MakePrimitiveFunction("proc-cancelled?", "1", ProcCancelledImpl)
+	MakePrimitiveFunction("with-timeout", "2", WithTimeoutImpl)
+	MakePrimitiveFunction("timeout-object?", "1", TimeoutObjectImpl)
 	MakePrimitiveFunction("join", "1", JoinImpl)
+	MakePrimitiveFunction("proc-join", "1", ProcJoinImpl)
+	MakePrimitiveFunction("proc-join-timeout", "2", ProcJoinTimeoutImpl)
+	MakePrimitiveFunction("proc-status", "1", ProcStatusImpl)
+	MakePrimitiveFunction("proc-error", "1", ProcErrorImpl)
 
 	MakePrimitiveFunction("atomic", "0|1", AtomicImpl)
 	MakePrimitiveFunction("atomic-load", "1", AtomicLoadImpl)
@@ -44,9 +108,74 @@ func RegisterConcurrencyPrimitives() {
 	MakePrimitiveFunction("atomic-add!", "2", AtomicAddImpl)
 	MakePrimitiveFunction("atomic-swap!", "2", AtomicSwapImpl)
 	MakePrimitiveFunction("atomic-compare-and-swap!", "3", AtomicCompareAndSwapImpl)
+
+	MakePrimitiveFunction("make-atomic", "0|1", MakeAtomicImpl)
+	MakePrimitiveFunction("atomic-get", "1", AtomicGetImpl)
+	MakePrimitiveFunction("atomic-add", "2", AtomicAddAliasImpl)
+	MakePrimitiveFunction("atomic-compare-and-swap", "3", AtomicCompareAndSwapAliasImpl)
+
+	MakePrimitiveFunction("make-mutex", "0", MakeMutexImpl)
+	MakePrimitiveFunction("mutex-lock", "1", MutexLockImpl)
+	MakePrimitiveFunction("mutex-unlock", "1", MutexUnlockImpl)
+	MakeSpecialForm("with-mutex", ">=1", WithMutexImpl)
+
+	MakePrimitiveFunction("make-waitgroup", "0", MakeWaitGroupImpl)
+	MakePrimitiveFunction("waitgroup-add", "2", WaitGroupAddImpl)
+	MakePrimitiveFunction("waitgroup-done", "1", WaitGroupDoneImpl)
+	MakePrimitiveFunction("waitgroup-wait", "1", WaitGroupWaitImpl)
+	MakePrimitiveFunction("parallel-map", "2", ParallelMapImpl)
+}
+
+// processArgsFit decides how a forked or scheduled function is called with
+// argCount trailing arguments. The Process object is prepended when the
+// function has room for it, otherwise the arguments are passed as they are.
+func processArgsFit(name string, function *Function, argCount int, env *SymbolTableFrame) (passProc bool, err error) {
+	fits := func(count int) bool {
+		if function.VarArgs {
+			return count >= function.RequiredArgCount
+		}
+		return count == function.RequiredArgCount
+	}
+
+	if fits(argCount + 1) {
+		return true, nil
+	}
+	if fits(argCount) {
+		return false, nil
+	}
+
+	if function.VarArgs {
+		err = ProcessError(fmt.Sprintf("%s was given %d arguments but the function requires at least %d.", name, argCount, function.RequiredArgCount), env)
+	} else {
+		err = ProcessError(fmt.Sprintf("%s was given %d arguments but the function takes %d.", name, argCount, function.RequiredArgCount), env)
+	}
+	return
+}
+
+func processArgs(passProc bool, procObj *Data, args *Data) *Data {
+	if passProc {
+		return Cons(procObj, args)
+	}
+	return args
 }
 
+// (fork [parent] f args...) runs f in a new goroutine. When a parent Process
+// is given the new process is its child: cancelling the parent, by abandon or
+// proc-cancel, cancels the child and everything forked beneath it.
+//
+// Cancellation is cooperative. A long running loop should check
+// (proc-cancelled? self) between steps and return when it becomes true:
+//
+//	(fork parent (lambda (self)
+//	               (do () ((proc-cancelled? self) 'stopped)
+//	                 (do-some-work))))
 func ForkImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	parentCtx := context.Background()
+	if ObjectP(Car(args)) && ObjectType(Car(args)) == "Process" {
+		parentCtx = (*Process)(ObjectValue(Car(args))).ctx
+		args = Cdr(args)
+	}
+
 	f := Car(args)
 
 	if !FunctionP(f) {
@@ -54,17 +183,10 @@ func ForkImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		return
 	}
 
-	argsCount := Length(Cdr(args)) + 1
 	function := FunctionValue(f)
-
-	if function.VarArgs {
-		if argsCount < function.RequiredArgCount {
-			return nil, ProcessError(fmt.Sprintf("fork expected a function with arity of at most %d, but it was %d.", argsCount, function.RequiredArgCount), env)
-		}
-	} else {
-		if argsCount != function.RequiredArgCount {
-			return nil, ProcessError(fmt.Sprintf("fork expected a function with arity of %d, but it was %d.", argsCount, function.RequiredArgCount), env)
-		}
+	passProc, err := processArgsFit("fork", function, Length(Cdr(args)), env)
+	if err != nil {
+		return
 	}
 
 	proc := &Process{
@@ -73,34 +195,40 @@ func ForkImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		Wake:        make(chan empty, 1),
 		Abort:       make(chan empty, 1),
 		Restart:     make(chan empty, 1),
-		ReturnValue: make(chan *Data, 1)}
+		ReturnValue: make(chan *Data, 1),
+		Done:        make(chan *Data),
+		Status:      ProcessRunning}
+	proc.ctx, proc.cancel = context.WithCancel(parentCtx)
 	procObj := ObjectWithTypeAndValue("Process", unsafe.Pointer(proc))
 
 	function.ParentProcess = proc
 
 	go func() {
 		var returnValue *Data
+		var forkedErr error
 		defer func() {
-			proc.ReturnValue <- returnValue
+			proc.finish(returnValue, forkedErr)
 		}()
 
-		callWithPanicProtection(func() {
-			var forkedErr error
-			returnValue, forkedErr = function.ApplyWithoutEval(Cons(procObj, Cdr(args)), env)
+		panicked := callWithPanicProtection(func() {
+			returnValue, forkedErr = function.ApplyWithoutEval(processArgs(passProc, procObj, Cdr(args)), env)
 			if forkedErr != nil {
 				fmt.Println(forkedErr)
 			}
 		}, "fork")
+		if panicked != nil {
+			forkedErr = panicked
+		}
 	}()
 
 	return procObj, nil
 }
 
-func ProcSleepImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+func procSleep(name string, args *Data, env *SymbolTableFrame) (woken bool, remaining int64, err error) {
 	procObj := Car(args)
 
 	if !ObjectP(procObj) || ObjectType(procObj) != "Process" {
-		err = ProcessError(fmt.Sprintf("proc-sleep expects a Process object expected but received %s.", ObjectType(procObj)), env)
+		err = ProcessError(fmt.Sprintf("%s expects a Process object expected but received %s.", name, ObjectType(procObj)), env)
 		return
 	}
 
@@ -108,20 +236,49 @@ func ProcSleepImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 
 	millis := Cadr(args)
 	if !IntegerP(millis) {
-		err = ProcessError(fmt.Sprintf("proc-sleep expected an integer as a delay, but received %v.", millis), env)
+		err = ProcessError(fmt.Sprintf("%s expected an integer as a delay, but received %v.", name, millis), env)
 		return
 	}
 
-	woken := false
+	sleeping := atomic.CompareAndSwapInt32(&proc.Status, ProcessRunning, ProcessSleeping)
+
+	delay := time.Duration(IntegerValue(millis)) * time.Millisecond
+	start := time.Now()
 	select {
 	case <-proc.Wake:
 		woken = true
-	case <-time.After(time.Duration(IntegerValue(millis)) * time.Millisecond):
+		if left := delay - time.Since(start); left > 0 {
+			remaining = int64(left / time.Millisecond)
+		}
+	case <-time.After(delay):
+	}
+
+	if sleeping {
+		atomic.CompareAndSwapInt32(&proc.Status, ProcessSleeping, ProcessRunning)
 	}
 
+	return
+}
+
+func ProcSleepImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	woken, _, err := procSleep("proc-sleep", args, env)
+	if err != nil {
+		return
+	}
 	return BooleanWithValue(woken), nil
 }
 
+// proc-sleep-remaining is proc-sleep that returns the values woken? and
+// millis-remaining, millis-remaining being 0 when the full delay elapsed, so a
+// polling loop can go back to sleep for the rest of its interval.
+func ProcSleepRemainingImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	woken, remaining, err := procSleep("proc-sleep-remaining", args, env)
+	if err != nil {
+		return
+	}
+	return ValuesWithArray([]*Data{BooleanWithValue(woken), IntegerWithValue(remaining)}), nil
+}
+
 func WakeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	procObj := Car(args)
 
@@ -151,17 +308,10 @@ func ScheduleImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		return
 	}
 
-	argsCount := Length(Cddr(args)) + 1
 	function := FunctionValue(f)
-
-	if function.VarArgs {
-		if argsCount < function.RequiredArgCount {
-			return nil, ProcessError(fmt.Sprintf("schedule expected a function with arity of at most %d, but it was %d.", argsCount, function.RequiredArgCount), env)
-		}
-	} else {
-		if argsCount != function.RequiredArgCount {
-			return nil, ProcessError(fmt.Sprintf("schedule expected a function with arity of %d, but it was %d.", argsCount, function.RequiredArgCount), env)
-		}
+	passProc, err := processArgsFit("schedule", function, Length(Cddr(args)), env)
+	if err != nil {
+		return
 	}
 
 	proc := &Process{
@@ -171,27 +321,32 @@ func ScheduleImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		Abort:         make(chan empty, 1),
 		Restart:       make(chan empty, 1),
 		ReturnValue:   make(chan *Data, 1),
-		ScheduleTimer: time.NewTimer(time.Duration(IntegerValue(millis)) * time.Millisecond)}
+		ScheduleTimer: time.NewTimer(time.Duration(IntegerValue(millis)) * time.Millisecond),
+		Done:          make(chan *Data),
+		Status:        ProcessPending}
+	proc.ctx, proc.cancel = context.WithCancel(context.Background())
 	procObj := ObjectWithTypeAndValue("Process", unsafe.Pointer(proc))
 
 	function.ParentProcess = proc
 
 	go func() {
 		var returnValue *Data
+		var forkedErr error
 		defer func() {
-			proc.ReturnValue <- returnValue
+			proc.finish(returnValue, forkedErr)
 		}()
-		callWithPanicProtection(func() {
+		panicked := callWithPanicProtection(func() {
 		Loop:
 			for {
 				select {
 				case <-proc.Abort:
+					atomic.StoreInt32(&proc.Status, ProcessAbandoned)
 					break Loop
 				case <-proc.Restart:
 					proc.ScheduleTimer.Reset(time.Duration(IntegerValue(millis)) * time.Millisecond)
 				case <-proc.ScheduleTimer.C:
-					var forkedErr error
-					returnValue, forkedErr = function.ApplyWithoutEval(Cons(procObj, Cddr(args)), env)
+					atomic.StoreInt32(&proc.Status, ProcessRunning)
+					returnValue, forkedErr = function.ApplyWithoutEval(processArgs(passProc, procObj, Cddr(args)), env)
 					if forkedErr != nil {
 						fmt.Println(forkedErr)
 					}
@@ -199,24 +354,132 @@ func ScheduleImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 				}
 			}
 		}, "schedule")
+		if panicked != nil {
+			forkedErr = panicked
+		}
 	}()
 
 	return procObj, nil
 
 }
 
+// (supervise delay-ms fn max-retries [backoff-ms]) is schedule that reruns fn
+// when it fails, up to max-retries more times, waiting backoff-ms between
+// attempts. The last attempt's outcome is what proc-join, proc-status and
+// proc-error report. Abandoning the process also stops any pending retry.
+func SuperviseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	millis := Car(args)
+	if !IntegerP(millis) {
+		err = ProcessError(fmt.Sprintf("supervise expected an integer as a delay, but received %s.", String(millis)), env)
+		return
+	}
+
+	f := Cadr(args)
+	if !FunctionP(f) {
+		err = ProcessError(fmt.Sprintf("supervise expected a function, but received %s.", String(f)), env)
+		return
+	}
+
+	maxRetries := Caddr(args)
+	if !IntegerP(maxRetries) || IntegerValue(maxRetries) < 0 {
+		err = ProcessError(fmt.Sprintf("supervise expected a non-negative integer retry count, but received %s.", String(maxRetries)), env)
+		return
+	}
+
+	backoff := IntegerWithValue(0)
+	if Length(args) == 4 {
+		backoff = Fourth(args)
+		if !IntegerP(backoff) {
+			err = ProcessError(fmt.Sprintf("supervise expected an integer backoff, but received %s.", String(backoff)), env)
+			return
+		}
+	}
+
+	function := FunctionValue(f)
+	passProc, err := processArgsFit("supervise", function, 0, env)
+	if err != nil {
+		return
+	}
+
+	proc := &Process{
+		Env:           env,
+		Code:          f,
+		Wake:          make(chan empty, 1),
+		Abort:         make(chan empty, 1),
+		Restart:       make(chan empty, 1),
+		ReturnValue:   make(chan *Data, 1),
+		ScheduleTimer: time.NewTimer(time.Duration(IntegerValue(millis)) * time.Millisecond),
+		Done:          make(chan *Data),
+		Status:        ProcessPending}
+	proc.ctx, proc.cancel = context.WithCancel(context.Background())
+	procObj := ObjectWithTypeAndValue("Process", unsafe.Pointer(proc))
+
+	function.ParentProcess = proc
+
+	go func() {
+		var returnValue *Data
+		var forkedErr error
+		defer func() {
+			proc.finish(returnValue, forkedErr)
+		}()
+
+	Wait:
+		for {
+			select {
+			case <-proc.Abort:
+				atomic.StoreInt32(&proc.Status, ProcessAbandoned)
+				return
+			case <-proc.Restart:
+				proc.ScheduleTimer.Reset(time.Duration(IntegerValue(millis)) * time.Millisecond)
+			case <-proc.ScheduleTimer.C:
+				break Wait
+			}
+		}
+
+		for {
+			atomic.StoreInt32(&proc.Status, ProcessRunning)
+			forkedErr = nil
+			panicked := callWithPanicProtection(func() {
+				returnValue, forkedErr = function.ApplyWithoutEval(processArgs(passProc, procObj, nil), env)
+				if forkedErr != nil {
+					fmt.Println(forkedErr)
+				}
+			}, "supervise")
+			if panicked != nil {
+				forkedErr = panicked
+			}
+
+			if forkedErr == nil || int64(atomic.LoadInt32(&proc.Retries)) >= IntegerValue(maxRetries) {
+				return
+			}
+			atomic.AddInt32(&proc.Retries, 1)
+
+			select {
+			case <-proc.Abort:
+				atomic.StoreInt32(&proc.Status, ProcessAbandoned)
+				return
+			case <-time.After(time.Duration(IntegerValue(backoff)) * time.Millisecond):
+			}
+		}
+	}()
+
+	return procObj, nil
+}
+
+// abandon cancels a process and all of its children. A scheduled process
+// that hasn't started yet never will.
 func AbandonImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	procObj := Car(args)
 
 	if !ObjectP(procObj) || ObjectType(procObj) != "Process" {
-		err = ProcessError(fmt.Sprintf("adandon expects a Process object expected but received %s.", ObjectType(procObj)), env)
+		err = ProcessError(fmt.Sprintf("abandon expects a Process object but received %s.", String(procObj)), env)
 		return
 	}
 
 	proc := (*Process)(ObjectValue(procObj))
-
+	proc.cancel()
 	if proc.ScheduleTimer == nil {
-		return nil, ProcessError("tried to adandon a Process that isn't scheduled", env)
+		return StringWithValue("OK"), nil
 	}
 
 	select {
@@ -226,6 +489,31 @@ func AbandonImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return StringWithValue("OK"), nil
 }
 
+// proc-cancel cancels any process, and with it all of its children, without
+// the scheduling side of abandon.
+func ProcCancelImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	procObj := Car(args)
+
+	if !ObjectP(procObj) || ObjectType(procObj) != "Process" {
+		err = ProcessError(fmt.Sprintf("proc-cancel expects a Process object but received %s.", String(procObj)), env)
+		return
+	}
+
+	(*Process)(ObjectValue(procObj)).cancel()
+	return
+}
+
+func ProcCancelledImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	procObj := Car(args)
+
+	if !ObjectP(procObj) || ObjectType(procObj) != "Process" {
+		err = ProcessError(fmt.Sprintf("proc-cancelled? expects a Process object but received %s.", String(procObj)), env)
+		return
+	}
+
+	return BooleanWithValue((*Process)(ObjectValue(procObj)).ctx.Err() != nil), nil
+}
+
 func ResetTimeoutImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	procObj := Car(args)
 
@@ -278,13 +566,186 @@ func JoinImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return nil, ProcessError("tried to join on a task twice", env)
 }
 
-func AtomicImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+func procToJoin(name string, procObj *Data, env *SymbolTableFrame) (proc *Process, err error) {
+	if !ObjectP(procObj) || ObjectType(procObj) != "Process" {
+		err = ProcessError(fmt.Sprintf("%s expects a Process object but received %s.", name, String(procObj)), env)
+		return
+	}
+	proc = (*Process)(ObjectValue(procObj))
+
+	parentProcData := env.ValueOf(SymbolWithName("parentProcess"))
+	if NotNilP(parentProcData) && ObjectP(parentProcData) && ObjectType(parentProcData) == "Process" {
+		if proc == (*Process)(ObjectValue(parentProcData)) {
+			err = ProcessError(fmt.Sprintf("%s can't wait on the process it is running in.", name), env)
+		}
+	}
+	return
+}
+
+func joinResult(name string, proc *Process, env *SymbolTableFrame) (result *Data, err error) {
+	if proc.Error != nil {
+		return nil, ProcessError(fmt.Sprintf("%s: the joined process failed: %s", name, proc.Error), env)
+	}
+	return proc.Result, nil
+}
+
+// proc-join waits for a forked or scheduled process to finish and returns its
+// result. Unlike join it can be called any number of times.
+func ProcJoinImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	proc, err := procToJoin("proc-join", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	<-proc.Done
+	return joinResult("proc-join", proc, env)
+}
+
+// proc-join-timeout is proc-join that gives up after the given number of
+// milliseconds, returning nil if the process hasn't finished by then.
+func ProcJoinTimeoutImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	proc, err := procToJoin("proc-join-timeout", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	millis := Cadr(args)
+	if !IntegerP(millis) {
+		err = ProcessError(fmt.Sprintf("proc-join-timeout expected an integer as a timeout, but received %s.", String(millis)), env)
+		return
+	}
+
+	select {
+	case <-proc.Done:
+		return joinResult("proc-join-timeout", proc, env)
+	case <-time.After(time.Duration(IntegerValue(millis)) * time.Millisecond):
+		return nil, nil
+	}
+}
+
+// proc-status returns one of the symbols pending, running, sleeping,
+// completed or abandoned.
+func ProcStatusImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	procObj := Car(args)
+
+	if !ObjectP(procObj) || ObjectType(procObj) != "Process" {
+		err = ProcessError(fmt.Sprintf("proc-status expects a Process object but received %s.", String(procObj)), env)
+		return
+	}
+	proc := (*Process)(ObjectValue(procObj))
+
+	return Intern(processStatusNames[atomic.LoadInt32(&proc.Status)]), nil
+}
+
+// proc-error waits for a process to finish and returns the error it failed
+// with as an Error object, or nil if it finished normally. A process that
+// failed by signalling a condition gives that condition.
+func ProcErrorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	proc, err := procToJoin("proc-error", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	<-proc.Done
+	if proc.Error == nil {
+		return
+	}
+	var conditionErr *ConditionError
+	if errors.As(proc.Error, &conditionErr) {
+		return conditionErr.Condition, nil
+	}
+	return ErrorObjectWithKindAndMessage(proc.ErrorKind(), proc.Error.Error()), nil
+}
+
+// (with-timeout ms thunk) runs thunk in its own process and returns its result,
+// or the timeout object if it hasn't finished within ms milliseconds. On a
+// timeout the process is cancelled and sent an abort; a thunk taking the
+// process as its argument can watch (proc-cancelled? self) to stop early. A
+// result that is ready by the time the deadline is noticed wins over the
+// timeout.
+func WithTimeoutImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	millis := Car(args)
+	if !IntegerP(millis) {
+		err = ProcessError(fmt.Sprintf("with-timeout expected an integer as a timeout, but received %s.", String(millis)), env)
+		return
+	}
+
+	f := Cadr(args)
+	if !FunctionP(f) {
+		err = ProcessError(fmt.Sprintf("with-timeout expected a function, but received %s.", String(f)), env)
+		return
+	}
+
+	function := FunctionValue(f)
+	passProc, err := processArgsFit("with-timeout", function, 0, env)
+	if err != nil {
+		return
+	}
+
+	proc := &Process{
+		Env:         env,
+		Code:        f,
+		Wake:        make(chan empty, 1),
+		Abort:       make(chan empty, 1),
+		Restart:     make(chan empty, 1),
+		ReturnValue: make(chan *Data, 1),
+		Done:        make(chan *Data),
+		Status:      ProcessRunning}
+	proc.ctx, proc.cancel = context.WithCancel(context.Background())
+	procObj := ObjectWithTypeAndValue("Process", unsafe.Pointer(proc))
+
+	function.ParentProcess = proc
+
+	go func() {
+		var returnValue *Data
+		var forkedErr error
+		defer func() {
+			proc.finish(returnValue, forkedErr)
+		}()
+
+		panicked := callWithPanicProtection(func() {
+			returnValue, forkedErr = function.ApplyWithoutEval(processArgs(passProc, procObj, nil), env)
+		}, "with-timeout")
+		if panicked != nil {
+			forkedErr = panicked
+		}
+	}()
+
+	timer := time.NewTimer(time.Duration(IntegerValue(millis)) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case <-proc.Done:
+	case <-timer.C:
+		select {
+		case <-proc.Done:
+		default:
+			proc.cancel()
+			select {
+			case proc.Abort <- empty{}:
+			default:
+			}
+			return TimeoutObject, nil
+		}
+	}
+
+	if proc.Error != nil {
+		return nil, proc.Error
+	}
+	return proc.Result, nil
+}
+
+func TimeoutObjectImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(Car(args) == TimeoutObject), nil
+}
+
+func makeAtomic(name string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	atomicVal := int64(0)
 
 	if Length(args) == 1 {
 		initObj := Car(args)
 		if !IntegerP(initObj) {
-			err = ProcessError(fmt.Sprintf("atomic expects an Integer as its argument but received %s.", TypeName(TypeOf(initObj))), env)
+			err = ProcessError(fmt.Sprintf("%s expects an Integer as its argument but received %s.", name, TypeName(TypeOf(initObj))), env)
 			return
 		}
 		atomicVal = IntegerValue(initObj)
@@ -293,10 +754,18 @@ func AtomicImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return ObjectWithTypeAndValue("Atomic", unsafe.Pointer(&atomicVal)), nil
 }
 
-func AtomicLoadImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+func AtomicImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return makeAtomic("atomic", args, env)
+}
+
+func MakeAtomicImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return makeAtomic("make-atomic", args, env)
+}
+
+func atomicLoad(name string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	atomicObj := Car(args)
 	if !ObjectP(atomicObj) || ObjectType(atomicObj) != "Atomic" {
-		err = ProcessError(fmt.Sprintf("atomic-load expects an Atomic object but received %s.", ObjectType(atomicObj)), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Atomic object but received %s.", name, ObjectType(atomicObj)), env)
 		return
 	}
 
@@ -307,6 +776,14 @@ func AtomicLoadImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 	return IntegerWithValue(value), nil
 }
 
+func AtomicLoadImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return atomicLoad("atomic-load", args, env)
+}
+
+func AtomicGetImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return atomicLoad("atomic-get", args, env)
+}
+
 func AtomicStoreImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	atomicObj := Car(args)
 	if !ObjectP(atomicObj) || ObjectType(atomicObj) != "Atomic" {
@@ -330,10 +807,10 @@ func AtomicStoreImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 	return
 }
 
-func AtomicAddImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+func atomicAdd(name string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	atomicObj := Car(args)
 	if !ObjectP(atomicObj) || ObjectType(atomicObj) != "Atomic" {
-		err = ProcessError(fmt.Sprintf("atomic-add! expects an Atomic object but received %s.", ObjectType(atomicObj)), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Atomic object but received %s.", name, ObjectType(atomicObj)), env)
 		return
 	}
 
@@ -342,7 +819,7 @@ func AtomicAddImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 	deltaObj := Cadr(args)
 
 	if !IntegerP(deltaObj) {
-		err = ProcessError(fmt.Sprintf("atomic-add! expects an Integer as its second argument but received %s.", TypeName(TypeOf(deltaObj))), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Integer as its second argument but received %s.", name, TypeName(TypeOf(deltaObj))), env)
 		return
 	}
 
@@ -353,6 +830,14 @@ func AtomicAddImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 	return IntegerWithValue(new), nil
 }
 
+func AtomicAddImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return atomicAdd("atomic-add!", args, env)
+}
+
+func AtomicAddAliasImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return atomicAdd("atomic-add", args, env)
+}
+
 func AtomicSwapImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	atomicObj := Car(args)
 	if !ObjectP(atomicObj) || ObjectType(atomicObj) != "Atomic" {
@@ -376,10 +861,10 @@ func AtomicSwapImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 	return IntegerWithValue(old), nil
 }
 
-func AtomicCompareAndSwapImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+func atomicCompareAndSwap(name string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	atomicObj := Car(args)
 	if !ObjectP(atomicObj) || ObjectType(atomicObj) != "Atomic" {
-		err = ProcessError(fmt.Sprintf("atomic-compare-and-swap! expects an Atomic object but received %s.", ObjectType(atomicObj)), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Atomic object but received %s.", name, ObjectType(atomicObj)), env)
 		return
 	}
 
@@ -388,14 +873,14 @@ func AtomicCompareAndSwapImpl(args *Data, env *SymbolTableFrame) (result *Data,
 	oldObj := Cadr(args)
 
 	if !IntegerP(oldObj) {
-		err = ProcessError(fmt.Sprintf("atomic-compare-and-swap! expects an Integer as its second argument but received %s.", TypeName(TypeOf(oldObj))), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Integer as its second argument but received %s.", name, TypeName(TypeOf(oldObj))), env)
 		return
 	}
 
 	newObj := Caddr(args)
 
 	if !IntegerP(newObj) {
-		err = ProcessError(fmt.Sprintf("atomic-compare-and-swap! expects an Integer as its third argument but received %s.", TypeName(TypeOf(newObj))), env)
+		err = ProcessError(fmt.Sprintf("%s expects an Integer as its third argument but received %s.", name, TypeName(TypeOf(newObj))), env)
 		return
 	}
 
@@ -407,9 +892,209 @@ func AtomicCompareAndSwapImpl(args *Data, env *SymbolTableFrame) (result *Data,
 	return BooleanWithValue(swapped), nil
 }
 
-func callWithPanicProtection(f func(), prefix string) {
+func AtomicCompareAndSwapImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return atomicCompareAndSwap("atomic-compare-and-swap!", args, env)
+}
+
+func AtomicCompareAndSwapAliasImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return atomicCompareAndSwap("atomic-compare-and-swap", args, env)
+}
+
+func MakeMutexImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return ObjectWithTypeAndValue("Mutex", unsafe.Pointer(&Mutex{})), nil
+}
+
+func mutexArg(name string, mutexObj *Data, env *SymbolTableFrame) (mutex *Mutex, err error) {
+	if !ObjectP(mutexObj) || ObjectType(mutexObj) != "Mutex" {
+		err = ProcessError(fmt.Sprintf("%s expects a Mutex object but received %s.", name, String(mutexObj)), env)
+		return
+	}
+	return (*Mutex)(ObjectValue(mutexObj)), nil
+}
+
+func (mutex *Mutex) lock() {
+	mutex.Lock()
+	atomic.StoreInt32(&mutex.locked, 1)
+}
+
+func (mutex *Mutex) unlock() bool {
+	if !atomic.CompareAndSwapInt32(&mutex.locked, 1, 0) {
+		return false
+	}
+	mutex.Unlock()
+	return true
+}
+
+func MutexLockImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	mutex, err := mutexArg("mutex-lock", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	mutex.lock()
+	return
+}
+
+func MutexUnlockImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	mutex, err := mutexArg("mutex-unlock", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	if !mutex.unlock() {
+		err = ProcessError("mutex-unlock tried to unlock a mutex that isn't locked.", env)
+	}
+	return
+}
+
+// (with-mutex mutex body...) evaluates body while holding mutex. The mutex is
+// released however the body exits, including errors and panics.
+func WithMutexImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	mutexObj, err := Eval(Car(args), env)
+	if err != nil {
+		return
+	}
+	mutex, err := mutexArg("with-mutex", mutexObj, env)
+	if err != nil {
+		return
+	}
+
+	mutex.lock()
+	defer mutex.unlock()
+
+	return evaluateBody(Cdr(args), env)
+}
+
+func MakeWaitGroupImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return ObjectWithTypeAndValue("WaitGroup", unsafe.Pointer(&WaitGroup{})), nil
+}
+
+func waitGroupArg(name string, groupObj *Data, env *SymbolTableFrame) (group *WaitGroup, err error) {
+	if !ObjectP(groupObj) || ObjectType(groupObj) != "WaitGroup" {
+		err = ProcessError(fmt.Sprintf("%s expects a WaitGroup object but received %s.", name, String(groupObj)), env)
+		return
+	}
+	return (*WaitGroup)(ObjectValue(groupObj)), nil
+}
+
+func (group *WaitGroup) add(delta int64) bool {
+	group.mutex.Lock()
+	defer group.mutex.Unlock()
+
+	if group.count+delta < 0 {
+		return false
+	}
+	group.count += delta
+	group.Add(int(delta))
+	return true
+}
+
+func WaitGroupAddImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	group, err := waitGroupArg("waitgroup-add", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	delta := Cadr(args)
+	if !IntegerP(delta) {
+		err = ProcessError(fmt.Sprintf("waitgroup-add expects an Integer delta but received %s.", String(delta)), env)
+		return
+	}
+
+	if !group.add(IntegerValue(delta)) {
+		err = ProcessError("waitgroup-add would make the counter negative.", env)
+	}
+	return
+}
+
+func WaitGroupDoneImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	group, err := waitGroupArg("waitgroup-done", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	if !group.add(-1) {
+		err = ProcessError("waitgroup-done called more times than were added.", env)
+	}
+	return
+}
+
+func WaitGroupWaitImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	group, err := waitGroupArg("waitgroup-wait", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	group.Wait()
+	return
+}
+
+// (parallel-map f list) applies f to each element of list in its own
+// goroutine and returns the results in the original order. The first error
+// raised by any call is returned once every call has finished.
+func ParallelMapImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	f := Car(args)
+	if !FunctionOrPrimitiveP(f) {
+		err = ProcessError(fmt.Sprintf("parallel-map expects a function as its first argument but received %s.", String(f)), env)
+		return
+	}
+
+	col := Cadr(args)
+	if !ListP(col) {
+		err = ProcessError(fmt.Sprintf("parallel-map expects a list as its second argument but received %s.", String(col)), env)
+		return
+	}
+
+	items := ToArray(col)
+	results := make([]*Data, len(items))
+	errs := make([]error, len(items))
+
+	var group sync.WaitGroup
+	group.Add(len(items))
+	for i, item := range items {
+		go func(i int, item *Data) {
+			defer group.Done()
+			panicked := callWithPanicProtection(func() {
+				results[i], errs[i] = ApplyWithoutEval(f, InternalMakeList(item), env)
+			}, "parallel-map")
+			if panicked != nil {
+				errs[i] = panicked
+			}
+		}(i, item)
+	}
+	group.Wait()
+
+	for _, e := range errs {
+		if e != nil {
+			return nil, e
+		}
+	}
+	return ArrayToList(results), nil
+}
+
+type processPanic struct {
+	message string
+}
+
+func (p *processPanic) Error() string {
+	return p.message
+}
+
+// ErrorKind names the sort of failure a finished process hit: panic when the
+// go code underneath panicked, otherwise error.
+func (proc *Process) ErrorKind() string {
+	if _, ok := proc.Error.(*processPanic); ok {
+		return "panic"
+	}
+	return "error"
+}
+
+// callWithPanicProtection runs f, printing the top of the stack and returning
+// the recovered value as an error if it panics.
+func callWithPanicProtection(f func(), prefix string) (panicked error) {
 	defer func() {
 		if recovered := recover(); recovered != nil {
+			panicked = &processPanic{fmt.Sprintf("%s panicked: %v", prefix, recovered)}
 			stackBuf := make([]byte, 10000)
 			stackBuf = stackBuf[:runtime.Stack(stackBuf, false)]
 			stack := strings.Split(string(stackBuf), "\n")
@@ -420,4 +1105,5 @@ func callWithPanicProtection(f func(), prefix string) {
 	}()
 
 	f()
+	return
 }
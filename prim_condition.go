@@ -0,0 +1,303 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the condition system primitive functions.
+
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+type ConditionType struct {
+	Name   string
+	Parent *ConditionType
+	Slots  []string
+}
+
+type Condition struct {
+	Type  *ConditionType
+	Slots map[string]*Data
+}
+
+// ConditionError carries a condition up through the go error returns so
+// handler-case can recover it. Its message is the condition's message slot
+// if it has one.
+type ConditionError struct {
+	Condition *Data
+}
+
+type conditionTypeTable struct {
+	Types map[string]*ConditionType
+	Mutex sync.RWMutex
+}
+
+var conditionTypes conditionTypeTable = conditionTypeTable{Types: make(map[string]*ConditionType)}
+
+func RegisterConditionPrimitives() {
+	condition := defineConditionType("condition", nil, nil)
+	errorType := defineConditionType("error", condition, nil)
+	defineConditionType("simple-error", errorType, []string{"message", "trace"})
+	MakePrimitiveFunction("simple-error-message", "1", conditionAccessor("simple-error-message", "message"))
+	MakePrimitiveFunction("simple-error-trace", "1", conditionAccessor("simple-error-trace", "trace"))
+
+	MakeSpecialForm("define-condition", ">=2", DefineConditionImpl)
+	MakePrimitiveFunction("make-condition", ">=1", MakeConditionImpl)
+	MakePrimitiveFunction("condition?", "1", ConditionPImpl)
+	MakePrimitiveFunction("condition-type", "1", ConditionTypeImpl)
+	MakePrimitiveFunction("condition-slot", "2", ConditionSlotImpl)
+	MakePrimitiveFunction("signal", "1", SignalImpl)
+	MakeSpecialForm("handler-case", ">=1", HandlerCaseImpl)
+}
+
+func defineConditionType(name string, parent *ConditionType, slots []string) *ConditionType {
+	t := &ConditionType{Name: name, Parent: parent}
+	if parent != nil {
+		t.Slots = append(t.Slots, parent.Slots...)
+	}
+	t.Slots = append(t.Slots, slots...)
+
+	conditionTypes.Mutex.Lock()
+	conditionTypes.Types[name] = t
+	conditionTypes.Mutex.Unlock()
+	return t
+}
+
+func conditionTypeNamed(name string) *ConditionType {
+	conditionTypes.Mutex.RLock()
+	defer conditionTypes.Mutex.RUnlock()
+	return conditionTypes.Types[name]
+}
+
+// distanceTo is how many parent links separate self from t, or -1 if self
+// isn't a t.
+func (self *ConditionType) distanceTo(t *ConditionType) int {
+	distance := 0
+	for c := self; c != nil; c = c.Parent {
+		if c == t {
+			return distance
+		}
+		distance++
+	}
+	return -1
+}
+
+func (self *ConditionType) hasSlot(name string) bool {
+	for _, slot := range self.Slots {
+		if slot == name {
+			return true
+		}
+	}
+	return false
+}
+
+func ConditionP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "Condition"
+}
+
+func ConditionValue(d *Data) *Condition {
+	return (*Condition)(ObjectValue(d))
+}
+
+func ConditionWithTypeAndSlots(t *ConditionType, slots map[string]*Data) *Data {
+	return ObjectWithTypeAndValue("Condition", unsafe.Pointer(&Condition{Type: t, Slots: slots}))
+}
+
+func (self *ConditionError) Error() string {
+	c := ConditionValue(self.Condition)
+	if message, ok := c.Slots["message"]; ok && StringP(message) {
+		return StringValue(message)
+	}
+	return fmt.Sprintf("Condition %s signalled.", c.Type.Name)
+}
+
+// bareErrorMessage is the message err was raised with, without the forms
+// and source locations it was wrapped in on the way up.
+func bareErrorMessage(err error) string {
+	for inner := errors.Unwrap(err); inner != nil; inner = errors.Unwrap(err) {
+		err = inner
+	}
+	return err.Error()
+}
+
+// conditionFromError recovers the condition carried by err. Plain errors
+// become simple-errors holding the message they were raised with, and in
+// their trace slot, the full text of err with the forms it went up through.
+func conditionFromError(err error) *Data {
+	var conditionErr *ConditionError
+	if errors.As(err, &conditionErr) {
+		return conditionErr.Condition
+	}
+	return ConditionWithTypeAndSlots(conditionTypeNamed("simple-error"), map[string]*Data{
+		"message": StringWithValue(bareErrorMessage(err)),
+		"trace":   StringWithValue(err.Error())})
+}
+
+func conditionAccessor(name string, slot string) func(*Data, *SymbolTableFrame) (*Data, error) {
+	return func(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+		c := Car(args)
+		if !ConditionP(c) || !ConditionValue(c).Type.hasSlot(slot) {
+			err = ProcessError(fmt.Sprintf("%s expects a condition with a %s slot but received %s.", name, slot, String(c)), env)
+			return
+		}
+		return ConditionValue(c).Slots[slot], nil
+	}
+}
+
+// (define-condition name (parent) (slot...)) declares a condition type and
+// defines a name-slot accessor for each of its slots. The parent defaults
+// to error.
+func DefineConditionImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	name := Car(args)
+	if !SymbolP(name) {
+		err = ProcessError(fmt.Sprintf("define-condition expects a symbol name but received %s.", String(name)), env)
+		return
+	}
+
+	parentList := Cadr(args)
+	if !ListP(parentList) || Length(parentList) > 1 {
+		err = ProcessError(fmt.Sprintf("define-condition expects a list of at most one parent type but received %s.", String(parentList)), env)
+		return
+	}
+	parentName := "error"
+	if Length(parentList) == 1 {
+		parentName = StringValue(Car(parentList))
+	}
+	parent := conditionTypeNamed(parentName)
+	if parent == nil {
+		err = ProcessError(fmt.Sprintf("define-condition couldn't find the parent condition type %s.", parentName), env)
+		return
+	}
+
+	slots := make([]string, 0)
+	for c := Caddr(args); NotNilP(c); c = Cdr(c) {
+		if !SymbolP(Car(c)) {
+			err = ProcessError(fmt.Sprintf("define-condition expects symbol slot names but received %s.", String(Car(c))), env)
+			return
+		}
+		slots = append(slots, StringValue(Car(c)))
+	}
+
+	t := defineConditionType(StringValue(name), parent, slots)
+	for _, slot := range t.Slots {
+		accessorName := fmt.Sprintf("%s-%s", t.Name, slot)
+		f := &PrimitiveFunction{Name: accessorName, Body: conditionAccessor(accessorName, slot)}
+		f.parseNumArgs("1")
+		_, err = env.BindLocallyTo(Intern(accessorName), PrimitiveWithNameAndFunc(accessorName, f))
+		if err != nil {
+			return
+		}
+	}
+	return name, nil
+}
+
+// (make-condition 'type slot: value ...) builds a condition. Slots that
+// aren't given are nil.
+func MakeConditionImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	typeName := Car(args)
+	if !SymbolP(typeName) {
+		err = ProcessError(fmt.Sprintf("make-condition expects a condition type name but received %s.", String(typeName)), env)
+		return
+	}
+	t := conditionTypeNamed(StringValue(typeName))
+	if t == nil {
+		err = ProcessError(fmt.Sprintf("make-condition couldn't find the condition type %s.", StringValue(typeName)), env)
+		return
+	}
+
+	slots := make(map[string]*Data, len(t.Slots))
+	for c := Cdr(args); NotNilP(c); c = Cddr(c) {
+		if !NakedP(Car(c)) || NilP(Cdr(c)) {
+			err = ProcessError(fmt.Sprintf("make-condition expects slot:/value pairs but received %s.", String(Cdr(args))), env)
+			return
+		}
+		slot := strings.TrimSuffix(StringValue(Car(c)), ":")
+		if !t.hasSlot(slot) {
+			err = ProcessError(fmt.Sprintf("make-condition: %s has no slot named %s.", t.Name, slot), env)
+			return
+		}
+		slots[slot] = Cadr(c)
+	}
+	return ConditionWithTypeAndSlots(t, slots), nil
+}
+
+func ConditionPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(ConditionP(Car(args))), nil
+}
+
+func ConditionTypeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	c := Car(args)
+	if !ConditionP(c) {
+		err = ProcessError(fmt.Sprintf("condition-type expects a condition but received %s.", String(c)), env)
+		return
+	}
+	return Intern(ConditionValue(c).Type.Name), nil
+}
+
+func ConditionSlotImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	slot := Cadr(args)
+	if !SymbolP(slot) {
+		err = ProcessError(fmt.Sprintf("condition-slot expects a slot name but received %s.", String(slot)), env)
+		return
+	}
+	return conditionAccessor("condition-slot", strings.TrimSuffix(StringValue(slot), ":"))(args, env)
+}
+
+func SignalImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	c := Car(args)
+	if !ConditionP(c) {
+		err = ProcessError(fmt.Sprintf("signal expects a condition but received %s.", String(c)), env)
+		return
+	}
+	return nil, &ConditionError{Condition: c}
+}
+
+// (handler-case expr (type (var) body...)...) evaluates expr, and if it
+// raises a condition runs the clause whose type is the closest ancestor of
+// the condition's type, with var bound to the condition. Conditions no
+// clause matches are raised again.
+func HandlerCaseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	result, err = Eval(Car(args), env)
+	if err == nil {
+		return
+	}
+
+	c := conditionFromError(err)
+	t := ConditionValue(c).Type
+
+	var handler *Data
+	best := -1
+	for clauses := Cdr(args); NotNilP(clauses); clauses = Cdr(clauses) {
+		clause := Car(clauses)
+		if !PairP(clause) || !SymbolP(Car(clause)) || !ListP(Cadr(clause)) || Length(Cadr(clause)) > 1 {
+			return nil, ProcessError(fmt.Sprintf("handler-case expects clauses of the form (type (var) body...) but received %s.", String(clause)), env)
+		}
+		clauseType := conditionTypeNamed(StringValue(Car(clause)))
+		if clauseType == nil {
+			return nil, ProcessError(fmt.Sprintf("handler-case couldn't find the condition type %s.", StringValue(Car(clause))), env)
+		}
+		if distance := t.distanceTo(clauseType); distance != -1 && (best == -1 || distance < best) {
+			handler = clause
+			best = distance
+		}
+	}
+
+	if handler == nil {
+		return
+	}
+
+	localEnv := NewSymbolTableFrameBelow(env, "handler-case")
+	if Length(Cadr(handler)) == 1 {
+		_, err = localEnv.BindLocallyTo(Car(Cadr(handler)), c)
+		if err != nil {
+			return
+		}
+	}
+	return BeginImpl(Cddr(handler), localEnv)
+}
@@ -11,19 +11,48 @@ import (
 	"errors"
 	"fmt"
 	"github.com/SteelSeries/set.v0"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 var DebugCommandPrefix string = ":"
 
+// tracedFunction is a function binding that trace has replaced with Shim.
+type tracedFunction struct {
+	Original *Data
+	Shim     *Data
+}
+
+var tracedFunctions = struct {
+	Bindings map[*Binding]*tracedFunction
+	Mutex    sync.Mutex
+}{Bindings: make(map[*Binding]*tracedFunction)}
+
+// traceDepth is how many traced calls are in progress, for indenting.
+var traceDepth int32
+
+// debugReadLine reads the debugger's input.
+var debugReadLine = ReadLine
+
+// debugResume is how the debugger is to be left once the form typed at it
+// has been evaluated: "step", "continue" or "" to stay.
+var debugResume string
+
 func RegisterDebugPrimitives() {
 	MakePrimitiveFunction("debug-trace", "0|1", DebugTraceImpl)
 	MakePrimitiveFunction("lisp-trace", "0|1", LispTraceImpl)
 	MakePrimitiveFunction("debug-on-entry", "0", DebugOnEntryImpl)
 	MakePrimitiveFunction("remove-debug-on-entry", "1", RemoveDebugOnEntryImpl)
 	MakePrimitiveFunction("dump", "0", DumpSymbolTableImpl)
+	MakeSpecialForm("trace", "1|2", TraceImpl)
+	MakeSpecialForm("untrace", "1", UntraceImpl)
 
 	MakeRestrictedPrimitiveFunction("debug", "0", DebugImpl)
+	MakeRestrictedPrimitiveFunction("break", "0|1", BreakImpl)
+	MakePrimitiveFunction("step", "0", StepImpl)
+	MakePrimitiveFunction("continue", "0", ContinueImpl)
 	MakeRestrictedPrimitiveFunction("debug-on-error", "0|1", DebugOnErrorImpl)
 	MakeRestrictedPrimitiveFunction("add-debug-on-entry", "1", AddDebugOnEntryImpl)
 }
@@ -77,6 +106,93 @@ func RemoveDebugOnEntryImpl(args *Data, env *SymbolTableFrame) (result *Data, er
 	return DebugOnEntryImpl(args, env)
 }
 
+// traceShim makes a primitive that prints each call to f and what it
+// returns to port, indented by the number of traced calls in progress.
+func traceShim(name string, f *Data, port outputPort) *Data {
+	shim := &PrimitiveFunction{Name: name, Body: func(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+		depth := atomic.AddInt32(&traceDepth, 1) - 1
+		defer atomic.AddInt32(&traceDepth, -1)
+		indent := strings.Repeat("  ", int(depth))
+		fmt.Fprintf(port, "%s%s\n", indent, String(Cons(Intern(name), args)))
+		result, err = ApplyWithoutEval(f, args, env)
+		if err == nil {
+			fmt.Fprintf(port, "%s%s => %s\n", indent, name, String(result))
+		}
+		return
+	}}
+	shim.parseNumArgs("*")
+	return PrimitiveWithNameAndFunc(name, shim)
+}
+
+// (trace name [port]) replaces the function bound to name with one that
+// prints each call's arguments and result, to port or the standard output.
+// Calls made while another traced call runs are indented one more step, so
+// recursion shows as a tree.
+func TraceImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sym := Car(args)
+	if !SymbolP(sym) {
+		err = ProcessError(fmt.Sprintf("trace expects a function name but received %s.", String(sym)), env)
+		return
+	}
+
+	var port outputPort = os.Stdout
+	if Length(args) == 2 {
+		p, evalErr := Eval(Cadr(args), env)
+		if evalErr != nil {
+			return nil, evalErr
+		}
+		if !OutputPortP(p) {
+			err = ProcessError(fmt.Sprintf("trace expects a port but received %s.", String(p)), env)
+			return
+		}
+		port = outputPortValue(p)
+	}
+
+	binding, found := env.FindBindingFor(sym)
+	if !found {
+		err = ProcessError(fmt.Sprintf("trace: %s is undefined.", StringValue(sym)), env)
+		return
+	}
+
+	tracedFunctions.Mutex.Lock()
+	defer tracedFunctions.Mutex.Unlock()
+	if traced, ok := tracedFunctions.Bindings[binding]; ok && binding.Val == traced.Shim {
+		binding.Val = traced.Original
+	}
+	if !FunctionP(binding.Val) {
+		err = ProcessError(fmt.Sprintf("trace expects %s to name a function but it is %s.", StringValue(sym), String(binding.Val)), env)
+		return
+	}
+	traced := &tracedFunction{Original: binding.Val, Shim: traceShim(StringValue(sym), binding.Val, port)}
+	tracedFunctions.Bindings[binding] = traced
+	binding.Val = traced.Shim
+	return sym, nil
+}
+
+// (untrace name) puts back the function that trace replaced, unless name
+// has been bound to something else since.
+func UntraceImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sym := Car(args)
+	if !SymbolP(sym) {
+		err = ProcessError(fmt.Sprintf("untrace expects a function name but received %s.", String(sym)), env)
+		return
+	}
+
+	binding, found := env.FindBindingFor(sym)
+	tracedFunctions.Mutex.Lock()
+	defer tracedFunctions.Mutex.Unlock()
+	traced, ok := tracedFunctions.Bindings[binding]
+	if !found || !ok {
+		err = ProcessError(fmt.Sprintf("untrace: %s isn't traced.", StringValue(sym)), env)
+		return
+	}
+	delete(tracedFunctions.Bindings, binding)
+	if binding.Val == traced.Shim {
+		binding.Val = traced.Original
+	}
+	return sym, nil
+}
+
 func DebugOnErrorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	if Length(args) == 1 {
 		DebugOnError = BooleanValue(Car(args))
@@ -92,6 +208,37 @@ func DebugImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return
 }
 
+// (break [message]) stops in the debugger, where the variables in scope at
+// the break can be inspected and changed. (step) leaves the debugger to
+// stop again at the next evaluation and (continue) leaves it to run on.
+func BreakImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if Length(args) == 1 {
+		fmt.Printf("Break: %s\n", PrintString(Car(args)))
+	} else {
+		fmt.Printf("Break\n")
+	}
+
+	DebugRepl(env)
+	return
+}
+
+func debugResumeWith(name string, env *SymbolTableFrame) (result *Data, err error) {
+	if !DebugEvalInDebugRepl {
+		err = ProcessError(fmt.Sprintf("%s can only be used in the debugger.", name), env)
+		return
+	}
+	debugResume = name
+	return
+}
+
+func StepImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return debugResumeWith("step", env)
+}
+
+func ContinueImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return debugResumeWith("continue", env)
+}
+
 func processState(tokens []string) (ok bool, state bool) {
 	if len(tokens) != 2 {
 		fmt.Printf("Missing on/off.\n")
@@ -128,7 +275,14 @@ func DebugRepl(env *SymbolTableFrame) {
 				println("Don't Panic!")
 			}
 		}()
-		input := *ReadLine(&prompt)
+		inputp := debugReadLine(&prompt)
+		if inputp == nil {
+			DebugCurrentFrame = nil
+			DebugSingleStep = false
+			DebugEvalInDebugRepl = false
+			return
+		}
+		input := *inputp
 		if input != "" {
 			if input != lastInput {
 				AddHistory(input)
@@ -170,6 +324,8 @@ func DebugRepl(env *SymbolTableFrame) {
 					fmt.Printf(":s        - single step (run to the next evaluation)\n")
 					fmt.Printf(":t on/off - Enable/disable tracing\n")
 					fmt.Printf(":u        - continue until the enclosing environment frame is returned to\n")
+					fmt.Printf("(step)    - single step, as :s\n")
+					fmt.Printf("(continue) - continue, as :c\n")
 					fmt.Printf("\n")
 				case "b":
 					env.DumpHeaders()
@@ -240,8 +396,17 @@ func DebugRepl(env *SymbolTableFrame) {
 					DebugEvalInDebugRepl = true
 					d, err := Eval(code, env)
 					DebugEvalInDebugRepl = false
+					resume := debugResume
+					debugResume = ""
 					if err != nil {
 						fmt.Printf("Error in evaluation: %s\n", err)
+					} else if resume == "step" {
+						DebugSingleStep = true
+						return
+					} else if resume == "continue" {
+						DebugCurrentFrame = nil
+						DebugSingleStep = false
+						return
 					} else {
 						fmt.Printf("==> %s\n", String(d))
 					}
@@ -0,0 +1,221 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the describe primitive function.
+
+package golisp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// describeSampleSize is how many entries describe shows of a hash table or
+// set.
+const describeSampleSize = 5
+
+func RegisterDescribePrimitives() {
+	MakePrimitiveFunction("describe", "1|2", DescribeImpl)
+}
+
+// Describe is a description of d: d itself, then a line for its type and
+// one for each thing worth knowing about it.
+func Describe(d *Data, env *SymbolTableFrame) string {
+	lines := append([]string{StringInEnv(d, env), fmt.Sprintf("type: %s", describeType(d))}, describeDetails(d, env)...)
+	return strings.Join(lines, "\n  ") + "\n"
+}
+
+func describeType(d *Data) string {
+	switch TypeOf(d) {
+	case ConsCellType:
+		if NilP(d) {
+			return "Nil"
+		}
+	case PrimitiveType:
+		if PrimitiveValue(d).Special {
+			return "Special Form"
+		}
+		return "Primitive Function"
+	case BoxedObjectType:
+		switch ObjectType(d) {
+		case "[]byte":
+			return "Bytearray"
+		case "HashTable":
+			return "Hash Table"
+		case "StringBuilder":
+			return "String Builder"
+		case "WeakRef":
+			return "Weak Reference"
+		case "Structure":
+			return fmt.Sprintf("Structure %s", StructureValue(d).Type.Name)
+		case "Vector", "Set", "Process", "Values", "Error", "Promise":
+			return ObjectType(d)
+		}
+		return fmt.Sprintf("Go Object of type %s", ObjectType(d))
+	}
+	return TypeName(TypeOf(d))
+}
+
+// listShape is the number of cells in the list d, what its last cell's cdr
+// is and whether it loops back on itself instead.
+func listShape(d *Data) (cells int, tail *Data, cyclic bool) {
+	seen := make(map[unsafe.Pointer]bool)
+	for tail = d; PairP(tail) && NotNilP(tail); tail = Cdr(tail) {
+		if seen[tail.Value] {
+			return cells, nil, true
+		}
+		seen[tail.Value] = true
+		cells++
+	}
+	return
+}
+
+func describeDetails(d *Data, env *SymbolTableFrame) []string {
+	switch TypeOf(d) {
+	case ConsCellType, AlistType, AlistCellType:
+		if NilP(d) {
+			return nil
+		}
+		cells, tail, cyclic := listShape(d)
+		switch {
+		case cyclic:
+			return []string{"cyclic", fmt.Sprintf("cells: %d", cells)}
+		case NotNilP(tail):
+			return []string{fmt.Sprintf("improper, ending in %s", String(tail)), fmt.Sprintf("cells: %d", cells)}
+		}
+		return []string{fmt.Sprintf("length: %d", cells)}
+	case StringType:
+		return []string{fmt.Sprintf("length: %d", utf8.RuneCountInString(StringValue(d)))}
+	case SymbolType:
+		if Intern(StringValue(d)) != d {
+			return []string{"uninterned"}
+		}
+	case FunctionType:
+		f := FunctionValue(d)
+		return []string{
+			fmt.Sprintf("name: %s", f.Name),
+			fmt.Sprintf("parameters: %s", String(f.Params)),
+			fmt.Sprintf("arity: %s", functionArity(f.RequiredArgCount, f.VarArgs || f.extended != nil)),
+			fmt.Sprintf("source: %s", String(Cons(Intern("lambda"), Cons(f.Params, f.Body))))}
+	case MacroType:
+		m := MacroValue(d)
+		return []string{
+			fmt.Sprintf("name: %s", m.Name),
+			fmt.Sprintf("parameters: %s", String(m.Params)),
+			fmt.Sprintf("source: %s", String(InternalMakeList(Intern("defmacro"), Cons(Intern(m.Name), m.Params), m.Body)))}
+	case PrimitiveType:
+		p := PrimitiveValue(d)
+		details := []string{fmt.Sprintf("name: %s", p.Name), fmt.Sprintf("arity: %s", p.argsString())}
+		if p.IsRestricted {
+			details = append(details, "restricted")
+		}
+		if p.parameter != nil {
+			details = append(details, fmt.Sprintf("parameter with the value %s", String(p.parameter.current(env))))
+		}
+		return details
+	case FrameType:
+		frame := FrameValue(d)
+		frame.Mutex.RLock()
+		slots := make([]string, 0, len(frame.Data))
+		for k, v := range frame.Data {
+			slots = append(slots, fmt.Sprintf("%s %s", k, String(v)))
+		}
+		frame.Mutex.RUnlock()
+		sort.Strings(slots)
+		return append([]string{fmt.Sprintf("slots: %d", len(slots))}, slots...)
+	case BoxedObjectType:
+		return describeObject(d)
+	}
+	return nil
+}
+
+// functionArity writes an arity the way primitives' are written.
+func functionArity(required int, more bool) string {
+	if more {
+		return fmt.Sprintf(">=%d", required)
+	}
+	return fmt.Sprintf("%d", required)
+}
+
+// sample is the first of lines, sorted, along with how many were left out.
+func sample(lines []string) []string {
+	sort.Strings(lines)
+	if len(lines) <= describeSampleSize {
+		return lines
+	}
+	return append(lines[:describeSampleSize], fmt.Sprintf("and %d more", len(lines)-describeSampleSize))
+}
+
+func describeObject(d *Data) []string {
+	switch ObjectType(d) {
+	case "[]byte":
+		return []string{fmt.Sprintf("length: %d", len(*(*[]byte)(ObjectValue(d))))}
+	case "Vector":
+		v := VectorValue(d)
+		details := []string{fmt.Sprintf("length: %d", len(v.Elements))}
+		if v.Immutable {
+			details = append(details, "immutable")
+		}
+		return details
+	case "HashTable":
+		table := (*HashTable)(ObjectValue(d))
+		table.Mutex.RLock()
+		entries := make([]string, 0, len(table.Entries))
+		for _, entry := range table.Entries {
+			entries = append(entries, fmt.Sprintf("%s => %s", String(entry.Key), String(entry.Value)))
+		}
+		table.Mutex.RUnlock()
+		return append([]string{fmt.Sprintf("entries: %d", len(entries))}, sample(entries)...)
+	case "Set":
+		elements := make([]string, 0)
+		for _, element := range SetValue(d).snapshot() {
+			elements = append(elements, String(element))
+		}
+		return append([]string{fmt.Sprintf("elements: %d", len(elements))}, sample(elements)...)
+	case "Structure":
+		s := StructureValue(d)
+		values := s.snapshot()
+		fields := make([]string, len(values))
+		for i, field := range s.Type.Fields {
+			fields[i] = fmt.Sprintf("%s: %s", field, String(values[i]))
+		}
+		return fields
+	case "Process":
+		return []string{fmt.Sprintf("status: %s", processStatusNames[atomic.LoadInt32(&(*Process)(ObjectValue(d)).Status)])}
+	case "Values":
+		return []string{fmt.Sprintf("values: %d", len(ValuesValue(d)))}
+	case "Promise":
+		if value, done := PromiseValue(d).forced(); done {
+			return []string{fmt.Sprintf("forced to %s", String(value))}
+		}
+		return []string{"not yet forced"}
+	case "WeakRef":
+		if value := WeakRefValue(d).Value(); value != nil {
+			return []string{fmt.Sprintf("value: %s", String(value))}
+		}
+		return []string{"collected"}
+	}
+	return nil
+}
+
+// (describe value [port]) writes a description of value, its type and for
+// compound values what is in them, to port or the current output port.
+func DescribeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	port := currentOutputPort(env)
+	if Length(args) == 2 {
+		p := Cadr(args)
+		if !OutputPortP(p) {
+			err = ProcessError(fmt.Sprintf("describe expects its second argument be a port but received %s.", String(p)), env)
+			return
+		}
+		port = outputPortValue(p)
+	}
+	_, err = port.WriteString(Describe(Car(args), env))
+	return
+}
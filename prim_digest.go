@@ -0,0 +1,65 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the hashing primitive functions.
+//
+// md5, sha1 and sha256 are cryptographic digests of the bytes of a string or
+// bytearray, written in hex as hex-encode writes. hash is not: it is FNV-1a,
+// quick to compute and fine for bucketing and cache keys, but easy to make
+// collide on purpose.
+
+package golisp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/fnv"
+	"math"
+)
+
+func RegisterDigestPrimitives() {
+	MakePrimitiveFunction("md5", "1", Md5Impl)
+	MakePrimitiveFunction("sha1", "1", Sha1Impl)
+	MakePrimitiveFunction("sha256", "1", Sha256Impl)
+	MakePrimitiveFunction("hash", "1", HashImpl)
+}
+
+func digest(name string, h hash.Hash, args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	b, err := bytesArg(name, args, env)
+	if err != nil {
+		return
+	}
+	h.Write(b)
+	return StringWithValue(hex.EncodeToString(h.Sum(nil))), nil
+}
+
+func Md5Impl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return digest("md5", md5.New(), args, env)
+}
+
+func Sha1Impl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return digest("sha1", sha1.New(), args, env)
+}
+
+func Sha256Impl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return digest("sha256", sha256.New(), args, env)
+}
+
+// (hash value) is a non-negative integer hash of value. Values that are
+// equal? as hash table keys hash the same, and the hash of a string is the
+// hash of its bytes.
+func HashImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	d := Car(args)
+	h := fnv.New64a()
+	if StringP(d) {
+		h.Write([]byte(StringValue(d)))
+	} else {
+		h.Write([]byte(hashKey(d)))
+	}
+	return IntegerWithValue(int64(h.Sum64() & math.MaxInt64)), nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the base64 and hex encoding primitive functions.
+//
+// They work on the bytes of strings, so what is decoded needn't be text.
+// A bytearray can be encoded as well.
+
+package golisp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+func RegisterEncodingPrimitives() {
+	MakePrimitiveFunction("base64-encode", "1", Base64EncodeImpl)
+	MakePrimitiveFunction("base64-decode", "1", Base64DecodeImpl)
+	MakePrimitiveFunction("hex-encode", "1", HexEncodeImpl)
+	MakePrimitiveFunction("hex-decode", "1", HexDecodeImpl)
+}
+
+// bytesArg is the bytes of the string or bytearray that is the first of
+// args.
+func bytesArg(name string, args *Data, env *SymbolTableFrame) (b []byte, err error) {
+	d := Car(args)
+	if StringP(d) {
+		return []byte(StringValue(d)), nil
+	}
+	if ObjectP(d) && ObjectType(d) == "[]byte" {
+		return *(*[]byte)(ObjectValue(d)), nil
+	}
+	err = ProcessError(fmt.Sprintf("%s expects a string or bytearray but received %s.", name, String(d)), env)
+	return
+}
+
+func encodedArg(name string, args *Data, env *SymbolTableFrame) (s string, err error) {
+	d := Car(args)
+	if !StringP(d) {
+		err = ProcessError(fmt.Sprintf("%s expects a string but received %s.", name, String(d)), env)
+		return
+	}
+	return StringValue(d), nil
+}
+
+// (base64-encode string) is string in standard, padded base64.
+func Base64EncodeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	b, err := bytesArg("base64-encode", args, env)
+	if err != nil {
+		return
+	}
+	return StringWithValue(base64.StdEncoding.EncodeToString(b)), nil
+}
+
+func Base64DecodeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	s, err := encodedArg("base64-decode", args, env)
+	if err != nil {
+		return
+	}
+	b, decodeErr := base64.StdEncoding.DecodeString(s)
+	if decodeErr != nil {
+		err = ProcessError(fmt.Sprintf("base64-decode was given invalid base64: %s.", decodeErr), env)
+		return
+	}
+	return StringWithValue(string(b)), nil
+}
+
+// (hex-encode string) is string as two lowercase hex digits a byte.
+func HexEncodeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	b, err := bytesArg("hex-encode", args, env)
+	if err != nil {
+		return
+	}
+	return StringWithValue(hex.EncodeToString(b)), nil
+}
+
+// (hex-decode string) reads hex digits of either case back into bytes.
+func HexDecodeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	s, err := encodedArg("hex-decode", args, env)
+	if err != nil {
+		return
+	}
+	b, decodeErr := hex.DecodeString(s)
+	if decodeErr != nil {
+		err = ProcessError(fmt.Sprintf("hex-decode was given invalid hex: %s.", decodeErr), env)
+		return
+	}
+	return StringWithValue(string(b)), nil
+}
@@ -7,12 +7,16 @@
 
 package golisp
 
+import (
+	"fmt"
+)
+
 func RegisterEnvironmentPrimitives() {
 	MakePrimitiveFunction("environment?", "1", EnvironmentPImpl)
 	MakePrimitiveFunction("environment-has-parent?", "1", EnvironmentParentPImpl)
 	MakePrimitiveFunction("environment-bound-names", "1", EnvironmentBoundNamesImpl)
 	MakePrimitiveFunction("environment-macro-names", "1", EnvironmentMacroNamesImpl)
-	MakePrimitiveFunction("environment-bindings", "1", EnvironmentBindingsImpl)
+	MakePrimitiveFunction("environment-bindings", "0|1", EnvironmentBindingsImpl)
 	MakePrimitiveFunction("environment-reference-type", "2", EnvironmentReferenceTypeImpl)
 	MakePrimitiveFunction("environment-bound?", "2", EnvironmentBoundPImpl)
 	MakePrimitiveFunction("environment-assigned?", "2", EnvironmentAssignedPImpl)
@@ -24,6 +28,8 @@ func RegisterEnvironmentPrimitives() {
 	MakePrimitiveFunction("environment-define", "3", EnvironmentDefineImpl)
 	MakePrimitiveFunction("the-environment", "0", TheEnvironmentImpl)
 	MakePrimitiveFunction("procedure-environment", "1", ProcedureEnvironmentImpl)
+	MakePrimitiveFunction("bound?", "1|2", BoundPImpl)
+	MakePrimitiveFunction("global-symbols", "0", GlobalSymbolsImpl)
 
 	MakePrimitiveFunction("restrict-environment", "0", RestrictEnvironmentImpl)
 	MakeRestrictedPrimitiveFunction("environment-parent", "1", EnvironmentParentImpl)
@@ -86,21 +92,53 @@ func EnvironmentMacroNamesImpl(args *Data, env *SymbolTableFrame) (result *Data,
 	return ArrayToList(keys), nil
 }
 
+// (environment-bindings [environment]) is an alist of the names bound in
+// environment's own frame, or the current one, to their values, sorted by
+// name. Names bound in enclosing frames aren't included.
 func EnvironmentBindingsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	if !EnvironmentP(Car(args)) {
-		err = ProcessError("environment-bindings requires an environment as it's argument", env)
+	e := env
+	if Length(args) == 1 {
+		if !EnvironmentP(Car(args)) {
+			err = ProcessError("environment-bindings requires an environment as it's argument", env)
+			return
+		}
+		e = EnvironmentValue(Car(args))
+	}
+	bindings := e.sortedBindings()
+	for i := len(bindings) - 1; i >= 0; i-- {
+		result = Acons(bindings[i].Sym, bindings[i].Val, result)
+	}
+	return
+}
+
+// (bound? symbol [environment]) is whether symbol has a value where it is
+// evaluated, or in environment: bound in the frame or one lexically
+// enclosing it, or a slot of the frame object a method runs in.
+func BoundPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sym := Car(args)
+	if !SymbolP(sym) {
+		err = ProcessError(fmt.Sprintf("bound? expects a symbol but received %s.", String(sym)), env)
 		return
 	}
-	e := EnvironmentValue(Car(args))
-	keys := make([]*Data, 0, 0)
-	for _, val := range e.Bindings {
-		if NilP(val.Val) {
-			keys = append(keys, InternalMakeList(val.Sym))
-		} else {
-			keys = append(keys, InternalMakeList(val.Sym, val.Val))
+	e := env
+	if Length(args) == 2 {
+		if !EnvironmentP(Cadr(args)) {
+			err = ProcessError(fmt.Sprintf("bound? expects an environment but received %s.", String(Cadr(args))), env)
+			return
 		}
+		e = EnvironmentValue(Cadr(args))
 	}
-	return ArrayToList(keys), nil
+	return BooleanWithValue(e.IsBound(sym)), nil
+}
+
+// (global-symbols) lists every name bound in the global environment, sorted.
+func GlobalSymbolsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	bindings := Global.sortedBindings()
+	symbols := make([]*Data, len(bindings))
+	for i, binding := range bindings {
+		symbols[i] = binding.Sym
+	}
+	return ArrayToList(symbols), nil
 }
 
 func EnvironmentReferenceTypeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
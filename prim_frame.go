@@ -23,8 +23,8 @@ func RegisterFramePrimitives() {
 	MakeSpecialForm("apply-slot", ">=3", ApplySlotImpl)
 	MakeSpecialForm("apply-slot-super", ">=2", ApplySlotSuperImpl)
 	MakePrimitiveFunction("clone", "1", CloneImpl)
-	MakePrimitiveFunction("json->lisp", "1", JsonToLispImpl)
-	MakePrimitiveFunction("lisp->json", "1", LispToJsonImpl)
+	MakePrimitiveFunction("json->lisp", "1|2", JsonToLispImpl)
+	MakePrimitiveFunction("lisp->json", "1|2", LispToJsonImpl)
 	MakePrimitiveFunction("frame-keys", "1", FrameKeysImpl)
 	MakePrimitiveFunction("frame-values", "1", FrameValuesImpl)
 }
@@ -336,6 +336,23 @@ func CloneImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return FrameWithValue(FrameValue(f).Clone()), nil
 }
 
+// jsonObjectsArg reads the optional argument of json->lisp and lisp->json
+// that says how json objects are represented: frame, alist or hash-table.
+func jsonObjectsArg(name string, args *Data, env *SymbolTableFrame) (objects string, err error) {
+	if Length(args) < 2 {
+		return "frame", nil
+	}
+	o := Cadr(args)
+	if SymbolP(o) {
+		switch StringValue(o) {
+		case "frame", "alist", "hash-table":
+			return StringValue(o), nil
+		}
+	}
+	err = ProcessError(fmt.Sprintf("%s expects its second argument to be one of frame, alist or hash-table but was given %s.", name, String(o)), env)
+	return
+}
+
 func JsonToLispImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	j := Car(args)
 	if !StringP(j) {
@@ -343,13 +360,28 @@ func JsonToLispImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 		return
 	}
 
-	return JsonStringToLispWithFrames(StringValue(j)), nil
+	objects, err := jsonObjectsArg("json->lisp", args, env)
+	if err != nil {
+		return
+	}
+	result, err = JsonStringToLispWithObjects(StringValue(j), objects)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("json->lisp: %s.", err), env)
+	}
+	return
 }
 
 func LispToJsonImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	l := Car(args)
-
-	return StringWithValue(LispWithFramesToJsonString(l)), nil
+	objects, err := jsonObjectsArg("lisp->json", args, env)
+	if err != nil {
+		return
+	}
+	j, err := LispToJsonStringWithObjects(Car(args), objects)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("lisp->json: %s.", err), env)
+		return
+	}
+	return StringWithValue(j), nil
 }
 
 func FrameKeysImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
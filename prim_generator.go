@@ -0,0 +1,162 @@
+// Copyright 2015 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the generator primitive functions.
+//
+// A generator's body runs in a goroutine of its own, but only ever while
+// the generator is being called: each call lets it run to its next yield
+// and waits for the value, and then it waits in turn until the next call.
+// Should a generator be collected before its body finishes, the yield the
+// body is waiting in raises an error so the goroutine can end.
+
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// generated is what a generator's body hands back to the generator: a
+// yielded value, or that it finished, with the error it raised if it did.
+type generated struct {
+	Value *Data
+	Err   error
+	Done  bool
+}
+
+// generatorLink is all that the body shares with its generator, so that
+// the generator can be collected while the body waits.
+type generatorLink struct {
+	Resume  chan empty
+	Yielded chan generated
+	Abandon chan empty
+}
+
+type Generator struct {
+	Link     *generatorLink
+	Started  bool
+	Finished bool
+	Mutex    sync.Mutex
+}
+
+var errGeneratorAbandoned = errors.New("The generator was abandoned.")
+
+func RegisterGeneratorPrimitives() {
+	MakePrimitiveFunction("make-generator", "1", MakeGeneratorImpl)
+	MakePrimitiveFunction("generator->list", "1|2", GeneratorToListImpl)
+}
+
+// yieldFunction is the yield a generator's body is given, which hands its
+// argument back to the generator and waits to be resumed.
+func yieldFunction(link *generatorLink) *Data {
+	yield := &PrimitiveFunction{Name: "yield", Body: func(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+		select {
+		case link.Yielded <- generated{Value: Car(args)}:
+		case <-link.Abandon:
+			return nil, errGeneratorAbandoned
+		}
+		select {
+		case <-link.Resume:
+			return
+		case <-link.Abandon:
+			return nil, errGeneratorAbandoned
+		}
+	}}
+	yield.parseNumArgs("1")
+	return PrimitiveWithNameAndFunc("yield", yield)
+}
+
+func runGenerator(f *Data, link *generatorLink, env *SymbolTableFrame) {
+	_, err := ApplyWithoutEval(f, InternalMakeList(yieldFunction(link)), env)
+	select {
+	case link.Yielded <- generated{Err: err, Done: true}:
+	case <-link.Abandon:
+	}
+}
+
+// Next runs the body of self to its next yield and returns what it
+// yielded, or the eof object once it has finished.
+func (self *Generator) Next(f *Data, env *SymbolTableFrame) (result *Data, err error) {
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	if self.Finished {
+		return EofObject, nil
+	}
+	if self.Started {
+		self.Link.Resume <- empty{}
+	} else {
+		self.Started = true
+		go runGenerator(f, self.Link, env)
+	}
+
+	next := <-self.Link.Yielded
+	if next.Done {
+		self.Finished = true
+		if next.Err != nil {
+			return nil, ProcessError(fmt.Sprintf("The generator failed: %s", next.Err), env)
+		}
+		return EofObject, nil
+	}
+	return next.Value, nil
+}
+
+// (make-generator f) is a generator: a function that, each time it is
+// called, returns the next value f yields, and the eof object once f has
+// returned. f is called with one argument, the function yield, when the
+// generator is first called, and with that call's parameter bindings, and
+// (yield value) passes value back to the generator's caller, waiting there
+// until the generator is called again.
+func MakeGeneratorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	f := Car(args)
+	if !FunctionOrPrimitiveP(f) {
+		err = ProcessError(fmt.Sprintf("make-generator expects a function but received %s.", String(f)), env)
+		return
+	}
+
+	g := &Generator{Link: &generatorLink{
+		Resume:  make(chan empty),
+		Yielded: make(chan generated),
+		Abandon: make(chan empty)}}
+	runtime.SetFinalizer(g, func(g *Generator) {
+		close(g.Link.Abandon)
+	})
+
+	next := &PrimitiveFunction{Name: "generator", Body: func(args *Data, callEnv *SymbolTableFrame) (*Data, error) {
+		return g.Next(f, callEnv)
+	}}
+	next.parseNumArgs("0")
+	return PrimitiveWithNameAndFunc("generator", next), nil
+}
+
+// (generator->list generator [n]) is a list of the values generator
+// produces until it is exhausted, or of the next n of them.
+func GeneratorToListImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	g := Car(args)
+	if !FunctionOrPrimitiveP(g) {
+		err = ProcessError(fmt.Sprintf("generator->list expects a generator but received %s.", String(g)), env)
+		return
+	}
+	limit := int64(-1)
+	if Length(args) == 2 {
+		if limit, err = countArg("generator->list", args, env); err != nil {
+			return
+		}
+	}
+
+	values := make([]*Data, 0)
+	for i := int64(0); limit < 0 || i < limit; i++ {
+		var value *Data
+		if value, err = ApplyWithoutEval(g, nil, env); err != nil {
+			return
+		}
+		if value == EofObject {
+			break
+		}
+		values = append(values, value)
+	}
+	return ArrayToList(values), nil
+}
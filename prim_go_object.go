@@ -0,0 +1,151 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the primitive functions for using Go structs from Lisp.
+
+package golisp
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// GoObject is a Go value handed to Lisp. Fields can only be set through a
+// pointer to a struct.
+type GoObject struct {
+	Value reflect.Value
+}
+
+func RegisterGoObjectPrimitives() {
+	MakePrimitiveFunction("go-object?", "1", GoObjectPImpl)
+	MakePrimitiveFunction("go-slot", "2", GoSlotImpl)
+	MakePrimitiveFunction("go-set-slot!", "3", GoSetSlotImpl)
+	MakePrimitiveFunction("go-invoke", ">=2", GoInvokeImpl)
+}
+
+func GoObjectWithValue(v interface{}) *Data {
+	return ObjectWithTypeAndValue("GoObject", unsafe.Pointer(&GoObject{Value: reflect.ValueOf(v)}))
+}
+
+func GoObjectP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "GoObject"
+}
+
+func GoObjectValue(d *Data) *GoObject {
+	return (*GoObject)(ObjectValue(d))
+}
+
+// RegisterGoObject binds name to v so scripts can use its fields and
+// methods. Pass a pointer to a struct to let scripts set its fields.
+func RegisterGoObject(name string, v interface{}) {
+	Global.BindTo(Intern(name), GoObjectWithValue(v))
+}
+
+func goObjectArgs(name string, args *Data, env *SymbolTableFrame) (object *GoObject, member string, err error) {
+	o := Car(args)
+	if !GoObjectP(o) {
+		err = ProcessError(fmt.Sprintf("%s expects a go object but received %s.", name, String(o)), env)
+		return
+	}
+	m := Cadr(args)
+	if !StringP(m) {
+		err = ProcessError(fmt.Sprintf("%s expects a field or method name string but received %s.", name, String(m)), env)
+		return
+	}
+	return GoObjectValue(o), StringValue(m), nil
+}
+
+// field finds the exported field called name.
+func (self *GoObject) field(primitive string, name string, env *SymbolTableFrame) (field reflect.Value, err error) {
+	v := self.Value
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		err = ProcessError(fmt.Sprintf("%s expects a go struct but received a %s.", primitive, self.Value.Type()), env)
+		return
+	}
+	structField, found := v.Type().FieldByName(name)
+	if !found {
+		err = ProcessError(fmt.Sprintf("%s: %s has no field %s.", primitive, v.Type(), name), env)
+		return
+	}
+	if structField.PkgPath != "" {
+		err = ProcessError(fmt.Sprintf("%s: field %s of %s is unexported.", primitive, name, v.Type()), env)
+		return
+	}
+	return v.FieldByIndex(structField.Index), nil
+}
+
+func GoObjectPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(GoObjectP(Car(args))), nil
+}
+
+// (go-slot object "Field") is the value of an exported field.
+func GoSlotImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	object, name, err := goObjectArgs("go-slot", args, env)
+	if err != nil {
+		return
+	}
+	field, err := object.field("go-slot", name, env)
+	if err != nil {
+		return
+	}
+	return goToLisp(field), nil
+}
+
+// (go-set-slot! object "Field" value) sets an exported field, converting
+// value to the field's type.
+func GoSetSlotImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	object, name, err := goObjectArgs("go-set-slot!", args, env)
+	if err != nil {
+		return
+	}
+	field, err := object.field("go-set-slot!", name, env)
+	if err != nil {
+		return
+	}
+	if !field.CanSet() {
+		err = ProcessError(fmt.Sprintf("go-set-slot! can't set %s of a %s; register a pointer to it instead.", name, object.Value.Type()), env)
+		return
+	}
+
+	value := Caddr(args)
+	v, ok := lispToGo(value, field.Type())
+	if !ok {
+		err = ProcessError(fmt.Sprintf("go-set-slot! expects %s to be set to %s but received %s.", name, goTypeDescription(field.Type()), String(value)), env)
+		return
+	}
+	field.Set(v)
+	return value, nil
+}
+
+// (go-invoke object "Method" arg...) calls an exported method, converting
+// the arguments and results as RegisterGoFunc does.
+func GoInvokeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	object, name, err := goObjectArgs("go-invoke", args, env)
+	if err != nil {
+		return
+	}
+	method := object.Value.MethodByName(name)
+	if !method.IsValid() {
+		err = ProcessError(fmt.Sprintf("go-invoke: %s has no exported method %s.", object.Value.Type(), name), env)
+		return
+	}
+
+	t := method.Type()
+	methodArgs := Cddr(args)
+	count := Length(methodArgs)
+	if t.IsVariadic() && count < t.NumIn()-1 {
+		err = ProcessError(fmt.Sprintf("go-invoke: %s takes at least %d arguments but received %d.", name, t.NumIn()-1, count), env)
+		return
+	}
+	if !t.IsVariadic() && count != t.NumIn() {
+		err = ProcessError(fmt.Sprintf("go-invoke: %s takes %d arguments but received %d.", name, t.NumIn(), count), env)
+		return
+	}
+	return callGoFunc(fmt.Sprintf("go-invoke %s", name), method, methodArgs, env)
+}
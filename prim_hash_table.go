@@ -0,0 +1,325 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the hash table primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+type hashEntry struct {
+	Key   *Data
+	Value *Data
+}
+
+type HashTable struct {
+	Mutex   sync.RWMutex
+	Entries map[string]hashEntry
+}
+
+func RegisterHashTablePrimitives() {
+	MakePrimitiveFunction("make-hash-table", "0", MakeHashTableImpl)
+	MakePrimitiveFunction("hash-table?", "1", HashTablePImpl)
+	MakePrimitiveFunction("hash-set!", "3", HashSetImpl)
+	MakePrimitiveFunction("hash-ref", "2|3", HashRefImpl)
+	MakePrimitiveFunction("hash-remove!", "2", HashRemoveImpl)
+	MakePrimitiveFunction("hash-keys", "1", HashKeysImpl)
+	MakePrimitiveFunction("hash-values", "1", HashValuesImpl)
+	MakePrimitiveFunction("hash-count", "1", HashCountImpl)
+	MakePrimitiveFunction("hash->alist", "1", HashToAlistImpl)
+}
+
+// hashKey encodes a key so that keys that are equal? encode the same, and
+// keys that aren't encode differently. It doesn't depend on how values
+// print, so the printer settings can't make two keys collide.
+func hashKey(key *Data) string {
+	var b strings.Builder
+	writeEqualKey(&b, key, make(map[unsafe.Pointer]bool))
+	return b.String()
+}
+
+// writeEqualKey writes the encoding hashKey makes of d. Numbers, strings,
+// characters, booleans, interned symbols and the contents of lists,
+// vectors, frames, bytearrays, sets and structures are encoded by value;
+// every other object, and a container met again inside itself, by
+// identity. Strings and symbols are prefixed with their length so that no
+// contents can be mistaken for the encoding around them.
+func writeEqualKey(key *strings.Builder, d *Data, visited map[unsafe.Pointer]bool) {
+	if NilP(d) {
+		key.WriteString("()")
+		return
+	}
+	switch TypeOf(d) {
+	case IntegerType:
+		key.WriteString("i")
+		key.WriteString(strconv.FormatInt(IntegerValue(d), 10))
+	case BignumType:
+		key.WriteString("i")
+		key.WriteString(BignumValue(d).String())
+	case RationalType:
+		key.WriteString("r")
+		key.WriteString(RationalValue(d).RatString())
+	case ComplexType:
+		key.WriteString("z")
+		key.WriteString(complexString(ComplexValue(d)))
+	case FloatType:
+		f := FloatValue(d)
+		if f == 0 {
+			f = 0 // -0.0 is equal? to 0.0
+		}
+		key.WriteString("f")
+		key.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	case BooleanType:
+		key.WriteString(String(d))
+	case StringType:
+		fmt.Fprintf(key, "s%d:%s", len(StringValue(d)), StringValue(d))
+	case CharacterType:
+		fmt.Fprintf(key, "c%d", CharacterValue(d))
+	case SymbolType:
+		if Intern(StringValue(d)) == d {
+			fmt.Fprintf(key, "y%d:%s", len(StringValue(d)), StringValue(d))
+		} else {
+			fmt.Fprintf(key, "@%p", d)
+		}
+	case ConsCellType, AlistType, AlistCellType:
+		if !enterEqualKey(key, d.Value, visited) {
+			return
+		}
+		key.WriteString("(")
+		writeEqualKey(key, Car(d), visited)
+		key.WriteString(" . ")
+		writeEqualKey(key, Cdr(d), visited)
+		key.WriteString(")")
+		delete(visited, d.Value)
+	case FrameType:
+		frame := FrameValue(d)
+		if !enterEqualKey(key, unsafe.Pointer(frame), visited) {
+			return
+		}
+		frame.Mutex.RLock()
+		slots := make([]string, 0, len(frame.Data))
+		values := make(map[string]*Data, len(frame.Data))
+		for k, v := range frame.Data {
+			slots = append(slots, k)
+			values[k] = v
+		}
+		frame.Mutex.RUnlock()
+		sort.Strings(slots)
+		key.WriteString("{")
+		for _, slot := range slots {
+			fmt.Fprintf(key, "%d:%s ", len(slot), slot)
+			writeEqualKey(key, values[slot], visited)
+			key.WriteString(" ")
+		}
+		key.WriteString("}")
+		delete(visited, unsafe.Pointer(frame))
+	case BoxedObjectType:
+		writeObjectEqualKey(key, d, visited)
+	default:
+		fmt.Fprintf(key, "@%d:%p", TypeOf(d), d.Value)
+	}
+}
+
+// enterEqualKey notes that the container at p is being encoded, or, if it
+// already is, encodes it by identity and says not to go into it again.
+func enterEqualKey(key *strings.Builder, p unsafe.Pointer, visited map[unsafe.Pointer]bool) bool {
+	if visited[p] {
+		fmt.Fprintf(key, "@%p", p)
+		return false
+	}
+	visited[p] = true
+	return true
+}
+
+func writeObjectEqualKey(key *strings.Builder, d *Data, visited map[unsafe.Pointer]bool) {
+	p := ObjectValue(d)
+	switch ObjectType(d) {
+	case "[]byte":
+		fmt.Fprintf(key, "b%x", *(*[]byte)(p))
+	case "Vector":
+		if !enterEqualKey(key, p, visited) {
+			return
+		}
+		key.WriteString("[")
+		for _, element := range VectorValue(d).Elements {
+			writeEqualKey(key, element, visited)
+			key.WriteString(" ")
+		}
+		key.WriteString("]")
+		delete(visited, p)
+	case "Set":
+		elements := SetValue(d).snapshot()
+		keys := make([]string, 0, len(elements))
+		for k := range elements {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		key.WriteString("<")
+		for _, k := range keys {
+			fmt.Fprintf(key, "%d:%s", len(k), k)
+		}
+		key.WriteString(">")
+	case "Structure":
+		if !enterEqualKey(key, p, visited) {
+			return
+		}
+		s := StructureValue(d)
+		fmt.Fprintf(key, "#%p[", s.Type)
+		for _, value := range s.snapshot() {
+			writeEqualKey(key, value, visited)
+			key.WriteString(" ")
+		}
+		key.WriteString("]")
+		delete(visited, p)
+	default:
+		fmt.Fprintf(key, "@%p", p)
+	}
+}
+
+func hashTableArg(name string, tableObj *Data, env *SymbolTableFrame) (table *HashTable, err error) {
+	if !ObjectP(tableObj) || ObjectType(tableObj) != "HashTable" {
+		err = ProcessError(fmt.Sprintf("%s expects a HashTable object but received %s.", name, String(tableObj)), env)
+		return
+	}
+	return (*HashTable)(ObjectValue(tableObj)), nil
+}
+
+func MakeHashTableImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	table := &HashTable{Entries: make(map[string]hashEntry)}
+	return ObjectWithTypeAndValue("HashTable", unsafe.Pointer(table)), nil
+}
+
+func HashTablePImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(ObjectP(Car(args)) && ObjectType(Car(args)) == "HashTable"), nil
+}
+
+func HashSetImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	table, err := hashTableArg("hash-set!", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	key := Cadr(args)
+	value := Caddr(args)
+
+	table.Mutex.Lock()
+	table.Entries[hashKey(key)] = hashEntry{Key: key, Value: value}
+	table.Mutex.Unlock()
+
+	return value, nil
+}
+
+// (hash-ref table key [default]) raises an error for a missing key unless a
+// default is given.
+func HashRefImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	table, err := hashTableArg("hash-ref", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	key := Cadr(args)
+
+	table.Mutex.RLock()
+	entry, ok := table.Entries[hashKey(key)]
+	table.Mutex.RUnlock()
+
+	if ok {
+		return entry.Value, nil
+	}
+	if Length(args) == 3 {
+		return Caddr(args), nil
+	}
+	err = ProcessError(fmt.Sprintf("hash-ref couldn't find the key %s.", String(key)), env)
+	return
+}
+
+func HashRemoveImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	table, err := hashTableArg("hash-remove!", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	k := hashKey(Cadr(args))
+
+	table.Mutex.Lock()
+	_, found := table.Entries[k]
+	delete(table.Entries, k)
+	table.Mutex.Unlock()
+
+	return BooleanWithValue(found), nil
+}
+
+// The listing primitives return entries in no particular order.
+
+func hashEntries(name string, args *Data, env *SymbolTableFrame) (entries []hashEntry, err error) {
+	table, err := hashTableArg(name, Car(args), env)
+	if err != nil {
+		return
+	}
+
+	table.Mutex.RLock()
+	defer table.Mutex.RUnlock()
+	entries = make([]hashEntry, 0, len(table.Entries))
+	for _, entry := range table.Entries {
+		entries = append(entries, entry)
+	}
+	return
+}
+
+func HashKeysImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	entries, err := hashEntries("hash-keys", args, env)
+	if err != nil {
+		return
+	}
+
+	keys := make([]*Data, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	return ArrayToList(keys), nil
+}
+
+func HashValuesImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	entries, err := hashEntries("hash-values", args, env)
+	if err != nil {
+		return
+	}
+
+	values := make([]*Data, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, entry.Value)
+	}
+	return ArrayToList(values), nil
+}
+
+func HashCountImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	table, err := hashTableArg("hash-count", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	table.Mutex.RLock()
+	defer table.Mutex.RUnlock()
+	return IntegerWithValue(int64(len(table.Entries))), nil
+}
+
+func HashToAlistImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	entries, err := hashEntries("hash->alist", args, env)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		result = Acons(entry.Key, entry.Value, result)
+	}
+	return
+}
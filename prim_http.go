@@ -0,0 +1,140 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the http client primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultHttpTimeout = 30000
+
+func RegisterHttpPrimitives() {
+	Global.BindTo(Intern("*http-timeout*"), IntegerWithValue(DefaultHttpTimeout))
+
+	MakeRestrictedPrimitiveFunction("http-get", "1|2|3", HttpGetImpl)
+	MakeRestrictedPrimitiveFunction("http-post", "3|4|5", HttpPostImpl)
+}
+
+// httpTimeout is the timeout in milliseconds given to name, or else the
+// value of *http-timeout*.
+func httpTimeout(name string, args *Data, env *SymbolTableFrame) (timeout time.Duration, err error) {
+	millis := env.ValueOf(Intern("*http-timeout*"))
+	if NotNilP(args) {
+		millis = Car(args)
+	}
+	if !IntegerP(millis) || IntegerValue(millis) <= 0 {
+		err = ProcessError(fmt.Sprintf("%s expects a timeout of a positive number of milliseconds but received %s.", name, String(millis)), env)
+		return
+	}
+	return time.Duration(IntegerValue(millis)) * time.Millisecond, nil
+}
+
+// addHttpHeaders sets the headers in alist, whose keys are strings or
+// symbols, on request.
+func addHttpHeaders(name string, request *http.Request, alist *Data, env *SymbolTableFrame) (err error) {
+	for c := alist; NotNilP(c); c = Cdr(c) {
+		pair := Car(c)
+		if !(PairP(pair) || DottedPairP(pair)) || NilP(pair) || !(StringP(Car(pair)) || SymbolP(Car(pair))) || !StringP(Cdr(pair)) {
+			return ProcessError(fmt.Sprintf("%s expects headers to be an alist of names to strings but found %s.", name, String(pair)), env)
+		}
+		request.Header.Add(StringValue(Car(pair)), StringValue(Cdr(pair)))
+	}
+	return
+}
+
+// httpResponseFrame makes the frame http-get and http-post return, with
+// status:, headers: and body: slots. Headers are an alist sorted by name,
+// repeated headers joined with commas.
+func httpResponseFrame(response *http.Response, body []byte) *Data {
+	names := make([]string, 0, len(response.Header))
+	for name := range response.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers *Data
+	for i := len(names) - 1; i >= 0; i-- {
+		headers = Acons(StringWithValue(names[i]), StringWithValue(strings.Join(response.Header[names[i]], ", ")), headers)
+	}
+
+	m := &FrameMap{Data: FrameMapData{
+		"status:":  IntegerWithValue(int64(response.StatusCode)),
+		"headers:": headers,
+		"body:":    StringWithValue(string(body)),
+	}}
+	return FrameWithValue(m)
+}
+
+func doHttpRequest(name string, method string, url *Data, body io.Reader, contentType string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if !StringP(url) {
+		err = ProcessError(fmt.Sprintf("%s expects a url string but received %s.", name, String(url)), env)
+		return
+	}
+
+	request, err := http.NewRequest(method, StringValue(url), body)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("%s: %s.", name, err), env)
+		return
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if NotNilP(args) {
+		if err = addHttpHeaders(name, request, Car(args), env); err != nil {
+			return
+		}
+		args = Cdr(args)
+	}
+	timeout, err := httpTimeout(name, args, env)
+	if err != nil {
+		return
+	}
+
+	client := &http.Client{Timeout: timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("%s: %s.", name, err), env)
+		return
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("%s: %s.", name, err), env)
+		return
+	}
+	return httpResponseFrame(response, responseBody), nil
+}
+
+// (http-get url [headers [timeout]]) fetches url, sending the headers in
+// the alist headers and waiting at most timeout milliseconds.
+func HttpGetImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return doHttpRequest("http-get", "GET", Car(args), nil, "", Cdr(args), env)
+}
+
+// (http-post url body content-type [headers [timeout]]) posts the string
+// body to url.
+func HttpPostImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	body := Cadr(args)
+	if !StringP(body) {
+		err = ProcessError(fmt.Sprintf("http-post expects its body to be a string but received %s.", String(body)), env)
+		return
+	}
+	contentType := Caddr(args)
+	if !StringP(contentType) {
+		err = ProcessError(fmt.Sprintf("http-post expects a content type string but received %s.", String(contentType)), env)
+		return
+	}
+	return doHttpRequest("http-post", "POST", Car(args), strings.NewReader(StringValue(body)), StringValue(contentType), Cdddr(args), env)
+}
@@ -8,35 +8,156 @@
 package golisp
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode"
+	"unsafe"
 )
 
+// portReaders holds the buffered readers behind read-line and read-char,
+// one per port, so that characters read ahead of a line aren't lost.
+var portReaders = struct {
+	Readers map[*os.File]*bufio.Reader
+	Mutex   sync.Mutex
+}{Readers: make(map[*os.File]*bufio.Reader)}
+
+// outputPort is what output is written to: a file port's file or a string
+// port's buffer.
+type outputPort interface {
+	io.Writer
+	io.StringWriter
+}
+
+// stringPort is an output port that keeps what is written to it in memory,
+// as with-output-to-string's port does. Processes forked while it is
+// current share it, so writes to it are locked.
+type stringPort struct {
+	Builder strings.Builder
+	Mutex   sync.Mutex
+}
+
+func (self *stringPort) Write(b []byte) (int, error) {
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	return self.Builder.Write(b)
+}
+
+func (self *stringPort) WriteString(s string) (int, error) {
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	return self.Builder.WriteString(s)
+}
+
+func (self *stringPort) String() string {
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	return self.Builder.String()
+}
+
+// currentOutput is the current-output-port parameter. Output written
+// without a port goes to its value, which is stdout outside of any
+// with-output-to-string or parameterize.
+var currentOutput *Parameter
+
 func RegisterIOPrimitives() {
+	printDepth = &Parameter{Value: EmptyCons()}
+	printLength = &Parameter{Value: EmptyCons()}
+	Global.BindToProtected(Intern("*print-depth*"), parameterPrimitive("*print-depth*", printDepth))
+	Global.BindToProtected(Intern("*print-length*"), parameterPrimitive("*print-length*", printLength))
+	currentOutput = &Parameter{Value: PortWithValue(os.Stdout), Converter: outputPortConverter()}
+	Global.BindToProtected(Intern("current-output-port"), parameterPrimitive("current-output-port", currentOutput))
+
 	MakeRestrictedPrimitiveFunction("open-input-file", "1", OpenInputFileImpl)
 	MakeRestrictedPrimitiveFunction("open-output-file", "1|2", OpenOutputFileImpl)
 	MakeRestrictedPrimitiveFunction("close-port", "1", ClosePortImpl)
+	MakeRestrictedPrimitiveFunction("call-with-output-file", "2", CallWithOutputFileImpl)
 	MakeRestrictedPrimitiveFunction("write-bytes", "2", WriteBytesImpl)
 
 	MakePrimitiveFunction("write-string", "1|2", WriteStringImpl)
 	MakePrimitiveFunction("newline", "0|1", NewlineImpl)
 	MakePrimitiveFunction("write", "1|2", WriteImpl)
+	MakePrimitiveFunction("write-shared", "1|2", WriteSharedImpl)
+	MakePrimitiveFunction("display", "1|2", DisplayImpl)
+	MakePrimitiveFunction("with-output-to-string", "1", WithOutputToStringImpl)
 	MakePrimitiveFunction("read", "1", ReadImpl)
+	MakePrimitiveFunction("read-line", "0|1", ReadLineImpl)
+	MakePrimitiveFunction("read-char", "0|1", ReadCharImpl)
+	MakePrimitiveFunction("read-from-string", "1|2", ReadFromStringImpl)
+	MakePrimitiveFunction("read-all-from-string", "1", ReadAllFromStringImpl)
 	MakePrimitiveFunction("eof-object?", "1", EofObjectImpl)
 
 	MakePrimitiveFunction("list-directory", "1|2", ListDirectoryImpl)
 
-	MakePrimitiveFunction("format", ">=2", FormatImpl)
+	MakePrimitiveFunction("format", ">=1", FormatImpl)
+}
+
+func StringPortP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "StringPort"
+}
+
+// OutputPortP is whether d can be written to: a file port or a string port.
+func OutputPortP(d *Data) bool {
+	return PortP(d) || StringPortP(d)
+}
+
+// outputPortValue is what writing to the output port d writes to.
+func outputPortValue(d *Data) outputPort {
+	if StringPortP(d) {
+		return (*stringPort)(ObjectValue(d))
+	}
+	return PortValue(d)
+}
+
+// outputPortConverter checks the values current-output-port is given.
+func outputPortConverter() *Data {
+	f := &PrimitiveFunction{Name: "current-output-port", Body: func(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+		if !OutputPortP(Car(args)) {
+			err = ProcessError(fmt.Sprintf("current-output-port expects a port but received %s.", String(Car(args))), env)
+			return
+		}
+		return Car(args), nil
+	}}
+	f.parseNumArgs("1")
+	return PrimitiveWithNameAndFunc("current-output-port", f)
+}
+
+func currentOutputPort(env *SymbolTableFrame) outputPort {
+	if currentOutput == nil {
+		return os.Stdout
+	}
+	return outputPortValue(currentOutput.current(env))
+}
+
+// (with-output-to-string thunk) calls thunk with the current output port
+// writing to a buffer, and returns what was written. Only the dynamic
+// extent of the call writes there: other processes go on writing where
+// they did.
+func WithOutputToStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	thunk := Car(args)
+	if !FunctionOrPrimitiveP(thunk) {
+		err = ProcessError(fmt.Sprintf("with-output-to-string expects a function but received %s.", String(thunk)), env)
+		return
+	}
+
+	port := &stringPort{}
+	bound := map[*Parameter]*Data{currentOutput: ObjectWithTypeAndValue("StringPort", unsafe.Pointer(port))}
+	if _, err = Apply(thunk, EmptyCons(), parameterizedFrame(env, "with-output-to-string", bound)); err != nil {
+		return
+	}
+	return StringWithValue(port.String()), nil
 }
 
 func OpenOutputFileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	filename := Car(args)
 	if !StringP(filename) {
-		err = ProcessError("open-output-port expects its argument to be a string", env)
+		err = ProcessError("open-output-file expects its argument to be a string", env)
 		return
 	}
 
@@ -55,7 +176,7 @@ func OpenOutputFileImpl(args *Data, env *SymbolTableFrame) (result *Data, err er
 func OpenInputFileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	filename := Car(args)
 	if !StringP(filename) {
-		err = ProcessError("open-input-port expects its argument to be a string", env)
+		err = ProcessError("open-input-file expects its argument to be a string", env)
 		return
 	}
 
@@ -68,16 +189,54 @@ func OpenInputFileImpl(args *Data, env *SymbolTableFrame) (result *Data, err err
 
 func ClosePortImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	p := Car(args)
+	if TcpListenerP(p) {
+		(*net.TCPListener)(ObjectValue(p)).Close()
+		return
+	}
+	if StringPortP(p) {
+		return
+	}
 	if !PortP(p) {
 		err = ProcessError("close-port expects its argument be a port", env)
 		return
 	}
 
-	(*os.File)(PortValue(p)).Close()
+	f := PortValue(p)
+	portReaders.Mutex.Lock()
+	delete(portReaders.Readers, f)
+	portReaders.Mutex.Unlock()
+	f.Close()
+	closeConnection(f)
 	return
 
 }
 
+// (call-with-output-file filename proc) opens filename for writing, calls
+// proc with the port and closes the port when proc returns or fails.
+func CallWithOutputFileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	filename := Car(args)
+	if !StringP(filename) {
+		err = ProcessError(fmt.Sprintf("call-with-output-file expects its first argument to be a string but received %s.", String(filename)), env)
+		return
+	}
+
+	proc := Cadr(args)
+	if !FunctionOrPrimitiveP(proc) {
+		err = ProcessError(fmt.Sprintf("call-with-output-file expects its second argument to be a function but received %s.", String(proc)), env)
+		return
+	}
+
+	f, err := os.OpenFile(StringValue(filename), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("call-with-output-file: %s.", err), env)
+		return
+	}
+	port := PortWithValue(f)
+	defer ClosePortImpl(InternalMakeList(port), env)
+
+	return Apply(proc, InternalMakeList(port), env)
+}
+
 func WriteBytesImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	bytes := Car(args)
 	if !ObjectP(bytes) || ObjectType(bytes) != "[]byte" {
@@ -86,12 +245,12 @@ func WriteBytesImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 	}
 
 	p := Cadr(args)
-	if !PortP(p) {
+	if !OutputPortP(p) {
 		err = ProcessError("write expects its second argument be a port", env)
 		return
 	}
 
-	_, err = (*os.File)(PortValue(p)).Write(*(*[]byte)(ObjectValue(bytes)))
+	_, err = outputPortValue(p).Write(*(*[]byte)(ObjectValue(bytes)))
 	return
 }
 
@@ -102,16 +261,16 @@ func WriteStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 		return
 	}
 
-	var port *os.File
+	var port outputPort
 	if Length(args) == 1 {
-		port = os.Stdout
+		port = currentOutputPort(env)
 	} else {
 		p := Cadr(args)
-		if !PortP(p) {
+		if !OutputPortP(p) {
 			err = ProcessError("write-string expects its second argument be a port", env)
 			return
 		}
-		port = PortValue(p)
+		port = outputPortValue(p)
 	}
 
 	_, err = port.WriteString(StringValue(str))
@@ -119,35 +278,71 @@ func WriteStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 }
 
 func WriteImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	var port *os.File
+	var port outputPort
 
 	if Length(args) == 1 {
-		port = os.Stdout
+		port = currentOutputPort(env)
 	} else {
 		p := Cadr(args)
-		if !PortP(p) {
+		if !OutputPortP(p) {
 			err = ProcessError("write expects its second argument be a port", env)
 			return
 		}
-		port = PortValue(p)
+		port = outputPortValue(p)
 	}
 
-	_, err = port.WriteString(String(Car(args)))
+	_, err = port.WriteString(StringInEnv(Car(args), env))
+	return
+}
+
+func WriteSharedImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	var port outputPort
+
+	if Length(args) == 1 {
+		port = currentOutputPort(env)
+	} else {
+		p := Cadr(args)
+		if !OutputPortP(p) {
+			err = ProcessError("write-shared expects its second argument be a port", env)
+			return
+		}
+		port = outputPortValue(p)
+	}
+
+	_, err = port.WriteString(SharedStringInEnv(Car(args), env))
+	return
+}
+
+func DisplayImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	var port outputPort
+
+	if Length(args) == 1 {
+		port = currentOutputPort(env)
+	} else {
+		p := Cadr(args)
+		if !OutputPortP(p) {
+			err = ProcessError("display expects its second argument be a port", env)
+			return
+		}
+		port = outputPortValue(p)
+	}
+
+	_, err = port.WriteString(DisplayStringInEnv(Car(args), env))
 	return
 }
 
 func NewlineImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	var port *os.File
+	var port outputPort
 
 	if Length(args) == 0 {
-		port = os.Stdout
+		port = currentOutputPort(env)
 	} else {
 		p := Car(args)
-		if !PortP(p) {
+		if !OutputPortP(p) {
 			err = ProcessError("newline expects its argument be a port", env)
 			return
 		}
-		port = PortValue(p)
+		port = outputPortValue(p)
 	}
 
 	_, err = port.WriteString("\n")
@@ -172,6 +367,127 @@ func ReadImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return
 }
 
+// inputPortArg is the port in args, or stdin when there is none, with its
+// buffered reader.
+func inputPortArg(name string, args *Data, env *SymbolTableFrame) (reader *bufio.Reader, err error) {
+	port := os.Stdin
+	if Length(args) == 1 {
+		p := Car(args)
+		if !PortP(p) {
+			err = ProcessError(fmt.Sprintf("%s expects its argument be a port but received %s.", name, String(p)), env)
+			return
+		}
+		port = PortValue(p)
+	}
+
+	portReaders.Mutex.Lock()
+	defer portReaders.Mutex.Unlock()
+	reader, ok := portReaders.Readers[port]
+	if !ok {
+		reader = bufio.NewReader(port)
+		portReaders.Readers[port] = reader
+	}
+	return
+}
+
+// (read-line [port]) reads up to the next newline, returning the line
+// without it, or the eof object at the end of the input.
+func ReadLineImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	reader, err := inputPortArg("read-line", args, env)
+	if err != nil {
+		return
+	}
+
+	line, err := reader.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return EofObject, nil
+		}
+		err = nil
+	}
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("read-line: %s.", err), env)
+		return
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return StringWithValue(strings.TrimSuffix(line, "\r")), nil
+}
+
+// (read-char [port]) reads the next character as a one character string,
+// or returns the eof object at the end of the input.
+func ReadCharImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	reader, err := inputPortArg("read-char", args, env)
+	if err != nil {
+		return
+	}
+
+	ch, _, err := reader.ReadRune()
+	if err == io.EOF {
+		return EofObject, nil
+	}
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("read-char: %s.", err), env)
+		return
+	}
+	return StringWithValue(string(ch)), nil
+}
+
+// (read-from-string string [start]) parses the first expression at or after
+// byte offset start, returning it and the offset of whatever follows it. At
+// the end of the string it returns the eof object.
+func ReadFromStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	source := Car(args)
+	if !StringP(source) {
+		err = ProcessError(fmt.Sprintf("read-from-string expects a string but received %s.", String(source)), env)
+		return
+	}
+	src := StringValue(source)
+
+	start := 0
+	if Length(args) == 2 {
+		startObj := Cadr(args)
+		if !IntegerP(startObj) || IntegerValue(startObj) < 0 || IntegerValue(startObj) > int64(len(src)) {
+			err = ProcessError(fmt.Sprintf("read-from-string expects a start index from 0 to %d but received %s.", len(src), String(startObj)), env)
+			return
+		}
+		start = int(IntegerValue(startObj))
+	}
+
+	s := NewTokenizerFromString(src[start:])
+	sexpr, eof, err := parseExpression(s)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("read-from-string: %s at position %d.", err, start+s.LookaheadPosition), env)
+		return
+	}
+	if eof {
+		sexpr = EofObject
+	}
+	return ValuesWithArray([]*Data{sexpr, IntegerWithValue(int64(start + s.LookaheadPosition))}), nil
+}
+
+func ReadAllFromStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	source := Car(args)
+	if !StringP(source) {
+		err = ProcessError(fmt.Sprintf("read-all-from-string expects a string but received %s.", String(source)), env)
+		return
+	}
+
+	s := NewTokenizerFromString(StringValue(source))
+	forms := make([]*Data, 0)
+	for {
+		sexpr, eof, parseErr := parseExpression(s)
+		if parseErr != nil {
+			err = ProcessError(fmt.Sprintf("read-all-from-string: %s at position %d.", parseErr, s.LookaheadPosition), env)
+			return
+		}
+		if eof {
+			break
+		}
+		forms = append(forms, sexpr)
+	}
+	return ArrayToList(forms), nil
+}
+
 func EofObjectImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return BooleanWithValue(IsEqual(Car(args), EofObject)), nil
 }
@@ -196,27 +512,43 @@ func ListDirectoryImpl(args *Data, env *SymbolTableFrame) (result *Data, err err
 	return ArrayToList(names), nil
 }
 
+func leftPad(s string, width int) string {
+	if len(s) < width {
+		return strings.Repeat(" ", width-len(s)) + s
+	}
+	return s
+}
+
+// (format [destination] control-string args...) substitutes args into
+// control-string. destination is #f, or missing, to return the string, #t to
+// write it to stdout, or a port to write it there. Directives are ~A ~S ~D ~X
+// ~F ~% and ~~; prefix parameters are separated by commas, e.g. ~8,2F.
 func FormatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	destination := Car(args)
-	if !BooleanP(destination) && !PortP(destination) {
-		err = ProcessError(fmt.Sprintf("format expects its second argument be a boolean or port, but was %s", String(destination)), env)
+	destination := LispFalse
+	if !StringP(Car(args)) {
+		destination = Car(args)
+		args = Cdr(args)
+	}
+	if !BooleanP(destination) && !OutputPortP(destination) {
+		err = ProcessError(fmt.Sprintf("format expects its first argument be a boolean, port or string, but was %s", String(destination)), env)
 		return
 	}
 
-	controlStringObj := Cadr(args)
+	controlStringObj := Car(args)
 	if !StringP(controlStringObj) {
-		err = ProcessError("format expects its second argument be a string", env)
+		err = ProcessError("format expects a control string", env)
 		return
 	}
 	controlString := StringValue(controlStringObj)
 
-	arguments := Cddr(args)
+	arguments := Cdr(args)
 
 	numberOfSubstitutions := strings.Count(controlString, "~")
 	parts := make([]string, 0, numberOfSubstitutions*2+1)
 	start := 0
 	var i int
 	var numericArg int
+	var params []int
 	var atModifier bool
 	var substitution string
 	var padding string
@@ -226,40 +558,67 @@ func FormatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		if controlString[i] == '~' { // start of a substitution
 			parts = append(parts, controlString[start:i])
 			i++
-			start = i
-			for unicode.IsDigit(rune(controlString[i])) {
-				i++
-			}
-			if i == start {
-				if controlString[i] == '#' {
-					numericArg = Length(arguments)
+			params = params[:0]
+			atModifier = false
+			for {
+				start = i
+				for i < len(controlString) && unicode.IsDigit(rune(controlString[i])) {
 					i++
-				} else if controlString[i] == 'V' || controlString[i] == 'v' {
-					if IntegerP(Car(arguments)) {
-						numericArg = int(IntegerValue(Car(arguments)))
-						arguments = Cdr(arguments)
+				}
+				if i >= len(controlString) {
+					err = ProcessError("format control string ends in the middle of a directive", env)
+					return
+				}
+				if i == start {
+					if controlString[i] == '#' {
+						numericArg = Length(arguments)
+						i++
+					} else if controlString[i] == 'V' || controlString[i] == 'v' {
+						if IntegerP(Car(arguments)) {
+							numericArg = int(IntegerValue(Car(arguments)))
+							arguments = Cdr(arguments)
+						} else {
+							err = ProcessError(fmt.Sprintf("format encountered a size argument mismatch at index %d", i), env)
+							return
+						}
+						i++
 					} else {
-						err = ProcessError(fmt.Sprintf("format encountered a size argument mismatch at index %d", i), env)
-						return
+						numericArg = 0
 					}
-					i++
 				} else {
-					numericArg = 0
+					n, err = strconv.ParseInt(string(controlString[start:i]), 10, 64)
+					if err != nil {
+						return
+					}
+					numericArg = int(n)
 				}
-			} else {
-				n, err = strconv.ParseInt(string(controlString[start:i]), 10, 64)
-				if err != nil {
-					return
+				params = append(params, numericArg)
+				if i < len(controlString) && controlString[i] == ',' {
+					i++
+					continue
 				}
-				numericArg = int(n)
+				break
+			}
+			numericArg = params[0]
+			if i >= len(controlString) {
+				err = ProcessError("format control string ends in the middle of a directive", env)
+				return
 			}
 			if controlString[i] == '@' {
 				atModifier = true
 				i++
+				if i >= len(controlString) {
+					err = ProcessError("format control string ends in the middle of a directive", env)
+					return
+				}
+			}
+			if strings.ContainsRune("AaSsDdXxFf", rune(controlString[i])) && NilP(arguments) {
+				err = ProcessError(fmt.Sprintf("format ran out of arguments for ~%c at index %d", controlString[i], i), env)
+				return
 			}
 			switch controlString[i] {
 			case 'A', 'a':
-				substitution = PrintString(Car(arguments))
+				substitution = PrintStringInEnv(Car(arguments), env)
 				if len(substitution) < numericArg {
 					padding = strings.Repeat(" ", numericArg-len(substitution))
 				} else {
@@ -276,7 +635,7 @@ func FormatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 				start = i + 1
 
 			case 'S', 's':
-				substitution = String(Car(arguments))
+				substitution = StringInEnv(Car(arguments), env)
 				if len(substitution) < numericArg {
 					padding = strings.Repeat(" ", numericArg-len(substitution))
 				} else {
@@ -292,6 +651,34 @@ func FormatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 				arguments = Cdr(arguments)
 				start = i + 1
 
+			case 'D', 'd', 'X', 'x':
+				if !ExactIntegerP(Car(arguments)) {
+					err = ProcessError(fmt.Sprintf("format's ~%c expects an integer but received %s", controlString[i], String(Car(arguments))), env)
+					return
+				}
+				if controlString[i] == 'D' || controlString[i] == 'd' {
+					substitution = BignumValue(Car(arguments)).Text(10)
+				} else {
+					substitution = BignumValue(Car(arguments)).Text(16)
+				}
+				parts = append(parts, leftPad(substitution, numericArg))
+				arguments = Cdr(arguments)
+				start = i + 1
+
+			case 'F', 'f':
+				if !NumberP(Car(arguments)) {
+					err = ProcessError(fmt.Sprintf("format's ~F expects a number but received %s", String(Car(arguments))), env)
+					return
+				}
+				if len(params) > 1 {
+					substitution = strconv.FormatFloat(float64(FloatValue(Car(arguments))), 'f', params[1], 32)
+				} else {
+					substitution = strconv.FormatFloat(float64(FloatValue(Car(arguments))), 'f', -1, 32)
+				}
+				parts = append(parts, leftPad(substitution, numericArg))
+				arguments = Cdr(arguments)
+				start = i + 1
+
 			case '%':
 				if numericArg > 0 {
 					parts = append(parts, strings.Repeat("\n", numericArg))
@@ -319,7 +706,7 @@ func FormatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 				i--
 
 			default:
-				err = ProcessError(fmt.Sprintf("format encountered an unsupported substitution at index %d", i), env)
+				err = ProcessError(fmt.Sprintf("format encountered an unsupported directive ~%c at index %d", controlString[i], i), env)
 				return
 			}
 		}
@@ -337,15 +724,19 @@ func FormatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 
 	combinedString := strings.Join(parts, "")
 
-	if PortP(destination) {
-		port := PortValue(destination)
+	if OutputPortP(destination) {
+		port := outputPortValue(destination)
 		_, err = port.WriteString(combinedString)
 	} else if BooleanValue(destination) {
-		// Make sure Stdout exists before writing to it, prevents issues with LDFLAGS="-H windowsgui"
-		stat, statErr := os.Stdout.Stat()
-		if stat != nil && statErr == nil {
-			_, err = os.Stdout.WriteString(combinedString)
+		port := currentOutputPort(env)
+		if port == os.Stdout {
+			// Make sure Stdout exists before writing to it, prevents issues with LDFLAGS="-H windowsgui"
+			stat, statErr := os.Stdout.Stat()
+			if stat == nil || statErr != nil {
+				return
+			}
 		}
+		_, err = port.WriteString(combinedString)
 	} else {
 		result = StringWithValue(combinedString)
 	}
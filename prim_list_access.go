@@ -9,6 +9,7 @@ package golisp
 
 import (
 	"errors"
+	"fmt"
 	"unsafe"
 )
 
@@ -65,6 +66,9 @@ func RegisterListAccessPrimitives() {
 	MakePrimitiveFunction("nth", "2", NthImpl)
 	MakePrimitiveFunction("take", "2", TakeImpl)
 	MakePrimitiveFunction("drop", "2", DropImpl)
+	MakePrimitiveFunction("take-while", "2", TakeWhileImpl)
+	MakePrimitiveFunction("drop-while", "2", DropWhileImpl)
+	MakePrimitiveFunction("list-slice", "2|3", ListSliceImpl)
 
 	MakePrimitiveFunction("list-ref", "2", ListRefImpl)
 	MakePrimitiveFunction("list-head", "2", ListHeadImpl)
@@ -267,10 +271,13 @@ func NthImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return Nth(col, int(IntegerValue(count))), nil
 }
 
+// (take n list) is the first n elements of list, or all of them if there
+// are fewer. It also takes from bytearrays.
 func TakeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	n := Car(args)
-	if !IntegerP(n) {
-		err = ProcessError("take requires a number as its first argument.", env)
+	if !IntegerP(n) || IntegerValue(n) < 0 {
+		err = ProcessError(fmt.Sprintf("take requires a non-negative count as its first argument but was given %s.", String(n)), env)
+		return
 	}
 	size := int(IntegerValue(n))
 
@@ -300,10 +307,12 @@ func TakeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return
 }
 
+// (drop n list) is list without its first n elements, sharing the rest.
 func DropImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	n := Car(args)
-	if !IntegerP(n) {
-		err = ProcessError("drop requires a number as its first argument.", env)
+	if !IntegerP(n) || IntegerValue(n) < 0 {
+		err = ProcessError(fmt.Sprintf("drop requires a non-negative count as its first argument but was given %s.", String(n)), env)
+		return
 	}
 	size := int(IntegerValue(n))
 
@@ -333,6 +342,95 @@ func DropImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return
 }
 
+// whilePrefix is the number of the leading elements of l that satisfy pred.
+func whilePrefix(name string, args *Data, env *SymbolTableFrame) (count int, err error) {
+	pred := Car(args)
+	if !FunctionOrPrimitiveP(pred) {
+		err = ProcessError(fmt.Sprintf("%s requires a function as its first argument but was given %s.", name, String(pred)), env)
+		return
+	}
+	l := Cadr(args)
+	if !ListP(l) {
+		err = ProcessError(fmt.Sprintf("%s requires a list as its second argument but was given %s.", name, String(l)), env)
+		return
+	}
+	for cell := l; NotNilP(cell); cell = Cdr(cell) {
+		b, applyErr := ApplyWithoutEval(pred, InternalMakeList(Car(cell)), env)
+		if applyErr != nil {
+			return 0, applyErr
+		}
+		if !BooleanValue(b) {
+			break
+		}
+		count++
+	}
+	return
+}
+
+// (take-while pred list) is the leading elements of list that satisfy pred.
+func TakeWhileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	count, err := whilePrefix("take-while", args, env)
+	if err != nil {
+		return
+	}
+	items := make([]*Data, 0, count)
+	for cell := Cadr(args); len(items) < count; cell = Cdr(cell) {
+		items = append(items, Car(cell))
+	}
+	return ArrayToList(items), nil
+}
+
+// (drop-while pred list) is list from the first element that doesn't satisfy
+// pred, sharing that part of list.
+func DropWhileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	count, err := whilePrefix("drop-while", args, env)
+	if err != nil {
+		return
+	}
+	result = Cadr(args)
+	for i := 0; i < count; i++ {
+		result = Cdr(result)
+	}
+	return
+}
+
+// (list-slice list start [end]) is a new list of the elements from index
+// start up to but not including end, counting from 0 as substring does. end
+// defaults to the length of list.
+func ListSliceImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	l := First(args)
+	if !ListP(l) {
+		err = ProcessError(fmt.Sprintf("list-slice requires a list as its first argument but was given %s.", String(l)), env)
+		return
+	}
+	length := int64(Length(l))
+
+	start := Second(args)
+	if !IntegerP(start) || IntegerValue(start) < 0 || IntegerValue(start) > length {
+		err = ProcessError(fmt.Sprintf("list-slice requires a start from 0 to the length of the list but was given %s.", String(start)), env)
+		return
+	}
+	end := IntegerWithValue(length)
+	if Length(args) == 3 {
+		end = Third(args)
+	}
+	if !IntegerP(end) || IntegerValue(end) < IntegerValue(start) || IntegerValue(end) > length {
+		err = ProcessError(fmt.Sprintf("list-slice requires an end from the start to the length of the list but was given %s.", String(end)), env)
+		return
+	}
+
+	cell := l
+	for i := int64(0); i < IntegerValue(start); i++ {
+		cell = Cdr(cell)
+	}
+	items := make([]*Data, 0, IntegerValue(end)-IntegerValue(start))
+	for i := IntegerValue(start); i < IntegerValue(end); i++ {
+		items = append(items, Car(cell))
+		cell = Cdr(cell)
+	}
+	return ArrayToList(items), nil
+}
+
 func ListRefImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	col := Car(args)
 	if !PairP(col) {
@@ -20,16 +20,19 @@ func RegisterListFunctionsPrimitives() {
 	MakePrimitiveFunction("reduce", "3", ReduceLeftImpl)
 	MakePrimitiveFunction("reduce-left", "3", ReduceLeftImpl)
 	MakePrimitiveFunction("reduce-right", "3", ReduceRightImpl)
-	MakePrimitiveFunction("fold-left", "3", FoldLeftImpl)
-	MakePrimitiveFunction("fold-right", "3", FoldRightImpl)
+	MakePrimitiveFunction("fold-left", ">=3", FoldLeftImpl)
+	MakePrimitiveFunction("fold-right", ">=3", FoldRightImpl)
 	MakePrimitiveFunction("filter", "2", FilterImpl)
 	MakePrimitiveFunction("remove", "2", RemoveImpl)
 	MakePrimitiveFunction("memq", "2", MemqImpl)
-	MakePrimitiveFunction("memv", "2", MemqImpl)
-	MakePrimitiveFunction("member", "2", MemqImpl)
+	MakePrimitiveFunction("memv", "2", MemvImpl)
+	MakePrimitiveFunction("member", "2", MemberImpl)
 	MakePrimitiveFunction("memp", "2", FindTailImpl)
 	MakePrimitiveFunction("find-tail", "2", FindTailImpl)
 	MakePrimitiveFunction("find", "2", FindImpl)
+	MakePrimitiveFunction("zip", ">=1", ZipImpl)
+	MakePrimitiveFunction("zip-with", ">=2", ZipWithImpl)
+	MakePrimitiveFunction("unzip", "1", UnzipImpl)
 }
 
 func intMin(x, y int64) int64 {
@@ -40,6 +43,10 @@ func intMin(x, y int64) int64 {
 	}
 }
 
+// (map f list...) is the list of the results of applying f to the first
+// elements of the lists, then to the second, and so on until the shortest
+// list runs out. The result is built forwards from its last cell, so lists
+// of any length work and keep their order.
 func MapImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	f := First(args)
 	if !FunctionOrPrimitiveP(f) {
@@ -48,7 +55,6 @@ func MapImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	}
 
 	var collections []*Data = make([]*Data, 0, Length(args)-1)
-	var loopCount int64 = math.MaxInt64
 	var col *Data
 	for a := Cdr(args); NotNilP(a); a = Cdr(a) {
 		col = Car(a)
@@ -56,76 +62,68 @@ func MapImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 			err = ProcessError(fmt.Sprintf("map needs lists as its other arguments, but got %s.", String(col)), env)
 			return
 		}
-		if NilP(col) || col == nil {
-			return
-		}
 		collections = append(collections, col)
-		loopCount = intMin(loopCount, int64(Length(col)))
-	}
-
-	if loopCount == math.MaxInt64 {
-		return
 	}
 
-	var d []*Data = make([]*Data, 0, loopCount)
+	head := Cons(nil, EmptyCons())
+	lastCell := head
+	mapArgs := make([]*Data, len(collections))
 	var v *Data
-	var a *Data
-	for index := 1; index <= int(loopCount); index++ {
-		mapArgs := make([]*Data, 0, len(collections))
+	for {
 		for key, mapArgCollection := range collections {
-			a = Car(mapArgCollection)
+			if NilP(mapArgCollection) {
+				return Cdr(head), nil
+			}
+			mapArgs[key] = Car(mapArgCollection)
 			collections[key] = Cdr(mapArgCollection)
-			mapArgs = append(mapArgs, a)
 		}
 		v, err = ApplyWithoutEval(f, ArrayToList(mapArgs), env)
 		if err != nil {
 			return
 		}
-		d = append(d, v)
+		if v == nil {
+			v = EmptyCons()
+		}
+		newCell := Cons(v, nil)
+		ConsValue(lastCell).Cdr = newCell
+		lastCell = newCell
 	}
-
-	return ArrayToList(d), nil
 }
 
+// (for-each f list...) applies f to the elements of the lists in parallel,
+// as map does, for its effects alone. Nothing is kept of what f returns.
 func ForEachImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	f := First(args)
 	if !FunctionOrPrimitiveP(f) {
-		err = ProcessError(fmt.Sprintf("foreach needs a function as its first argument, but got %s.", String(f)), env)
+		err = ProcessError(fmt.Sprintf("for-each needs a function as its first argument, but got %s.", String(f)), env)
 		return
 	}
 
 	var collections []*Data = make([]*Data, 0, Length(args)-1)
-	var loopCount int64 = math.MaxInt64
 	var col *Data
 	for a := Cdr(args); NotNilP(a); a = Cdr(a) {
 		col = Car(a)
 		if !ListP(col) {
-			err = ProcessError(fmt.Sprintf("foreach needs lists as its other arguments, but got %s.", String(col)), env)
+			err = ProcessError(fmt.Sprintf("for-each needs lists as its other arguments, but got %s.", String(col)), env)
 			return
 		}
 		collections = append(collections, col)
-		loopCount = intMin(loopCount, int64(Length(col)))
-	}
-
-	if loopCount == math.MaxInt64 {
-		return
 	}
 
-	var a *Data
-	for index := 1; index <= int(loopCount); index++ {
-		mapArgs := make([]*Data, 0, len(collections))
+	mapArgs := make([]*Data, len(collections))
+	for {
 		for key, mapArgCollection := range collections {
-			a = Car(mapArgCollection)
+			if NilP(mapArgCollection) {
+				return nil, nil
+			}
+			mapArgs[key] = Car(mapArgCollection)
 			collections[key] = Cdr(mapArgCollection)
-			mapArgs = append(mapArgs, a)
 		}
 		_, err = ApplyWithoutEval(f, ArrayToList(mapArgs), env)
 		if err != nil {
 			return
 		}
 	}
-
-	return nil, nil
 }
 
 func AnyImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -294,50 +292,97 @@ func ReduceRightImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 	return
 }
 
-func FoldLeftImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	f := First(args)
+// foldLists checks the arguments shared by fold-left and fold-right:
+// (fold-x function initial list...). When folding several lists they are
+// truncated to the length of the shortest.
+func foldLists(name string, args *Data, env *SymbolTableFrame) (f *Data, initial *Data, lists [][]*Data, err error) {
+	f = First(args)
 	if !FunctionOrPrimitiveP(f) {
-		err = ProcessError("fold-left needs a function as its first argument", env)
+		err = ProcessError(fmt.Sprintf("%s needs a function as its first argument", name), env)
 		return
 	}
 
-	initial := Second(args)
-	col := Third(args)
+	initial = Second(args)
 
-	if !ListP(col) {
-		err = ProcessError("fold-left needs a list as its third argument", env)
+	shortest := -1
+	for c := Cddr(args); NotNilP(c); c = Cdr(c) {
+		col := Car(c)
+		if !ListP(col) {
+			err = ProcessError(fmt.Sprintf("%s needs lists as its third and following arguments", name), env)
+			return
+		}
+		list := ToArray(col)
+		if shortest == -1 || len(list) < shortest {
+			shortest = len(list)
+		}
+		lists = append(lists, list)
+	}
+
+	for i := range lists {
+		lists[i] = lists[i][:shortest]
+	}
+	return
+}
+
+// foldArgs collects the i'th element of each list, putting the accumulator
+// first when folding left and last when folding right.
+func foldArgs(acc *Data, lists [][]*Data, i int, rightToLeft bool) *Data {
+	elements := make([]*Data, 0, len(lists)+1)
+	if !rightToLeft {
+		elements = append(elements, acc)
+	}
+	for _, list := range lists {
+		elements = append(elements, list[i])
+	}
+	if rightToLeft {
+		elements = append(elements, acc)
+	}
+	return ArrayToList(elements)
+}
+
+func FoldLeftImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	f, initial, lists, err := foldLists("fold-left", args, env)
+	if err != nil {
 		return
 	}
 
-	list := ToArray(col)
+	if len(lists) == 1 {
+		return reduceFoldProc(initial, lists[0], f, env, false)
+	}
 
-	result, err = reduceFoldProc(initial, list, f, env, false)
+	result = initial
+	for i := 0; i < len(lists[0]); i++ {
+		result, err = ApplyWithoutEval(f, foldArgs(result, lists, i, false), env)
+		if err != nil {
+			return
+		}
+	}
 
 	return
 }
 
 func FoldRightImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	f := First(args)
-	if !FunctionOrPrimitiveP(f) {
-		err = ProcessError("fold-right needs a function as its first argument", env)
+	f, initial, lists, err := foldLists("fold-right", args, env)
+	if err != nil {
 		return
 	}
 
-	initial := Second(args)
-	col := Third(args)
-
-	if !ListP(col) {
-		err = ProcessError("fold-right needs a list as its third argument", env)
-		return
+	if len(lists) == 1 {
+		return reduceFoldProc(initial, lists[0], f, env, true)
 	}
 
-	list := ToArray(col)
-
-	result, err = reduceFoldProc(initial, list, f, env, true)
+	result = initial
+	for i := len(lists[0]) - 1; i >= 0; i-- {
+		result, err = ApplyWithoutEval(f, foldArgs(result, lists, i, true), env)
+		if err != nil {
+			return
+		}
+	}
 
 	return
 }
 
+// (filter pred list) is the elements of list pred is true of, in order.
 func FilterImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	f := First(args)
 	if !FunctionOrPrimitiveP(f) {
@@ -351,7 +396,8 @@ func FilterImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		return
 	}
 
-	var d []*Data = make([]*Data, 0, Length(col))
+	head := Cons(nil, EmptyCons())
+	lastCell := head
 	var v *Data
 	for c := col; NotNilP(c); c = Cdr(c) {
 		v, err = ApplyWithoutEval(f, Cons(Car(c), nil), env)
@@ -364,11 +410,13 @@ func FilterImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		}
 
 		if BooleanValue(v) {
-			d = append(d, Car(c))
+			newCell := Cons(Car(c), nil)
+			ConsValue(lastCell).Cdr = newCell
+			lastCell = newCell
 		}
 	}
 
-	return ArrayToList(d), nil
+	return Cdr(head), nil
 }
 
 func RemoveImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -404,18 +452,35 @@ func RemoveImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return ArrayToList(d), nil
 }
 
-func MemqImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+// memberTail is the first tail of the second of args whose car is the same
+// as the first of args by equal, or #f if there is none.
+func memberTail(args *Data, equal func(*Data, *Data) bool) *Data {
 	key := First(args)
 
 	l := Second(args)
 
 	for c := l; NotNilP(c); c = Cdr(c) {
-		if IsEqual(key, Car(c)) {
-			return c, nil
+		if equal(key, Car(c)) {
+			return c
 		}
 	}
 
-	return LispFalse, nil
+	return LispFalse
+}
+
+// (memq obj list) is the first tail of list whose car is eq? to obj.
+func MemqImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return memberTail(args, IsEq), nil
+}
+
+// (memv obj list) is memq comparing with eqv?.
+func MemvImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return memberTail(args, IsEqv), nil
+}
+
+// (member obj list) is memq comparing with equal?.
+func MemberImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return memberTail(args, IsEqual), nil
 }
 
 func FindTailImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -474,3 +539,102 @@ func FindImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 
 	return LispFalse, nil
 }
+
+// zipLists is the elements of each of lists, all truncated to the length of
+// the shortest one.
+func zipLists(name string, lists *Data, env *SymbolTableFrame) (arrays [][]*Data, err error) {
+	shortest := -1
+	for c := lists; NotNilP(c); c = Cdr(c) {
+		col := Car(c)
+		if !ListP(col) {
+			err = ProcessError(fmt.Sprintf("%s needs lists, but got %s.", name, String(col)), env)
+			return
+		}
+		array := ToArray(col)
+		if shortest == -1 || len(array) < shortest {
+			shortest = len(array)
+		}
+		arrays = append(arrays, array)
+	}
+
+	for i := range arrays {
+		arrays[i] = arrays[i][:shortest]
+	}
+	return
+}
+
+// zipTuple is the i'th element of each of arrays.
+func zipTuple(arrays [][]*Data, i int) *Data {
+	tuple := make([]*Data, 0, len(arrays))
+	for _, array := range arrays {
+		tuple = append(tuple, array[i])
+	}
+	return ArrayToList(tuple)
+}
+
+// (zip list...) is a list of lists of the corresponding elements of each
+// list. It stops at the end of the shortest list, so the extra elements of
+// longer lists are dropped.
+func ZipImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	arrays, err := zipLists("zip", args, env)
+	if err != nil {
+		return
+	}
+
+	tuples := make([]*Data, 0, len(arrays[0]))
+	for i := range arrays[0] {
+		tuples = append(tuples, zipTuple(arrays, i))
+	}
+	return ArrayToList(tuples), nil
+}
+
+// (zip-with function list...) is like zip but applies function to the
+// corresponding elements rather than listing them.
+func ZipWithImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	f := First(args)
+	if !FunctionOrPrimitiveP(f) {
+		err = ProcessError(fmt.Sprintf("zip-with needs a function as its first argument, but got %s.", String(f)), env)
+		return
+	}
+
+	arrays, err := zipLists("zip-with", Cdr(args), env)
+	if err != nil {
+		return
+	}
+
+	values := make([]*Data, 0, len(arrays[0]))
+	for i := range arrays[0] {
+		v, applyErr := ApplyWithoutEval(f, zipTuple(arrays, i), env)
+		if applyErr != nil {
+			return nil, applyErr
+		}
+		values = append(values, v)
+	}
+	return ArrayToList(values), nil
+}
+
+// (unzip tuples) undoes zip: it is a list of the first elements of each
+// tuple, then of the second elements, and so on. Tuples of uneven length
+// are truncated to the shortest, and unzipping an empty list is an empty
+// list.
+func UnzipImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	tuples := Car(args)
+	if !ListP(tuples) {
+		err = ProcessError(fmt.Sprintf("unzip needs a list of lists, but got %s.", String(tuples)), env)
+		return
+	}
+	if NilP(tuples) {
+		return
+	}
+
+	arrays, err := zipLists("unzip", tuples, env)
+	if err != nil {
+		return
+	}
+
+	lists := make([]*Data, 0, len(arrays[0]))
+	for i := range arrays[0] {
+		lists = append(lists, zipTuple(arrays, i))
+	}
+	return ArrayToList(lists), nil
+}
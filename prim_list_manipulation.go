@@ -4,10 +4,17 @@
 
 // This package implements a basic LISP interpretor for embedding in a go program for scripting.
 // This file contains the list manipulation primitive functions.
+//
+// These build new lists and leave their arguments alone, except for
+// append!, which splices its second argument onto the end of the first.
+// The other primitives that change a list in place are set-car!, set-cdr!
+// and set-nth!, and acons when the key is already in the alist. Each has a
+// counterpart that copies instead: cons, replace-nth and alist-update.
 
 package golisp
 
 import (
+	"fmt"
 	"sort"
 )
 
@@ -18,14 +25,19 @@ func RegisterListManipulationPrimitives() {
 	MakePrimitiveFunction("cons", "2", ConsImpl)
 	MakePrimitiveFunction("cons*", ">=1", ConsStarImpl)
 	MakePrimitiveFunction("reverse", "1", ReverseImpl)
-	MakePrimitiveFunction("flatten", "1", FlattenImpl)
+	MakePrimitiveFunction("deep-reverse", "1", DeepReverseImpl)
+	MakePrimitiveFunction("flatten", "1|2", FlattenImpl)
 	MakePrimitiveFunction("flatten*", "1", RecursiveFlattenImpl)
 	MakePrimitiveFunction("append", "*", AppendImpl)
 	MakeSpecialForm("append!", "2", AppendBangImpl)
+	MakePrimitiveFunction("replace-nth", "3", ReplaceNthImpl)
 	MakePrimitiveFunction("copy", "1", CopyImpl)
+	MakePrimitiveFunction("deep-copy", "1", DeepCopyImpl)
 	MakePrimitiveFunction("partition", "2", PartitionImpl)
+	MakePrimitiveFunction("group-by", "2", GroupByImpl)
 	MakePrimitiveFunction("sublist", "3", SublistImpl)
 	MakePrimitiveFunction("sort", "2", SortImpl)
+	MakePrimitiveFunction("sort-by", "2|3", SortByImpl)
 }
 
 func MakeListImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -81,14 +93,76 @@ func ReverseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return Reverse(Car(args)), nil
 }
 
+// (deep-reverse list) reverses list and every list nested in it.
+func DeepReverseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return deepReverse(Car(args), make(map[*Data]bool), env)
+}
+
+// deepReverse does the work of deep-reverse. visiting holds the cells of
+// the lists being reversed, so coming back to one means a cycle.
+func deepReverse(d *Data, visiting map[*Data]bool, env *SymbolTableFrame) (result *Data, err error) {
+	if d == nil || !ListP(d) {
+		return d, nil
+	}
+
+	var cells []*Data
+	defer func() {
+		for _, c := range cells {
+			delete(visiting, c)
+		}
+	}()
+
+	for c := d; NotNilP(c); c = Cdr(c) {
+		if !ListP(c) {
+			err = ProcessError(fmt.Sprintf("deep-reverse expects proper lists but received a list ending in %s.", String(c)), env)
+			return
+		}
+		if visiting[c] {
+			err = ProcessError("deep-reverse can't reverse a cyclic list.", env)
+			return
+		}
+		visiting[c] = true
+		cells = append(cells, c)
+
+		element, elementErr := deepReverse(Car(c), visiting, env)
+		if elementErr != nil {
+			return nil, elementErr
+		}
+		result = Cons(element, result)
+	}
+	return
+}
+
+// (flatten list [depth]) splices the elements of nested lists into list,
+// at every level or only depth levels deep. (flatten* list) is (flatten list).
 func FlattenImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	return Flatten(Car(args))
+	depth := -1
+	if Length(args) == 2 {
+		d := Cadr(args)
+		if !IntegerP(d) || IntegerValue(d) < 0 {
+			err = ProcessError(fmt.Sprintf("flatten expects a non-negative integer depth but received %s.", String(d)), env)
+			return
+		}
+		depth = int(IntegerValue(d))
+	}
+	result, err = FlattenToDepth(Car(args), depth)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("flatten: %s", err), env)
+	}
+	return
 }
 
 func RecursiveFlattenImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	return RecursiveFlatten(Car(args))
+	result, err = RecursiveFlatten(Car(args))
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("flatten*: %s", err), env)
+	}
+	return
 }
 
+// (append! list other) changes the last pair of list to point at other, or
+// to a new pair holding other if it isn't a list, and sets list to the
+// result when it's a variable. Anything sharing list sees the change.
 func AppendBangImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	firstList, err := Eval(Car(args), env)
 	if err != nil {
@@ -113,6 +187,8 @@ func AppendBangImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 	return
 }
 
+// (append list...) copies the elements of its arguments into a new list, so
+// none of them is changed or shared by the result.
 func AppendImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	// No args -> empty list
 	if Length(args) == 0 {
@@ -136,10 +212,34 @@ func AppendImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return
 }
 
+// (replace-nth list n value) is a copy of list with its nth element, counting
+// from 1 as nth does, replaced by value. set-nth! changes list instead.
+func ReplaceNthImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	l := First(args)
+	if !ListP(l) {
+		err = ProcessError(fmt.Sprintf("replace-nth requires a list as its first argument but was given %s.", String(l)), env)
+		return
+	}
+	n := Second(args)
+	if !IntegerP(n) || IntegerValue(n) < 1 || IntegerValue(n) > int64(Length(l)) {
+		err = ProcessError(fmt.Sprintf("replace-nth requires an index from 1 to the length of the list but was given %s.", String(n)), env)
+		return
+	}
+	elements := ToArray(l)
+	elements[IntegerValue(n)-1] = Third(args)
+	return ArrayToList(elements), nil
+}
+
 func CopyImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return Copy(Car(args)), nil
 }
 
+// (deep-copy value) is a copy of value that shares nothing mutable with it,
+// though it shares structure within itself as value does.
+func DeepCopyImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return DeepCopy(Car(args)), nil
+}
+
 func partitionBySize(determiner *Data, l *Data, env *SymbolTableFrame) (result *Data, err error) {
 	size := int(IntegerValue(determiner))
 	if size < 1 {
@@ -165,7 +265,6 @@ func partitionBySize(determiner *Data, l *Data, env *SymbolTableFrame) (result *
 }
 
 func partitionByPredicate(determiner *Data, l *Data, env *SymbolTableFrame) (result *Data, err error) {
-	pieces := make([]*Data, 0, 5)
 	falseSection := make([]*Data, 0, 5)
 	trueSection := make([]*Data, 0, 5)
 	var predicateResult *Data
@@ -181,12 +280,12 @@ func partitionByPredicate(determiner *Data, l *Data, env *SymbolTableFrame) (res
 		}
 	}
 
-	pieces = append(pieces, ArrayToList(trueSection))
-	pieces = append(pieces, ArrayToList(falseSection))
-
-	return ArrayToList(pieces), nil
+	return ValuesWithArray([]*Data{ArrayToList(trueSection), ArrayToList(falseSection)}), nil
 }
 
+// (partition n list) splits list into lists of n elements, the last of which
+// may be shorter. (partition pred list) returns two values: the elements
+// that satisfy pred and those that don't, each in their original order.
 func PartitionImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	determiner := Car(args)
 	if !IntegerP(determiner) && !FunctionOrPrimitiveP(determiner) {
@@ -207,6 +306,44 @@ func PartitionImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 	}
 }
 
+// (group-by key list) is an alist from each key that key returns for the
+// elements of list to those elements, in their original order. The keys are
+// in the order they first appear and are compared as hash table keys are.
+func GroupByImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	keyProc := Car(args)
+	if !FunctionOrPrimitiveP(keyProc) {
+		err = ProcessError(fmt.Sprintf("group-by requires a function as its first argument but was given %s.", String(keyProc)), env)
+		return
+	}
+	l := Cadr(args)
+	if !ListP(l) {
+		err = ProcessError(fmt.Sprintf("group-by requires a list as its second argument but was given %s.", String(l)), env)
+		return
+	}
+
+	var keys []*Data
+	var groups [][]*Data
+	indices := make(map[string]int)
+	for c := l; NotNilP(c); c = Cdr(c) {
+		key, keyErr := ApplyWithoutEval(keyProc, InternalMakeList(Car(c)), env)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		i, found := indices[hashKey(key)]
+		if !found {
+			i = len(keys)
+			indices[hashKey(key)] = i
+			keys = append(keys, key)
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], Car(c))
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		result = aconsCell(keys[i], ArrayToList(groups[i]), result)
+	}
+	return
+}
+
 func SublistImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	l := Car(args)
 	if !ListP(l) {
@@ -286,7 +423,8 @@ func SortImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 
 	arr := ToArray(coll)
 
-	sort.Slice(arr, func(i, j int) bool {
+	// Elements that compare equal keep their original order.
+	sort.SliceStable(arr, func(i, j int) bool {
 		var ret bool
 		if err == nil {
 			a := arr[i]
@@ -296,5 +434,84 @@ func SortImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		return ret
 	})
 
-	return ArrayToList(arr), err
+	if err != nil {
+		return
+	}
+	return ArrayToList(arr), nil
+}
+
+// keyLess is the default ordering for sort-by: strings compare
+// lexically and everything else must be a number.
+func keyLess(a *Data, b *Data, env *SymbolTableFrame) (result bool, err error) {
+	if StringP(a) && StringP(b) {
+		return StringValue(a) < StringValue(b), nil
+	}
+	less, err := LessThanImpl(InternalMakeList(a, b), env)
+	if err == nil {
+		result = BooleanValue(less)
+	}
+	return
+}
+
+// (sort-by list key-function [comparator]) stable sorts list by the key
+// extracted from each element. Keys are computed once per element.
+func SortByImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	coll := Car(args)
+	if !ListP(coll) {
+		err = ProcessError("sort-by requires a list as it's first argument.", env)
+		return
+	}
+
+	keyProc := Cadr(args)
+	if !FunctionOrPrimitiveP(keyProc) {
+		err = ProcessError("sort-by requires a function or primitive as it's second argument.", env)
+		return
+	}
+
+	var proc *Data
+	if Length(args) == 3 {
+		proc = Caddr(args)
+		if !FunctionOrPrimitiveP(proc) {
+			err = ProcessError("sort-by requires a function or primitive as it's third argument.", env)
+			return
+		}
+	}
+
+	arr := ToArray(coll)
+	keys := make([]*Data, len(arr))
+	for i, element := range arr {
+		keys[i], err = ApplyWithoutEval(keyProc, InternalMakeList(element), env)
+		if err != nil {
+			return
+		}
+	}
+
+	order := make([]int, len(arr))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		var ret bool
+		if err == nil {
+			a := keys[order[i]]
+			b := keys[order[j]]
+			if proc == nil {
+				ret, err = keyLess(a, b, env)
+			} else {
+				ret, err = sortCompare(a, b, proc, env)
+			}
+		}
+		return ret
+	})
+
+	if err != nil {
+		return
+	}
+
+	sorted := make([]*Data, len(arr))
+	for i, index := range order {
+		sorted[i] = arr[index]
+	}
+	return ArrayToList(sorted), nil
 }
@@ -17,6 +17,8 @@ func RegisterMacroPrimitives() {
 	MakeSpecialForm("unquote", "1", UnquoteImpl)
 	MakeSpecialForm("unquote-splicing", "1", UnquoteSplicingImpl)
 	MakeSpecialForm("expand", ">=1", ExpandImpl)
+	MakePrimitiveFunction("macroexpand-1", "1", MacroexpandOneImpl)
+	MakePrimitiveFunction("macroexpand", "1", MacroexpandImpl)
 }
 
 func QuoteImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -77,24 +79,56 @@ func processQuasiquoted(sexpr *Data, level int, env *SymbolTableFrame) (result *
 			return Cons(Cons(Intern("unquote-splicing"), processed), nil), nil
 		}
 	} else {
-		parts := make([]*Data, 0, Length(Cdr(sexpr)))
-		for _, exp := range ToArray(sexpr) {
-			processed, err := processQuasiquoted(exp, level, env)
+		items := make([]*Data, 0, Length(sexpr))
+		var tail *Data
+		for c := sexpr; NotNilP(c); c = Cdr(c) {
+			if !ListP(c) {
+				// the tail of a dotted list: `(a . b)
+				tail = c
+				break
+			}
+			if isQuasiquoteForm(c, "unquote") || isQuasiquoteForm(c, "unquote-splicing") {
+				// `(a . ,b) reads as (a unquote b), so the rest of the list is the unquoted tail
+				processed, err := processQuasiquoted(c, level, env)
+				if err != nil {
+					return nil, err
+				}
+				if level == 1 && isQuasiquoteForm(c, "unquote-splicing") {
+					tail = processed
+				} else {
+					tail = Car(processed)
+				}
+				break
+			}
+
+			processed, err := processQuasiquoted(Car(c), level, env)
 			if err != nil {
 				return nil, err
 			}
-			if processed != nil {
-				parts = append(parts, processed)
+			if level != 1 || !isQuasiquoteForm(Car(c), "unquote-splicing") {
+				items = append(items, Car(processed))
+				continue
+			}
+
+			spliced := processed
+			for ; PairP(spliced) && NotNilP(spliced); spliced = Cdr(spliced) {
+				items = append(items, Car(spliced))
+			}
+			if NotNilP(spliced) {
+				if NotNilP(Cdr(c)) {
+					return nil, ProcessError(fmt.Sprintf("unquote-splicing expected a list but received %s.", String(spliced)), env)
+				}
+				tail = spliced
 			}
 		}
-		flat, err := Flatten(ArrayToList(parts))
-		if err != nil {
-			return nil, err
-		}
-		return Cons(flat, nil), nil
+		return Cons(ArrayToListWithTail(items, tail), nil), nil
 	}
 }
 
+func isQuasiquoteForm(sexpr *Data, name string) bool {
+	return PairP(sexpr) && SymbolP(Car(sexpr)) && StringValue(Car(sexpr)) == name
+}
+
 func QuasiquoteImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	r, err := processQuasiquoted(Car(args), 1, env)
 	if err != nil {
@@ -124,3 +158,35 @@ func ExpandImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	}
 	return MacroValue(n).Expand(Cdr(args), env)
 }
+
+// macroexpandOnce expands form if its head names a macro, reporting whether
+// it did.
+func macroexpandOnce(form *Data, env *SymbolTableFrame) (result *Data, expanded bool, err error) {
+	if !PairP(form) || NilP(form) || !SymbolP(Car(form)) {
+		return form, false, nil
+	}
+
+	m := env.ValueOf(Car(form))
+	if !MacroP(m) {
+		return form, false, nil
+	}
+
+	result, err = MacroValue(m).Expand(Cdr(form), env)
+	return result, err == nil, err
+}
+
+func MacroexpandOneImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	result, _, err = macroexpandOnce(Car(args), env)
+	return
+}
+
+func MacroexpandImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	result = Car(args)
+	for expanded := true; expanded; {
+		result, expanded, err = macroexpandOnce(result, env)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
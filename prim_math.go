@@ -10,7 +10,11 @@ package golisp
 import (
 	"fmt"
 	"math"
+	"math/big"
+	"math/cmplx"
 	"math/rand"
+	"strconv"
+	"strings"
 )
 
 func RegisterMathPrimitives() {
@@ -20,19 +24,28 @@ func RegisterMathPrimitives() {
 	MakePrimitiveFunction("/", "*", QuotientImpl)
 	MakePrimitiveFunction("succ", "1", IncrementImpl)
 	MakePrimitiveFunction("pred", "1", DecrementImpl)
-	MakePrimitiveFunction("quotient", "*", QuotientImpl)
+	MakePrimitiveFunction("quotient", "2", IntegerQuotientImpl)
+	MakePrimitiveFunction("remainder", "2", RemainderImpl)
 	MakePrimitiveFunction("%", "2", RemainderImpl)
-	MakePrimitiveFunction("modulo", "2", RemainderImpl)
+	MakePrimitiveFunction("modulo", "2", ModuloImpl)
+	MakePrimitiveFunction("gcd", "*", GcdImpl)
+	MakePrimitiveFunction("lcm", "*", LcmImpl)
 	MakePrimitiveFunction("random-byte", "0", RandomByteImpl)
 	MakePrimitiveFunction("interval", "1|2|3", IntervalImpl)
 	MakePrimitiveFunction("integer", "1", ToIntImpl)
 	MakePrimitiveFunction("float", "1", ToFloatImpl)
 	MakePrimitiveFunction("number->string", "1|2", NumberToStringImpl)
 	MakePrimitiveFunction("string->number", "1|2", StringToNumberImpl)
-	MakePrimitiveFunction("min", "1", MinImpl)
-	MakePrimitiveFunction("max", "1", MaxImpl)
+	MakePrimitiveFunction("min", ">=1", MinImpl)
+	MakePrimitiveFunction("max", ">=1", MaxImpl)
 	MakePrimitiveFunction("floor", "1", FloorImpl)
 	MakePrimitiveFunction("ceiling", "1", CeilingImpl)
+	MakePrimitiveFunction("round", "1", RoundImpl)
+	MakePrimitiveFunction("truncate", "1", TruncateImpl)
+	MakePrimitiveFunction("sqrt", "1", SqrtImpl)
+	MakePrimitiveFunction("atan", "1|2", AtanImpl)
+	MakePrimitiveFunction("atan2", "2", Atan2Impl)
+	MakePrimitiveFunction("log", "1|2", LogImpl)
 	MakePrimitiveFunction("abs", "1", AbsImpl)
 	MakePrimitiveFunction("zero?", "1", ZeroImpl)
 	MakePrimitiveFunction("positive?", "1", PositiveImpl)
@@ -41,40 +54,50 @@ func RegisterMathPrimitives() {
 	MakePrimitiveFunction("odd?", "1", OddImpl)
 	MakePrimitiveFunction("sign", "1", SignImpl)
 	MakePrimitiveFunction("pow", "2", PowImpl)
+	MakePrimitiveFunction("expt", "2", PowImpl)
+	MakePrimitiveFunction("bignum?", "1", BignumPImpl)
+	MakePrimitiveFunction("->bignum", "1", ToBignumImpl)
+	MakePrimitiveFunction("->fixnum", "1", ToFixnumImpl)
+	MakePrimitiveFunction("exact/", "*", ExactQuotientImpl)
+	MakePrimitiveFunction("rational?", "1", RationalPImpl)
+	MakePrimitiveFunction("exact?", "1", ExactPImpl)
+	MakePrimitiveFunction("inexact?", "1", InexactPImpl)
+	MakePrimitiveFunction("exact->inexact", "1", ExactToInexactImpl)
+	MakePrimitiveFunction("inexact->exact", "1", InexactToExactImpl)
+	MakePrimitiveFunction("numerator", "1", NumeratorImpl)
+	MakePrimitiveFunction("denominator", "1", DenominatorImpl)
+	MakePrimitiveFunction("rationalize", "2", RationalizeImpl)
 	MakePrimitiveFunction("inf?", "1", IsInfImpl)
 	MakePrimitiveFunction("nan?", "1", IsNaNImpl)
 	MakePrimitiveFunction("float->bits", "1", FloatToBitsImpl)
 	MakePrimitiveFunction("bits->float", "1", BitsToFloatImpl)
 
-	makeUnaryFloatFunction("acos", math.Acos)
-	makeUnaryFloatFunction("acosh", math.Acosh)
-	makeUnaryFloatFunction("asin", math.Asin)
-	makeUnaryFloatFunction("asinh", math.Asinh)
-	makeUnaryFloatFunction("atan", math.Atan)
-	makeUnaryFloatFunction("atanh", math.Atanh)
-	makeUnaryFloatFunction("cbrt", math.Cbrt)
-	makeUnaryFloatFunction("cos", math.Cos)
-	makeUnaryFloatFunction("cosh", math.Cosh)
-	makeUnaryFloatFunction("erf", math.Erf)
-	makeUnaryFloatFunction("erfc", math.Erfc)
-	makeUnaryFloatFunction("exp", math.Exp)
-	makeUnaryFloatFunction("exp2", math.Exp2)
-	makeUnaryFloatFunction("expm1", math.Expm1)
-	makeUnaryFloatFunction("gamma", math.Gamma)
-	makeUnaryFloatFunction("j0", math.J0)
-	makeUnaryFloatFunction("j1", math.J1)
-	makeUnaryFloatFunction("log", math.Log)
-	makeUnaryFloatFunction("log10", math.Log10)
-	makeUnaryFloatFunction("log1p", math.Log1p)
-	makeUnaryFloatFunction("log2", math.Log2)
-	makeUnaryFloatFunction("logb", math.Logb)
-	makeUnaryFloatFunction("sin", math.Sin)
-	makeUnaryFloatFunction("sinh", math.Sinh)
-	makeUnaryFloatFunction("sqrt", math.Sqrt)
-	makeUnaryFloatFunction("tan", math.Tan)
-	makeUnaryFloatFunction("tanh", math.Tanh)
-	makeUnaryFloatFunction("y0", math.Y0)
-	makeUnaryFloatFunction("y1", math.Y1)
+	makeUnaryFloatFunction("acos", math.Acos, cmplx.Acos)
+	makeUnaryFloatFunction("acosh", math.Acosh, cmplx.Acosh)
+	makeUnaryFloatFunction("asin", math.Asin, cmplx.Asin)
+	makeUnaryFloatFunction("asinh", math.Asinh, cmplx.Asinh)
+	makeUnaryFloatFunction("atanh", math.Atanh, cmplx.Atanh)
+	makeUnaryFloatFunction("cbrt", math.Cbrt, nil)
+	makeUnaryFloatFunction("cos", math.Cos, cmplx.Cos)
+	makeUnaryFloatFunction("cosh", math.Cosh, cmplx.Cosh)
+	makeUnaryFloatFunction("erf", math.Erf, nil)
+	makeUnaryFloatFunction("erfc", math.Erfc, nil)
+	makeUnaryFloatFunction("exp", math.Exp, cmplx.Exp)
+	makeUnaryFloatFunction("exp2", math.Exp2, nil)
+	makeUnaryFloatFunction("expm1", math.Expm1, nil)
+	makeUnaryFloatFunction("gamma", math.Gamma, nil)
+	makeUnaryFloatFunction("j0", math.J0, nil)
+	makeUnaryFloatFunction("j1", math.J1, nil)
+	makeUnaryFloatFunction("log10", math.Log10, cmplx.Log10)
+	makeUnaryFloatFunction("log1p", math.Log1p, nil)
+	makeUnaryFloatFunction("log2", math.Log2, nil)
+	makeUnaryFloatFunction("logb", math.Logb, nil)
+	makeUnaryFloatFunction("sin", math.Sin, cmplx.Sin)
+	makeUnaryFloatFunction("sinh", math.Sinh, cmplx.Sinh)
+	makeUnaryFloatFunction("tan", math.Tan, cmplx.Tan)
+	makeUnaryFloatFunction("tanh", math.Tanh, cmplx.Tanh)
+	makeUnaryFloatFunction("y0", math.Y0, nil)
+	makeUnaryFloatFunction("y1", math.Y1, nil)
 
 	Global.BindToProtected(Intern("pi"), FloatWithValue(float32(math.Pi)))
 	Global.BindToProtected(Intern("e"), FloatWithValue(float32(math.E)))
@@ -92,18 +115,31 @@ func RegisterMathPrimitives() {
 	Global.BindToProtected(Intern("-inf"), FloatWithValue(float32(math.Inf(-1))))
 }
 
-func makeUnaryFloatFunction(name string, f func(float64) float64) {
+// makeUnaryFloatFunction defines name to apply f to a real number. If
+// complexF is given, it is applied to complex numbers, and to real numbers
+// that f is undefined for, so that (asin 2) is complex like (sqrt -1).
+func makeUnaryFloatFunction(name string, f func(float64) float64, complexF func(complex128) complex128) {
 	primFunc := func(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		valObj := Car(args)
 
+		if ComplexP(valObj) && complexF != nil {
+			return ComplexWithValue(complexF(ComplexValue(valObj))), nil
+		}
 		if !NumberP(valObj) {
-			err = ProcessError(fmt.Sprintf("%s expects a number as a parameter, got %s", name, String(valObj)), env)
+			err = ProcessError(fmt.Sprintf("%s expects a %s as a parameter, got %s", name, numberKind(complexF != nil), String(valObj)), env)
 			return
 		}
 
 		val := FloatValue(valObj)
 
 		ret := f(float64(val))
+		if math.IsNaN(ret) && !math.IsNaN(float64(val)) {
+			if complexF != nil {
+				return ComplexWithValue(complexF(ComplexValue(valObj))), nil
+			}
+			err = ProcessError(fmt.Sprintf("%s is undefined for %s.", name, String(valObj)), env)
+			return
+		}
 
 		return FloatWithValue(float32(ret)), nil
 	}
@@ -111,6 +147,15 @@ func makeUnaryFloatFunction(name string, f func(float64) float64) {
 	MakePrimitiveFunction(name, "1", primFunc)
 }
 
+// numberKind is what a function expects in its error messages: any number
+// if it takes complex ones, and otherwise a real number.
+func numberKind(complexes bool) string {
+	if complexes {
+		return "number"
+	}
+	return "real number"
+}
+
 func sgn(a float32) int64 {
 	switch {
 	case a < 0:
@@ -153,14 +198,35 @@ func addFloats(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return FloatWithValue(acc), nil
 }
 
+// The integer versions of the arithmetic functions work on int64 until
+// either an argument is a bignum or a step overflows, at which point they
+// redo the whole computation with big.Int. Results that fit are demoted back
+// to fixnums.
+
 func addInts(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	var acc int64 = 0
 	for c := args; NotNilP(c); c = Cdr(c) {
-		acc += IntegerValue(Car(c))
+		if BignumP(Car(c)) {
+			return addBignums(args, env)
+		}
+		n := IntegerValue(Car(c))
+		sum := acc + n
+		if (n > 0 && sum < acc) || (n < 0 && sum > acc) {
+			return addBignums(args, env)
+		}
+		acc = sum
 	}
 	return IntegerWithValue(acc), nil
 }
 
+func addBignums(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	acc := new(big.Int)
+	for c := args; NotNilP(c); c = Cdr(c) {
+		acc.Add(acc, BignumValue(Car(c)))
+	}
+	return ExactIntegerWithValue(acc), nil
+}
+
 func anyFloats(args *Data, env *SymbolTableFrame) (result bool, err error) {
 	for c := args; NotNilP(c); c = Cdr(c) {
 		if !NumberP(Car(c)) {
@@ -174,26 +240,99 @@ func anyFloats(args *Data, env *SymbolTableFrame) (result bool, err error) {
 	return false, nil
 }
 
+func anyRationals(args *Data) bool {
+	for c := args; NotNilP(c); c = Cdr(c) {
+		if RationalP(Car(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// foldRationals does exact arithmetic on a list of exact numbers, starting
+// from the first one, or from identity if it's given.
+func foldRationals(args *Data, identity *big.Rat, op func(*big.Rat, *big.Rat, *big.Rat) *big.Rat) *big.Rat {
+	acc := identity
+	if acc == nil {
+		acc = new(big.Rat).Set(RationalValue(Car(args)))
+		args = Cdr(args)
+	}
+	for c := args; NotNilP(c); c = Cdr(c) {
+		op(acc, acc, RationalValue(Car(c)))
+	}
+	return acc
+}
+
+func addRationals(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return RationalWithValue(foldRationals(args, new(big.Rat), (*big.Rat).Add)), nil
+}
+
+func subtractRationals(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return RationalWithValue(foldRationals(args, nil, (*big.Rat).Sub)), nil
+}
+
+func multiplyRationals(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return RationalWithValue(foldRationals(args, big.NewRat(1, 1), (*big.Rat).Mul)), nil
+}
+
+func quotientRationals(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		if RationalValue(Car(c)).Sign() == 0 {
+			err = ProcessError(fmt.Sprintf("Quotent: %s -> Divide by zero.", String(args)), env)
+			return
+		}
+	}
+	return RationalWithValue(foldRationals(args, nil, (*big.Rat).Quo)), nil
+}
+
 func AddImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	areComplexes, err := anyComplexes(args, env)
+	if err != nil {
+		return
+	}
+	if areComplexes {
+		return addComplexes(args, env)
+	}
 	areFloats, err := anyFloats(args, env)
 	if err != nil {
 		return
 	}
 	if areFloats {
 		return addFloats(args, env)
+	} else if anyRationals(args) {
+		return addRationals(args, env)
 	} else {
 		return addInts(args, env)
 	}
 }
 
 func subtractInts(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if BignumP(Car(args)) {
+		return subtractBignums(args, env)
+	}
 	acc := IntegerValue(Car(args))
 	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
-		acc -= IntegerValue(Car(c))
+		if BignumP(Car(c)) {
+			return subtractBignums(args, env)
+		}
+		n := IntegerValue(Car(c))
+		diff := acc - n
+		if (n > 0 && diff > acc) || (n < 0 && diff < acc) {
+			return subtractBignums(args, env)
+		}
+		acc = diff
 	}
 	return IntegerWithValue(acc), nil
 }
 
+func subtractBignums(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	acc := new(big.Int).Set(BignumValue(Car(args)))
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		acc.Sub(acc, BignumValue(Car(c)))
+	}
+	return ExactIntegerWithValue(acc), nil
+}
+
 func subtractFloats(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	acc := FloatValue(Car(args))
 	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
@@ -203,25 +342,59 @@ func subtractFloats(args *Data, env *SymbolTableFrame) (result *Data, err error)
 }
 
 func SubtractImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	areComplexes, err := anyComplexes(args, env)
+	if err != nil {
+		return
+	}
+	if areComplexes {
+		return subtractComplexes(args, env)
+	}
 	areFloats, err := anyFloats(args, env)
 	if err != nil {
 		return
 	}
 	if areFloats {
 		return subtractFloats(args, env)
+	} else if anyRationals(args) {
+		return subtractRationals(args, env)
 	} else {
 		return subtractInts(args, env)
 	}
 }
 
+func multiplyOverflows(a int64, b int64) bool {
+	if a == 0 || b == 0 {
+		return false
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return true
+	}
+	return (a*b)/b != a
+}
+
 func multiplyInts(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	var acc int64 = 1
 	for c := args; NotNilP(c); c = Cdr(c) {
-		acc *= IntegerValue(Car(c))
+		if BignumP(Car(c)) {
+			return multiplyBignums(args, env)
+		}
+		n := IntegerValue(Car(c))
+		if multiplyOverflows(acc, n) {
+			return multiplyBignums(args, env)
+		}
+		acc *= n
 	}
 	return IntegerWithValue(acc), nil
 }
 
+func multiplyBignums(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	acc := big.NewInt(1)
+	for c := args; NotNilP(c); c = Cdr(c) {
+		acc.Mul(acc, BignumValue(Car(c)))
+	}
+	return ExactIntegerWithValue(acc), nil
+}
+
 func multiplyFloats(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	var acc float32 = 1.0
 	for c := args; NotNilP(c); c = Cdr(c) {
@@ -231,21 +404,39 @@ func multiplyFloats(args *Data, env *SymbolTableFrame) (result *Data, err error)
 }
 
 func MultiplyImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	areComplexes, err := anyComplexes(args, env)
+	if err != nil {
+		return
+	}
+	if areComplexes {
+		return multiplyComplexes(args, env)
+	}
 	areFloats, err := anyFloats(args, env)
 	if err != nil {
 		return
 	}
 	if areFloats {
 		return multiplyFloats(args, env)
+	} else if anyRationals(args) {
+		return multiplyRationals(args, env)
 	} else {
 		return multiplyInts(args, env)
 	}
 }
 
 func quotientInts(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if BignumP(Car(args)) {
+		return quotientBignums(args, env)
+	}
 	acc := IntegerValue(Car(args))
 	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		if BignumP(Car(c)) {
+			return quotientBignums(args, env)
+		}
 		v := IntegerValue(Car(c))
+		if acc == math.MinInt64 && v == -1 {
+			return quotientBignums(args, env)
+		}
 		if v == 0 {
 			err = ProcessError(fmt.Sprintf("Quotent: %s -> Divide by zero.", String(args)), env)
 			return
@@ -256,6 +447,19 @@ func quotientInts(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return IntegerWithValue(acc), nil
 }
 
+func quotientBignums(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	acc := new(big.Int).Set(BignumValue(Car(args)))
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		v := BignumValue(Car(c))
+		if v.Sign() == 0 {
+			err = ProcessError(fmt.Sprintf("Quotent: %s -> Divide by zero.", String(args)), env)
+			return
+		}
+		acc.Quo(acc, v)
+	}
+	return ExactIntegerWithValue(acc), nil
+}
+
 func quotientFloats(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	var acc float32 = FloatValue(Car(args))
 	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
@@ -271,32 +475,154 @@ func quotientFloats(args *Data, env *SymbolTableFrame) (result *Data, err error)
 }
 
 func QuotientImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	areComplexes, err := anyComplexes(args, env)
+	if err != nil {
+		return
+	}
+	if areComplexes {
+		return quotientComplexes(args, env)
+	}
 	areFloats, err := anyFloats(args, env)
 	if err != nil {
 		return
 	}
 	if areFloats {
 		return quotientFloats(args, env)
+	} else if anyRationals(args) {
+		return quotientRationals(args, env)
 	} else {
 		return quotientInts(args, env)
 	}
 }
 
+// exact/ divides without truncating: exact arguments give an exact result,
+// a rational when it isn't whole, and any float makes the result a float.
+func ExactQuotientImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	areFloats, err := anyFloats(args, env)
+	if err != nil {
+		return
+	}
+	if areFloats {
+		return quotientFloats(args, env)
+	} else {
+		return quotientRationals(args, env)
+	}
+}
+
+// integerDivisionArgs checks that the two args of the integer division
+// primitive name are integers and that the divisor isn't zero. Unless both
+// are fixnums they are returned as bignums too.
+func integerDivisionArgs(name string, args *Data, env *SymbolTableFrame) (dividend *Data, divisor *Data, bigDividend *big.Int, bigDivisor *big.Int, err error) {
+	dividend = Car(args)
+	if !ExactIntegerP(dividend) {
+		err = ProcessError(fmt.Sprintf("%s expected an integer first arg, received %s", name, String(dividend)), env)
+		return
+	}
+
+	divisor = Cadr(args)
+	if !ExactIntegerP(divisor) {
+		err = ProcessError(fmt.Sprintf("%s expected an integer second arg, received %s", name, String(divisor)), env)
+		return
+	}
+
+	if BignumP(dividend) || BignumP(divisor) {
+		bigDividend, bigDivisor = BignumValue(dividend), BignumValue(divisor)
+		if bigDivisor.Sign() == 0 {
+			err = ProcessError(fmt.Sprintf("%s: divide by zero.", name), env)
+		}
+		return
+	}
+	if IntegerValue(divisor) == 0 {
+		err = ProcessError(fmt.Sprintf("%s: divide by zero.", name), env)
+	}
+	return
+}
+
+// (quotient n d) is n / d truncated toward zero.
+func IntegerQuotientImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	dividend, divisor, bigDividend, bigDivisor, err := integerDivisionArgs("quotient", args, env)
+	if err != nil {
+		return
+	}
+	if bigDividend == nil {
+		n, d := IntegerValue(dividend), IntegerValue(divisor)
+		if n != math.MinInt64 || d != -1 {
+			return IntegerWithValue(n / d), nil
+		}
+		bigDividend, bigDivisor = BignumValue(dividend), BignumValue(divisor)
+	}
+	return ExactIntegerWithValue(new(big.Int).Quo(bigDividend, bigDivisor)), nil
+}
+
+// (remainder n d), or (% n d), is what is left of n after (quotient n d), so
+// it takes the sign of n.
 func RemainderImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	dividend := Car(args)
-	if !IntegerP(dividend) {
-		err = ProcessError(fmt.Sprintf("%%/modulo expected an integer first arg, received %s", String(dividend)), env)
+	dividend, divisor, bigDividend, bigDivisor, err := integerDivisionArgs("remainder", args, env)
+	if err != nil {
 		return
 	}
+	if bigDividend == nil {
+		return IntegerWithValue(IntegerValue(dividend) % IntegerValue(divisor)), nil
+	}
+	return ExactIntegerWithValue(new(big.Int).Rem(bigDividend, bigDivisor)), nil
+}
+
+// (modulo n d) is n modulo d, which takes the sign of d: what is left of n
+// after dividing it by d rounding toward negative infinity.
+func ModuloImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	dividend, divisor, bigDividend, bigDivisor, err := integerDivisionArgs("modulo", args, env)
+	if err != nil {
+		return
+	}
+	if bigDividend == nil {
+		m, d := IntegerValue(dividend)%IntegerValue(divisor), IntegerValue(divisor)
+		if m != 0 && (m < 0) != (d < 0) {
+			m += d
+		}
+		return IntegerWithValue(m), nil
+	}
+	m := new(big.Int).Rem(bigDividend, bigDivisor)
+	if m.Sign() != 0 && m.Sign() != bigDivisor.Sign() {
+		m.Add(m, bigDivisor)
+	}
+	return ExactIntegerWithValue(m), nil
+}
 
-	divisor := Cadr(args)
-	if !IntegerP(divisor) {
-		err = ProcessError(fmt.Sprintf("%%/modulo expected an integer second arg, received %s", String(divisor)), env)
+// (gcd n...) is the greatest common divisor of the integers, 0 when there
+// are none. Like lcm, it is never negative.
+func GcdImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ns, err := integerArgs("gcd", args, env)
+	if err != nil {
 		return
 	}
+	acc := new(big.Int)
+	for _, n := range ns {
+		if acc.Sign() == 0 {
+			acc.Abs(n)
+		} else if n.Sign() != 0 {
+			acc.GCD(nil, nil, acc, new(big.Int).Abs(n))
+		}
+	}
+	return ExactIntegerWithValue(acc), nil
+}
 
-	val := IntegerValue(dividend) % IntegerValue(divisor)
-	return IntegerWithValue(val), nil
+// (lcm n...) is the least common multiple of the integers, 1 when there are
+// none and 0 when any of them is 0.
+func LcmImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ns, err := integerArgs("lcm", args, env)
+	if err != nil {
+		return
+	}
+	acc := big.NewInt(1)
+	for _, n := range ns {
+		if n.Sign() == 0 {
+			return IntegerWithValue(0), nil
+		}
+		abs := new(big.Int).Abs(n)
+		gcd := new(big.Int).GCD(nil, nil, acc, abs)
+		acc.Mul(acc, abs.Quo(abs, gcd))
+	}
+	return ExactIntegerWithValue(acc), nil
 }
 
 // Not tested since it just wraps rand.Int()
@@ -377,240 +703,371 @@ func ToFloatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return FloatWithValue(FloatValue(n)), nil
 }
 
+// radixArg is the optional radix in the second of args, which defaults to 10.
+func radixArg(name string, args *Data, env *SymbolTableFrame) (base int, err error) {
+	if Length(args) < 2 {
+		return 10, nil
+	}
+	r := Second(args)
+	if IntegerP(r) {
+		switch IntegerValue(r) {
+		case 2, 8, 10, 16:
+			return int(IntegerValue(r)), nil
+		}
+	}
+	err = ProcessError(fmt.Sprintf("%s expects a radix of 2, 8, 10 or 16 but received %s.", name, String(r)), env)
+	return
+}
+
+// (number->string n [radix]) writes n in radix. Only exact numbers can be
+// written in a radix other than 10.
 func NumberToStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	valObj := First(args)
-	val := IntegerValue(valObj)
-	var base int64
-	if Length(args) == 2 {
-		baseObj := Second(args)
-		base = IntegerValue(baseObj)
-	} else {
-		base = 10
+	if !NumberP(valObj) && !ComplexP(valObj) {
+		err = ProcessError(fmt.Sprintf("number->string expects a number but received %s.", String(valObj)), env)
+		return
 	}
-
-	var format string
-	switch base {
-	case 2:
-		format = "%b"
-	case 8:
-		format = "%o"
-	case 10:
-		format = "%d"
-	case 16:
-		format = "%x"
-	default:
-		format = "Unsupported base: %d"
-		val = base
+	base, err := radixArg("number->string", args, env)
+	if err != nil {
+		return
 	}
-	return StringWithValue(fmt.Sprintf(format, val)), nil
+
+	switch {
+	case IntegerP(valObj):
+		return StringWithValue(strconv.FormatInt(IntegerValue(valObj), base)), nil
+	case BignumP(valObj):
+		return StringWithValue(BignumValue(valObj).Text(base)), nil
+	case RationalP(valObj):
+		r := RationalValue(valObj)
+		return StringWithValue(fmt.Sprintf("%s/%s", r.Num().Text(base), r.Denom().Text(base))), nil
+	case base == 10:
+		return StringWithValue(String(valObj)), nil
+	}
+	err = ProcessError(fmt.Sprintf("number->string can only write %s in radix 10.", String(valObj)), env)
+	return
 }
 
+// (string->number str [radix]) reads the integer, bignum, rational, float or
+// complex number written in str, or is #f if str isn't a number. Floats and
+// complex numbers are only read in radix 10.
 func StringToNumberImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	strObj := First(args)
-	str := StringValue(strObj)
-	var base int64
-	if Length(args) == 2 {
-		baseObj := Second(args)
-		base = IntegerValue(baseObj)
-	} else {
-		base = 10
-	}
-
-	var format string
-	switch base {
-	case 2:
-		format = "%b"
-	case 8:
-		format = "%o"
-	case 10:
-		format = "%d"
-	case 16:
-		format = "%x"
-	default:
-		return IntegerWithValue(0), nil
-	}
-	var val int64
-	_, err = fmt.Sscanf(str, format, &val)
+	if !StringP(strObj) {
+		err = ProcessError(fmt.Sprintf("string->number expects a string but received %s.", String(strObj)), env)
+		return
+	}
+	base, err := radixArg("string->number", args, env)
 	if err != nil {
 		return
 	}
-	return IntegerWithValue(val), nil
+
+	if n := parseNumber(StringValue(strObj), base); n != nil {
+		return n, nil
+	}
+	return LispFalse, nil
 }
 
-func minInts(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	n := Car(args)
-	if !IntegerP(n) {
-		err = ProcessError(fmt.Sprintf("min requires numbers, received %s", String(n)), env)
-		return
+// parseNumber is the number str is written as in base, or nil.
+func parseNumber(str string, base int) *Data {
+	if base == 10 && strings.HasSuffix(str, "i") {
+		if !onlyRunesIn(str, "0123456789+-./eEi") {
+			return nil
+		}
+		n, err := makeComplex(str)
+		if err != nil {
+			return nil
+		}
+		return n
 	}
-	var acc int64 = IntegerValue(n)
 
-	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
-		n = Car(c)
-		if !IntegerP(n) {
-			err = ProcessError(fmt.Sprintf("min requires numbers, received %s", String(n)), env)
-			return
+	if slash := strings.Index(str, "/"); slash >= 0 {
+		num, numOk := new(big.Int).SetString(str[:slash], base)
+		denom, denomOk := new(big.Int).SetString(str[slash+1:], base)
+		if !numOk || !denomOk || strings.ContainsAny(str[slash+1:], "+-") || denom.Sign() == 0 {
+			return nil
 		}
-		if IntegerValue(n) < acc {
-			acc = IntegerValue(n)
+		return RationalWithValue(new(big.Rat).SetFrac(num, denom))
+	}
+
+	if i, ok := new(big.Int).SetString(str, base); ok {
+		return ExactIntegerWithValue(i)
+	}
+
+	if base == 10 && onlyRunesIn(str, "0123456789+-.eE") {
+		if f, err := strconv.ParseFloat(str, 32); err == nil {
+			return FloatWithValue(float32(f))
 		}
 	}
+	return nil
+}
 
-	return IntegerWithValue(acc), nil
+func onlyRunesIn(str string, allowed string) bool {
+	for _, r := range str {
+		if !strings.ContainsRune(allowed, r) {
+			return false
+		}
+	}
+	return true
 }
 
-func minFloats(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	n := Car(args)
-	if !NumberP(n) {
-		err = ProcessError(fmt.Sprintf("min requires numbers, received %s", String(n)), env)
-		return
+// extremeNumber is the smallest of numbers when smallest is true and the
+// largest otherwise. Numbers can be given as the arguments or, as they used
+// to have to be, as a single list. As in Scheme, when any of them is a float
+// the result is too.
+func extremeNumber(name string, numbers *Data, smallest bool, env *SymbolTableFrame) (result *Data, err error) {
+	if Length(numbers) == 1 && ListP(Car(numbers)) {
+		numbers = Car(numbers)
+		if Length(numbers) == 0 {
+			return IntegerWithValue(0), nil
+		}
 	}
-	var acc float32 = FloatValue(n)
 
-	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
-		n = Car(c)
+	inexact := false
+	for c := numbers; NotNilP(c); c = Cdr(c) {
+		n := Car(c)
 		if !NumberP(n) {
-			err = ProcessError(fmt.Sprintf("min requires numbers, received %s", String(n)), env)
+			err = ProcessError(fmt.Sprintf("%s requires numbers, received %s", name, String(n)), env)
 			return
 		}
-		if FloatValue(n) < acc {
-			acc = FloatValue(n)
+		inexact = inexact || FloatP(n)
+		if result == nil {
+			result = n
+			continue
+		}
+		cmp, ok := exactComparison(n, result)
+		if !ok {
+			switch {
+			case FloatValue(n) < FloatValue(result):
+				cmp = -1
+			case FloatValue(n) > FloatValue(result):
+				cmp = 1
+			}
+		}
+		if (smallest && cmp < 0) || (!smallest && cmp > 0) {
+			result = n
 		}
 	}
 
-	return FloatWithValue(acc), nil
+	if inexact && !FloatP(result) {
+		result = FloatWithValue(FloatValue(result))
+	}
+	return
 }
 
+// (min number...) is the smallest of the numbers.
 func MinImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	numbers := Car(args)
-	if !ListP(numbers) {
-		err = ProcessError(fmt.Sprintf("min requires a list of numbers, received %s", String(numbers)), env)
-		return
-	}
-	if Length(numbers) == 0 {
-		return IntegerWithValue(0), nil
-	}
+	return extremeNumber("min", args, true, env)
+}
 
-	areFloats, err := anyFloats(numbers, env)
-	if err != nil {
-		return
-	}
-	if areFloats {
-		return minFloats(numbers, env)
-	} else {
-		return minInts(numbers, env)
-	}
+// (max number...) is the largest of the numbers.
+func MaxImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return extremeNumber("max", args, false, env)
 }
 
-func maxInts(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	n := Car(args)
-	if !IntegerP(n) {
-		err = ProcessError(fmt.Sprintf("max requires numbers, received %s", String(n)), env)
-		return
+// roundNumber rounds the first of args to an integer: with intRound if it
+// is a rational, which is given its numerator and denominator, and with
+// floatRound if it is a float. Integers are already rounded, and only
+// floats give floats back.
+func roundNumber(name string, args *Data, intRound func(num, den *big.Int) *big.Int, floatRound func(float64) float64, env *SymbolTableFrame) (result *Data, err error) {
+	val := Car(args)
+
+	switch {
+	case IntegerP(val) || BignumP(val):
+		return val, nil
+	case RationalP(val):
+		r := RationalValue(val)
+		return ExactIntegerWithValue(intRound(r.Num(), r.Denom())), nil
+	case FloatP(val):
+		return FloatWithValue(float32(floatRound(float64(FloatValue(val))))), nil
 	}
-	var acc int64 = IntegerValue(n)
 
-	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
-		n = Car(c)
-		if !IntegerP(n) {
-			err = ProcessError(fmt.Sprintf("max requires numbers, received %s", String(n)), env)
-			return
-		}
-		if IntegerValue(n) > acc {
-			acc = IntegerValue(n)
+	err = ProcessError(fmt.Sprintf("%s expected a real number, received %s", name, String(val)), env)
+	return
+}
+
+// floorQuotient is num/den rounded down. den is positive, as a big.Rat's
+// denominator always is, so Euclidean division rounds down.
+func floorQuotient(num, den *big.Int) *big.Int {
+	return new(big.Int).Div(num, den)
+}
+
+// ceilingQuotient is num/den rounded up. A rational is never an integer, so
+// that is one more than rounding it down.
+func ceilingQuotient(num, den *big.Int) *big.Int {
+	q := floorQuotient(num, den)
+	return q.Add(q, big.NewInt(1))
+}
+
+func truncateQuotient(num, den *big.Int) *big.Int {
+	return new(big.Int).Quo(num, den)
+}
+
+// roundQuotient is num/den rounded to the nearest integer, and to the even
+// one when it is halfway between two.
+func roundQuotient(num, den *big.Int) *big.Int {
+	q, m := new(big.Int).DivMod(num, den, new(big.Int))
+	switch m.Lsh(m, 1).Cmp(den) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
 		}
 	}
+	return q
+}
 
-	return IntegerWithValue(acc), nil
+func FloorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return roundNumber("floor", args, floorQuotient, math.Floor, env)
 }
 
-func maxFloats(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	n := Car(args)
-	if !NumberP(n) {
-		err = ProcessError(fmt.Sprintf("max requires numbers, received %s", String(n)), env)
+func CeilingImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return roundNumber("ceiling", args, ceilingQuotient, math.Ceil, env)
+}
+
+func RoundImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return roundNumber("round", args, roundQuotient, math.RoundToEven, env)
+}
+
+func TruncateImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return roundNumber("truncate", args, truncateQuotient, math.Trunc, env)
+}
+
+// exactSqrt is the exact square root of n, if n is a perfect square.
+func exactSqrt(n *big.Int) (root *big.Int, ok bool) {
+	root = new(big.Int).Sqrt(n)
+	return root, new(big.Int).Mul(root, root).Cmp(n) == 0
+}
+
+// sqrt is exact for exact squares, such as 4 and 9/16, and a float
+// otherwise. The square roots of negative and complex numbers are complex.
+func SqrtImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	val := Car(args)
+
+	if ComplexP(val) || (NumberP(val) && FloatValue(val) < 0) {
+		return ComplexWithValue(cmplx.Sqrt(ComplexValue(val))), nil
+	}
+	if !NumberP(val) {
+		err = ProcessError(fmt.Sprintf("sqrt expects a number as a parameter, got %s", String(val)), env)
 		return
 	}
-	var acc float32 = FloatValue(n)
 
-	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
-		n = Car(c)
-		if !NumberP(n) {
-			err = ProcessError(fmt.Sprintf("max requires numbers, received %s", String(n)), env)
-			return
+	if IntegerP(val) || BignumP(val) {
+		if root, ok := exactSqrt(BignumValue(val)); ok {
+			return ExactIntegerWithValue(root), nil
 		}
-		if FloatValue(n) > acc {
-			acc = FloatValue(n)
+	} else if RationalP(val) {
+		num, numOk := exactSqrt(RationalValue(val).Num())
+		den, denOk := exactSqrt(RationalValue(val).Denom())
+		if numOk && denOk {
+			return RationalWithValue(new(big.Rat).SetFrac(num, den)), nil
 		}
 	}
 
-	return FloatWithValue(acc), nil
+	return FloatWithValue(float32(math.Sqrt(float64(FloatValue(val))))), nil
 }
 
-func MaxImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	numbers := Car(args)
-	if !ListP(numbers) {
-		err = ProcessError(fmt.Sprintf("max requires a list of numbers, received %s", String(numbers)), env)
-		return
+// (atan y [x]) is the arc tangent of y, or with x, of y/x using the signs
+// of both to find the quadrant, like atan2.
+func AtanImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if Length(args) == 2 {
+		return atan2("atan", args, env)
 	}
 
-	if Length(numbers) == 0 {
-		return IntegerWithValue(0), nil
+	val := Car(args)
+	if ComplexP(val) {
+		return ComplexWithValue(cmplx.Atan(ComplexValue(val))), nil
 	}
-
-	areFloats, err := anyFloats(numbers, env)
-	if err != nil {
+	if !NumberP(val) {
+		err = ProcessError(fmt.Sprintf("atan expects a number as a parameter, got %s", String(val)), env)
 		return
 	}
-	if areFloats {
-		return maxFloats(numbers, env)
-	} else {
-		return maxInts(numbers, env)
-	}
+	return FloatWithValue(float32(math.Atan(float64(FloatValue(val))))), nil
 }
 
-func FloorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	val := Car(args)
+func Atan2Impl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return atan2("atan2", args, env)
+}
 
-	if !NumberP(val) {
-		err = ProcessError(fmt.Sprintf("floor expected an number, received %s", String(Car(args))), env)
+func atan2(name string, args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	y := Car(args)
+	x := Cadr(args)
+	if !NumberP(y) || !NumberP(x) {
+		err = ProcessError(fmt.Sprintf("%s expects real numbers as parameters, got %s", name, String(args)), env)
 		return
 	}
-
-	return FloatWithValue(float32(math.Floor(float64(FloatValue(val))))), nil
+	return FloatWithValue(float32(math.Atan2(float64(FloatValue(y)), float64(FloatValue(x))))), nil
 }
 
-func CeilingImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+// (log x [base]) is the natural logarithm of x, or its logarithm to base.
+// The logarithms of negative and complex numbers are complex.
+func LogImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	val := Car(args)
-
-	if !NumberP(val) {
-		err = ProcessError(fmt.Sprintf("ceiling expected a number, received %s", String(Car(args))), env)
+	if !NumberP(val) && !ComplexP(val) {
+		err = ProcessError(fmt.Sprintf("log expects a number as a parameter, got %s", String(val)), env)
 		return
 	}
+	if NumberP(val) && FloatValue(val) == 0 {
+		err = ProcessError(fmt.Sprintf("log is undefined for %s.", String(val)), env)
+		return
+	}
+	ret := cmplx.Log(ComplexValue(val))
+
+	if Length(args) == 2 {
+		base := Cadr(args)
+		if !NumberP(base) && !ComplexP(base) {
+			err = ProcessError(fmt.Sprintf("log expects a number as its base, got %s", String(base)), env)
+			return
+		}
+		if ComplexValue(base) == 0 || ComplexValue(base) == 1 {
+			err = ProcessError(fmt.Sprintf("log is undefined for base %s.", String(base)), env)
+			return
+		}
+		ret /= cmplx.Log(ComplexValue(base))
+	}
 
-	return FloatWithValue(float32(math.Ceil(float64(FloatValue(val))))), nil
+	return ComplexWithValue(ret), nil
 }
 
+// abs keeps the kind of real number it is given. The one fixnum whose
+// absolute value isn't a fixnum gives a bignum, and the absolute value of a
+// complex number is its magnitude.
 func AbsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	val := Car(args)
+	if ComplexP(val) {
+		return FloatWithValue(float32(cmplx.Abs(ComplexValue(val)))), nil
+	}
 	if !NumberP(val) {
 		err = ProcessError(fmt.Sprintf("abs expected a number, received %s", String(Car(args))), env)
 		return
 	}
-	absval := math.Abs(float64(FloatValue(val)))
+	if BignumP(val) || (IntegerP(val) && IntegerValue(val) == math.MinInt64) {
+		return ExactIntegerWithValue(new(big.Int).Abs(BignumValue(val))), nil
+	}
+	if RationalP(val) {
+		return RationalWithValue(new(big.Rat).Abs(RationalValue(val))), nil
+	}
 	if IntegerP(val) {
-		result = IntegerWithValue(int64(absval))
-	} else {
-		result = FloatWithValue(float32(absval))
+		if IntegerValue(val) < 0 {
+			return IntegerWithValue(-IntegerValue(val)), nil
+		}
+		return val, nil
 	}
-	return
+	return FloatWithValue(float32(math.Abs(float64(FloatValue(val))))), nil
 }
 
 func ZeroImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	val := Car(args)
+	if ComplexP(val) {
+		return LispFalse, nil
+	}
 	if !NumberP(val) {
 		err = ProcessError(fmt.Sprintf("zero? expected a number, received %s", String(Car(args))), env)
 		return
 	}
+	if BignumP(val) {
+		return BooleanWithValue(BignumValue(val).Sign() == 0), nil
+	}
 	return BooleanWithValue(FloatValue(val) == 0.0), nil
 }
 
@@ -620,6 +1077,9 @@ func PositiveImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		err = ProcessError(fmt.Sprintf("positive? expected a number, received %s", String(Car(args))), env)
 		return
 	}
+	if BignumP(val) || RationalP(val) {
+		return BooleanWithValue(RationalValue(val).Sign() > 0), nil
+	}
 	return BooleanWithValue(FloatValue(val) > 0.0), nil
 }
 
@@ -629,25 +1089,28 @@ func NegativeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		err = ProcessError(fmt.Sprintf("negative expected a number, received %s", String(Car(args))), env)
 		return
 	}
+	if BignumP(val) || RationalP(val) {
+		return BooleanWithValue(RationalValue(val).Sign() < 0), nil
+	}
 	return BooleanWithValue(FloatValue(val) < 0.0), nil
 }
 
 func EvenImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	val := Car(args)
-	if !IntegerP(val) {
+	if !ExactIntegerP(val) {
 		err = ProcessError(fmt.Sprintf("even? expected an integer, received %s", String(Car(args))), env)
 		return
 	}
-	return BooleanWithValue(IntegerValue(val)%2 == 0), nil
+	return BooleanWithValue(BignumValue(val).Bit(0) == 0), nil
 }
 
 func OddImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	val := Car(args)
-	if !IntegerP(val) {
+	if !ExactIntegerP(val) {
 		err = ProcessError(fmt.Sprintf("odd? expected an integer, received %s", String(Car(args))), env)
 		return
 	}
-	return BooleanWithValue(IntegerValue(val)%2 != 0), nil
+	return BooleanWithValue(BignumValue(val).Bit(0) != 0), nil
 }
 
 func SignImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -659,13 +1122,19 @@ func SignImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 
 	if FloatP(val) {
 		return IntegerWithValue(sgn(float32(FloatValue(val)))), nil
+	} else if BignumP(val) || RationalP(val) {
+		return IntegerWithValue(int64(RationalValue(val).Sign())), nil
 	} else {
 		return IntegerWithValue(intSgn(IntegerValue(val))), nil
 	}
 }
 
+// pow and expt are exact when the base is exact and the exponent is an
+// integer, growing into a bignum or producing a rational as needed; otherwise
+// they use floats. They are complex when either argument is, or when a
+// negative base is raised to a fractional power.
 func PowImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	areFloats, err := anyFloats(args, env)
+	areComplexes, err := anyComplexes(args, env)
 	if err != nil {
 		return
 	}
@@ -673,21 +1142,196 @@ func PowImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	base := Car(args)
 	exponent := Cadr(args)
 
-	if areFloats {
-		return FloatWithValue(float32(math.Pow(float64(FloatValue(base)), float64(FloatValue(exponent))))), nil
-	} else {
-		ret := int64(1)
-		b := IntegerValue(base)
-		e := IntegerValue(exponent)
-		for e > 0 {
-			if e&1 != 0 {
-				ret *= b
-			}
-			b *= b
-			e >>= 1
+	if areComplexes {
+		return powComplexes(base, exponent, env)
+	}
+
+	areFloats, err := anyFloats(args, env)
+	if err != nil {
+		return
+	}
+
+	if areFloats || !ExactIntegerP(exponent) {
+		ret := math.Pow(float64(FloatValue(base)), float64(FloatValue(exponent)))
+		if math.IsNaN(ret) && FloatValue(base) < 0 {
+			return powComplexes(base, exponent, env)
 		}
-		return IntegerWithValue(ret), nil
+		return FloatWithValue(float32(ret)), nil
+	}
+
+	e := new(big.Int).Abs(BignumValue(exponent))
+	b := RationalValue(base)
+	if BignumValue(exponent).Sign() < 0 && b.Sign() == 0 {
+		err = ProcessError("expt: zero can't be raised to a negative power.", env)
+		return
+	}
+
+	r := new(big.Rat).SetFrac(new(big.Int).Exp(b.Num(), e, nil), new(big.Int).Exp(b.Denom(), e, nil))
+	if BignumValue(exponent).Sign() < 0 {
+		r.Inv(r)
+	}
+	return RationalWithValue(r), nil
+}
+
+func BignumPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(BignumP(Car(args))), nil
+}
+
+// ->bignum makes a bignum out of any integer, even one that would fit in a
+// fixnum.
+func ToBignumImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if !ExactIntegerP(n) {
+		err = ProcessError(fmt.Sprintf("->bignum expected an integer, received %s", String(n)), env)
+		return
+	}
+	return BignumWithValue(new(big.Int).Set(BignumValue(n))), nil
+}
+
+func ToFixnumImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if !ExactIntegerP(n) {
+		err = ProcessError(fmt.Sprintf("->fixnum expected an integer, received %s", String(n)), env)
+		return
+	}
+	if !BignumValue(n).IsInt64() {
+		err = ProcessError(fmt.Sprintf("->fixnum: %s doesn't fit in a fixnum", String(n)), env)
+		return
+	}
+	return IntegerWithValue(BignumValue(n).Int64()), nil
+}
+
+// rational? is true for any exact number, since integers are rationals too.
+func RationalPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(ExactP(Car(args))), nil
+}
+
+// Integers, bignums and rationals are exact. Floats, and complex numbers,
+// whose parts are floats, are inexact.
+
+func ExactPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if !NumberP(n) && !ComplexP(n) {
+		err = ProcessError(fmt.Sprintf("exact? expected a number, received %s", String(n)), env)
+		return
+	}
+	return BooleanWithValue(ExactP(n)), nil
+}
+
+func InexactPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if !NumberP(n) && !ComplexP(n) {
+		err = ProcessError(fmt.Sprintf("inexact? expected a number, received %s", String(n)), env)
+		return
+	}
+	return BooleanWithValue(!ExactP(n)), nil
+}
+
+// (exact->inexact n) is the float nearest n.
+func ExactToInexactImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if ComplexP(n) || FloatP(n) {
+		return n, nil
+	}
+	if !NumberP(n) {
+		err = ProcessError(fmt.Sprintf("exact->inexact expected a number, received %s", String(n)), env)
+		return
+	}
+	return FloatWithValue(FloatValue(n)), nil
+}
+
+// (inexact->exact n) is the exact number equal to the float n, an integer
+// when n is whole. That is exactly the value the float holds, which for a
+// float written in decimal often isn't the decimal: 0.5 gives 1/2, but 0.1
+// gives 13421773/134217728, the binary fraction nearest 0.1. rationalize
+// finds simpler rationals that are close.
+func InexactToExactImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if ExactP(n) {
+		return n, nil
+	}
+	if !FloatP(n) {
+		err = ProcessError(fmt.Sprintf("inexact->exact expected a real number, received %s", String(n)), env)
+		return
+	}
+	f := float64(FloatValue(n))
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		err = ProcessError(fmt.Sprintf("inexact->exact can't make %s exact", String(n)), env)
+		return
+	}
+	return RationalWithValue(new(big.Rat).SetFloat64(f)), nil
+}
+
+func NumeratorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if !ExactP(n) {
+		err = ProcessError(fmt.Sprintf("numerator expected an exact number, received %s", String(n)), env)
+		return
+	}
+	return ExactIntegerWithValue(new(big.Int).Set(RationalValue(n).Num())), nil
+}
+
+func DenominatorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n := Car(args)
+	if !ExactP(n) {
+		err = ProcessError(fmt.Sprintf("denominator expected an exact number, received %s", String(n)), env)
+		return
+	}
+	return ExactIntegerWithValue(new(big.Int).Set(RationalValue(n).Denom())), nil
+}
+
+func ratFloor(r *big.Rat) *big.Rat {
+	n := new(big.Int)
+	m := new(big.Int)
+	n.DivMod(r.Num(), r.Denom(), m)
+	return new(big.Rat).SetInt(n)
+}
+
+// simplestRational finds the rational with the smallest denominator in
+// [lo, hi] by walking down the continued fraction of the bounds.
+func simplestRational(lo *big.Rat, hi *big.Rat) *big.Rat {
+	if hi.Sign() < 0 {
+		r := simplestRational(new(big.Rat).Neg(hi), new(big.Rat).Neg(lo))
+		return r.Neg(r)
+	}
+	if lo.Sign() <= 0 {
+		return new(big.Rat)
+	}
+
+	fl := ratFloor(lo)
+	if fl.Cmp(lo) == 0 {
+		return fl
+	}
+	if fl.Cmp(ratFloor(hi)) < 0 {
+		return fl.Add(fl, big.NewRat(1, 1))
+	}
+
+	loFrac := new(big.Rat).Sub(lo, fl)
+	hiFrac := new(big.Rat).Sub(hi, fl)
+	rest := simplestRational(new(big.Rat).Inv(hiFrac), new(big.Rat).Inv(loFrac))
+	return fl.Add(fl, rest.Inv(rest))
+}
+
+// (rationalize x y) returns the simplest rational within y of x. It is exact
+// only when both arguments are.
+func RationalizeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	x := Car(args)
+	y := Cadr(args)
+	if !NumberP(x) || !NumberP(y) {
+		err = ProcessError(fmt.Sprintf("rationalize expected numbers, received %s", String(args)), env)
+		return
+	}
+
+	tolerance := new(big.Rat).Abs(RationalValue(y))
+	lo := new(big.Rat).Sub(RationalValue(x), tolerance)
+	hi := new(big.Rat).Add(RationalValue(x), tolerance)
+	r := simplestRational(lo, hi)
+
+	if FloatP(x) || FloatP(y) {
+		f, _ := r.Float32()
+		return FloatWithValue(f), nil
 	}
+	return RationalWithValue(r), nil
 }
 
 func IsInfImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
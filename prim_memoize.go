@@ -0,0 +1,130 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the memoization primitive functions.
+
+package golisp
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// memoCache holds the results of a memoized function, keyed by an encoding
+// of the arguments. When MaxSize is positive the least recently used entry
+// is dropped to make room for a new one.
+type memoCache struct {
+	Function *Data
+	MaxSize  int
+	Entries  map[string]*list.Element
+	Order    *list.List
+	Mutex    sync.Mutex
+}
+
+type memoEntry struct {
+	Key    string
+	Result *Data
+}
+
+func RegisterMemoizePrimitives() {
+	MakePrimitiveFunction("memoize", "1|2", MemoizeImpl)
+	MakePrimitiveFunction("memoize-clear!", "1", MemoizeClearImpl)
+}
+
+func (self *memoCache) lookup(key string) (result *Data, found bool) {
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	element, found := self.Entries[key]
+	if !found {
+		return
+	}
+	self.Order.MoveToFront(element)
+	return element.Value.(*memoEntry).Result, true
+}
+
+func (self *memoCache) store(key string, result *Data) {
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	if element, found := self.Entries[key]; found {
+		element.Value.(*memoEntry).Result = result
+		self.Order.MoveToFront(element)
+		return
+	}
+	self.Entries[key] = self.Order.PushFront(&memoEntry{Key: key, Result: result})
+	if self.MaxSize > 0 && self.Order.Len() > self.MaxSize {
+		oldest := self.Order.Back()
+		self.Order.Remove(oldest)
+		delete(self.Entries, oldest.Value.(*memoEntry).Key)
+	}
+}
+
+func (self *memoCache) clear() {
+	self.Mutex.Lock()
+	self.Entries = make(map[string]*list.Element)
+	self.Order.Init()
+	self.Mutex.Unlock()
+}
+
+// call answers from the cache or applies the function to args, which have
+// already been evaluated. Failed calls aren't cached. The mutex isn't held
+// while the function runs, so a memoized function can call itself; two
+// processes missing on the same arguments both compute the result.
+func (self *memoCache) call(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	key := hashKey(args)
+	if result, found := self.lookup(key); found {
+		return result, nil
+	}
+	result, err = ApplyWithoutEval(self.Function, args, env)
+	if err != nil {
+		return
+	}
+	self.store(key, result)
+	return
+}
+
+// (memoize f [max-size]) returns a function that calls f only for
+// arguments it hasn't seen, answering repeated calls from a cache. With a
+// max-size the cache keeps only that many of the most recently used
+// results.
+func MemoizeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	f := Car(args)
+	if !FunctionP(f) && !(PrimitiveP(f) && !PrimitiveValue(f).Special) {
+		err = ProcessError(fmt.Sprintf("memoize expects a function but received %s.", String(f)), env)
+		return
+	}
+
+	maxSize := 0
+	if Length(args) == 2 {
+		size := Cadr(args)
+		if !IntegerP(size) || IntegerValue(size) < 1 {
+			err = ProcessError(fmt.Sprintf("memoize expects a positive integer max size but received %s.", String(size)), env)
+			return
+		}
+		maxSize = int(IntegerValue(size))
+	}
+
+	var name string
+	if FunctionP(f) {
+		name = fmt.Sprintf("memoized %s", FunctionValue(f).Name)
+	} else {
+		name = fmt.Sprintf("memoized %s", PrimitiveValue(f).Name)
+	}
+	cache := &memoCache{Function: f, MaxSize: maxSize, Entries: make(map[string]*list.Element), Order: list.New()}
+	memoized := &PrimitiveFunction{Name: name, Body: cache.call, memo: cache}
+	memoized.parseNumArgs("*")
+	return PrimitiveWithNameAndFunc(name, memoized), nil
+}
+
+// (memoize-clear! f) empties the cache of a function made by memoize.
+func MemoizeClearImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	f := Car(args)
+	if !PrimitiveP(f) || PrimitiveValue(f).memo == nil {
+		err = ProcessError(fmt.Sprintf("memoize-clear! expects a memoized function but received %s.", String(f)), env)
+		return
+	}
+	PrimitiveValue(f).memo.clear()
+	return f, nil
+}
@@ -3,7 +3,8 @@
 // license that can be found in the LICENSE file.
 
 // This package implements a basic LISP interpretor for embedding in a go program for scripting.
-// This file contains the mutator primitive functions.
+// This file contains the mutator primitive functions. The pair mutators
+// change the list in place, so every other reference to it sees the change.
 
 package golisp
 
@@ -25,7 +26,7 @@ func SetVarImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	if err != nil {
 		return
 	}
-	return env.SetTo(symbol, value)
+	return env.SetTo(symbol, SingleValue(value))
 }
 
 func SetCarImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
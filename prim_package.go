@@ -0,0 +1,239 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the package system.
+//
+// A package is a top-level environment of its own, so definitions made in
+// it don't collide with those of other packages:
+//
+//   (define-package math (export square) (import util))
+//   (in-package math)
+//   (define (square x) (* x x))
+//   (in-package)
+//   (math:square 3)
+//
+// Top-level forms are evaluated in the current package, set by in-package.
+// Loading a file or evaluating a string puts back the package that was
+// current before, so in-package only lasts to the end of the file. A name
+// not bound in a package is looked for among the names its imports export,
+// then globally. A name of the form package:name refers to a name the
+// package exports.
+
+package golisp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Package struct {
+	Name    string
+	Env     *SymbolTableFrame
+	Exports map[*Data]bool
+	Imports []*Package
+	Mutex   sync.RWMutex
+}
+
+var packages = struct {
+	Packages map[string]*Package
+	Current  *Package
+	Mutex    sync.RWMutex
+}{Packages: make(map[string]*Package)}
+
+func RegisterPackagePrimitives() {
+	MakeSpecialForm("define-package", ">=1", DefinePackageImpl)
+	MakeSpecialForm("in-package", "0|1", InPackageImpl)
+	MakePrimitiveFunction("current-package", "0", CurrentPackageImpl)
+	MakePrimitiveFunction("package-exports", "1", PackageExportsImpl)
+}
+
+func findPackage(name string) *Package {
+	packages.Mutex.RLock()
+	defer packages.Mutex.RUnlock()
+	return packages.Packages[name]
+}
+
+func currentPackage() *Package {
+	packages.Mutex.RLock()
+	defer packages.Mutex.RUnlock()
+	return packages.Current
+}
+
+func setCurrentPackage(p *Package) {
+	packages.Mutex.Lock()
+	packages.Current = p
+	packages.Mutex.Unlock()
+}
+
+// packageEnvironment is where a top-level form read for env is evaluated:
+// the current package, if there is one and env is the global environment.
+func packageEnvironment(env *SymbolTableFrame) *SymbolTableFrame {
+	if p := currentPackage(); p != nil && env == Global {
+		return p.Env
+	}
+	return env
+}
+
+// exportedBinding is the binding of symbol in the package, if it exports
+// symbol.
+func (self *Package) exportedBinding(symbol *Data) (binding *Binding, found bool) {
+	self.Mutex.RLock()
+	exported := self.Exports[symbol]
+	self.Mutex.RUnlock()
+	if !exported {
+		return nil, false
+	}
+	return self.Env.bindingOf(symbol)
+}
+
+// importedBinding is the binding of symbol exported by one of the
+// package's imports.
+func (self *Package) importedBinding(symbol *Data) (binding *Binding, found bool) {
+	self.Mutex.RLock()
+	imports := self.Imports
+	self.Mutex.RUnlock()
+	for _, p := range imports {
+		if binding, found = p.exportedBinding(symbol); found {
+			return
+		}
+	}
+	return nil, false
+}
+
+// qualifiedBinding is the binding a package:name symbol refers to.
+func qualifiedBinding(symbol *Data) (binding *Binding, found bool) {
+	name := StringValue(symbol)
+	colon := strings.IndexRune(name, ':')
+	if colon < 1 || colon == len(name)-1 {
+		return nil, false
+	}
+	p := findPackage(name[:colon])
+	if p == nil {
+		return nil, false
+	}
+	return p.exportedBinding(Intern(name[colon+1:]))
+}
+
+func packageNames(primitive string, clause *Data, env *SymbolTableFrame) (names []*Data, err error) {
+	for c := Cdr(clause); NotNilP(c); c = Cdr(c) {
+		if !SymbolP(Car(c)) {
+			err = ProcessError(fmt.Sprintf("%s expects names in its %s clause but received %s.", primitive, String(Car(clause)), String(Car(c))), env)
+			return
+		}
+		names = append(names, Car(c))
+	}
+	return
+}
+
+// (define-package name (export symbol...) (import package...)) makes a
+// package, or redefines the exports and imports of an existing one. The
+// imported packages have to be defined already.
+func DefinePackageImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	name := Car(args)
+	if !SymbolP(name) {
+		err = ProcessError(fmt.Sprintf("define-package expects a package name but received %s.", String(name)), env)
+		return
+	}
+
+	exports := make(map[*Data]bool)
+	var imports []*Package
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		clause := Car(c)
+		var names []*Data
+		switch {
+		case PairP(clause) && SymbolP(Car(clause)) && StringValue(Car(clause)) == "export":
+			names, err = packageNames("define-package", clause, env)
+			for _, sym := range names {
+				exports[sym] = true
+			}
+		case PairP(clause) && SymbolP(Car(clause)) && StringValue(Car(clause)) == "import":
+			names, err = packageNames("define-package", clause, env)
+			for _, sym := range names {
+				imported := findPackage(StringValue(sym))
+				if imported == nil {
+					err = ProcessError(fmt.Sprintf("define-package: there is no package %s to import.", StringValue(sym)), env)
+					return
+				}
+				imports = append(imports, imported)
+			}
+		default:
+			err = ProcessError(fmt.Sprintf("define-package expects export and import clauses but received %s.", String(clause)), env)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	packages.Mutex.Lock()
+	p := packages.Packages[StringValue(name)]
+	if p == nil {
+		p = &Package{Name: StringValue(name)}
+		p.Env = NewSymbolTableFrameBelow(Global, fmt.Sprintf("package %s", p.Name))
+		p.Env.Package = p
+		packages.Packages[p.Name] = p
+	}
+	packages.Mutex.Unlock()
+
+	p.Mutex.Lock()
+	p.Exports = exports
+	p.Imports = imports
+	p.Mutex.Unlock()
+	return name, nil
+}
+
+// (in-package name) makes top-level forms be evaluated in the package
+// called name; (in-package) goes back to the global environment.
+func InPackageImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if Length(args) == 0 {
+		setCurrentPackage(nil)
+		return
+	}
+	name := Car(args)
+	if !SymbolP(name) {
+		err = ProcessError(fmt.Sprintf("in-package expects a package name but received %s.", String(name)), env)
+		return
+	}
+	p := findPackage(StringValue(name))
+	if p == nil {
+		err = ProcessError(fmt.Sprintf("in-package: there is no package %s.", StringValue(name)), env)
+		return
+	}
+	setCurrentPackage(p)
+	return name, nil
+}
+
+func CurrentPackageImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if p := currentPackage(); p != nil {
+		result = Intern(p.Name)
+	}
+	return
+}
+
+func PackageExportsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	name := Car(args)
+	if !SymbolP(name) {
+		err = ProcessError(fmt.Sprintf("package-exports expects a package name but received %s.", String(name)), env)
+		return
+	}
+	p := findPackage(StringValue(name))
+	if p == nil {
+		err = ProcessError(fmt.Sprintf("package-exports: there is no package %s.", StringValue(name)), env)
+		return
+	}
+	p.Mutex.RLock()
+	names := make([]string, 0, len(p.Exports))
+	for sym := range p.Exports {
+		names = append(names, StringValue(sym))
+	}
+	p.Mutex.RUnlock()
+	sort.Strings(names)
+	symbols := make([]*Data, len(names))
+	for i, n := range names {
+		symbols[i] = Intern(n)
+	}
+	return ArrayToList(symbols), nil
+}
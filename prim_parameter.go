@@ -0,0 +1,152 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the dynamic parameter primitive functions.
+//
+// A parameter is a function of no arguments that returns its current value.
+// parameterize gives parameters new values for the dynamic extent of its
+// body: anything the body calls sees them, and the old values are back once
+// the body returns, raises an error or panics. The bindings in effect are
+// kept in each environment frame, which gets them from the frame it was
+// made in or, for a function's frame, from its caller's. So parameterizing
+// in one process doesn't change what another sees, and a process forked in
+// the body, which runs in its caller's environment, starts out with the
+// bindings in effect when it was forked.
+
+package golisp
+
+import (
+	"fmt"
+)
+
+// Parameter holds a parameter's value outside of any parameterize, and the
+// converter applied to every value it is given.
+type Parameter struct {
+	Value     *Data
+	Converter *Data
+}
+
+// parameterization is the bindings made by one parameterize, and the
+// bindings in effect outside it.
+type parameterization struct {
+	Bindings map[*Parameter]*Data
+	Outer    *parameterization
+}
+
+func RegisterParameterPrimitives() {
+	MakePrimitiveFunction("make-parameter", "1|2", MakeParameterImpl)
+	MakePrimitiveFunction("parameter?", "1", ParameterPImpl)
+	MakeSpecialForm("parameterize", ">=1", ParameterizeImpl)
+}
+
+// parametersIn is the parameter bindings in effect in env.
+func parametersIn(env *SymbolTableFrame) *parameterization {
+	if env == nil {
+		return nil
+	}
+	return env.parameters
+}
+
+func ParameterP(d *Data) bool {
+	return PrimitiveP(d) && PrimitiveValue(d).parameter != nil
+}
+
+// current is the value of self in env.
+func (self *Parameter) current(env *SymbolTableFrame) *Data {
+	for b := parametersIn(env); b != nil; b = b.Outer {
+		if value, found := b.Bindings[self]; found {
+			return value
+		}
+	}
+	return self.Value
+}
+
+func (self *Parameter) convert(value *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if self.Converter == nil {
+		return value, nil
+	}
+	return ApplyWithoutEval(self.Converter, InternalMakeList(value), env)
+}
+
+// parameterPrimitive is the function called name that returns the value of p.
+func parameterPrimitive(name string, p *Parameter) *Data {
+	f := &PrimitiveFunction{Name: name, Body: func(args *Data, env *SymbolTableFrame) (*Data, error) {
+		return p.current(env), nil
+	}, parameter: p}
+	f.parseNumArgs("0")
+	return PrimitiveWithNameAndFunc(name, f)
+}
+
+// parameterizedFrame is a frame below env, called name, in which the
+// parameters in bound have the values they are mapped to.
+func parameterizedFrame(env *SymbolTableFrame, name string, bound map[*Parameter]*Data) *SymbolTableFrame {
+	localEnv := NewSymbolTableFrameBelow(env, name)
+	localEnv.Previous = env
+	localEnv.parameters = &parameterization{Bindings: bound, Outer: parametersIn(env)}
+	return localEnv
+}
+
+// (make-parameter value [converter]) makes a parameter whose value is value,
+// or what converter returns for it. Values given by parameterize are passed
+// through converter too.
+func MakeParameterImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	p := &Parameter{}
+	if Length(args) == 2 {
+		p.Converter = Cadr(args)
+		if !FunctionOrPrimitiveP(p.Converter) {
+			err = ProcessError(fmt.Sprintf("make-parameter expects a function as its converter but received %s.", String(p.Converter)), env)
+			return
+		}
+	}
+	p.Value, err = p.convert(Car(args), env)
+	if err != nil {
+		return
+	}
+	return parameterPrimitive("parameter", p), nil
+}
+
+func ParameterPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(ParameterP(Car(args))), nil
+}
+
+// (parameterize ((parameter value)...) body...) evaluates the body with
+// each parameter bound to its value. The parameters and values are all
+// evaluated before any of them is bound.
+func ParameterizeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	bindings := Car(args)
+	if !ListP(bindings) {
+		err = ProcessError(fmt.Sprintf("parameterize expects a list of bindings but received %s.", String(bindings)), env)
+		return
+	}
+
+	bound := make(map[*Parameter]*Data)
+	for c := bindings; NotNilP(c); c = Cdr(c) {
+		binding := Car(c)
+		if !ListP(binding) || Length(binding) != 2 {
+			err = ProcessError(fmt.Sprintf("parameterize expects (parameter value) bindings but received %s.", String(binding)), env)
+			return
+		}
+		var p, value *Data
+		p, err = Eval(Car(binding), env)
+		if err != nil {
+			return
+		}
+		if !ParameterP(p) {
+			err = ProcessError(fmt.Sprintf("parameterize expects a parameter but received %s.", String(p)), env)
+			return
+		}
+		value, err = Eval(Cadr(binding), env)
+		if err != nil {
+			return
+		}
+		parameter := PrimitiveValue(p).parameter
+		bound[parameter], err = parameter.convert(SingleValue(value), env)
+		if err != nil {
+			return
+		}
+	}
+
+	return evaluateBody(Cdr(args), parameterizedFrame(env, "parameterize", bound))
+}
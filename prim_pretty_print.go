@@ -0,0 +1,237 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the pretty printer.
+
+package golisp
+
+import (
+	"fmt"
+	"strings"
+)
+
+const DefaultPrettyPrintWidth = 80
+
+// prettyIndentation is how many arguments of each special form stay on the
+// line with the form's name. The rest of the form is indented as a body.
+var prettyIndentation = map[string]int{
+	"define":           1,
+	"defmacro":         1,
+	"define-macro":     1,
+	"define-condition": 2,
+	"lambda":           1,
+	"named-lambda":     1,
+	"let":              1,
+	"let*":             1,
+	"letrec":           1,
+	"let-values":       1,
+	"receive":          2,
+	"do":               2,
+	"when":             1,
+	"unless":           1,
+	"case":             1,
+	"with-mutex":       1,
+	"handler-case":     1,
+	"unwind-protect":   1,
+	"begin":            0,
+}
+
+type prettyPrinter struct {
+	Width   int
+	Align   bool
+	Printer *printer
+}
+
+func RegisterPrettyPrintPrimitives() {
+	Global.BindTo(Intern("*pretty-print-width*"), IntegerWithValue(DefaultPrettyPrintWidth))
+	Global.BindTo(Intern("*pretty-print-align*"), BooleanWithValue(true))
+
+	MakePrimitiveFunction("pretty-print", "1|2", PrettyPrintImpl)
+	MakePrimitiveFunction("pretty-print-to-string", "1", PrettyPrintToStringImpl)
+}
+
+// PrettyString formats d across lines no wider than width where it can.
+// With align set, the arguments of a call line up under the first one;
+// otherwise they are indented two spaces from the opening paren.
+// Circular data is written on one line, labelled as String does.
+func PrettyString(d *Data, width int, align bool) string {
+	return PrettyStringInEnv(d, width, align, nil)
+}
+
+// PrettyStringInEnv is PrettyString with the print limits in effect in env.
+func PrettyStringInEnv(d *Data, width int, align bool, env *SymbolTableFrame) string {
+	p := &prettyPrinter{Width: width, Align: align, Printer: newPrinter(d, false, env)}
+	if p.Printer.Labels != nil {
+		return p.Printer.write(d, 0)
+	}
+	return p.format(d, 0, 0)
+}
+
+func (self *prettyPrinter) format(d *Data, column int, level int) string {
+	flat := self.Printer.write(d, level)
+	if column+len(flat) <= self.Width {
+		return flat
+	}
+
+	if VectorP(d) {
+		return self.formatSequence("#(", self.limit(VectorValue(d).Elements), column, level)
+	}
+
+	if !PairP(d) || NilP(d) || !properListP(d) {
+		return flat
+	}
+
+	elements := self.limit(ToArray(d))
+	if !SymbolP(elements[0]) {
+		return self.formatSequence("(", elements, column, level)
+	}
+
+	name := StringValue(elements[0])
+	if name == "quote" && len(elements) == 2 {
+		return "'" + self.format(elements[1], column+1, level+1)
+	}
+	if distinguished, ok := prettyIndentation[name]; ok {
+		if name == "let" && len(elements) > 1 && SymbolP(elements[1]) {
+			distinguished++
+		}
+		return self.formatForm(elements, distinguished, column, level)
+	}
+	return self.formatCall(elements, column, level)
+}
+
+// limit cuts elements down to *print-length*, marking the cut with ...
+func (self *prettyPrinter) limit(elements []*Data) []*Data {
+	if self.Printer.Length < 0 || len(elements) <= self.Printer.Length {
+		return elements
+	}
+	return append(elements[:self.Printer.Length:self.Printer.Length], Intern("..."))
+}
+
+// formatSequence lays out data: atoms are packed onto lines as they fit and
+// nested structures go on their own lines, all aligned under the first
+// element.
+func (self *prettyPrinter) formatSequence(open string, elements []*Data, column int, level int) string {
+	start := column + len(open)
+	indent := "\n" + strings.Repeat(" ", start)
+
+	var buffer strings.Builder
+	buffer.WriteString(open)
+	end := start
+	for i, element := range elements {
+		atom := !PairP(element) && !VectorP(element)
+		text := self.format(element, start, level+1)
+		if i > 0 {
+			if atom && end+1+len(text) < self.Width {
+				buffer.WriteString(" ")
+				end++
+			} else {
+				buffer.WriteString(indent)
+				end = start
+			}
+		}
+		buffer.WriteString(text)
+		end = endColumn(end, text)
+		if !atom {
+			end = self.Width
+		}
+	}
+	buffer.WriteString(")")
+	return buffer.String()
+}
+
+// formatForm keeps the form name and its first distinguished arguments on
+// the opening line and indents the rest as a body.
+func (self *prettyPrinter) formatForm(elements []*Data, distinguished int, column int, level int) string {
+	var buffer strings.Builder
+	buffer.WriteString("(")
+	buffer.WriteString(String(elements[0]))
+	end := column + 1 + len(String(elements[0]))
+
+	i := 1
+	for ; i < len(elements) && i <= distinguished; i++ {
+		element := self.format(elements[i], end+1, level+1)
+		buffer.WriteString(" ")
+		buffer.WriteString(element)
+		end = endColumn(end+1, element)
+	}
+
+	indent := strings.Repeat(" ", column+2)
+	for ; i < len(elements); i++ {
+		buffer.WriteString("\n")
+		buffer.WriteString(indent)
+		buffer.WriteString(self.format(elements[i], column+2, level+1))
+	}
+	buffer.WriteString(")")
+	return buffer.String()
+}
+
+// formatCall lays out a function call, either aligning the arguments under
+// the first one or indenting them under the function name.
+func (self *prettyPrinter) formatCall(elements []*Data, column int, level int) string {
+	head := String(elements[0])
+	argColumn := column + len(head) + 2
+	if !self.Align || len(elements) == 1 || argColumn > self.Width/2 {
+		return self.formatForm(elements, 0, column, level)
+	}
+
+	lines := make([]string, 0, len(elements)-1)
+	for _, element := range elements[1:] {
+		lines = append(lines, self.format(element, argColumn, level+1))
+	}
+	return fmt.Sprintf("(%s %s)", head, strings.Join(lines, "\n"+strings.Repeat(" ", argColumn)))
+}
+
+// endColumn is the column just past s when s starts at column start. Lines
+// after the first already carry their full indentation.
+func endColumn(start int, s string) int {
+	if i := strings.LastIndex(s, "\n"); i != -1 {
+		return len(s) - i - 1
+	}
+	return start + len(s)
+}
+
+func properListP(d *Data) bool {
+	c := d
+	for ; PairP(c) && NotNilP(c); c = Cdr(c) {
+	}
+	return NilP(c)
+}
+
+// prettyPrinterSettings reads the width and alignment knobs visible from env.
+func prettyPrinterSettings(name string, env *SymbolTableFrame) (width int, align bool, err error) {
+	w := env.ValueOf(Intern("*pretty-print-width*"))
+	if !IntegerP(w) || IntegerValue(w) <= 0 {
+		err = ProcessError(fmt.Sprintf("%s expects *pretty-print-width* to be a positive integer but it is %s.", name, String(w)), env)
+		return
+	}
+	return int(IntegerValue(w)), BooleanValue(env.ValueOf(Intern("*pretty-print-align*"))), nil
+}
+
+func PrettyPrintImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	port := currentOutputPort(env)
+	if Length(args) == 2 {
+		p := Cadr(args)
+		if !OutputPortP(p) {
+			err = ProcessError(fmt.Sprintf("pretty-print expects its second argument be a port but received %s.", String(p)), env)
+			return
+		}
+		port = outputPortValue(p)
+	}
+
+	width, align, err := prettyPrinterSettings("pretty-print", env)
+	if err != nil {
+		return
+	}
+	_, err = port.WriteString(PrettyStringInEnv(Car(args), width, align, env) + "\n")
+	return
+}
+
+func PrettyPrintToStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	width, align, err := prettyPrinterSettings("pretty-print-to-string", env)
+	if err != nil {
+		return
+	}
+	return StringWithValue(PrettyStringInEnv(Car(args), width, align, env)), nil
+}
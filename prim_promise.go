@@ -0,0 +1,218 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the promise and stream primitive functions.
+//
+// A promise's expression isn't evaluated while its lock is held, so a
+// promise whose expression forces it again works as it does in Scheme
+// rather than deadlocking. The price is that two processes forcing a promise
+// at the same time can both evaluate it; the first to finish sets its value
+// and both get that one.
+//
+// A stream is a pair whose cdr is a promise of the rest of the stream, or
+// the empty list at its end.
+
+package golisp
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+type Promise struct {
+	Expr  *Data
+	Env   *SymbolTableFrame
+	Value *Data
+	Done  bool
+	Mutex sync.Mutex
+}
+
+func RegisterPromisePrimitives() {
+	MakeSpecialForm("delay", "1", DelayImpl)
+	MakePrimitiveFunction("make-promise", "1", MakePromiseImpl)
+	MakePrimitiveFunction("force", "1", ForceImpl)
+	MakePrimitiveFunction("promise?", "1", PromisePImpl)
+	MakePrimitiveFunction("promise-forced?", "1", PromiseForcedPImpl)
+
+	Global.BindToProtected(Intern("the-empty-stream"), EmptyCons())
+	MakeSpecialForm("stream-cons", "2", StreamConsImpl)
+	MakePrimitiveFunction("stream-pair?", "1", StreamPairPImpl)
+	MakePrimitiveFunction("stream-null?", "1", StreamNullPImpl)
+	MakePrimitiveFunction("stream-car", "1", StreamCarImpl)
+	MakePrimitiveFunction("stream-cdr", "1", StreamCdrImpl)
+	MakePrimitiveFunction("stream-take", "2", StreamTakeImpl)
+	MakePrimitiveFunction("stream-tail", "2", StreamTailImpl)
+}
+
+func PromiseP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "Promise"
+}
+
+func PromiseValue(d *Data) *Promise {
+	return (*Promise)(ObjectValue(d))
+}
+
+func PromiseWithExpr(expr *Data, env *SymbolTableFrame) *Data {
+	return ObjectWithTypeAndValue("Promise", unsafe.Pointer(&Promise{Expr: expr, Env: env}))
+}
+
+func (self *Promise) forced() (value *Data, done bool) {
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	return self.Value, self.Done
+}
+
+// Force is the value of self's expression, evaluating it the first time.
+func (self *Promise) Force() (result *Data, err error) {
+	if value, done := self.forced(); done {
+		return value, nil
+	}
+	value, err := Eval(self.Expr, self.Env)
+	if err != nil {
+		return
+	}
+	value = SingleValue(value)
+
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	if !self.Done {
+		self.Value, self.Done = value, true
+		self.Expr, self.Env = nil, nil
+	}
+	return self.Value, nil
+}
+
+// (delay expr) is a promise to evaluate expr when it is forced.
+func DelayImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return PromiseWithExpr(Car(args), env), nil
+}
+
+// (make-promise value) is a promise already forced to value, or value if it
+// is a promise.
+func MakePromiseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if PromiseP(Car(args)) {
+		return Car(args), nil
+	}
+	return ObjectWithTypeAndValue("Promise", unsafe.Pointer(&Promise{Value: Car(args), Done: true})), nil
+}
+
+// (force promise) is the value of promise. Anything that isn't a promise is
+// its own value.
+func ForceImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if !PromiseP(Car(args)) {
+		return Car(args), nil
+	}
+	return PromiseValue(Car(args)).Force()
+}
+
+func PromisePImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(PromiseP(Car(args))), nil
+}
+
+func PromiseForcedPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	p := Car(args)
+	if !PromiseP(p) {
+		err = ProcessError(fmt.Sprintf("promise-forced? expects a promise but received %s.", String(p)), env)
+		return
+	}
+	_, done := PromiseValue(p).forced()
+	return BooleanWithValue(done), nil
+}
+
+// (stream-cons a b) is a stream of a followed by the stream b evaluates to,
+// which isn't evaluated until it's needed.
+func StreamConsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	a, err := Eval(Car(args), env)
+	if err != nil {
+		return
+	}
+	return Cons(SingleValue(a), PromiseWithExpr(Cadr(args), env)), nil
+}
+
+func StreamPairP(d *Data) bool {
+	return PairP(d) && NotNilP(d) && PromiseP(Cdr(d))
+}
+
+func StreamPairPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(StreamPairP(Car(args))), nil
+}
+
+func StreamNullPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(NilP(Car(args))), nil
+}
+
+func streamPairArg(name string, s *Data, env *SymbolTableFrame) (err error) {
+	if !StreamPairP(s) {
+		err = ProcessError(fmt.Sprintf("%s expects a non-empty stream but received %s.", name, String(s)), env)
+	}
+	return
+}
+
+func StreamCarImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if err = streamPairArg("stream-car", Car(args), env); err != nil {
+		return
+	}
+	return Car(Car(args)), nil
+}
+
+// (stream-cdr stream) is the rest of stream, forcing it if need be.
+func StreamCdrImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if err = streamPairArg("stream-cdr", Car(args), env); err != nil {
+		return
+	}
+	return PromiseValue(Cdr(Car(args))).Force()
+}
+
+// countArg is the non-negative integer that is the second of args.
+func countArg(name string, args *Data, env *SymbolTableFrame) (n int64, err error) {
+	k := Cadr(args)
+	if !IntegerP(k) || IntegerValue(k) < 0 {
+		err = ProcessError(fmt.Sprintf("%s expects a non-negative integer count but received %s.", name, String(k)), env)
+		return
+	}
+	return IntegerValue(k), nil
+}
+
+// (stream-take stream n) is a list of the first n elements of stream, or
+// all of them if it has fewer.
+func StreamTakeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n, err := countArg("stream-take", args, env)
+	if err != nil {
+		return
+	}
+	elements := make([]*Data, 0)
+	s := Car(args)
+	for i := int64(0); i < n && NotNilP(s); i++ {
+		if err = streamPairArg("stream-take", s, env); err != nil {
+			return
+		}
+		elements = append(elements, Car(s))
+		if i < n-1 {
+			if s, err = PromiseValue(Cdr(s)).Force(); err != nil {
+				return
+			}
+		}
+	}
+	return ArrayToList(elements), nil
+}
+
+// (stream-tail stream n) is stream without its first n elements.
+func StreamTailImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	n, err := countArg("stream-tail", args, env)
+	if err != nil {
+		return
+	}
+	s := Car(args)
+	for i := int64(0); i < n; i++ {
+		if err = streamPairArg("stream-tail", s, env); err != nil {
+			return
+		}
+		if s, err = PromiseValue(Cdr(s)).Force(); err != nil {
+			return
+		}
+	}
+	return s, nil
+}
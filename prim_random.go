@@ -0,0 +1,137 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the random number primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Random is a random number generator. Seeded alike, two generators give
+// the same numbers. The mutex lets processes share one.
+type Random struct {
+	Rand  *rand.Rand
+	Mutex sync.Mutex
+}
+
+func RegisterRandomPrimitives() {
+	MakePrimitiveFunction("make-random", "0|1", MakeRandomImpl)
+	MakePrimitiveFunction("random?", "1", RandomPImpl)
+	MakePrimitiveFunction("random-int", "1|2", RandomIntImpl)
+	MakePrimitiveFunction("random-float", "0|1", RandomFloatImpl)
+	MakePrimitiveFunction("random-choice", "1|2", RandomChoiceImpl)
+}
+
+func RandomWithSeed(seed int64) *Data {
+	return ObjectWithTypeAndValue("Random", unsafe.Pointer(&Random{Rand: rand.New(rand.NewSource(seed))}))
+}
+
+func RandomP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "Random"
+}
+
+func RandomValue(d *Data) *Random {
+	return (*Random)(ObjectValue(d))
+}
+
+// randomArgs takes the generator off the front of args if there is one.
+// Without one the numbers come from the global source.
+func randomArgs(args *Data) (r *Random, rest *Data) {
+	if RandomP(Car(args)) {
+		return RandomValue(Car(args)), Cdr(args)
+	}
+	return nil, args
+}
+
+func (self *Random) int63n(n int64) int64 {
+	if self == nil {
+		return rand.Int63n(n)
+	}
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	return self.Rand.Int63n(n)
+}
+
+func (self *Random) float32() float32 {
+	if self == nil {
+		return rand.Float32()
+	}
+	self.Mutex.Lock()
+	defer self.Mutex.Unlock()
+	return self.Rand.Float32()
+}
+
+// (make-random [seed]) makes a generator, seeded with the time if no seed
+// is given.
+func MakeRandomImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if Length(args) == 0 {
+		return RandomWithSeed(time.Now().UnixNano()), nil
+	}
+	seed := Car(args)
+	if !IntegerP(seed) {
+		err = ProcessError(fmt.Sprintf("make-random expects an integer seed but received %s.", String(seed)), env)
+		return
+	}
+	return RandomWithSeed(IntegerValue(seed)), nil
+}
+
+func RandomPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(RandomP(Car(args))), nil
+}
+
+// (random-int [generator] n) is an integer from 0 up to but not including n.
+func RandomIntImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	r, rest := randomArgs(args)
+	if Length(rest) != 1 {
+		err = ProcessError(fmt.Sprintf("random-int expects an optional generator and a limit but received %s.", String(args)), env)
+		return
+	}
+	n := Car(rest)
+	if !IntegerP(n) || IntegerValue(n) < 1 {
+		err = ProcessError(fmt.Sprintf("random-int expects a positive integer limit but received %s.", String(n)), env)
+		return
+	}
+	return IntegerWithValue(r.int63n(IntegerValue(n))), nil
+}
+
+// (random-float [generator]) is a float from 0 up to but not including 1.
+func RandomFloatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	r, rest := randomArgs(args)
+	if NotNilP(rest) {
+		err = ProcessError(fmt.Sprintf("random-float expects an optional generator but received %s.", String(Car(rest))), env)
+		return
+	}
+	return FloatWithValue(r.float32()), nil
+}
+
+// (random-choice [generator] list) is an element of list or vector.
+func RandomChoiceImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	r, rest := randomArgs(args)
+	if Length(rest) != 1 {
+		err = ProcessError(fmt.Sprintf("random-choice expects an optional generator and a list but received %s.", String(args)), env)
+		return
+	}
+	var elements []*Data
+	choices := Car(rest)
+	if VectorP(choices) {
+		elements = VectorValue(choices).Elements
+	} else if ListP(choices) {
+		elements = ToArray(choices)
+	} else {
+		err = ProcessError(fmt.Sprintf("random-choice expects a list or vector but received %s.", String(choices)), env)
+		return
+	}
+	if len(elements) == 0 {
+		err = ProcessError("random-choice can't choose from an empty list.", env)
+		return
+	}
+	return elements[r.int63n(int64(len(elements)))], nil
+}
@@ -0,0 +1,257 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the read table and the reader macro primitive functions.
+//
+// A reader macro is a function of one argument, a reader stream, that is
+// called when the reader finds the macro's character at the start of a
+// datum. The stream is positioned just after the character, and whatever
+// the function returns is the datum read. The stream offers:
+//
+//   (reader-peek-char stream)  the next character as a one character
+//                              string, without consuming it
+//   (reader-read-char stream)  the next character, consumed
+//   (reader-read stream)       the next complete datum, which may itself
+//                              use reader macros
+//
+// At the end of the input the first two return the eof object, as does
+// reader-read, so (eof-object? x) tests for it. A stream is only valid
+// while its reader macro runs.
+
+package golisp
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// readerMacro reads the datum its character starts. Builtin macros are
+// handed the tokenizer with the character's token as the lookahead token;
+// macros written in Lisp get a stream positioned just past the character.
+type readerMacro struct {
+	Builtin  func(s *Tokenizer) (sexpr *Data, eof bool, err error)
+	Function *Data
+}
+
+type ReaderStream struct {
+	Tokenizer *Tokenizer
+	Lexed     bool
+}
+
+type readTableTable struct {
+	Macros  map[rune]*readerMacro
+	Version int64
+	Mutex   sync.RWMutex
+}
+
+var standardReaderMacros = map[rune]*readerMacro{
+	'\'': &readerMacro{Builtin: readQuoted("quote")},
+	'`':  &readerMacro{Builtin: readQuoted("quasiquote")},
+	',':  &readerMacro{Builtin: readUnquoted},
+	'#':  &readerMacro{Builtin: readHashSyntax},
+}
+
+var readTable readTableTable = readTableTable{Macros: make(map[rune]*readerMacro)}
+
+func RegisterReaderMacroPrimitives() {
+	readTable.Mutex.Lock()
+	for ch, macro := range standardReaderMacros {
+		readTable.Macros[ch] = macro
+	}
+	readTable.Mutex.Unlock()
+	atomic.AddInt64(&readTable.Version, 1)
+
+	MakePrimitiveFunction("set-reader-macro", "2", SetReaderMacroImpl)
+	MakePrimitiveFunction("reader-peek-char", "1", ReaderPeekCharImpl)
+	MakePrimitiveFunction("reader-read-char", "1", ReaderReadCharImpl)
+	MakePrimitiveFunction("reader-read", "1", ReaderReadImpl)
+}
+
+func currentReadTableVersion() int64 {
+	return atomic.LoadInt64(&readTable.Version)
+}
+
+func readerMacroFor(ch rune) *readerMacro {
+	readTable.Mutex.RLock()
+	defer readTable.Mutex.RUnlock()
+	return readTable.Macros[ch]
+}
+
+// customReaderMacroP is whether ch starts a reader macro written in Lisp.
+// The tokenizer leaves the built-in macro characters to its own tokens.
+func customReaderMacroP(ch rune) bool {
+	macro := readerMacroFor(ch)
+	return macro != nil && macro.Builtin == nil
+}
+
+// readMacro reads the datum that the lookahead token, starting with ch,
+// begins.
+func readMacro(ch rune, s *Tokenizer) (sexpr *Data, eof bool, err error) {
+	macro := readerMacroFor(ch)
+	if macro == nil {
+		err = fmt.Errorf("No reader macro for %c", ch)
+		return
+	}
+	if macro.Builtin != nil {
+		return macro.Builtin(s)
+	}
+
+	stream := &ReaderStream{Tokenizer: s}
+	sexpr, err = Apply(macro.Function, InternalMakeList(ObjectWithTypeAndValue("ReaderStream", unsafe.Pointer(stream))), Global)
+	if err != nil {
+		return
+	}
+	if !stream.Lexed {
+		s.ConsumeToken()
+	}
+	return
+}
+
+func readQuoted(name string) func(s *Tokenizer) (sexpr *Data, eof bool, err error) {
+	return func(s *Tokenizer) (sexpr *Data, eof bool, err error) {
+		s.ConsumeToken()
+		sexpr, eof, err = parseExpression(s)
+		if sexpr != nil {
+			sexpr = Cons(Intern(name), Cons(sexpr, nil))
+		}
+		return
+	}
+}
+
+func readUnquoted(s *Tokenizer) (sexpr *Data, eof bool, err error) {
+	if tok, _ := s.NextToken(); tok == COMMAAT {
+		return readQuoted("unquote-splicing")(s)
+	}
+	return readQuoted("unquote")(s)
+}
+
+func readHashSyntax(s *Tokenizer) (sexpr *Data, eof bool, err error) {
+	tok, lit := s.NextToken()
+	switch tok {
+	case TRUE:
+		s.ConsumeToken()
+		sexpr = LispTrue
+	case FALSE:
+		s.ConsumeToken()
+		sexpr = LispFalse
+	case HASHLPAREN:
+		s.ConsumeToken()
+		sexpr, eof, err = parseVector(s)
+	case CHARACTER:
+		s.ConsumeToken()
+		sexpr, err = makeCharacter(lit)
+	case SYMBOL:
+		s.ConsumeToken()
+		sexpr = Intern(lit)
+	default:
+		err = fmt.Errorf("Unexpected %s", lit)
+	}
+	return
+}
+
+func readerStreamArg(name string, args *Data, env *SymbolTableFrame) (stream *ReaderStream, err error) {
+	d := Car(args)
+	if !ObjectP(d) || ObjectType(d) != "ReaderStream" {
+		err = ProcessError(fmt.Sprintf("%s expects a reader stream but received %s.", name, String(d)), env)
+		return
+	}
+	return (*ReaderStream)(ObjectValue(d)), nil
+}
+
+// rawTokenizer gets stream's tokenizer back to reading characters, undoing
+// any token read ahead by reader-read.
+func (self *ReaderStream) rawTokenizer() *Tokenizer {
+	if self.Lexed {
+		self.Tokenizer.unreadLookahead()
+		self.Lexed = false
+	}
+	return self.Tokenizer
+}
+
+// (set-reader-macro "c" function) makes the reader call function when it
+// finds c at the start of a datum. A function of nil puts back the
+// standard meaning of c.
+func SetReaderMacroImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	chString := Car(args)
+	if !StringP(chString) || utf8.RuneCountInString(StringValue(chString)) != 1 {
+		err = ProcessError(fmt.Sprintf("set-reader-macro expects a one character string but received %s.", String(chString)), env)
+		return
+	}
+	ch, _ := utf8.DecodeRuneInString(StringValue(chString))
+	if ch == '(' || ch == ')' || ch == '"' || ch == ';' {
+		err = ProcessError(fmt.Sprintf("set-reader-macro can't redefine %c.", ch), env)
+		return
+	}
+
+	f := Cadr(args)
+	if NotNilP(f) && !FunctionOrPrimitiveP(f) {
+		err = ProcessError(fmt.Sprintf("set-reader-macro expects a function but received %s.", String(f)), env)
+		return
+	}
+
+	readTable.Mutex.Lock()
+	if NilP(f) {
+		if standard, ok := standardReaderMacros[ch]; ok {
+			readTable.Macros[ch] = standard
+		} else {
+			delete(readTable.Macros, ch)
+		}
+	} else {
+		readTable.Macros[ch] = &readerMacro{Function: f}
+	}
+	readTable.Mutex.Unlock()
+	atomic.AddInt64(&readTable.Version, 1)
+	return f, nil
+}
+
+func ReaderPeekCharImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	stream, err := readerStreamArg("reader-peek-char", args, env)
+	if err != nil {
+		return
+	}
+	s := stream.rawTokenizer()
+	if s.isEof() {
+		return EofObject, nil
+	}
+	return StringWithValue(string(s.CurrentCh)), nil
+}
+
+func ReaderReadCharImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	stream, err := readerStreamArg("reader-read-char", args, env)
+	if err != nil {
+		return
+	}
+	s := stream.rawTokenizer()
+	if s.isEof() {
+		return EofObject, nil
+	}
+	result = StringWithValue(string(s.CurrentCh))
+	s.Advance()
+	return
+}
+
+func ReaderReadImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	stream, err := readerStreamArg("reader-read", args, env)
+	if err != nil {
+		return
+	}
+	s := stream.Tokenizer
+	if !stream.Lexed {
+		s.ConsumeToken()
+		stream.Lexed = true
+	}
+	result, eof, err := parseExpression(s)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("reader-read: %s", err), env)
+		return
+	}
+	if eof {
+		result = EofObject
+	}
+	return
+}
@@ -0,0 +1,167 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the regular expression primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"unsafe"
+)
+
+// Patterns given as strings are compiled once and kept here, so matching
+// against a string literal in a loop doesn't recompile it every time.
+var regexCache map[string]*regexp.Regexp = make(map[string]*regexp.Regexp)
+var regexCacheMutex sync.RWMutex
+
+func RegisterRegexPrimitives() {
+	MakePrimitiveFunction("regex-compile", "1", RegexCompileImpl)
+	MakePrimitiveFunction("regex-match?", "2", RegexMatchImpl)
+	MakePrimitiveFunction("regex-find", "2", RegexFindImpl)
+	MakePrimitiveFunction("regex-find-all", "2", RegexFindAllImpl)
+	MakePrimitiveFunction("regex-replace", "3", RegexReplaceImpl)
+}
+
+func compileRegex(pattern string) (re *regexp.Regexp, err error) {
+	regexCacheMutex.RLock()
+	re, ok := regexCache[pattern]
+	regexCacheMutex.RUnlock()
+	if ok {
+		return
+	}
+
+	re, err = regexp.Compile(pattern)
+	if err != nil {
+		return
+	}
+
+	regexCacheMutex.Lock()
+	regexCache[pattern] = re
+	regexCacheMutex.Unlock()
+	return
+}
+
+// regexArg accepts either a compiled Regexp object or a pattern string.
+func regexArg(name string, patternObj *Data, env *SymbolTableFrame) (re *regexp.Regexp, err error) {
+	if ObjectP(patternObj) && ObjectType(patternObj) == "Regexp" {
+		return (*regexp.Regexp)(ObjectValue(patternObj)), nil
+	}
+
+	if !StringP(patternObj) {
+		err = ProcessError(fmt.Sprintf("%s expects a Regexp object or a pattern string but received %s.", name, String(patternObj)), env)
+		return
+	}
+
+	re, compileErr := compileRegex(StringValue(patternObj))
+	if compileErr != nil {
+		err = ProcessError(fmt.Sprintf("%s received a bad pattern: %s", name, compileErr), env)
+	}
+	return
+}
+
+func regexStringArg(name string, strObj *Data, env *SymbolTableFrame) (str string, err error) {
+	if !StringP(strObj) {
+		err = ProcessError(fmt.Sprintf("%s expects a string to match against but received %s.", name, String(strObj)), env)
+		return
+	}
+	return StringValue(strObj), nil
+}
+
+func RegexCompileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if !StringP(Car(args)) {
+		err = ProcessError(fmt.Sprintf("regex-compile expects a pattern string but received %s.", String(Car(args))), env)
+		return
+	}
+
+	re, err := regexArg("regex-compile", Car(args), env)
+	if err != nil {
+		return
+	}
+	return ObjectWithTypeAndValue("Regexp", unsafe.Pointer(re)), nil
+}
+
+func RegexMatchImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	re, err := regexArg("regex-match?", Car(args), env)
+	if err != nil {
+		return
+	}
+	str, err := regexStringArg("regex-match?", Cadr(args), env)
+	if err != nil {
+		return
+	}
+
+	return BooleanWithValue(re.MatchString(str)), nil
+}
+
+// regex-find returns the first match as a list of the whole match followed by
+// each capture group, or nil if there is no match. Groups that didn't take
+// part in the match are nil.
+func RegexFindImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	re, err := regexArg("regex-find", Car(args), env)
+	if err != nil {
+		return
+	}
+	str, err := regexStringArg("regex-find", Cadr(args), env)
+	if err != nil {
+		return
+	}
+
+	indexes := re.FindStringSubmatchIndex(str)
+	if indexes == nil {
+		return
+	}
+
+	groups := make([]*Data, 0, len(indexes)/2)
+	for i := 0; i < len(indexes); i += 2 {
+		if indexes[i] < 0 {
+			groups = append(groups, nil)
+		} else {
+			groups = append(groups, StringWithValue(str[indexes[i]:indexes[i+1]]))
+		}
+	}
+	return ArrayToList(groups), nil
+}
+
+func RegexFindAllImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	re, err := regexArg("regex-find-all", Car(args), env)
+	if err != nil {
+		return
+	}
+	str, err := regexStringArg("regex-find-all", Cadr(args), env)
+	if err != nil {
+		return
+	}
+
+	matches := re.FindAllString(str, -1)
+	items := make([]*Data, 0, len(matches))
+	for _, match := range matches {
+		items = append(items, StringWithValue(match))
+	}
+	return ArrayToList(items), nil
+}
+
+// (regex-replace pattern string replacement) replaces every match, expanding
+// $1 or ${name} in replacement to the matching group.
+func RegexReplaceImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	re, err := regexArg("regex-replace", Car(args), env)
+	if err != nil {
+		return
+	}
+	str, err := regexStringArg("regex-replace", Cadr(args), env)
+	if err != nil {
+		return
+	}
+
+	replacement := Caddr(args)
+	if !StringP(replacement) {
+		err = ProcessError(fmt.Sprintf("regex-replace expects a replacement string but received %s.", String(replacement)), env)
+		return
+	}
+
+	return StringWithValue(re.ReplaceAllString(str, StringValue(replacement))), nil
+}
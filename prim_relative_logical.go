@@ -15,11 +15,11 @@ func RegisterRelativePrimitives() {
 	MakePrimitiveFunction("<", "2", LessThanImpl)
 	MakePrimitiveFunction(">", "2", GreaterThanImpl)
 	MakePrimitiveFunction("==", "2", EqualToImpl)
-	MakePrimitiveFunction("eqv?", "2", EqualToImpl)
-	MakePrimitiveFunction("eq?", "2", EqualToImpl)
+	MakePrimitiveFunction("eqv?", "2", EqvImpl)
+	MakePrimitiveFunction("eq?", "2", EqImpl)
 	MakePrimitiveFunction("equal?", "2", EqualToImpl)
 	MakePrimitiveFunction("!=", "2", NotEqualImpl)
-	MakePrimitiveFunction("neq?", "2", NotEqualImpl)
+	MakePrimitiveFunction("neq?", "2", NotEqImpl)
 	MakePrimitiveFunction("<=", "2", LessThanOrEqualToImpl)
 	MakePrimitiveFunction(">=", "2", GreaterThanOrEqualToImpl)
 	MakePrimitiveFunction("!", "1", BooleanNotImpl)
@@ -28,6 +28,18 @@ func RegisterRelativePrimitives() {
 	MakeSpecialForm("or", "*", BooleanOrImpl)
 }
 
+// exactComparison compares two exact numbers exactly when either is a bignum
+// or rational, since going through FloatValue would lose precision.
+func exactComparison(arg1 *Data, arg2 *Data) (cmp int, ok bool) {
+	if (IntegerP(arg1) && IntegerP(arg2)) || !ExactP(arg1) || !ExactP(arg2) {
+		return 0, false
+	}
+	if RationalP(arg1) || RationalP(arg2) {
+		return RationalValue(arg1).Cmp(RationalValue(arg2)), true
+	}
+	return BignumValue(arg1).Cmp(BignumValue(arg2)), true
+}
+
 func LessThanImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	arg1 := Car(args)
 	if !NumberP(arg1) {
@@ -41,6 +53,10 @@ func LessThanImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		return
 	}
 
+	if cmp, ok := exactComparison(arg1, arg2); ok {
+		return BooleanWithValue(cmp < 0), nil
+	}
+
 	val := FloatValue(arg1) < FloatValue(arg2)
 	return BooleanWithValue(val), nil
 }
@@ -58,6 +74,10 @@ func GreaterThanImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 		return
 	}
 
+	if cmp, ok := exactComparison(arg1, arg2); ok {
+		return BooleanWithValue(cmp > 0), nil
+	}
+
 	val := FloatValue(arg1) > FloatValue(arg2)
 	return BooleanWithValue(val), nil
 }
@@ -68,6 +88,19 @@ func EqualToImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return BooleanWithValue(IsEqual(arg1, arg2)), nil
 }
 
+// (eq? a b) is whether a and b are the same object; see IsEq.
+func EqImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(IsEq(Car(args), Cadr(args))), nil
+}
+
+func NotEqImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(!IsEq(Car(args), Cadr(args))), nil
+}
+
+func EqvImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(IsEqv(Car(args), Cadr(args))), nil
+}
+
 func NotEqualImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	arg1 := Car(args)
 	arg2 := Cadr(args)
@@ -87,6 +120,10 @@ func LessThanOrEqualToImpl(args *Data, env *SymbolTableFrame) (result *Data, err
 		return
 	}
 
+	if cmp, ok := exactComparison(arg1, arg2); ok {
+		return BooleanWithValue(cmp <= 0), nil
+	}
+
 	val := FloatValue(arg1) <= FloatValue(arg2)
 	return BooleanWithValue(val), nil
 }
@@ -104,6 +141,10 @@ func GreaterThanOrEqualToImpl(args *Data, env *SymbolTableFrame) (result *Data,
 		return
 	}
 
+	if cmp, ok := exactComparison(arg1, arg2); ok {
+		return BooleanWithValue(cmp >= 0), nil
+	}
+
 	val := FloatValue(arg1) >= FloatValue(arg2)
 	return BooleanWithValue(val), nil
 }
@@ -112,22 +153,33 @@ func BooleanNotImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 	return BooleanWithValue(!BooleanValue(Car(args))), nil
 }
 
+// (and form...) evaluates the forms until one is false, and is that value,
+// or the last form's value if none are false. (and) is #t.
 func BooleanAndImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	result = LispTrue
 	for c := args; NotNilP(c); c = Cdr(c) {
+		if NilP(Cdr(c)) {
+			return evalTail(Car(c), env)
+		}
 		result, err = Eval(Car(c), env)
-		if err != nil || !BooleanValue(result) {
+		if err != nil || !BooleanValue(SingleValue(result)) {
 			return
 		}
 	}
 	return
 }
 
+// (or form...) evaluates the forms until one is true, and is that value, or
+// the last form's value if none are true. (or) is #f.
 func BooleanOrImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	for c := args; NotNilP(c); c = Cdr(c) {
+		if NilP(Cdr(c)) {
+			return evalTail(Car(c), env)
+		}
 		result, err = Eval(Car(c), env)
-		if err != nil || BooleanValue(result) {
+		if err != nil || BooleanValue(SingleValue(result)) {
 			return
 		}
 	}
-	return
+	return LispFalse, nil
 }
@@ -0,0 +1,538 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the serialization primitive functions.
+//
+// serialize writes data in a binary format that deserialize reads back. It
+// starts with a header naming the format and its version, followed by the
+// value. Each value is a tag byte and then what that kind of value needs:
+// lengths and integers are varints, floats are their IEEE bits, and bignums
+// and rationals are in the form math/big gob-encodes them. A list, frame,
+// vector, hash table, set, bytearray or string written a second time is
+// written as a reference to the first, so sharing and cycles survive the
+// round trip. Symbols come back interned.
+
+package golisp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"unsafe"
+)
+
+const serializationHeader = "GLSP\x01"
+
+const (
+	serialNil byte = iota
+	serialTrue
+	serialFalse
+	serialInteger
+	serialFloat
+	serialBignum
+	serialRational
+	serialComplex
+	serialCharacter
+	serialString
+	serialSymbol
+	serialCons
+	serialAlist
+	serialAlistCell
+	serialFrame
+	serialVector
+	serialHashTable
+	serialSet
+	serialBytes
+	serialReference
+)
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+type serializer struct {
+	buf  bytes.Buffer
+	seen map[unsafe.Pointer]uint64
+}
+
+type deserializer struct {
+	in      byteReader
+	objects []*Data
+}
+
+func RegisterSerializationPrimitives() {
+	MakePrimitiveFunction("serialize", "1|2", SerializeImpl)
+	MakePrimitiveFunction("deserialize", "1", DeserializeImpl)
+}
+
+// Serialize is d in the serialized form.
+func Serialize(d *Data) (result []byte, err error) {
+	s := &serializer{seen: make(map[unsafe.Pointer]uint64)}
+	s.buf.WriteString(serializationHeader)
+	if err = s.write(d); err != nil {
+		return
+	}
+	return s.buf.Bytes(), nil
+}
+
+// Deserialize reads one serialized value from in.
+func Deserialize(in byteReader) (result *Data, err error) {
+	header := make([]byte, len(serializationHeader))
+	if _, err = io.ReadFull(in, header); err != nil || string(header) != serializationHeader {
+		return nil, errors.New("the data isn't serialized lisp data")
+	}
+	d := &deserializer{in: in}
+	return d.read()
+}
+
+func (self *serializer) writeUvarint(n uint64) {
+	var b [binary.MaxVarintLen64]byte
+	self.buf.Write(b[:binary.PutUvarint(b[:], n)])
+}
+
+func (self *serializer) writeVarint(n int64) {
+	var b [binary.MaxVarintLen64]byte
+	self.buf.Write(b[:binary.PutVarint(b[:], n)])
+}
+
+func (self *serializer) writeBytes(b []byte) {
+	self.writeUvarint(uint64(len(b)))
+	self.buf.Write(b)
+}
+
+func (self *serializer) writeGob(tag byte, g interface{ GobEncode() ([]byte, error) }) (err error) {
+	b, err := g.GobEncode()
+	if err != nil {
+		return
+	}
+	self.buf.WriteByte(tag)
+	self.writeBytes(b)
+	return
+}
+
+// shared writes a reference and returns true if d has been written before,
+// and otherwise remembers it so later appearances can refer to it.
+func (self *serializer) shared(d *Data) bool {
+	if index, found := self.seen[d.Value]; found {
+		self.buf.WriteByte(serialReference)
+		self.writeUvarint(index)
+		return true
+	}
+	self.seen[d.Value] = uint64(len(self.seen))
+	return false
+}
+
+func (self *serializer) write(d *Data) (err error) {
+	if NilP(d) {
+		self.buf.WriteByte(serialNil)
+		return
+	}
+
+	switch d.Type {
+	case BooleanType:
+		if BooleanValue(d) {
+			self.buf.WriteByte(serialTrue)
+		} else {
+			self.buf.WriteByte(serialFalse)
+		}
+	case IntegerType:
+		self.buf.WriteByte(serialInteger)
+		self.writeVarint(IntegerValue(d))
+	case FloatType:
+		self.buf.WriteByte(serialFloat)
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(FloatValue(d)))
+		self.buf.Write(b[:])
+	case BignumType:
+		return self.writeGob(serialBignum, BignumValue(d))
+	case RationalType:
+		return self.writeGob(serialRational, RationalValue(d))
+	case ComplexType:
+		self.buf.WriteByte(serialComplex)
+		var b [16]byte
+		c := ComplexValue(d)
+		binary.LittleEndian.PutUint64(b[:8], math.Float64bits(real(c)))
+		binary.LittleEndian.PutUint64(b[8:], math.Float64bits(imag(c)))
+		self.buf.Write(b[:])
+	case CharacterType:
+		self.buf.WriteByte(serialCharacter)
+		self.writeVarint(int64(CharacterValue(d)))
+	case StringType:
+		if !self.shared(d) {
+			self.buf.WriteByte(serialString)
+			self.writeBytes([]byte(StringValue(d)))
+		}
+	case SymbolType:
+		self.buf.WriteByte(serialSymbol)
+		self.writeBytes([]byte(StringValue(d)))
+	case ConsCellType, AlistType, AlistCellType:
+		if self.shared(d) {
+			return
+		}
+		switch d.Type {
+		case ConsCellType:
+			self.buf.WriteByte(serialCons)
+		case AlistType:
+			self.buf.WriteByte(serialAlist)
+		default:
+			self.buf.WriteByte(serialAlistCell)
+		}
+		if err = self.write(Car(d)); err != nil {
+			return
+		}
+		return self.write(Cdr(d))
+	case FrameType:
+		if self.shared(d) {
+			return
+		}
+		frame := FrameValue(d)
+		frame.Mutex.RLock()
+		slots := make(FrameMapData, len(frame.Data))
+		for k, v := range frame.Data {
+			slots[k] = v
+		}
+		frame.Mutex.RUnlock()
+		self.buf.WriteByte(serialFrame)
+		self.writeUvarint(uint64(len(slots)))
+		for k, v := range slots {
+			self.writeBytes([]byte(k))
+			if err = self.write(v); err != nil {
+				return
+			}
+		}
+	case BoxedObjectType:
+		return self.writeObject(d)
+	default:
+		return fmt.Errorf("can't serialize %s", String(d))
+	}
+	return
+}
+
+func (self *serializer) writeObject(d *Data) (err error) {
+	switch ObjectType(d) {
+	case "[]byte":
+		if !self.shared(d) {
+			self.buf.WriteByte(serialBytes)
+			self.writeBytes(*(*[]byte)(ObjectValue(d)))
+		}
+	case "Vector":
+		if self.shared(d) {
+			return
+		}
+		vector := VectorValue(d)
+		self.buf.WriteByte(serialVector)
+		if vector.Immutable {
+			self.buf.WriteByte(1)
+		} else {
+			self.buf.WriteByte(0)
+		}
+		self.writeUvarint(uint64(len(vector.Elements)))
+		for _, element := range vector.Elements {
+			if err = self.write(element); err != nil {
+				return
+			}
+		}
+	case "HashTable":
+		if self.shared(d) {
+			return
+		}
+		table := (*HashTable)(ObjectValue(d))
+		table.Mutex.RLock()
+		entries := make(map[string]hashEntry, len(table.Entries))
+		for k, entry := range table.Entries {
+			entries[k] = entry
+		}
+		table.Mutex.RUnlock()
+		self.buf.WriteByte(serialHashTable)
+		self.writeUvarint(uint64(len(entries)))
+		for k, entry := range entries {
+			self.writeBytes([]byte(k))
+			if err = self.write(entry.Key); err != nil {
+				return
+			}
+			if err = self.write(entry.Value); err != nil {
+				return
+			}
+		}
+	case "Set":
+		if self.shared(d) {
+			return
+		}
+		elements := SetValue(d).snapshot()
+		self.buf.WriteByte(serialSet)
+		self.writeUvarint(uint64(len(elements)))
+		for k, element := range elements {
+			self.writeBytes([]byte(k))
+			if err = self.write(element); err != nil {
+				return
+			}
+		}
+	default:
+		return fmt.Errorf("can't serialize %s", String(d))
+	}
+	return
+}
+
+var errMalformedSerialization = errors.New("the serialized data is malformed")
+
+func (self *deserializer) readUvarint() (n uint64, err error) {
+	n, err = binary.ReadUvarint(self.in)
+	if err != nil {
+		err = errMalformedSerialization
+	}
+	return
+}
+
+func (self *deserializer) readVarint() (n int64, err error) {
+	n, err = binary.ReadVarint(self.in)
+	if err != nil {
+		err = errMalformedSerialization
+	}
+	return
+}
+
+func (self *deserializer) readFixed(n int) (b []byte, err error) {
+	b = make([]byte, n)
+	if _, err = io.ReadFull(self.in, b); err != nil {
+		err = errMalformedSerialization
+	}
+	return
+}
+
+func (self *deserializer) readBytes() (b []byte, err error) {
+	n, err := self.readUvarint()
+	if err != nil {
+		return
+	}
+	return self.readFixed(int(n))
+}
+
+// remember records d so that later references can refer to it. Containers
+// are remembered before their contents are read, so the contents can refer
+// back to them.
+func (self *deserializer) remember(d *Data) *Data {
+	self.objects = append(self.objects, d)
+	return d
+}
+
+func (self *deserializer) read() (result *Data, err error) {
+	tag, err := self.in.ReadByte()
+	if err != nil {
+		return nil, errMalformedSerialization
+	}
+
+	switch tag {
+	case serialNil:
+		return nil, nil
+	case serialTrue:
+		return LispTrue, nil
+	case serialFalse:
+		return LispFalse, nil
+	case serialInteger:
+		n, nErr := self.readVarint()
+		return IntegerWithValue(n), nErr
+	case serialFloat:
+		b, bErr := self.readFixed(4)
+		if bErr != nil {
+			return nil, bErr
+		}
+		return FloatWithValue(math.Float32frombits(binary.LittleEndian.Uint32(b))), nil
+	case serialBignum:
+		b, bErr := self.readBytes()
+		if bErr != nil {
+			return nil, bErr
+		}
+		n := new(big.Int)
+		if n.GobDecode(b) != nil {
+			return nil, errMalformedSerialization
+		}
+		return BignumWithValue(n), nil
+	case serialRational:
+		b, bErr := self.readBytes()
+		if bErr != nil {
+			return nil, bErr
+		}
+		r := new(big.Rat)
+		if r.GobDecode(b) != nil {
+			return nil, errMalformedSerialization
+		}
+		return RationalWithValue(r), nil
+	case serialComplex:
+		b, bErr := self.readFixed(16)
+		if bErr != nil {
+			return nil, bErr
+		}
+		return ComplexWithValue(complex(math.Float64frombits(binary.LittleEndian.Uint64(b[:8])), math.Float64frombits(binary.LittleEndian.Uint64(b[8:])))), nil
+	case serialCharacter:
+		n, nErr := self.readVarint()
+		return CharacterWithValue(rune(n)), nErr
+	case serialString:
+		b, bErr := self.readBytes()
+		if bErr != nil {
+			return nil, bErr
+		}
+		return self.remember(StringWithValue(string(b))), nil
+	case serialSymbol:
+		b, bErr := self.readBytes()
+		if bErr != nil {
+			return nil, bErr
+		}
+		return Intern(string(b)), nil
+	case serialCons, serialAlist, serialAlistCell:
+		cell := &ConsCell{}
+		cellType := uint8(ConsCellType)
+		if tag == serialAlist {
+			cellType = AlistType
+		} else if tag == serialAlistCell {
+			cellType = AlistCellType
+		}
+		result = self.remember(&Data{Type: cellType, Value: unsafe.Pointer(cell)})
+		if cell.Car, err = self.read(); err != nil {
+			return
+		}
+		cell.Cdr, err = self.read()
+		return
+	case serialFrame:
+		m := &FrameMap{Data: make(FrameMapData)}
+		result = self.remember(FrameWithValue(m))
+		n, nErr := self.readUvarint()
+		if nErr != nil {
+			return nil, nErr
+		}
+		for i := uint64(0); i < n; i++ {
+			k, kErr := self.readBytes()
+			if kErr != nil {
+				return nil, kErr
+			}
+			if m.Data[string(k)], err = self.read(); err != nil {
+				return
+			}
+		}
+		return
+	case serialVector:
+		immutable, iErr := self.in.ReadByte()
+		if iErr != nil {
+			return nil, errMalformedSerialization
+		}
+		n, nErr := self.readUvarint()
+		if nErr != nil {
+			return nil, nErr
+		}
+		v := &Vector{Immutable: immutable == 1}
+		result = self.remember(ObjectWithTypeAndValue("Vector", unsafe.Pointer(v)))
+		for i := uint64(0); i < n; i++ {
+			var element *Data
+			if element, err = self.read(); err != nil {
+				return
+			}
+			v.Elements = append(v.Elements, element)
+		}
+		return
+	case serialHashTable:
+		table := &HashTable{Entries: make(map[string]hashEntry)}
+		result = self.remember(ObjectWithTypeAndValue("HashTable", unsafe.Pointer(table)))
+		n, nErr := self.readUvarint()
+		if nErr != nil {
+			return nil, nErr
+		}
+		for i := uint64(0); i < n; i++ {
+			k, kErr := self.readBytes()
+			if kErr != nil {
+				return nil, kErr
+			}
+			var entry hashEntry
+			if entry.Key, err = self.read(); err != nil {
+				return
+			}
+			if entry.Value, err = self.read(); err != nil {
+				return
+			}
+			table.Entries[string(k)] = entry
+		}
+		return
+	case serialSet:
+		set := &Set{Elements: make(map[string]*Data)}
+		result = self.remember(ObjectWithTypeAndValue("Set", unsafe.Pointer(set)))
+		n, nErr := self.readUvarint()
+		if nErr != nil {
+			return nil, nErr
+		}
+		for i := uint64(0); i < n; i++ {
+			k, kErr := self.readBytes()
+			if kErr != nil {
+				return nil, kErr
+			}
+			if set.Elements[string(k)], err = self.read(); err != nil {
+				return
+			}
+		}
+		return
+	case serialBytes:
+		b, bErr := self.readBytes()
+		if bErr != nil {
+			return nil, bErr
+		}
+		return self.remember(ObjectWithTypeAndValue("[]byte", unsafe.Pointer(&b))), nil
+	case serialReference:
+		index, iErr := self.readUvarint()
+		if iErr != nil || index >= uint64(len(self.objects)) {
+			return nil, errMalformedSerialization
+		}
+		return self.objects[index], nil
+	}
+	return nil, errMalformedSerialization
+}
+
+// (serialize value [port]) is value serialized into a bytearray, or, given
+// a port, writes it there.
+func SerializeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	b, err := Serialize(Car(args))
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("serialize %s.", err), env)
+		return
+	}
+
+	if Length(args) == 1 {
+		return ObjectWithTypeAndValue("[]byte", unsafe.Pointer(&b)), nil
+	}
+	p := Cadr(args)
+	if !OutputPortP(p) {
+		err = ProcessError(fmt.Sprintf("serialize expects its second argument be a port but received %s.", String(p)), env)
+		return
+	}
+	_, err = outputPortValue(p).Write(b)
+	return
+}
+
+// (deserialize bytearray-or-port) reads back a value written by serialize.
+// From a port it reads one value, leaving whatever follows it unread.
+func DeserializeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	var in byteReader
+	source := Car(args)
+	if PortP(source) {
+		var reader *bufio.Reader
+		if reader, err = inputPortArg("deserialize", args, env); err != nil {
+			return
+		}
+		in = reader
+	} else if ObjectP(source) && ObjectType(source) == "[]byte" {
+		in = bytes.NewReader(*(*[]byte)(ObjectValue(source)))
+	} else {
+		err = ProcessError(fmt.Sprintf("deserialize expects a bytearray or a port but received %s.", String(source)), env)
+		return
+	}
+
+	result, err = Deserialize(in)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("deserialize can't read it: %s.", err), env)
+	}
+	return
+}
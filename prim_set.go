@@ -0,0 +1,240 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the set primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// Set holds elements keyed as hash tables key them, so elements that are
+// equal? are the same element.
+type Set struct {
+	Mutex    sync.RWMutex
+	Elements map[string]*Data
+}
+
+func RegisterSetPrimitives() {
+	MakePrimitiveFunction("make-set", "*", MakeSetImpl)
+	MakePrimitiveFunction("list->set", "1", ListToSetImpl)
+	MakePrimitiveFunction("set?", "1", SetPImpl)
+	MakePrimitiveFunction("set-add!", ">=1", SetAddImpl)
+	MakePrimitiveFunction("set-remove!", "2", SetRemoveImpl)
+	MakePrimitiveFunction("set-member?", "2", SetMemberImpl)
+	MakePrimitiveFunction("set-count", "1", SetCountImpl)
+	MakePrimitiveFunction("set->list", "1", SetToListImpl)
+	MakePrimitiveFunction("set-union", "*", SetUnionImpl)
+	MakePrimitiveFunction("set-intersection", ">=1", SetIntersectionImpl)
+	MakePrimitiveFunction("set-difference", ">=1", SetDifferenceImpl)
+}
+
+func newSet(elements map[string]*Data) *Data {
+	return ObjectWithTypeAndValue("Set", unsafe.Pointer(&Set{Elements: elements}))
+}
+
+func SetP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "Set"
+}
+
+func SetValue(d *Data) *Set {
+	return (*Set)(ObjectValue(d))
+}
+
+func setArg(name string, d *Data, env *SymbolTableFrame) (set *Set, err error) {
+	if !SetP(d) {
+		err = ProcessError(fmt.Sprintf("%s expects a set but received %s.", name, String(d)), env)
+		return
+	}
+	return SetValue(d), nil
+}
+
+// setArgs is the sets in args, checking that each is one.
+func setArgs(name string, args *Data, env *SymbolTableFrame) (sets []*Set, err error) {
+	for c := args; NotNilP(c); c = Cdr(c) {
+		set, setErr := setArg(name, Car(c), env)
+		if setErr != nil {
+			return nil, setErr
+		}
+		sets = append(sets, set)
+	}
+	return
+}
+
+// snapshot copies the elements so that they can be read without the lock.
+func (self *Set) snapshot() map[string]*Data {
+	self.Mutex.RLock()
+	defer self.Mutex.RUnlock()
+	elements := make(map[string]*Data, len(self.Elements))
+	for k, v := range self.Elements {
+		elements[k] = v
+	}
+	return elements
+}
+
+func (self *Set) contains(k string) bool {
+	self.Mutex.RLock()
+	defer self.Mutex.RUnlock()
+	_, found := self.Elements[k]
+	return found
+}
+
+// setsEqual is whether two sets have the same elements.
+func setsEqual(a *Set, b *Set) bool {
+	if a == b {
+		return true
+	}
+	elements := a.snapshot()
+	b.Mutex.RLock()
+	defer b.Mutex.RUnlock()
+	if len(elements) != len(b.Elements) {
+		return false
+	}
+	for k := range elements {
+		if _, found := b.Elements[k]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// (make-set element...) makes a set of the elements.
+func MakeSetImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	elements := make(map[string]*Data)
+	for c := args; NotNilP(c); c = Cdr(c) {
+		elements[hashKey(Car(c))] = Car(c)
+	}
+	return newSet(elements), nil
+}
+
+func ListToSetImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	l := Car(args)
+	if !ListP(l) {
+		err = ProcessError(fmt.Sprintf("list->set expects a list but received %s.", String(l)), env)
+		return
+	}
+	return MakeSetImpl(l, env)
+}
+
+func SetPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(SetP(Car(args))), nil
+}
+
+// (set-add! set element...) adds the elements to set and returns it.
+func SetAddImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	set, err := setArg("set-add!", Car(args), env)
+	if err != nil {
+		return
+	}
+	set.Mutex.Lock()
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		set.Elements[hashKey(Car(c))] = Car(c)
+	}
+	set.Mutex.Unlock()
+	return Car(args), nil
+}
+
+// (set-remove! set element) removes element from set, returning whether it
+// was there.
+func SetRemoveImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	set, err := setArg("set-remove!", Car(args), env)
+	if err != nil {
+		return
+	}
+	k := hashKey(Cadr(args))
+	set.Mutex.Lock()
+	_, found := set.Elements[k]
+	delete(set.Elements, k)
+	set.Mutex.Unlock()
+	return BooleanWithValue(found), nil
+}
+
+func SetMemberImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	set, err := setArg("set-member?", Car(args), env)
+	if err != nil {
+		return
+	}
+	return BooleanWithValue(set.contains(hashKey(Cadr(args)))), nil
+}
+
+func SetCountImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	set, err := setArg("set-count", Car(args), env)
+	if err != nil {
+		return
+	}
+	set.Mutex.RLock()
+	defer set.Mutex.RUnlock()
+	return IntegerWithValue(int64(len(set.Elements))), nil
+}
+
+// (set->list set) is the elements of set in no particular order.
+func SetToListImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	set, err := setArg("set->list", Car(args), env)
+	if err != nil {
+		return
+	}
+	set.Mutex.RLock()
+	defer set.Mutex.RUnlock()
+	elements := make([]*Data, 0, len(set.Elements))
+	for _, element := range set.Elements {
+		elements = append(elements, element)
+	}
+	return ArrayToList(elements), nil
+}
+
+// The set operations make a new set and leave their arguments alone.
+
+func SetUnionImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sets, err := setArgs("set-union", args, env)
+	if err != nil {
+		return
+	}
+	elements := make(map[string]*Data)
+	for _, set := range sets {
+		for k, v := range set.snapshot() {
+			elements[k] = v
+		}
+	}
+	return newSet(elements), nil
+}
+
+// (set-intersection set...) is the elements of the first set that are in
+// all the others.
+func SetIntersectionImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sets, err := setArgs("set-intersection", args, env)
+	if err != nil {
+		return
+	}
+	elements := sets[0].snapshot()
+	for _, set := range sets[1:] {
+		for k := range elements {
+			if !set.contains(k) {
+				delete(elements, k)
+			}
+		}
+	}
+	return newSet(elements), nil
+}
+
+// (set-difference set...) is the elements of the first set that aren't in
+// any of the others.
+func SetDifferenceImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sets, err := setArgs("set-difference", args, env)
+	if err != nil {
+		return
+	}
+	elements := sets[0].snapshot()
+	for _, set := range sets[1:] {
+		for k := range elements {
+			if set.contains(k) {
+				delete(elements, k)
+			}
+		}
+	}
+	return newSet(elements), nil
+}
@@ -48,4 +48,33 @@ func InitBuiltins() {
 	RegisterEnvironmentPrimitives()
 	RegisterIOPrimitives()
 	RegisterChannelPrimitives()
+	RegisterRegexPrimitives()
+	RegisterHashTablePrimitives()
+	RegisterVectorPrimitives()
+	RegisterConditionPrimitives()
+	RegisterValuesPrimitives()
+	RegisterPrettyPrintPrimitives()
+	RegisterReaderMacroPrimitives()
+	RegisterTcpPrimitives()
+	RegisterHttpPrimitives()
+	RegisterGoObjectPrimitives()
+	RegisterCompilerPrimitives()
+	RegisterMemoizePrimitives()
+	RegisterPackagePrimitives()
+	RegisterRandomPrimitives()
+	RegisterComplexPrimitives()
+	RegisterStringBuilderPrimitives()
+	RegisterCharacterPrimitives()
+	RegisterSetPrimitives()
+	RegisterParameterPrimitives()
+	RegisterStructurePrimitives()
+	RegisterSerializationPrimitives()
+	RegisterSubprocessPrimitives()
+	RegisterEncodingPrimitives()
+	RegisterDigestPrimitives()
+	RegisterDescribePrimitives()
+	RegisterTestingPrimitives()
+	RegisterWeakRefPrimitives()
+	RegisterPromisePrimitives()
+	RegisterGeneratorPrimitives()
 }
@@ -9,14 +9,16 @@ package golisp
 
 import (
 	"fmt"
+	"strings"
 )
 
 func RegisterSpecialFormPrimitives() {
 	MakeSpecialForm("cond", "*", CondImpl)
 	MakeSpecialForm("case", ">=1", CaseImpl)
 	MakeSpecialForm("if", "2|3", IfImpl)
-	MakeSpecialForm("when", ">=2", WhenImpl)
-	MakeSpecialForm("unless", ">=2", UnlessImpl)
+	MakeSpecialForm("when", ">=1", WhenImpl)
+	MakeSpecialForm("unless", ">=1", UnlessImpl)
+	MakeSpecialForm("assert", "1|2", AssertImpl)
 	MakeSpecialForm("lambda", ">=1", LambdaImpl)
 	MakeSpecialForm("named-lambda", ">=1", NamedLambdaImpl)
 	MakeSpecialForm("define", ">=1", DefineImpl)
@@ -26,7 +28,9 @@ func RegisterSpecialFormPrimitives() {
 	MakeSpecialForm("letrec", ">=1", LetRecImpl)
 	MakeSpecialForm("begin", "*", BeginImpl)
 	MakeSpecialForm("do", ">=2", DoImpl)
-	MakePrimitiveFunction("apply", ">=1", ApplyImpl)
+	MakeSpecialForm("unwind-protect", ">=2", UnwindProtectImpl)
+	MakePrimitiveFunction("dynamic-wind", "3", DynamicWindImpl)
+	MakePrimitiveFunction("apply", ">=2", ApplyImpl)
 	MakeSpecialForm("->", ">=1", ChainImpl)
 	MakeSpecialForm("=>", ">=1", TapImpl)
 	MakeSpecialForm("definition-of", "1", DefinitionOfImpl)
@@ -42,6 +46,9 @@ func evaluateBody(sexprs *Data, env *SymbolTableFrame) (result *Data, err error)
 	return
 }
 
+// (cond clause...) evaluates the body of the first clause whose test is
+// true. A clause with no body is the test's value, and in a (test => f)
+// clause f is called with it.
 func CondImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	var condition *Data
 	for c := args; NotNilP(c); c = Cdr(c) {
@@ -51,20 +58,46 @@ func CondImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 			return
 		}
 		if IsEqual(Car(clause), Intern("else")) {
-			return evaluateBody(Cdr(clause), env)
+			return evaluateBodyTail(Cdr(clause), env)
 		} else {
 			condition, err = Eval(Car(clause), env)
 			if err != nil {
 				return
 			}
-			if BooleanValue(condition) {
-				return evaluateBody(Cdr(clause), env)
+			condition = SingleValue(condition)
+			if !BooleanValue(condition) {
+				continue
 			}
+			if NilP(Cdr(clause)) {
+				return condition, nil
+			}
+			if Cadr(clause) == Intern("=>") {
+				return condArrow(clause, condition, env)
+			}
+			return evaluateBodyTail(Cdr(clause), env)
 		}
 	}
 	return
 }
 
+// condArrow calls the function in a (test => f) clause with the test's
+// value.
+func condArrow(clause *Data, condition *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if Length(clause) != 3 {
+		err = ProcessError(fmt.Sprintf("Cond expects one function after => but received %s", String(Cddr(clause))), env)
+		return
+	}
+	f, err := Eval(Caddr(clause), env)
+	if err != nil {
+		return
+	}
+	if !FunctionOrPrimitiveP(f) {
+		err = ProcessError(fmt.Sprintf("Cond expects a function after => but received %s", String(f)), env)
+		return
+	}
+	return ApplyWithoutEval(f, InternalMakeList(condition), env)
+}
+
 func CaseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	var keyValue *Data
 
@@ -76,19 +109,19 @@ func CaseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	for clauseCell := Cdr(args); NotNilP(clauseCell); clauseCell = Cdr(clauseCell) {
 		clause := Car(clauseCell)
 		if !PairP(clause) {
-			err = ProcessError("Case expectes a sequence of clauses that are lists", env)
+			err = ProcessError("Case expects a sequence of clauses that are lists", env)
 			return
 		}
 		if IsEqual(Car(clause), Intern("else")) {
-			return evaluateBody(Cdr(clause), env)
+			return evaluateBodyTail(Cdr(clause), env)
 		} else if ListP(Car(clause)) {
 			for v := Car(clause); NotNilP(v); v = Cdr(v) {
 				if IsEqual(Car(v), keyValue) {
-					return evaluateBody(Cdr(clause), env)
+					return evaluateBodyTail(Cdr(clause), env)
 				}
 			}
 		} else {
-			err = ProcessError("Case the condition part of clauses to be lists of 'else", env)
+			err = ProcessError("Case expects the key part of clauses to be lists or else", env)
 			return
 		}
 	}
@@ -102,27 +135,24 @@ func IfImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		return
 	}
 
-	if BooleanValue(c) {
-		return Eval(Second(args), env)
+	if BooleanValue(SingleValue(c)) {
+		return evalTail(Second(args), env)
 	} else {
-		return Eval(Third(args), env)
+		return evalTail(Third(args), env)
 	}
 }
 
+// (when test body...) is the value of the last body form if test is true
+// and nil otherwise, as is (when test) with no body. unless is the same for
+// a false test.
 func WhenImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	c, err := Eval(Car(args), env)
 	if err != nil {
 		return
 	}
 
-	if BooleanValue(c) {
-		for cell := Cdr(args); NotNilP(cell); cell = Cdr(cell) {
-			sexpr := Car(cell)
-			result, err = Eval(sexpr, env)
-			if err != nil {
-				return
-			}
-		}
+	if BooleanValue(SingleValue(c)) {
+		return evaluateBodyTail(Cdr(args), env)
 	}
 	return
 }
@@ -133,14 +163,97 @@ func UnlessImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		return
 	}
 
-	if !BooleanValue(c) {
-		for cell := Cdr(args); NotNilP(cell); cell = Cdr(cell) {
-			sexpr := Car(cell)
-			result, err = Eval(sexpr, env)
-			if err != nil {
-				return
-			}
+	if !BooleanValue(SingleValue(c)) {
+		return evaluateBodyTail(Cdr(args), env)
+	}
+	return
+}
+
+// evalAsserted evaluates the asserted expr. When expr is a call of a
+// function its arguments are evaluated one at a time so that those which
+// aren't literals can be reported, as "subform is value", should it fail.
+func evalAsserted(expr *Data, env *SymbolTableFrame) (result *Data, subforms []string, err error) {
+	if !PairP(expr) || NilP(expr) {
+		result, err = Eval(expr, env)
+		return
+	}
+	f, err := evalHelper(Car(expr), env, true)
+	if err != nil {
+		return
+	}
+	if !FunctionP(f) && !(PrimitiveP(f) && !PrimitiveValue(f).Special) {
+		result, err = Eval(expr, env)
+		return
+	}
+
+	values := make([]*Data, 0, Length(expr)-1)
+	for c := Cdr(expr); NotNilP(c); c = Cdr(c) {
+		var value *Data
+		value, err = Eval(Car(c), env)
+		if err != nil {
+			return
+		}
+		value = SingleValue(value)
+		values = append(values, value)
+		if PairP(Car(c)) || SymbolP(Car(c)) {
+			subforms = append(subforms, fmt.Sprintf("%s is %s", String(Car(c)), String(value)))
+		}
+	}
+	result, err = ApplyWithoutEval(f, ArrayToList(values), env)
+	return
+}
+
+// (assert expr [message]) is the value of expr if it is true. Otherwise it
+// is an error naming expr, as written, and the value of each of its
+// subforms, after message if one is given.
+func AssertImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	expr := Car(args)
+	result, subforms, err := evalAsserted(expr, env)
+	if err != nil {
+		return
+	}
+	if BooleanValue(SingleValue(result)) {
+		return
+	}
+
+	failure := fmt.Sprintf("Assertion %s failed", String(expr))
+	if len(subforms) > 0 {
+		failure = fmt.Sprintf("%s: %s", failure, strings.Join(subforms, ", "))
+	}
+	if Length(args) == 2 {
+		var message *Data
+		message, err = Eval(Cadr(args), env)
+		if err != nil {
+			return
+		}
+		if !StringP(message) {
+			err = ProcessError(fmt.Sprintf("assert expects its message to be a string but received %s.", String(message)), env)
+			return
 		}
+		failure = fmt.Sprintf("%s. %s", StringValue(message), failure)
+	}
+	return nil, ProcessError(failure+".", env)
+}
+
+// checkParameters makes sure params is a list of symbols, optionally ending
+// in a dotted rest parameter as in (a b . rest), which collects any
+// arguments after the required ones, or a parameter list with optional and
+// keyword parameters as described in lambda_list.go.
+func checkParameters(name string, params *Data, env *SymbolTableFrame) (err error) {
+	if extendedParamsP(params) {
+		if _, llErr := parseLambdaList(params); llErr != nil {
+			err = ProcessError(fmt.Sprintf("%s parameter list is malformed: %s.", name, llErr), env)
+		}
+		return
+	}
+	p := params
+	for ; PairP(p) && NotNilP(p); p = Cdr(p) {
+		if !SymbolP(Car(p)) {
+			return ProcessError(fmt.Sprintf("%s parameters must be symbols but received %s.", name, String(Car(p))), env)
+		}
+	}
+	if NotNilP(p) && !SymbolP(p) {
+		return ProcessError(fmt.Sprintf("%s rest parameter must be a symbol but received %s.", name, String(p)), env)
 	}
 	return
 }
@@ -151,6 +264,9 @@ func LambdaImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		return
 	}
 	params := Car(args)
+	if err = checkParameters("lambda", params, env); err != nil {
+		return
+	}
 	body := Cdr(args)
 	return FunctionWithNameParamsBodyAndParent("unnamed", params, body, env), nil
 }
@@ -166,6 +282,9 @@ func NamedLambdaImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 		return
 	}
 	params := Cdar(args)
+	if err = checkParameters("named-lambda", params, env); err != nil {
+		return
+	}
 	body := Cdr(args)
 	return FunctionWithNameParamsBodyAndParent(StringValue(name), params, body, env), nil
 }
@@ -178,6 +297,7 @@ func DefineImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		if err != nil {
 			return
 		}
+		value = SingleValue(value)
 	} else if PairP(thing) {
 		name := Car(thing)
 		params := Cdr(thing)
@@ -191,6 +311,9 @@ func DefineImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 			err = ProcessError(fmt.Sprintf("Primitive function %s can not be redefined.", StringValue(name)), env)
 			return
 		}
+		if err = checkParameters("define", params, env); err != nil {
+			return
+		}
 		body := Cdr(args)
 		value = FunctionWithNameParamsBodyAndParent(StringValue(name), params, body, env)
 	} else {
@@ -253,7 +376,7 @@ func bindLetLocals(bindingForms *Data, rec bool, localEnv *SymbolTableFrame, eva
 		if err != nil {
 			return
 		}
-		_, err = localEnv.BindLocallyTo(name, value)
+		_, err = localEnv.BindLocallyTo(name, SingleValue(value))
 		if err != nil {
 			return
 		}
@@ -267,10 +390,14 @@ func LetCommon(args *Data, env *SymbolTableFrame, star bool, rec bool) (result *
 		return
 	}
 
+	if star && !rec {
+		return letStar(args, env)
+	}
+
 	localEnv := NewSymbolTableFrameBelow(env, "let")
 	localEnv.Previous = env
 	var evalEnv *SymbolTableFrame
-	if star || rec {
+	if rec {
 		evalEnv = localEnv
 	} else {
 		evalEnv = env
@@ -280,15 +407,29 @@ func LetCommon(args *Data, env *SymbolTableFrame, star bool, rec bool) (result *
 		return
 	}
 
-	for cell := Cdr(args); NotNilP(cell); cell = Cdr(cell) {
-		sexpr := Car(cell)
-		result, err = Eval(sexpr, localEnv)
+	return evaluateBodyTail(Cdr(args), localEnv)
+}
+
+// letStar binds each of let*'s bindings in a frame of its own below the one
+// before, so a closure over an earlier binding keeps seeing it even when a
+// later binding reuses the name.
+func letStar(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	localEnv := env
+	for cell := Car(args); NotNilP(cell); cell = Cdr(cell) {
+		bindingEnv := NewSymbolTableFrameBelow(localEnv, "let")
+		bindingEnv.Previous = env
+		err = bindLetLocals(Cons(Car(cell), nil), false, bindingEnv, localEnv)
 		if err != nil {
 			return
 		}
+		localEnv = bindingEnv
+	}
+	if localEnv == env {
+		localEnv = NewSymbolTableFrameBelow(env, "let")
+		localEnv.Previous = env
 	}
 
-	return
+	return evaluateBodyTail(Cdr(args), localEnv)
 }
 
 func namedLetImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -347,15 +488,59 @@ func LetRecImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return LetCommon(args, env, false, true)
 }
 
+// (begin form...) evaluates the forms in order, each one's side effects done
+// before the next starts, and is the value of the last one, or nil when
+// there are none.
 func BeginImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	for cell := args; NotNilP(cell); cell = Cdr(cell) {
-		sexpr := Car(cell)
-		result, err = Eval(sexpr, env)
-		if err != nil {
+	return evaluateBodyTail(args, env)
+}
+
+// (unwind-protect protected cleanup...) evaluates protected and then the
+// cleanup forms, whether protected returned, raised an error or panicked.
+// The value is protected's; an error from protected wins over one from the
+// cleanup. Nested unwind-protects clean up innermost first.
+//
+// Abandoning or cancelling a process doesn't interrupt the form it is
+// running, so a cancelled body that notices (proc-cancelled? self) and
+// returns early still runs its cleanup on the way out.
+func UnwindProtectImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	defer func() {
+		_, cleanupErr := evaluateBody(Cdr(args), env)
+		if err == nil && cleanupErr != nil {
+			result, err = nil, cleanupErr
+		}
+	}()
+
+	return Eval(Car(args), env)
+}
+
+// (dynamic-wind before during after) calls the three thunks in order,
+// calling after whether during returned, raised an error or panicked, and
+// returns during's value. If before fails neither of the others is called.
+// As with unwind-protect, an error from during wins over one from after.
+// There are no continuations, so the extent can't be re-entered.
+func DynamicWindImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	for c := args; NotNilP(c); c = Cdr(c) {
+		if !FunctionOrPrimitiveP(Car(c)) {
+			err = ProcessError(fmt.Sprintf("dynamic-wind expects thunks but received %s.", String(Car(c))), env)
 			return
 		}
 	}
-	return
+	before, during, after := First(args), Second(args), Third(args)
+
+	_, err = ApplyWithoutEval(before, nil, env)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_, afterErr := ApplyWithoutEval(after, nil, env)
+		if err == nil && afterErr != nil {
+			result, err = nil, afterErr
+		}
+	}()
+
+	return ApplyWithoutEval(during, nil, env)
 }
 
 func rebindDoLocals(bindingForms *Data, env *SymbolTableFrame) (err error) {
@@ -419,53 +604,41 @@ func DoImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 		}
 
 		if BooleanValue(shouldExit) {
-			for cell := Cdr(testClause); NotNilP(cell); cell = Cdr(cell) {
-				sexpr := Car(cell)
-				result, err = Eval(sexpr, localEnv)
-				if err != nil {
-					return
-				}
-			}
-			return
+			// with no result expressions the value is nil, not the last body value
+			return evaluateBodyTail(Cdr(testClause), localEnv)
 		}
 
-		for cell := body; NotNilP(cell); cell = Cdr(cell) {
-			sexpr := Car(cell)
-			result, err = Eval(sexpr, localEnv)
-			if err != nil {
-				return
-			}
+		_, err = evaluateBody(body, localEnv)
+		if err != nil {
+			return
 		}
 
-		if rebindDoLocals(bindings, localEnv) != nil {
+		err = rebindDoLocals(bindings, localEnv)
+		if err != nil {
 			return
 		}
 	}
 	return
 }
 
+// (apply f arg... list) calls f with the args followed by the elements of
+// list, so (apply f 1 2 '(3 4)) is (f 1 2 3 4).
 func ApplyImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	f := Car(args)
 
 	if !FunctionOrPrimitiveP(f) {
-		err = ProcessError(fmt.Sprintf("apply requires a function as it's first argument, but got %s.", String(f)), env)
+		err = ProcessError(fmt.Sprintf("apply requires a function as its first argument, but got %s.", String(f)), env)
 		return
 	}
 
 	ary := ToArray(Cdr(args))
-	var argList *Data
-	if ListP(ary[len(ary)-1]) {
-		if len(ary) > 1 {
-			argList = ArrayToListWithTail(ary[0:len(ary)-1], ary[len(ary)-1])
-		} else {
-			argList = ary[0]
-		}
-	} else {
-		err = ProcessError("The last argument to apply must be a list", env)
+	last := ary[len(ary)-1]
+	if !ProperListP(last) {
+		err = ProcessError(fmt.Sprintf("apply requires a proper list as its last argument, but got %s.", String(last)), env)
 		return
 	}
 
-	return ApplyWithoutEval(f, argList, env)
+	return ApplyWithoutEval(f, ArrayToListWithTail(ary[0:len(ary)-1], last), env)
 }
 
 func ChainImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
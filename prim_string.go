@@ -10,8 +10,23 @@ package golisp
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"unicode/utf8"
+	"unsafe"
 )
 
+// runeCache keeps the characters of the string most recently indexed by
+// string-ref or substring. Indexing by character means decoding the string
+// from the start, which is O(n), so a loop over one string would otherwise
+// be O(n²). Comparing a string with the cached one is quick when it's the
+// same string, since the contents are only compared when they're stored in
+// different places.
+var runeCache = struct {
+	Str   string
+	Runes []rune
+	Mutex sync.Mutex
+}{}
+
 const (
 	TrimLeft  = iota
 	TrimBoth  = iota
@@ -19,7 +34,7 @@ const (
 )
 
 func RegisterStringPrimitives() {
-	MakePrimitiveFunction("string-split", "2", StringSplitImpl)
+	MakePrimitiveFunction("string-split", "2|3", StringSplitImpl)
 	MakePrimitiveFunction("string-join", "1|2", StringJoinImpl)
 	MakePrimitiveFunction("string-trim", "1|2", StringTrimImpl)
 	MakePrimitiveFunction("string-trim-left", "1|2", StringTrimLeftImpl)
@@ -30,7 +45,11 @@ func RegisterStringPrimitives() {
 	MakePrimitiveFunction("string-downcase!", "1", StringDowncaseBangImpl)
 	MakePrimitiveFunction("string-capitalize", "1", StringCapitalizeImpl)
 	MakePrimitiveFunction("string-capitalize!", "1", StringCapitalizeBangImpl)
+	MakePrimitiveFunction("string", "*", StringImpl)
+	MakePrimitiveFunction("string-ref", "2", StringRefImpl)
 	MakePrimitiveFunction("string-length", "1", StringLengthImpl)
+	MakePrimitiveFunction("string->bytes", "1", StringToBytesImpl)
+	MakePrimitiveFunction("bytes->string", "1", BytesToStringImpl)
 	MakePrimitiveFunction("string-null?", "1", StringNullImpl)
 	MakePrimitiveFunction("substring", "3", SubstringImpl)
 	MakePrimitiveFunction("substring?", "2", SubstringpImpl)
@@ -51,10 +70,13 @@ func RegisterStringPrimitives() {
 	MakePrimitiveFunction("parse", "1", ParseImpl)
 }
 
+// (string-split string separator [drop-empty]) splits string on each
+// occurrence of separator, or into single characters if separator is empty.
+// Empty fields are kept unless drop-empty is true.
 func StringSplitImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	theString := Car(args)
 	if !StringP(theString) {
-		err = ProcessError(fmt.Sprintf("trim requires a string but was given %s.", String(theString)), env)
+		err = ProcessError(fmt.Sprintf("string-split requires a string but was given %s.", String(theString)), env)
 		return
 	}
 
@@ -64,9 +86,14 @@ func StringSplitImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 		return
 	}
 
+	dropEmpty := Length(args) == 3 && BooleanValue(Caddr(args))
+
 	pieces := strings.Split(StringValue(theString), StringValue(theSeparator))
 	ary := make([]*Data, 0, len(pieces))
 	for _, p := range pieces {
+		if dropEmpty && p == "" {
+			continue
+		}
 		ary = append(ary, StringWithValue(p))
 	}
 	return ArrayToList(ary), nil
@@ -211,6 +238,57 @@ func StringCapitalizeBangImpl(args *Data, env *SymbolTableFrame) (result *Data,
 	return SetStringValue(theString, capitalize(StringValue(theString))), nil
 }
 
+// (string piece...) joins characters and strings into a new string.
+func StringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	var buffer strings.Builder
+	for c := args; NotNilP(c); c = Cdr(c) {
+		piece := Car(c)
+		if CharacterP(piece) {
+			buffer.WriteRune(CharacterValue(piece))
+		} else if StringP(piece) {
+			buffer.WriteString(StringValue(piece))
+		} else {
+			err = ProcessError(fmt.Sprintf("string requires characters or strings but was given %s.", String(piece)), env)
+			return
+		}
+	}
+	return StringWithValue(buffer.String()), nil
+}
+
+// (string-ref string k) is the character at index k, counting characters
+// rather than bytes.
+func StringRefImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	theString := Car(args)
+	if !StringP(theString) {
+		err = ProcessError(fmt.Sprintf("string-ref requires a string but was given %s.", String(theString)), env)
+		return
+	}
+	k := Cadr(args)
+	if !IntegerP(k) {
+		err = ProcessError(fmt.Sprintf("string-ref requires an integer index but was given %s.", String(k)), env)
+		return
+	}
+	runes := stringRunes(StringValue(theString))
+	i := IntegerValue(k)
+	if i < 0 || i >= int64(len(runes)) {
+		err = ProcessError(fmt.Sprintf("string-ref index %d is out of range for %s.", i, String(theString)), env)
+		return
+	}
+	return CharacterWithValue(runes[i]), nil
+}
+
+// stringRunes is the characters of str. The slice may be shared, so callers
+// must not modify it.
+func stringRunes(str string) []rune {
+	runeCache.Mutex.Lock()
+	defer runeCache.Mutex.Unlock()
+	if str != runeCache.Str || runeCache.Runes == nil {
+		runeCache.Str = str
+		runeCache.Runes = []rune(str)
+	}
+	return runeCache.Runes
+}
+
 func StringLengthImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	theString := Car(args)
 
@@ -218,7 +296,7 @@ func StringLengthImpl(args *Data, env *SymbolTableFrame) (result *Data, err erro
 		err = ProcessError(fmt.Sprintf("string-length requires a string but was given %s.", String(theString)), env)
 		return
 	}
-	return IntegerWithValue(int64(len(StringValue(theString)))), nil
+	return IntegerWithValue(int64(utf8.RuneCountInString(StringValue(theString)))), nil
 }
 
 func StringNullImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -237,7 +315,7 @@ func SubstringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 		err = ProcessError(fmt.Sprintf("substring requires a string but was given %s.", String(theString)), env)
 		return
 	}
-	stringValue := StringValue(theString)
+	runes := stringRunes(StringValue(theString))
 
 	startObj := Cadr(args)
 	if !IntegerP(startObj) {
@@ -245,7 +323,7 @@ func SubstringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 		return
 	}
 	startValue := int(IntegerValue(startObj))
-	if startValue > len(stringValue) {
+	if startValue < 0 || startValue > len(runes) {
 		err = ProcessError(fmt.Sprintf("substring requires start < length of the string."), env)
 		return
 	}
@@ -256,7 +334,7 @@ func SubstringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 		return
 	}
 	endValue := int(IntegerValue(endObj))
-	if endValue > len(stringValue) {
+	if endValue > len(runes) {
 		err = ProcessError(fmt.Sprintf("substring requires end < length of the string."), env)
 		return
 	}
@@ -266,7 +344,29 @@ func SubstringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error)
 		return
 	}
 
-	return StringWithValue(stringValue[startValue:endValue]), nil
+	return StringWithValue(string(runes[startValue:endValue])), nil
+}
+
+// (string->bytes string) is the UTF-8 encoding of string as a bytearray.
+func StringToBytesImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	theString := Car(args)
+	if !StringP(theString) {
+		err = ProcessError(fmt.Sprintf("string->bytes requires a string but was given %s.", String(theString)), env)
+		return
+	}
+	bytes := []byte(StringValue(theString))
+	return ObjectWithTypeAndValue("[]byte", unsafe.Pointer(&bytes)), nil
+}
+
+// (bytes->string bytearray) decodes bytearray as UTF-8. Bytes that aren't
+// valid UTF-8 are kept, and each counts as one character.
+func BytesToStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	bytes := Car(args)
+	if !ObjectP(bytes) || ObjectType(bytes) != "[]byte" {
+		err = ProcessError(fmt.Sprintf("bytes->string requires a bytearray but was given %s.", String(bytes)), env)
+		return
+	}
+	return StringWithValue(string(*(*[]byte)(ObjectValue(bytes)))), nil
 }
 
 func SubstringpImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
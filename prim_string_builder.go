@@ -0,0 +1,93 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the string builder primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// StringBuilder accumulates a string without copying it on every append.
+// The mutex lets processes share one.
+type StringBuilder struct {
+	Builder strings.Builder
+	Mutex   sync.Mutex
+}
+
+func RegisterStringBuilderPrimitives() {
+	MakePrimitiveFunction("make-string-builder", "0", MakeStringBuilderImpl)
+	MakePrimitiveFunction("string-builder?", "1", StringBuilderPImpl)
+	MakePrimitiveFunction("sb-append!", ">=1", StringBuilderAppendImpl)
+	MakePrimitiveFunction("sb-length", "1", StringBuilderLengthImpl)
+	MakePrimitiveFunction("sb->string", "1", StringBuilderToStringImpl)
+}
+
+func StringBuilderP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "StringBuilder"
+}
+
+func StringBuilderValue(d *Data) *StringBuilder {
+	return (*StringBuilder)(ObjectValue(d))
+}
+
+func stringBuilderArg(name string, args *Data, env *SymbolTableFrame) (sb *StringBuilder, err error) {
+	b := Car(args)
+	if !StringBuilderP(b) {
+		err = ProcessError(fmt.Sprintf("%s expects a string builder but received %s.", name, String(b)), env)
+		return
+	}
+	return StringBuilderValue(b), nil
+}
+
+func MakeStringBuilderImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return ObjectWithTypeAndValue("StringBuilder", unsafe.Pointer(&StringBuilder{})), nil
+}
+
+func StringBuilderPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(StringBuilderP(Car(args))), nil
+}
+
+// (sb-append! builder value...) appends each value the way display would
+// write it, and returns the builder.
+func StringBuilderAppendImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sb, err := stringBuilderArg("sb-append!", args, env)
+	if err != nil {
+		return
+	}
+	sb.Mutex.Lock()
+	defer sb.Mutex.Unlock()
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		sb.Builder.WriteString(DisplayStringInEnv(Car(c), env))
+	}
+	return Car(args), nil
+}
+
+// (sb-length builder) is the number of characters appended so far, as
+// string-length would count them.
+func StringBuilderLengthImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sb, err := stringBuilderArg("sb-length", args, env)
+	if err != nil {
+		return
+	}
+	sb.Mutex.Lock()
+	defer sb.Mutex.Unlock()
+	return IntegerWithValue(int64(utf8.RuneCountInString(sb.Builder.String()))), nil
+}
+
+func StringBuilderToStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sb, err := stringBuilderArg("sb->string", args, env)
+	if err != nil {
+		return
+	}
+	sb.Mutex.Lock()
+	defer sb.Mutex.Unlock()
+	return StringWithValue(sb.Builder.String()), nil
+}
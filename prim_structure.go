@@ -0,0 +1,172 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the structure (record type) primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// StructureType is a type declared by define-structure. Every
+// define-structure makes a new one, even for a name used before.
+type StructureType struct {
+	Name   string
+	Fields []string
+}
+
+// Structure is an instance of a structure type, its values in the order of
+// the type's fields. The mutex lets processes share one.
+type Structure struct {
+	Type   *StructureType
+	Values []*Data
+	Mutex  sync.RWMutex
+}
+
+func RegisterStructurePrimitives() {
+	MakeSpecialForm("define-structure", ">=1", DefineStructureImpl)
+	MakePrimitiveFunction("structure?", "1", StructurePImpl)
+}
+
+func StructureP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "Structure"
+}
+
+func StructureValue(d *Data) *Structure {
+	return (*Structure)(ObjectValue(d))
+}
+
+// snapshot copies the values so that they can be read without the lock.
+func (self *Structure) snapshot() []*Data {
+	self.Mutex.RLock()
+	defer self.Mutex.RUnlock()
+	return append([]*Data{}, self.Values...)
+}
+
+// structuresEqual is whether a and b are of the same type and have equal?
+// values in every field.
+func structuresEqual(a *Structure, b *Structure, visited map[eqvPair]bool) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	aValues, bValues := a.snapshot(), b.snapshot()
+	for i := range aValues {
+		if !isEqual(aValues[i], bValues[i], visited) {
+			return false
+		}
+	}
+	return true
+}
+
+// structureString writes s as #[name field: value ...].
+func structureString(s *Structure, write func(*Data) string) string {
+	values := s.snapshot()
+	contents := make([]string, 0, len(values)+1)
+	contents = append(contents, s.Type.Name)
+	for i, field := range s.Type.Fields {
+		contents = append(contents, fmt.Sprintf("%s: %s", field, write(values[i])))
+	}
+	return fmt.Sprintf("#[%s]", strings.Join(contents, " "))
+}
+
+func bindStructureFunction(name string, argCount string, body func(*Data, *SymbolTableFrame) (*Data, error), env *SymbolTableFrame) (err error) {
+	f := &PrimitiveFunction{Name: name, Body: body}
+	f.parseNumArgs(argCount)
+	_, err = env.BindLocallyTo(Intern(name), PrimitiveWithNameAndFunc(name, f))
+	return
+}
+
+// structureArg is the first of args if it is an instance of t.
+func structureArg(name string, t *StructureType, args *Data, env *SymbolTableFrame) (s *Structure, err error) {
+	d := Car(args)
+	if !StructureP(d) || StructureValue(d).Type != t {
+		err = ProcessError(fmt.Sprintf("%s expects a %s but received %s.", name, t.Name, String(d)), env)
+		return
+	}
+	return StructureValue(d), nil
+}
+
+// (define-structure name field...) declares a structure type and defines
+// make-name, which takes a value for each field in order, name?, and for
+// each field a name-field accessor and a set-name-field! setter.
+func DefineStructureImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	name := Car(args)
+	if !SymbolP(name) {
+		err = ProcessError(fmt.Sprintf("define-structure expects a symbol name but received %s.", String(name)), env)
+		return
+	}
+
+	t := &StructureType{Name: StringValue(name)}
+	for c := Cdr(args); NotNilP(c); c = Cdr(c) {
+		if !SymbolP(Car(c)) {
+			err = ProcessError(fmt.Sprintf("define-structure expects symbol field names but received %s.", String(Car(c))), env)
+			return
+		}
+		field := StringValue(Car(c))
+		for _, existing := range t.Fields {
+			if existing == field {
+				err = ProcessError(fmt.Sprintf("define-structure was given the field %s more than once.", field), env)
+				return
+			}
+		}
+		t.Fields = append(t.Fields, field)
+	}
+
+	err = bindStructureFunction(fmt.Sprintf("make-%s", t.Name), fmt.Sprintf("%d", len(t.Fields)), func(args *Data, env *SymbolTableFrame) (*Data, error) {
+		s := &Structure{Type: t, Values: ToArray(args)}
+		return ObjectWithTypeAndValue("Structure", unsafe.Pointer(s)), nil
+	}, env)
+	if err != nil {
+		return
+	}
+
+	err = bindStructureFunction(fmt.Sprintf("%s?", t.Name), "1", func(args *Data, env *SymbolTableFrame) (*Data, error) {
+		return BooleanWithValue(StructureP(Car(args)) && StructureValue(Car(args)).Type == t), nil
+	}, env)
+	if err != nil {
+		return
+	}
+
+	for i, field := range t.Fields {
+		i := i
+		accessorName := fmt.Sprintf("%s-%s", t.Name, field)
+		err = bindStructureFunction(accessorName, "1", func(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+			s, err := structureArg(accessorName, t, args, env)
+			if err != nil {
+				return
+			}
+			s.Mutex.RLock()
+			defer s.Mutex.RUnlock()
+			return s.Values[i], nil
+		}, env)
+		if err != nil {
+			return
+		}
+
+		setterName := fmt.Sprintf("set-%s-%s!", t.Name, field)
+		err = bindStructureFunction(setterName, "2", func(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+			s, err := structureArg(setterName, t, args, env)
+			if err != nil {
+				return
+			}
+			s.Mutex.Lock()
+			defer s.Mutex.Unlock()
+			s.Values[i] = Cadr(args)
+			return Cadr(args), nil
+		}, env)
+		if err != nil {
+			return
+		}
+	}
+	return name, nil
+}
+
+func StructurePImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(StructureP(Car(args))), nil
+}
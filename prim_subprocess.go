@@ -0,0 +1,148 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the subprocess primitive functions.
+//
+// A subprocess is tied to the lisp process that runs it: cancelling that
+// process, with proc-cancel or by with-timeout giving up on it, kills the
+// subprocess too.
+
+package golisp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"unsafe"
+)
+
+func RegisterSubprocessPrimitives() {
+	MakeRestrictedPrimitiveFunction("run-process", "2|3", RunProcessImpl)
+	MakeRestrictedPrimitiveFunction("start-process", "2|3|4", StartProcessImpl)
+}
+
+// currentProcessContext is the context of the lisp process env is running
+// in, or the background context outside of one.
+func currentProcessContext(env *SymbolTableFrame) context.Context {
+	procObj := env.ValueOf(Intern("parentProcess"))
+	if ObjectP(procObj) && ObjectType(procObj) == "Process" {
+		return (*Process)(ObjectValue(procObj)).ctx
+	}
+	return context.Background()
+}
+
+// subprocessCommand makes the command described by args: a command, a list
+// of its arguments and, optionally, a string to give it as its input.
+func subprocessCommand(name string, ctx context.Context, args *Data, env *SymbolTableFrame) (cmd *exec.Cmd, err error) {
+	command := First(args)
+	if !StringP(command) {
+		err = ProcessError(fmt.Sprintf("%s expects a string command but received %s.", name, String(command)), env)
+		return
+	}
+
+	argList := Second(args)
+	if !ListP(argList) {
+		err = ProcessError(fmt.Sprintf("%s expects a list of arguments but received %s.", name, String(argList)), env)
+		return
+	}
+	cmdArgs := make([]string, 0, Length(argList))
+	for c := argList; NotNilP(c); c = Cdr(c) {
+		if StringP(Car(c)) || SymbolP(Car(c)) {
+			cmdArgs = append(cmdArgs, StringValue(Car(c)))
+		} else {
+			cmdArgs = append(cmdArgs, String(Car(c)))
+		}
+	}
+
+	cmd = exec.CommandContext(ctx, StringValue(command), cmdArgs...)
+	if Length(args) == 3 {
+		input := Third(args)
+		if !StringP(input) {
+			err = ProcessError(fmt.Sprintf("%s expects a string as input but received %s.", name, String(input)), env)
+			return
+		}
+		cmd.Stdin = strings.NewReader(StringValue(input))
+	}
+	return
+}
+
+// runSubprocess runs cmd to completion, returning its exit code, output and
+// error output as three values. Exiting with a nonzero code isn't an error;
+// not being able to start, or being killed by ctx, is.
+func runSubprocess(name string, ctx context.Context, cmd *exec.Cmd) (result *Data, err error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s: %s was cancelled.", name, cmd.Args[0])
+	}
+	if _, exited := runErr.(*exec.ExitError); runErr != nil && !exited {
+		return nil, fmt.Errorf("%s can't run %s: %s.", name, cmd.Args[0], runErr)
+	}
+	return ValuesWithArray([]*Data{
+		IntegerWithValue(int64(cmd.ProcessState.ExitCode())),
+		StringWithValue(stdout.String()),
+		StringWithValue(stderr.String())}), nil
+}
+
+// (run-process command args [input]) runs command with args, a list of
+// strings, giving it input as its standard input, and waits for it to
+// finish. It returns the values exit-code, output and error-output.
+func RunProcessImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ctx := currentProcessContext(env)
+	cmd, err := subprocessCommand("run-process", ctx, args, env)
+	if err != nil {
+		return
+	}
+	result, err = runSubprocess("run-process", ctx, cmd)
+	if err != nil {
+		err = ProcessError(err.Error(), env)
+	}
+	return
+}
+
+// (start-process [parent] command args [input]) is run-process that doesn't
+// wait: it returns a Process that proc-join, proc-status and the rest work
+// on, proc-join returning run-process's values. Cancelling the Process, or
+// the parent Process if one is given, kills the subprocess.
+func StartProcessImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	parentCtx := currentProcessContext(env)
+	if ObjectP(Car(args)) && ObjectType(Car(args)) == "Process" {
+		parentCtx = (*Process)(ObjectValue(Car(args))).ctx
+		args = Cdr(args)
+	}
+	if Length(args) < 2 || Length(args) > 3 {
+		err = ProcessError("start-process expects a command, a list of arguments and optionally input.", env)
+		return
+	}
+
+	proc := &Process{
+		Env:         env,
+		Wake:        make(chan empty, 1),
+		Abort:       make(chan empty, 1),
+		Restart:     make(chan empty, 1),
+		ReturnValue: make(chan *Data, 1),
+		Done:        make(chan *Data),
+		Status:      ProcessRunning}
+	proc.ctx, proc.cancel = context.WithCancel(parentCtx)
+
+	cmd, err := subprocessCommand("start-process", proc.ctx, args, env)
+	if err != nil {
+		proc.cancel()
+		return
+	}
+
+	go func() {
+		defer proc.cancel()
+		returnValue, runErr := runSubprocess("start-process", proc.ctx, cmd)
+		proc.finish(returnValue, runErr)
+	}()
+
+	return ObjectWithTypeAndValue("Process", unsafe.Pointer(proc)), nil
+}
@@ -12,46 +12,179 @@ import (
 	"math/rand"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 )
 
-var symbolCounts map[string]int = make(map[string]int)
-var symbolCountsMutex sync.Mutex
+// commandLineArgs are what command-line-args returns. They start out as the
+// program's own arguments; a program embedding the interpreter can give its
+// scripts others with SetCommandLineArgs.
+var commandLineArgs = struct {
+	Args  []string
+	Mutex sync.RWMutex
+}{Args: os.Args}
+
+// SetCommandLineArgs sets the arguments command-line-args returns.
+func SetCommandLineArgs(args []string) {
+	commandLineArgs.Mutex.Lock()
+	defer commandLineArgs.Mutex.Unlock()
+	commandLineArgs.Args = append([]string{}, args...)
+}
+
+// gensymCounter is shared by every prefix so generated names are unique for
+// the life of the interpreter.
+var gensymCounter int64
+
+// ErrorObject is an error captured as lisp data, so it can be handed to lisp
+// code and inspected rather than just printed. Kind is a symbol naming what
+// sort of error it was.
+type ErrorObject struct {
+	Kind    *Data
+	Message string
+}
+
+func ErrorObjectWithKindAndMessage(kind string, message string) *Data {
+	return ObjectWithTypeAndValue("Error", unsafe.Pointer(&ErrorObject{Kind: Intern(kind), Message: message}))
+}
+
+func ErrorObjectP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "Error"
+}
 
 func RegisterSystemPrimitives() {
 	MakePrimitiveFunction("sleep", "1", SleepImpl)
 	MakePrimitiveFunction("millis", "0", MillisImpl)
+	MakePrimitiveFunction("current-milliseconds", "0", MillisImpl)
+	MakePrimitiveFunction("current-time", "0", CurrentTimeImpl)
+	MakePrimitiveFunction("time-format", "2", TimeFormatImpl)
+	MakePrimitiveFunction("time-parse", "2", TimeParseImpl)
 	MakePrimitiveFunction("write-line", "*", WriteLineImpl)
 	MakePrimitiveFunction("write-log", "*", WriteLogImpl)
 	MakePrimitiveFunction("str", "*", MakeStringImpl)
 	MakePrimitiveFunction("intern", "1", InternImpl)
+	MakePrimitiveFunction("string->symbol", "1", StringToSymbolImpl)
+	MakePrimitiveFunction("string->uninterned-symbol", "1", StringToUninternedSymbolImpl)
+	MakePrimitiveFunction("symbol->string", "1", SymbolToStringImpl)
 	MakePrimitiveFunction("quit", "0", QuitImpl)
 	MakePrimitiveFunction("gensym", "0|1", GensymImpl)
 	MakePrimitiveFunction("gensym-naked", "0|1", GensymNakedImpl)
 	MakePrimitiveFunction("eval", "1|2", EvalImpl)
 
-	MakeRestrictedPrimitiveFunction("load", "1", LoadFileImpl)
+	Global.BindTo(Intern("*load-path*"), InternalMakeList(StringWithValue(".")))
+	MakeRestrictedPrimitiveFunction("load", "1|2", LoadFileImpl)
+	MakeRestrictedPrimitiveFunction("require", "1|2", RequireImpl)
 	MakeRestrictedPrimitiveFunction("global-eval", "1", GlobalEvalImpl)
 	MakeRestrictedPrimitiveFunction("panic!", "1", PanicImpl)
 	MakePrimitiveFunction("error", "1", ErrorImpl)
 	MakeSpecialForm("on-error", "2|3", OnErrorImpl)
+	MakePrimitiveFunction("error-object?", "1", ErrorObjectPImpl)
+	MakePrimitiveFunction("error-object-message", "1", ErrorObjectMessageImpl)
+	MakePrimitiveFunction("error-object-type", "1", ErrorObjectTypeImpl)
 
 	MakeSpecialForm("time", "1", TimeImpl)
 	MakeSpecialForm("profile", "1|2", ProfileImpl)
 
 	MakeRestrictedPrimitiveFunction("exec", ">=1", ExecImpl)
+	MakeRestrictedPrimitiveFunction("getenv", "1", GetenvImpl)
+	MakeRestrictedPrimitiveFunction("setenv", "2", SetenvImpl)
+	MakePrimitiveFunction("command-line-args", "0", CommandLineArgsImpl)
 }
 
-func LoadFileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+// loadedFiles are the absolute paths of the files load and require have
+// loaded, or are loading.
+var loadedFiles = struct {
+	Files map[string]bool
+	Mutex sync.Mutex
+}{Files: make(map[string]bool)}
+
+// findLoadFile finds the file name refers to: name itself if it's absolute,
+// otherwise the first file called name in a directory of *load-path*. For
+// require, name can leave off the .lsp extension.
+func findLoadFile(primitive string, name string, env *SymbolTableFrame) (path string, err error) {
+	candidates := []string{name}
+	if primitive == "require" && filepath.Ext(name) == "" {
+		candidates = append(candidates, name+".lsp")
+	}
+	for _, candidate := range candidates {
+		if filepath.IsAbs(candidate) {
+			if _, statErr := os.Stat(candidate); statErr == nil {
+				return filepath.Clean(candidate), nil
+			}
+			continue
+		}
+		for dirs := Global.ValueOf(Intern("*load-path*")); NotNilP(dirs); dirs = Cdr(dirs) {
+			if !StringP(Car(dirs)) {
+				continue
+			}
+			path = filepath.Join(StringValue(Car(dirs)), candidate)
+			if _, statErr := os.Stat(path); statErr == nil {
+				return filepath.Abs(path)
+			}
+		}
+	}
+	err = ProcessError(fmt.Sprintf("%s can't find %s in the load path %s.", primitive, name, String(Global.ValueOf(Intern("*load-path*")))), env)
+	return
+}
+
+func loadArgs(primitive string, args *Data, env *SymbolTableFrame) (path string, loadEnv *SymbolTableFrame, err error) {
 	filename := Car(args)
 	if !StringP(filename) {
-		err = ProcessError("Filename must be a string", env)
+		err = ProcessError(fmt.Sprintf("%s expects a filename string but received %s.", primitive, String(filename)), env)
 		return
 	}
+	loadEnv = Global
+	if Length(args) == 2 {
+		if !EnvironmentP(Cadr(args)) {
+			err = ProcessError(fmt.Sprintf("%s expects an environment but received %s.", primitive, String(Cadr(args))), env)
+			return
+		}
+		loadEnv = EnvironmentValue(Cadr(args))
+	}
+	path, err = findLoadFile(primitive, StringValue(filename), env)
+	return
+}
 
-	return ProcessFile(StringValue(filename))
+// (load filename [environment]) evaluates the forms in a file, in the
+// global environment or the one given, returning the value of the last.
+// A relative filename is looked for in the directories of *load-path*.
+func LoadFileImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	path, loadEnv, err := loadArgs("load", args, env)
+	if err != nil {
+		return
+	}
+	loadedFiles.Mutex.Lock()
+	loadedFiles.Files[path] = true
+	loadedFiles.Mutex.Unlock()
+	return ProcessFileInEnvironment(path, loadEnv)
+}
+
+// (require filename [environment]) loads a file unless load or require has
+// already loaded it, returning whether it did.
+func RequireImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	path, loadEnv, err := loadArgs("require", args, env)
+	if err != nil {
+		return
+	}
+	loadedFiles.Mutex.Lock()
+	loaded := loadedFiles.Files[path]
+	loadedFiles.Files[path] = true
+	loadedFiles.Mutex.Unlock()
+	if loaded {
+		return LispFalse, nil
+	}
+
+	_, err = ProcessFileInEnvironment(path, loadEnv)
+	if err != nil {
+		loadedFiles.Mutex.Lock()
+		delete(loadedFiles.Files, path)
+		loadedFiles.Mutex.Unlock()
+		return
+	}
+	return LispTrue, nil
 }
 
 var goodbyes []string = []string{
@@ -75,6 +208,9 @@ func PanicImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 }
 
 func ErrorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if ConditionP(Car(args)) {
+		return nil, &ConditionError{Condition: Car(args)}
+	}
 	return nil, ProcessError(String(Car(args)), env)
 }
 
@@ -111,9 +247,29 @@ func OnErrorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return handler.Apply(InternalMakeList(errString), env)
 }
 
+func ErrorObjectPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(ErrorObjectP(Car(args))), nil
+}
+
+func ErrorObjectMessageImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if !ErrorObjectP(Car(args)) {
+		err = ProcessError(fmt.Sprintf("error-object-message expects an Error object but received %s.", String(Car(args))), env)
+		return
+	}
+	return StringWithValue((*ErrorObject)(ObjectValue(Car(args))).Message), nil
+}
+
+func ErrorObjectTypeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if !ErrorObjectP(Car(args)) {
+		err = ProcessError(fmt.Sprintf("error-object-type expects an Error object but received %s.", String(Car(args))), env)
+		return
+	}
+	return (*ErrorObject)(ObjectValue(Car(args))).Kind, nil
+}
+
 func QuitImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	if IsInteractive || DebugEvalInDebugRepl {
-		WriteHistoryToFile(".golisp_history")
+		WriteHistoryToFile(historyFile())
 		rand.Seed(time.Now().Unix())
 		LogPrintf("\n\n%s\n\n", goodbyes[rand.Intn(len(goodbyes))])
 		os.Exit(0)
@@ -137,44 +293,92 @@ func MillisImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return
 }
 
-func concatStringForms(args *Data) (str string) {
+// Times are integer nanoseconds since the Unix epoch, so they can be
+// compared and subtracted like any other number. Layouts are Go's, written
+// in terms of Mon Jan 2 15:04:05 MST 2006.
+
+func CurrentTimeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return IntegerWithValue(time.Now().UnixNano()), nil
+}
+
+// (time-format time layout) writes time, in the local time zone, as layout
+// shows.
+func TimeFormatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	t := First(args)
+	if !IntegerP(t) {
+		err = ProcessError(fmt.Sprintf("time-format expects an integer time but received %s.", String(t)), env)
+		return
+	}
+	layout := Second(args)
+	if !StringP(layout) {
+		err = ProcessError(fmt.Sprintf("time-format expects a string layout but received %s.", String(layout)), env)
+		return
+	}
+	return StringWithValue(time.Unix(0, IntegerValue(t)).Format(StringValue(layout))), nil
+}
+
+// (time-parse string layout) is the time string shows when read as layout
+// shows. A string without a time zone is taken to be UTC.
+func TimeParseImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	str := First(args)
+	if !StringP(str) {
+		err = ProcessError(fmt.Sprintf("time-parse expects a string but received %s.", String(str)), env)
+		return
+	}
+	layout := Second(args)
+	if !StringP(layout) {
+		err = ProcessError(fmt.Sprintf("time-parse expects a string layout but received %s.", String(layout)), env)
+		return
+	}
+	t, parseErr := time.Parse(StringValue(layout), StringValue(str))
+	if parseErr != nil {
+		err = ProcessError(fmt.Sprintf("time-parse can't parse %s as %s.", String(str), String(layout)), env)
+		return
+	}
+	return IntegerWithValue(t.UnixNano()), nil
+}
+
+func concatStringForms(args *Data, env *SymbolTableFrame) (str string) {
 	if NilP(args) || Length(args) == 0 {
 		return "()"
 	}
 	pieces := make([]string, 2)
 	for cell := args; NotNilP(cell); cell = Cdr(cell) {
-		pieces = append(pieces, PrintString(Car(cell)))
+		pieces = append(pieces, PrintStringInEnv(Car(cell), env))
 	}
 	return strings.Join(pieces, "")
 }
 
 func WriteLineImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	println(concatStringForms(args))
+	println(concatStringForms(args, env))
 	return
 }
 
 func WriteLogImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	LogPrintf("%s\r\n", concatStringForms(args))
+	LogPrintf("%s\r\n", concatStringForms(args, env))
 	return
 }
 
 func MakeStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	return StringWithValue(concatStringForms(args)), nil
+	return StringWithValue(concatStringForms(args, env)), nil
 }
 
+// (time expr) evaluates expr and returns two values: the milliseconds it
+// took and its value. Where one value is expected that is just the time.
 func TimeImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	startTime := time.Now()
 
+	var value *Data
 	for cell := args; NotNilP(cell); cell = Cdr(cell) {
 		sexpr := Car(cell)
-		result, err = Eval(sexpr, env)
+		value, err = Eval(sexpr, env)
 		if err != nil {
 			break
 		}
 	}
 
 	d := time.Since(startTime)
-	result = IntegerWithValue(int64(d.Nanoseconds() / 1000000))
+	result = ValuesWithArray([]*Data{IntegerWithValue(int64(d.Nanoseconds() / 1000000)), SingleValue(value)})
 	return
 }
 
@@ -188,7 +392,39 @@ func InternImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	return Intern(StringValue(sym)), nil
 }
 
-func gensymHelper(primitiveName string, args *Data, env *SymbolTableFrame) (prefix string, count int, err error) {
+func StringToSymbolImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	str := Car(args)
+	if !StringP(str) {
+		err = ProcessError(fmt.Sprintf("string->symbol expects a string, but received %s.", String(str)), env)
+		return
+	}
+
+	return Intern(StringValue(str)), nil
+}
+
+// (string->uninterned-symbol "name") makes a symbol that isn't eq? to any
+// other, even one with the same name.
+func StringToUninternedSymbolImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	str := Car(args)
+	if !StringP(str) {
+		err = ProcessError(fmt.Sprintf("string->uninterned-symbol expects a string, but received %s.", String(str)), env)
+		return
+	}
+
+	return UninternedSymbolWithName(StringValue(str)), nil
+}
+
+func SymbolToStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sym := Car(args)
+	if !SymbolP(sym) {
+		err = ProcessError(fmt.Sprintf("symbol->string expects a symbol, but received %s.", String(sym)), env)
+		return
+	}
+
+	return StringWithValue(StringValue(sym)), nil
+}
+
+func gensymHelper(primitiveName string, args *Data, env *SymbolTableFrame) (prefix string, count int64, err error) {
 	if Length(args) > 1 {
 		err = ProcessError(fmt.Sprintf("%s expects 0 or 1 argument, but received %d.", primitiveName, Length(args)), env)
 		return
@@ -205,12 +441,7 @@ func gensymHelper(primitiveName string, args *Data, env *SymbolTableFrame) (pref
 		prefix = StringValue(arg)
 	}
 
-	symbolCountsMutex.Lock()
-
-	count = symbolCounts[prefix]
-	symbolCounts[prefix] = count + 1
-
-	symbolCountsMutex.Unlock()
+	count = atomic.AddInt64(&gensymCounter, 1)
 	return
 }
 
@@ -219,7 +450,8 @@ func GensymImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	if err != nil {
 		return
 	}
-	result = SymbolWithName(fmt.Sprintf("%s-%d", prefix, count))
+	// The reader rejects #:, so no symbol read from source can share the name.
+	result = UninternedSymbolWithName(fmt.Sprintf("#:%s-%d", prefix, count))
 	return
 }
 
@@ -294,3 +526,50 @@ func ExecImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	err = cmd.Start()
 	return
 }
+
+// (getenv name) is the value of the environment variable name, or #f if it
+// isn't set.
+func GetenvImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	name := First(args)
+	if !StringP(name) {
+		err = ProcessError(fmt.Sprintf("getenv expects a string name but received %s.", String(name)), env)
+		return
+	}
+	value, found := os.LookupEnv(StringValue(name))
+	if !found {
+		return LispFalse, nil
+	}
+	return StringWithValue(value), nil
+}
+
+// (setenv name value) sets the environment variable name, for this process
+// and those it starts, and returns value.
+func SetenvImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	name := First(args)
+	if !StringP(name) {
+		err = ProcessError(fmt.Sprintf("setenv expects a string name but received %s.", String(name)), env)
+		return
+	}
+	value := Second(args)
+	if !StringP(value) {
+		err = ProcessError(fmt.Sprintf("setenv expects a string value but received %s.", String(value)), env)
+		return
+	}
+	if setErr := os.Setenv(StringValue(name), StringValue(value)); setErr != nil {
+		err = ProcessError(fmt.Sprintf("setenv can't set %s: %s.", StringValue(name), setErr), env)
+		return
+	}
+	return value, nil
+}
+
+// (command-line-args) is the program's arguments as a list of strings, the
+// program's name first.
+func CommandLineArgsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	commandLineArgs.Mutex.RLock()
+	defer commandLineArgs.Mutex.RUnlock()
+	strs := make([]*Data, 0, len(commandLineArgs.Args))
+	for _, arg := range commandLineArgs.Args {
+		strs = append(strs, StringWithValue(arg))
+	}
+	return ArrayToList(strs), nil
+}
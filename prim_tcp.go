@@ -0,0 +1,167 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the tcp socket primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+// portConnections holds the connection behind each port made by tcp-connect
+// or tcp-accept. The port reads and writes a copy of the connection's file
+// descriptor, so close-port closes both.
+var portConnections = struct {
+	Conns map[*os.File]net.Conn
+	Mutex sync.Mutex
+}{Conns: make(map[*os.File]net.Conn)}
+
+func RegisterTcpPrimitives() {
+	MakeRestrictedPrimitiveFunction("tcp-connect", "2", TcpConnectImpl)
+	MakeRestrictedPrimitiveFunction("tcp-listen", "1|2", TcpListenImpl)
+	MakePrimitiveFunction("tcp-accept", "1|2", TcpAcceptImpl)
+	MakePrimitiveFunction("tcp-listener?", "1", TcpListenerPImpl)
+	MakePrimitiveFunction("tcp-listener-port", "1", TcpListenerPortImpl)
+}
+
+func TcpListenerP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "TcpListener"
+}
+
+// connectionPort makes a port that reads and writes conn.
+func connectionPort(conn *net.TCPConn) (port *Data, err error) {
+	f, err := conn.File()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	portConnections.Mutex.Lock()
+	portConnections.Conns[f] = conn
+	portConnections.Mutex.Unlock()
+	return PortWithValue(f), nil
+}
+
+// closeConnection closes the connection behind f, if there is one.
+func closeConnection(f *os.File) {
+	portConnections.Mutex.Lock()
+	conn, ok := portConnections.Conns[f]
+	delete(portConnections.Conns, f)
+	portConnections.Mutex.Unlock()
+	if ok {
+		conn.Close()
+	}
+}
+
+func tcpPortArg(name string, d *Data, env *SymbolTableFrame) (port int64, err error) {
+	if !IntegerP(d) || IntegerValue(d) < 0 || IntegerValue(d) > 65535 {
+		err = ProcessError(fmt.Sprintf("%s expects a port number from 0 to 65535 but received %s.", name, String(d)), env)
+		return
+	}
+	return IntegerValue(d), nil
+}
+
+// (tcp-connect host port) opens a connection, returning a port that both
+// reads and writes.
+func TcpConnectImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	host := Car(args)
+	if !StringP(host) {
+		err = ProcessError(fmt.Sprintf("tcp-connect expects a host name string but received %s.", String(host)), env)
+		return
+	}
+	port, err := tcpPortArg("tcp-connect", Cadr(args), env)
+	if err != nil {
+		return
+	}
+
+	conn, err := net.Dial("tcp", net.JoinHostPort(StringValue(host), strconv.FormatInt(port, 10)))
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("tcp-connect: %s.", err), env)
+		return
+	}
+	result, err = connectionPort(conn.(*net.TCPConn))
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("tcp-connect: %s.", err), env)
+	}
+	return
+}
+
+// (tcp-listen port [host]) listens for connections on port, on every
+// interface unless host is given. Port 0 picks a free port, which
+// tcp-listener-port reports.
+func TcpListenImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	port, err := tcpPortArg("tcp-listen", Car(args), env)
+	if err != nil {
+		return
+	}
+	host := ""
+	if Length(args) == 2 {
+		h := Cadr(args)
+		if !StringP(h) {
+			err = ProcessError(fmt.Sprintf("tcp-listen expects a host name string but received %s.", String(h)), env)
+			return
+		}
+		host = StringValue(h)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.FormatInt(port, 10)))
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("tcp-listen: %s.", err), env)
+		return
+	}
+	return ObjectWithTypeAndValue("TcpListener", unsafe.Pointer(listener.(*net.TCPListener))), nil
+}
+
+// (tcp-accept listener [handler]) waits for the next connection and returns
+// its port. Given a handler, it instead forks a process that calls the
+// handler with the port and returns the process.
+func TcpAcceptImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	l := Car(args)
+	if !TcpListenerP(l) {
+		err = ProcessError(fmt.Sprintf("tcp-accept expects a tcp listener but received %s.", String(l)), env)
+		return
+	}
+	var handler *Data
+	if Length(args) == 2 {
+		handler = Cadr(args)
+		if !FunctionP(handler) {
+			err = ProcessError(fmt.Sprintf("tcp-accept expects its handler to be a function but received %s.", String(handler)), env)
+			return
+		}
+	}
+
+	conn, err := (*net.TCPListener)(ObjectValue(l)).AcceptTCP()
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("tcp-accept: %s.", err), env)
+		return
+	}
+	port, err := connectionPort(conn)
+	if err != nil {
+		err = ProcessError(fmt.Sprintf("tcp-accept: %s.", err), env)
+		return
+	}
+	if handler == nil {
+		return port, nil
+	}
+	return ForkImpl(InternalMakeList(handler, port), env)
+}
+
+func TcpListenerPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(TcpListenerP(Car(args))), nil
+}
+
+func TcpListenerPortImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	l := Car(args)
+	if !TcpListenerP(l) {
+		err = ProcessError(fmt.Sprintf("tcp-listener-port expects a tcp listener but received %s.", String(l)), env)
+		return
+	}
+	return IntegerWithValue(int64((*net.TCPListener)(ObjectValue(l)).Addr().(*net.TCPAddr).Port)), nil
+}
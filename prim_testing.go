@@ -0,0 +1,186 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the unit testing primitive functions.
+//
+// A check that doesn't hold signals a check-failure condition, so a check
+// can be used anywhere, and handler-case can catch it like any other error.
+// run-tests runs each test defined with define-test on its own, counting
+// one as failed if a check signals and as an error if anything else does.
+
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type unitTest struct {
+	Name string
+	Body *Data
+	Env  *SymbolTableFrame
+}
+
+type unitTestTable struct {
+	Tests []*unitTest
+	Mutex sync.Mutex
+}
+
+var unitTests unitTestTable
+
+var checkFailureType *ConditionType
+
+func RegisterTestingPrimitives() {
+	checkFailureType = defineConditionType("check-failure", conditionTypeNamed("error"), []string{"message"})
+	MakePrimitiveFunction("check-failure-message", "1", conditionAccessor("check-failure-message", "message"))
+
+	MakeSpecialForm("define-test", ">=1", DefineTestImpl)
+	MakeSpecialForm("check-equal", "2", CheckEqualImpl)
+	MakeSpecialForm("check-true", "1", CheckTrueImpl)
+	MakeSpecialForm("check-error", "1|2", CheckErrorImpl)
+	MakePrimitiveFunction("run-tests", "0", RunTestsImpl)
+	MakePrimitiveFunction("clear-tests", "0", ClearTestsImpl)
+}
+
+func checkFailed(format string, args ...interface{}) error {
+	message := StringWithValue(fmt.Sprintf(format, args...))
+	return &ConditionError{Condition: ConditionWithTypeAndSlots(checkFailureType, map[string]*Data{"message": message})}
+}
+
+// (define-test name body...) registers body to be run by run-tests, in the
+// environment define-test is evaluated in. Defining a test again with the
+// same name replaces it, keeping its place in the order tests run in.
+func DefineTestImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	name := Car(args)
+	if !SymbolP(name) && !StringP(name) {
+		err = ProcessError(fmt.Sprintf("define-test expects a symbol or string name but received %s.", String(name)), env)
+		return
+	}
+	test := &unitTest{Name: StringValue(name), Body: Cdr(args), Env: env}
+
+	unitTests.Mutex.Lock()
+	defer unitTests.Mutex.Unlock()
+	for i, t := range unitTests.Tests {
+		if t.Name == test.Name {
+			unitTests.Tests[i] = test
+			return name, nil
+		}
+	}
+	unitTests.Tests = append(unitTests.Tests, test)
+	return name, nil
+}
+
+// (check-equal actual expected) checks that actual and expected are equal?.
+func CheckEqualImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	actual, err := Eval(First(args), env)
+	if err != nil {
+		return
+	}
+	expected, err := Eval(Second(args), env)
+	if err != nil {
+		return
+	}
+	actual, expected = SingleValue(actual), SingleValue(expected)
+	if !IsEqual(actual, expected) {
+		return nil, checkFailed("%s is %s but %s was expected.", String(First(args)), String(actual), String(expected))
+	}
+	return LispTrue, nil
+}
+
+// (check-true expr) checks that expr is true.
+func CheckTrueImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	value, err := Eval(Car(args), env)
+	if err != nil {
+		return
+	}
+	if !BooleanValue(SingleValue(value)) {
+		return nil, checkFailed("%s is %s but a true value was expected.", String(Car(args)), String(value))
+	}
+	return LispTrue, nil
+}
+
+// (check-error expr [type]) checks that evaluating expr raises an error,
+// one whose condition is of type if that is given. Plain errors are
+// simple-errors.
+func CheckErrorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	var want *ConditionType
+	if Length(args) == 2 {
+		if !SymbolP(Cadr(args)) {
+			err = ProcessError(fmt.Sprintf("check-error expects a condition type name but received %s.", String(Cadr(args))), env)
+			return
+		}
+		want = conditionTypeNamed(StringValue(Cadr(args)))
+		if want == nil {
+			err = ProcessError(fmt.Sprintf("check-error couldn't find the condition type %s.", StringValue(Cadr(args))), env)
+			return
+		}
+	}
+
+	value, evalErr := Eval(Car(args), env)
+	if evalErr == nil {
+		return nil, checkFailed("%s is %s but an error was expected.", String(Car(args)), String(value))
+	}
+	if want != nil {
+		if t := ConditionValue(conditionFromError(evalErr)).Type; t.distanceTo(want) == -1 {
+			return nil, checkFailed("%s raised a %s but a %s was expected.", String(Car(args)), t.Name, want.Name)
+		}
+	}
+	return LispTrue, nil
+}
+
+// testErrorMessage is the last line of err's message, leaving out the
+// forms it was raised while evaluating, or a check failure's message.
+func testErrorMessage(err error) (message string, failed bool) {
+	var conditionErr *ConditionError
+	if errors.As(err, &conditionErr) && ConditionValue(conditionErr.Condition).Type == checkFailureType {
+		return conditionErr.Error(), true
+	}
+	lines := strings.Split(strings.TrimSpace(err.Error()), "\n")
+	return lines[len(lines)-1], false
+}
+
+// (run-tests) runs the defined tests in the order they were defined, each
+// in its own environment, and writes a line for each that failed or raised
+// an error followed by a count of each. It is whether they all passed.
+func RunTestsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	unitTests.Mutex.Lock()
+	tests := make([]*unitTest, len(unitTests.Tests))
+	copy(tests, unitTests.Tests)
+	unitTests.Mutex.Unlock()
+
+	var report strings.Builder
+	passes, failures, errorCount := 0, 0, 0
+	for _, test := range tests {
+		_, testErr := evaluateBody(test.Body, NewSymbolTableFrameBelow(test.Env, "define-test"))
+		if testErr == nil {
+			passes++
+			continue
+		}
+		message, failed := testErrorMessage(testErr)
+		if failed {
+			failures++
+			fmt.Fprintf(&report, "FAIL %s: %s\n", test.Name, message)
+		} else {
+			errorCount++
+			fmt.Fprintf(&report, "ERROR %s: %s\n", test.Name, message)
+		}
+	}
+	fmt.Fprintf(&report, "Ran %d tests: %d passed, %d failed, %d errors.\n", len(tests), passes, failures, errorCount)
+
+	if _, err = currentOutputPort(env).WriteString(report.String()); err != nil {
+		return
+	}
+	return BooleanWithValue(failures == 0 && errorCount == 0), nil
+}
+
+// (clear-tests) forgets the defined tests.
+func ClearTestsImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	unitTests.Mutex.Lock()
+	unitTests.Tests = nil
+	unitTests.Mutex.Unlock()
+	return
+}
@@ -59,11 +59,11 @@ func IsStringImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 }
 
 func IsIntegerImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	return BooleanWithValue(IntegerP(Car(args))), nil
+	return BooleanWithValue(ExactIntegerP(Car(args))), nil
 }
 
 func IsNumberImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	return BooleanWithValue(NumberP(Car(args))), nil
+	return BooleanWithValue(NumberP(Car(args)) || ComplexP(Car(args))), nil
 }
 
 func IsFloatImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
@@ -87,7 +87,7 @@ func IsByteArrayImpl(args *Data, env *SymbolTableFrame) (result *Data, err error
 }
 
 func IsPortImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
-	return BooleanWithValue(PortP(Car(args))), nil
+	return BooleanWithValue(OutputPortP(Car(args))), nil
 }
 
 func IsBooleanImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
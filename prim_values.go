@@ -0,0 +1,163 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the multiple values primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+func RegisterValuesPrimitives() {
+	MakePrimitiveFunction("values", "*", ValuesImpl)
+	MakePrimitiveFunction("call-with-values", "2", CallWithValuesImpl)
+	MakeSpecialForm("receive", ">=2", ReceiveImpl)
+	MakeSpecialForm("let-values", ">=1", LetValuesImpl)
+}
+
+// ValuesWithArray packages results as multiple values. A single result is
+// just returned, so (values x) is x.
+func ValuesWithArray(values []*Data) *Data {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return ObjectWithTypeAndValue("Values", unsafe.Pointer(&values))
+}
+
+func ValuesP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "Values"
+}
+
+func ValuesValue(d *Data) []*Data {
+	return *(*[]*Data)(ObjectValue(d))
+}
+
+// ValuesToArray unpacks d, treating anything but multiple values as one.
+func ValuesToArray(d *Data) []*Data {
+	if ValuesP(d) {
+		return ValuesValue(d)
+	}
+	return []*Data{d}
+}
+
+// SingleValue is what d means where one value is expected: the first of
+// multiple values, or nil if there are none.
+func SingleValue(d *Data) *Data {
+	if !ValuesP(d) {
+		return d
+	}
+	values := ValuesValue(d)
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+func ValuesImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return ValuesWithArray(ToArray(args)), nil
+}
+
+func CallWithValuesImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	producer := Car(args)
+	if !FunctionOrPrimitiveP(producer) {
+		err = ProcessError(fmt.Sprintf("call-with-values expects a producer function but received %s.", String(producer)), env)
+		return
+	}
+
+	consumer := Cadr(args)
+	if !FunctionOrPrimitiveP(consumer) {
+		err = ProcessError(fmt.Sprintf("call-with-values expects a consumer function but received %s.", String(consumer)), env)
+		return
+	}
+
+	// Apply rather than ApplyWithoutEval, which would pass a
+	// primitive a single nil argument when given none.
+	produced, err := Apply(producer, nil, env)
+	if err != nil {
+		return
+	}
+	values := ValuesToArray(produced)
+	if len(values) == 0 {
+		return Apply(consumer, nil, env)
+	}
+	return ApplyWithoutEval(consumer, ArrayToList(values), env)
+}
+
+// bindValues binds formals to values in env. Formals are a list of symbols,
+// a dotted list whose tail collects the rest, or a single symbol that
+// collects them all, as for lambda.
+func bindValues(name string, formals *Data, values []*Data, env *SymbolTableFrame) (err error) {
+	i := 0
+	f := formals
+	for ; PairP(f) && NotNilP(f); f = Cdr(f) {
+		if i == len(values) {
+			return ProcessError(fmt.Sprintf("%s received %d values, too few for %s.", name, len(values), String(formals)), env)
+		}
+		if !SymbolP(Car(f)) {
+			return ProcessError(fmt.Sprintf("%s expects symbols to bind but received %s.", name, String(Car(f))), env)
+		}
+		_, err = env.BindLocallyTo(Car(f), values[i])
+		if err != nil {
+			return
+		}
+		i++
+	}
+
+	if SymbolP(f) {
+		_, err = env.BindLocallyTo(f, ArrayToList(values[i:]))
+		return
+	}
+	if i != len(values) {
+		return ProcessError(fmt.Sprintf("%s received %d values, too many for %s.", name, len(values), String(formals)), env)
+	}
+	return
+}
+
+// (receive formals expr body...)
+func ReceiveImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	produced, err := Eval(Cadr(args), env)
+	if err != nil {
+		return
+	}
+
+	localEnv := NewSymbolTableFrameBelow(env, "receive")
+	err = bindValues("receive", Car(args), ValuesToArray(produced), localEnv)
+	if err != nil {
+		return
+	}
+	return evaluateBodyTail(Cddr(args), localEnv)
+}
+
+// (let-values ((formals expr)...) body...) evaluates each expr in the
+// enclosing environment, like let.
+func LetValuesImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	bindings := Car(args)
+	if !ListP(bindings) {
+		err = ProcessError(fmt.Sprintf("let-values expects a list of bindings but received %s.", String(bindings)), env)
+		return
+	}
+
+	localEnv := NewSymbolTableFrameBelow(env, "let-values")
+	for c := bindings; NotNilP(c); c = Cdr(c) {
+		binding := Car(c)
+		if !PairP(binding) || Length(binding) != 2 {
+			err = ProcessError(fmt.Sprintf("let-values expects bindings of the form (formals expr) but received %s.", String(binding)), env)
+			return
+		}
+
+		var produced *Data
+		produced, err = Eval(Cadr(binding), env)
+		if err != nil {
+			return
+		}
+		err = bindValues("let-values", Car(binding), ValuesToArray(produced), localEnv)
+		if err != nil {
+			return
+		}
+	}
+	return evaluateBodyTail(Cdr(args), localEnv)
+}
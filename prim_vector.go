@@ -0,0 +1,161 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the vector primitive functions.
+
+package golisp
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Vector literals read from source are marked Immutable since the same
+// object is returned every time the literal is evaluated.
+type Vector struct {
+	Elements  []*Data
+	Immutable bool
+}
+
+func RegisterVectorPrimitives() {
+	MakePrimitiveFunction("make-vector", "1|2", MakeVectorImpl)
+	MakePrimitiveFunction("vector", "*", VectorImpl)
+	MakePrimitiveFunction("vector?", "1", VectorPImpl)
+	MakePrimitiveFunction("vector-ref", "2", VectorRefImpl)
+	MakePrimitiveFunction("vector-set!", "3", VectorSetImpl)
+	MakePrimitiveFunction("vector-length", "1", VectorLengthImpl)
+	MakePrimitiveFunction("vector->list", "1", VectorToListImpl)
+	MakePrimitiveFunction("list->vector", "1", ListToVectorImpl)
+	MakePrimitiveFunction("vector-fill!", "2", VectorFillImpl)
+}
+
+func VectorWithValue(elements []*Data, immutable bool) *Data {
+	return ObjectWithTypeAndValue("Vector", unsafe.Pointer(&Vector{Elements: elements, Immutable: immutable}))
+}
+
+func VectorP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "Vector"
+}
+
+func VectorValue(d *Data) *Vector {
+	return (*Vector)(ObjectValue(d))
+}
+
+func vectorArg(name string, vectorObj *Data, env *SymbolTableFrame) (vector *Vector, err error) {
+	if !VectorP(vectorObj) {
+		err = ProcessError(fmt.Sprintf("%s expects a vector but received %s.", name, String(vectorObj)), env)
+		return
+	}
+	return VectorValue(vectorObj), nil
+}
+
+func mutableVectorArg(name string, vectorObj *Data, env *SymbolTableFrame) (vector *Vector, err error) {
+	vector, err = vectorArg(name, vectorObj, env)
+	if err == nil && vector.Immutable {
+		err = ProcessError(fmt.Sprintf("%s can not modify the vector literal %s.", name, String(vectorObj)), env)
+	}
+	return
+}
+
+func vectorIndex(name string, vector *Vector, indexObj *Data, env *SymbolTableFrame) (index int, err error) {
+	if !IntegerP(indexObj) {
+		err = ProcessError(fmt.Sprintf("%s expects an integer index but received %s.", name, String(indexObj)), env)
+		return
+	}
+	i := IntegerValue(indexObj)
+	if i < 0 || i >= int64(len(vector.Elements)) {
+		err = ProcessError(fmt.Sprintf("%s index %d is out of bounds for a vector of length %d.", name, i, len(vector.Elements)), env)
+		return
+	}
+	return int(i), nil
+}
+
+func MakeVectorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	sizeObj := Car(args)
+	if !IntegerP(sizeObj) || IntegerValue(sizeObj) < 0 {
+		err = ProcessError(fmt.Sprintf("make-vector expects a non-negative integer size but received %s.", String(sizeObj)), env)
+		return
+	}
+
+	fill := Cadr(args)
+	elements := make([]*Data, IntegerValue(sizeObj))
+	for i := range elements {
+		elements[i] = fill
+	}
+	return VectorWithValue(elements, false), nil
+}
+
+func VectorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return VectorWithValue(ToArray(args), false), nil
+}
+
+func VectorPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(VectorP(Car(args))), nil
+}
+
+func VectorRefImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	vector, err := vectorArg("vector-ref", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	index, err := vectorIndex("vector-ref", vector, Cadr(args), env)
+	if err != nil {
+		return
+	}
+	return vector.Elements[index], nil
+}
+
+func VectorSetImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	vector, err := mutableVectorArg("vector-set!", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	index, err := vectorIndex("vector-set!", vector, Cadr(args), env)
+	if err != nil {
+		return
+	}
+	vector.Elements[index] = Caddr(args)
+	return Caddr(args), nil
+}
+
+func VectorLengthImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	vector, err := vectorArg("vector-length", Car(args), env)
+	if err != nil {
+		return
+	}
+	return IntegerWithValue(int64(len(vector.Elements))), nil
+}
+
+func VectorToListImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	vector, err := vectorArg("vector->list", Car(args), env)
+	if err != nil {
+		return
+	}
+	return ArrayToList(vector.Elements), nil
+}
+
+func ListToVectorImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	list := Car(args)
+	if !ListP(list) {
+		err = ProcessError(fmt.Sprintf("list->vector expects a list but received %s.", String(list)), env)
+		return
+	}
+	return VectorWithValue(ToArray(list), false), nil
+}
+
+func VectorFillImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	vector, err := mutableVectorArg("vector-fill!", Car(args), env)
+	if err != nil {
+		return
+	}
+
+	fill := Cadr(args)
+	for i := range vector.Elements {
+		vector.Elements[i] = fill
+	}
+	return Car(args), nil
+}
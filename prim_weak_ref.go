@@ -0,0 +1,122 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the weak reference primitive functions.
+//
+// A weak reference holds the address of its value, which the garbage
+// collector doesn't see, and a finalizer on the value notes when it has been
+// collected. That makes it approximate:
+//
+//  - A value is only known to be collected once its finalizer has run, some
+//    time after the collection, so weak-ref-value can go on returning it for
+//    a while after the last other reference is dropped.
+//  - Getting a value back from weak-ref-value after it became unreachable
+//    but before its finalizer ran doesn't save it: the weak reference will
+//    still say it was collected.
+//  - Go doesn't promise to collect cycles that include an object with a
+//    finalizer, so a value that refers back to itself may never be let go.
+//  - Values the interpreter holds on to itself, such as symbols, booleans
+//    and small literals in code, are never collected.
+//  - A weak reference to #f can't tell you whether it was collected.
+
+package golisp
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+type WeakRef struct {
+	address   uintptr
+	collected bool
+}
+
+// weakTargets is the weak references to each value that has any, by the
+// value's address. A value gets a finalizer when it gets its first one.
+type weakTargetTable struct {
+	Refs  map[uintptr][]*WeakRef
+	Mutex sync.Mutex
+}
+
+var weakTargets = weakTargetTable{Refs: make(map[uintptr][]*WeakRef)}
+
+func RegisterWeakRefPrimitives() {
+	MakePrimitiveFunction("make-weak-ref", "1", MakeWeakRefImpl)
+	MakePrimitiveFunction("weak-ref?", "1", WeakRefPImpl)
+	MakePrimitiveFunction("weak-ref-value", "1", WeakRefValueImpl)
+}
+
+func collectWeakTarget(d *Data) {
+	address := uintptr(unsafe.Pointer(d))
+	weakTargets.Mutex.Lock()
+	defer weakTargets.Mutex.Unlock()
+	for _, ref := range weakTargets.Refs[address] {
+		ref.collected = true
+	}
+	delete(weakTargets.Refs, address)
+}
+
+// NewWeakRef is a weak reference to d.
+func NewWeakRef(d *Data) *WeakRef {
+	ref := &WeakRef{address: uintptr(unsafe.Pointer(d))}
+	weakTargets.Mutex.Lock()
+	defer weakTargets.Mutex.Unlock()
+	refs, found := weakTargets.Refs[ref.address]
+	if !found {
+		runtime.SetFinalizer(d, collectWeakTarget)
+	}
+	weakTargets.Refs[ref.address] = append(refs, ref)
+	return ref
+}
+
+// Value is the value self refers to, or nil once it has been collected.
+func (self *WeakRef) Value() *Data {
+	weakTargets.Mutex.Lock()
+	defer weakTargets.Mutex.Unlock()
+	if self.collected {
+		return nil
+	}
+	// The value can't have been freed: it would have been finalized first.
+	return (*Data)(*(*unsafe.Pointer)(unsafe.Pointer(&self.address)))
+}
+
+func WeakRefP(d *Data) bool {
+	return ObjectP(d) && ObjectType(d) == "WeakRef"
+}
+
+func WeakRefValue(d *Data) *WeakRef {
+	return (*WeakRef)(ObjectValue(d))
+}
+
+// (make-weak-ref value) is a reference to value that doesn't keep it from
+// being collected.
+func MakeWeakRefImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	d := Car(args)
+	if d == nil {
+		err = ProcessError("make-weak-ref can't refer to nil.", env)
+		return
+	}
+	return ObjectWithTypeAndValue("WeakRef", unsafe.Pointer(NewWeakRef(d))), nil
+}
+
+func WeakRefPImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return BooleanWithValue(WeakRefP(Car(args))), nil
+}
+
+// (weak-ref-value ref) is the value ref refers to, or #f if it has been
+// collected.
+func WeakRefValueImpl(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	ref := Car(args)
+	if !WeakRefP(ref) {
+		err = ProcessError(fmt.Sprintf("weak-ref-value expects a weak reference but received %s.", String(ref)), env)
+		return
+	}
+	if value := WeakRefValue(ref).Value(); value != nil {
+		return value, nil
+	}
+	return LispFalse, nil
+}
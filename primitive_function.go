@@ -37,6 +37,8 @@ type PrimitiveFunction struct {
 	ArgTypes        []uint32
 	Body            func(d *Data, env *SymbolTableFrame) (*Data, error)
 	IsRestricted    bool
+	memo            *memoCache
+	parameter       *Parameter
 }
 
 func MakePrimitiveFunction(name string, argCount string, function func(*Data, *SymbolTableFrame) (*Data, error)) {
@@ -146,6 +148,12 @@ func (self *PrimitiveFunction) checkArgumentCount(argCount int) bool {
 }
 
 func (self *PrimitiveFunction) Apply(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return forceTailCall(self.applyUnforced(args, env))
+}
+
+// applyUnforced is Apply without running the tail call a special form may
+// return.
+func (self *PrimitiveFunction) applyUnforced(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	if self.IsRestricted && env.IsRestricted {
 		err = fmt.Errorf("The %s primitive is restricted from execution in this environment\n", self.Name)
 		return
@@ -166,6 +174,7 @@ func (self *PrimitiveFunction) Apply(args *Data, env *SymbolTableFrame) (result
 			if err != nil {
 				return
 			}
+			argValue = SingleValue(argValue)
 		}
 
 		argArray = append(argArray, argValue)
@@ -187,6 +196,26 @@ func (self *PrimitiveFunction) Apply(args *Data, env *SymbolTableFrame) (result
 	return
 }
 
+// applyToValues calls a primitive that isn't a special form with arguments
+// that have already been evaluated.
+func (self *PrimitiveFunction) applyToValues(args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if self.IsRestricted && env.IsRestricted {
+		err = fmt.Errorf("The %s primitive is restricted from execution in this environment\n", self.Name)
+		return
+	}
+
+	if !self.checkArgumentCount(Length(args)) {
+		err = ProcessError(fmt.Sprintf("Wrong number of args to %s, expected %s but got %d.", self.Name, self.argsString(), Length(args)), env)
+		return
+	}
+
+	localGuid := atomic.AddInt64(&ProfileGUID, 1) - 1
+	ProfileEnter("prim", self.Name, localGuid)
+	result, err = (self.Body)(args, env)
+	ProfileExit("prim", self.Name, localGuid)
+	return
+}
+
 func (self *PrimitiveFunction) ApplyWithoutEval(args *Data, env *SymbolTableFrame) (result *Data, err error) {
 	if self.Special {
 		return self.Apply(args, env)
@@ -0,0 +1,141 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains the state used while writing data as text.
+
+package golisp
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// printer holds what String needs while writing one datum: whether the
+// text should read back in, the depth and length limits (-1 for none) and
+// the labels of structure reached more than once. A label is -1 until its
+// first occurrence has been written.
+type printer struct {
+	Readable  bool
+	Depth     int
+	Length    int
+	Labels    map[unsafe.Pointer]int
+	NextLabel int
+}
+
+func newPrinter(d *Data, shared bool, env *SymbolTableFrame) *printer {
+	p := &printer{Readable: true, Depth: printLimit(printDepth, env), Length: printLimit(printLength, env)}
+	if _, ok := compoundIdentity(d); ok {
+		p.findLabels(d, make(map[unsafe.Pointer]bool), shared)
+	}
+	return p
+}
+
+// printDepth and printLength are the *print-depth* and *print-length*
+// parameters.
+var printDepth, printLength *Parameter
+
+// printLimit is the value of a print limit parameter in env. Anything but a
+// non-negative integer means no limit.
+func printLimit(limit *Parameter, env *SymbolTableFrame) int {
+	if limit == nil {
+		return -1
+	}
+	value := limit.current(env)
+	if !IntegerP(value) || IntegerValue(value) < 0 {
+		return -1
+	}
+	return int(IntegerValue(value))
+}
+
+func compoundP(d *Data) bool {
+	return (ListP(d) && NotNilP(d)) || DottedPairP(d) || VectorP(d) || FrameP(d)
+}
+
+// compoundIdentity is what makes two references to a list cell, vector or
+// frame the same object.
+func compoundIdentity(d *Data) (id unsafe.Pointer, ok bool) {
+	switch {
+	case d == nil:
+		return nil, false
+	case TypeOf(d) == ConsCellType && NotNilP(d):
+		return d.Value, true
+	case VectorP(d):
+		return ObjectValue(d), true
+	case FrameP(d):
+		return unsafe.Pointer(FrameValue(d)), true
+	}
+	return nil, false
+}
+
+// findLabels walks d marking the objects that need labels: those reached
+// again while still being walked, which are cycles, and with shared set
+// those reached again at all. active tracks which objects are being walked.
+func (self *printer) findLabels(d *Data, active map[unsafe.Pointer]bool, shared bool) {
+	spine := make([]unsafe.Pointer, 0, 1)
+	for {
+		id, ok := compoundIdentity(d)
+		if !ok {
+			break
+		}
+		if walking, seen := active[id]; seen {
+			if walking || shared {
+				if self.Labels == nil {
+					self.Labels = make(map[unsafe.Pointer]int)
+				}
+				self.Labels[id] = -1
+			}
+			break
+		}
+		active[id] = true
+		spine = append(spine, id)
+
+		if PairP(d) {
+			self.findLabels(Car(d), active, shared)
+			d = Cdr(d)
+			continue
+		}
+		if VectorP(d) {
+			for _, element := range VectorValue(d).Elements {
+				self.findLabels(element, active, shared)
+			}
+		} else {
+			frame := FrameValue(d)
+			frame.Mutex.RLock()
+			for _, value := range frame.Data {
+				self.findLabels(value, active, shared)
+			}
+			frame.Mutex.RUnlock()
+		}
+		break
+	}
+	for _, id := range spine {
+		active[id] = false
+	}
+}
+
+func (self *printer) labelled(d *Data) bool {
+	id, ok := compoundIdentity(d)
+	if !ok || self.Labels == nil {
+		return false
+	}
+	_, labelled := self.Labels[id]
+	return labelled
+}
+
+// label returns the #N= prefix for the first occurrence of a labelled
+// object and the #N# reference for later ones.
+func (self *printer) label(d *Data) (label string, reference bool) {
+	if !self.labelled(d) {
+		return "", false
+	}
+	id, _ := compoundIdentity(d)
+	if n := self.Labels[id]; n >= 0 {
+		return fmt.Sprintf("#%d#", n), true
+	}
+	n := self.NextLabel
+	self.NextLabel++
+	self.Labels[id] = n
+	return fmt.Sprintf("#%d=", n), false
+}
@@ -106,3 +106,67 @@ func (s *PrintingSuite) TestBytearray(c *C) {
 	sexpr := ObjectWithTypeAndValue("[]byte", unsafe.Pointer(&dataBytes))
 	c.Assert(String(sexpr), Equals, "[1 2 3 4 5]")
 }
+
+func (s *PrintingSuite) TestPrettyPrintShortListStaysFlat(c *C) {
+	sexpr, _ := Parse("(a (b c) d)")
+	c.Assert(PrettyString(sexpr, 80, true), Equals, "(a (b c) d)")
+}
+
+func (s *PrintingSuite) TestPrettyPrintAlignsCallArguments(c *C) {
+	sexpr, _ := Parse("(list 'alpha 'beta 'gamma 'delta)")
+	c.Assert(PrettyString(sexpr, 20, true), Equals, "(list 'alpha\n      'beta\n      'gamma\n      'delta)")
+}
+
+func (s *PrintingSuite) TestPrettyPrintIndentsCallArgumentsWithoutAlignment(c *C) {
+	sexpr, _ := Parse("(list 'alpha 'beta 'gamma 'delta)")
+	c.Assert(PrettyString(sexpr, 20, false), Equals, "(list\n  'alpha\n  'beta\n  'gamma\n  'delta)")
+}
+
+func (s *PrintingSuite) TestPrettyPrintSpecialForms(c *C) {
+	sexpr, _ := Parse("(define (fact n) (let loop ((i n) (acc 1)) (if (<= i 1) acc (loop (- i 1) (* acc i)))))")
+	c.Assert(PrettyString(sexpr, 40, true), Equals, `(define (fact n)
+  (let loop ((i n) (acc 1))
+    (if (<= i 1)
+        acc
+        (loop (- i 1) (* acc i)))))`)
+}
+
+func (s *PrintingSuite) TestPrettyPrintData(c *C) {
+	sexpr, _ := Parse(`((alpha 1 2 3) (beta "some string") #(1 2 3 4 5 6 7 8 9 10 11 12))`)
+	c.Assert(PrettyString(sexpr, 20, true), Equals, `((alpha 1 2 3)
+ (beta "some string")
+ #(1 2 3 4 5 6 7 8
+   9 10 11 12))`)
+}
+
+func (s *PrintingSuite) TestCircularList(c *C) {
+	sexpr := InternalMakeList(IntegerWithValue(1), IntegerWithValue(2))
+	ConsValue(Cdr(sexpr)).Cdr = sexpr
+	c.Assert(String(sexpr), Equals, "#0=(1 2 . #0#)")
+}
+
+func (s *PrintingSuite) TestSharedStructure(c *C) {
+	shared := InternalMakeList(IntegerWithValue(1))
+	sexpr := InternalMakeList(shared, shared)
+	c.Assert(String(sexpr), Equals, "((1) (1))")
+	c.Assert(SharedString(sexpr), Equals, "(#0=(1) #0#)")
+}
+
+func (s *PrintingSuite) TestStringEscapes(c *C) {
+	sexpr := StringWithValue("say \"hi\"\\\n")
+	c.Assert(String(sexpr), Equals, `"say \"hi\"\\\n"`)
+}
+
+func (s *PrintingSuite) TestWrittenStringsReadBack(c *C) {
+	sexpr := InternalMakeList(StringWithValue("a \"quoted\" word"), StringWithValue("back\\slash"), StringWithValue("two\nlines"))
+	read, err := Parse(String(sexpr))
+	c.Assert(err, IsNil)
+	c.Assert(IsEqual(read, sexpr), Equals, true)
+}
+
+func (s *PrintingSuite) TestDisplayString(c *C) {
+	c.Assert(DisplayString(StringWithValue("say \"hi\"")), Equals, `say "hi"`)
+	sexpr := InternalMakeList(StringWithValue("a"), InternalMakeList(StringWithValue("b")), Intern("c"))
+	c.Assert(DisplayString(sexpr), Equals, "(a (b) c)")
+	c.Assert(String(sexpr), Equals, `("a" ("b") c)`)
+}
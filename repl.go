@@ -10,15 +10,55 @@ package golisp
 import (
 	"container/list"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
+// historyLength is how many entries are kept in the history file.
+const historyLength = 1000
+
+// historyFile is where the REPL keeps its history between sessions: in the
+// home directory, or the current one when there isn't a home directory.
+func historyFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".golisp_history"
+	}
+	return filepath.Join(home, ".golisp_history")
+}
+
+// readInput reads lines until they make up complete forms, prompting with
+// continuation for the lines after the first. It returns nil at the end of
+// the input.
+func readInput(prompt string, continuation string) *string {
+	inputp := ReadLine(&prompt)
+	if inputp == nil {
+		return nil
+	}
+	input := *inputp
+	for !InputComplete(input) {
+		linep := ReadLine(&continuation)
+		if linep == nil {
+			return nil
+		}
+		input = input + "\n" + *linep
+	}
+	return &input
+}
+
+// Repl reads, evaluates and prints until the input ends or (quit). A form
+// can span lines: the REPL keeps reading, with a continuation prompt, until
+// its parentheses balance.
 func Repl() {
 	IsInteractive = true
 	fmt.Printf("Welcome to GoLisp 1.0\n")
 	fmt.Printf("Copyright 2015 SteelSeries\n")
 	fmt.Printf("Evaluate '(quit)' to exit.\n\n")
 	prompt := "> "
-	LoadHistoryFromFile(".golisp_history")
+	continuation := "... "
+	history := historyFile()
+	LoadHistoryFromFile(history)
 	lastInput := ""
 	replEnv := NewSymbolTableFrameBelow(Global, "Repl")
 	for true {
@@ -31,7 +71,7 @@ func Repl() {
 		DebugSingleStep = false
 		DebugEvalInDebugRepl = false
 		replEnv.CurrentCode = list.New()
-		inputp := ReadLine(&prompt)
+		inputp := readInput(prompt, continuation)
 		if inputp == nil {
 			QuitImpl(nil, nil)
 		} else {
@@ -43,10 +83,17 @@ func Repl() {
 					fmt.Printf("Error: %s\n", err)
 				} else {
 					if input != lastInput {
-						AddHistory(input)
+						// The history file holds an entry a line.
+						AddHistory(strings.Replace(input, "\n", " ", -1))
+						WriteHistoryToFile(history)
+						TruncateHistoryFile(history, historyLength)
 						lastInput = input
 					}
-					d, err := Eval(code, replEnv)
+					env := replEnv
+					if p := currentPackage(); p != nil {
+						env = p.Env
+					}
+					d, err := Eval(code, env)
 					if err != nil {
 						fmt.Printf("Error in evaluation: %s\n", err)
 						if DebugOnError {
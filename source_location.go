@@ -0,0 +1,71 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file contains source location tracking for error messages.
+
+package golisp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SourceLocation is where in a file the reader found a list.
+type SourceLocation struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// LocatedError is an error raised while evaluating a list the reader found
+// at Location.
+type LocatedError struct {
+	Location *SourceLocation
+	Err      error
+}
+
+func (self *SourceLocation) String() string {
+	return fmt.Sprintf("%s:%d:%d", self.File, self.Line, self.Column)
+}
+
+func (self *LocatedError) Error() string {
+	return fmt.Sprintf("error at %s: %s", self.Location, self.Err)
+}
+
+func (self *LocatedError) Unwrap() error {
+	return self.Err
+}
+
+// lookaheadLocation is where the tokenizer's next token starts, or nil when
+// the source isn't a file.
+func lookaheadLocation(s *Tokenizer) *SourceLocation {
+	if s.File == "" {
+		return nil
+	}
+	return &SourceLocation{File: s.File, Line: s.LookaheadLine, Column: s.LookaheadColumn}
+}
+
+// LocationOf is where the reader found d, or nil if it isn't a list read
+// from a file.
+func LocationOf(d *Data) *SourceLocation {
+	if !PairP(d) || NilP(d) {
+		return nil
+	}
+	return ConsValue(d).Location
+}
+
+// locateError attaches d's location to err, unless a form nearer the error
+// already has.
+func locateError(err error, d *Data) error {
+	location := LocationOf(d)
+	if location == nil {
+		return err
+	}
+	var located *LocatedError
+	if errors.As(err, &located) {
+		return err
+	}
+	return &LocatedError{Location: location, Err: err}
+}
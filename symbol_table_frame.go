@@ -11,6 +11,7 @@ import (
 	"container/list"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 )
@@ -25,10 +26,12 @@ type SymbolTableFrame struct {
 	Parent       *SymbolTableFrame
 	Previous     *SymbolTableFrame
 	Frame        *FrameMap
-	Bindings     map[string]*Binding
+	Bindings     map[*Data]*Binding
 	Mutex        sync.RWMutex
 	CurrentCode  *list.List
 	IsRestricted bool
+	Package      *Package
+	parameters   *parameterization
 }
 
 type symbolsTable struct {
@@ -46,14 +49,20 @@ var TopLevelEnvironments environmentsTable = environmentsTable{make(map[string]*
 
 var internedSymbols symbolsTable = symbolsTable{make(map[string]*Data, 256), sync.RWMutex{}}
 
+// Intern returns the symbol called name, making it the first time. Every
+// symbol read or interned with the same name is the same *Data, so symbols
+// compare, and are bound, by identity.
 func Intern(name string) (sym *Data) {
 	internedSymbols.Mutex.RLock()
 	sym = internedSymbols.Symbols[name]
 	internedSymbols.Mutex.RUnlock()
 	if sym == nil {
 		internedSymbols.Mutex.Lock()
-		sym = SymbolWithName(name)
-		internedSymbols.Symbols[name] = sym
+		sym = internedSymbols.Symbols[name]
+		if sym == nil {
+			sym = UninternedSymbolWithName(name)
+			internedSymbols.Symbols[name] = sym
+		}
 		internedSymbols.Mutex.Unlock()
 	}
 	return
@@ -135,7 +144,7 @@ func NewSymbolTableFrameBelow(p *SymbolTableFrame, name string) *SymbolTableFram
 		f = p.Frame
 	}
 	restricted := p != nil && p.IsRestricted
-	env := &SymbolTableFrame{Name: name, Parent: p, Bindings: make(map[string]*Binding), Frame: f, CurrentCode: list.New(), IsRestricted: restricted}
+	env := &SymbolTableFrame{Name: name, Parent: p, Bindings: make(map[*Data]*Binding), Frame: f, CurrentCode: list.New(), IsRestricted: restricted, parameters: parametersIn(p)}
 	if p == nil || p == Global {
 		TopLevelEnvironments.Mutex.Lock()
 		TopLevelEnvironments.Environments[name] = env
@@ -149,7 +158,7 @@ func NewSymbolTableFrameBelowWithFrame(p *SymbolTableFrame, f *FrameMap, name st
 		f = p.Frame
 	}
 	restricted := p != nil && p.IsRestricted
-	env := &SymbolTableFrame{Name: name, Parent: p, Bindings: make(map[string]*Binding, 10), Frame: f, CurrentCode: list.New(), IsRestricted: restricted}
+	env := &SymbolTableFrame{Name: name, Parent: p, Bindings: make(map[*Data]*Binding), Frame: f, CurrentCode: list.New(), IsRestricted: restricted, parameters: parametersIn(p)}
 	if p == nil || p == Global {
 		TopLevelEnvironments.Mutex.Lock()
 		TopLevelEnvironments.Environments[name] = env
@@ -162,45 +171,76 @@ func (self *SymbolTableFrame) HasFrame() bool {
 	return self.Frame != nil
 }
 
+// BindingNamed finds the binding of the interned symbol called name.
 func (self *SymbolTableFrame) BindingNamed(name string) (b *Binding, present bool) {
-	self.Mutex.RLock()
-	b, present = self.Bindings[name]
-	self.Mutex.RUnlock()
-	return
+	return self.bindingOf(Intern(name))
 }
 
 func (self *SymbolTableFrame) SetBindingAt(name string, b *Binding) {
+	self.setBindingOf(Intern(name), b)
+}
+
+func (self *SymbolTableFrame) DeleteBinding(name string) {
 	self.Mutex.Lock()
-	self.Bindings[name] = b
+	delete(self.Bindings, Intern(name))
 	self.Mutex.Unlock()
 }
 
-func (self *SymbolTableFrame) DeleteBinding(name string) {
+func (self *SymbolTableFrame) bindingOf(symbol *Data) (b *Binding, present bool) {
+	self.Mutex.RLock()
+	b, present = self.Bindings[symbol]
+	self.Mutex.RUnlock()
+	return
+}
+
+func (self *SymbolTableFrame) setBindingOf(symbol *Data, b *Binding) {
 	self.Mutex.Lock()
-	delete(self.Bindings, name)
+	self.Bindings[symbol] = b
 	self.Mutex.Unlock()
 }
 
-func (self *SymbolTableFrame) findSymbol(name string) (symbol *Data, found bool) {
-	binding, found := self.BindingNamed(name)
-	if found {
-		return binding.Sym, true
-	} else if self.Parent != nil {
-		return self.Parent.findSymbol(name)
-	} else {
-		return nil, false
+// sortedBindings lists the bindings made in this frame, sorted by name.
+func (self *SymbolTableFrame) sortedBindings() []*Binding {
+	self.Mutex.RLock()
+	bindings := make([]*Binding, 0, len(self.Bindings))
+	for _, binding := range self.Bindings {
+		bindings = append(bindings, binding)
+	}
+	self.Mutex.RUnlock()
+	sort.Slice(bindings, func(i, j int) bool {
+		return StringValue(bindings[i].Sym) < StringValue(bindings[j].Sym)
+	})
+	return bindings
+}
+
+// IsBound is whether evaluating symbol here finds a value, as ValueOf
+// looks for one: in this frame, a slot of the frame object, or a frame
+// lexically enclosing this one.
+func (self *SymbolTableFrame) IsBound(symbol *Data) bool {
+	if _, found := self.findBindingInLocalFrameFor(symbol); found {
+		return true
+	}
+	if self.HasFrame() && self.Frame.HasSlot(StringValue(NakedSymbolFrom(symbol))) {
+		return true
 	}
+	_, found := self.FindBindingFor(symbol)
+	return found
 }
 
 func (self *SymbolTableFrame) FindBindingFor(symbol *Data) (binding *Binding, found bool) {
-	name := StringValue(symbol)
-	binding, found = self.BindingNamed(name)
+	binding, found = self.bindingOf(symbol)
 	if found {
 		return
-	} else if self.Parent != nil {
+	}
+	if self.Package != nil {
+		if binding, found = self.Package.importedBinding(symbol); found {
+			return
+		}
+	}
+	if self.Parent != nil {
 		return self.Parent.FindBindingFor(symbol)
 	} else {
-		return nil, false
+		return qualifiedBinding(symbol)
 	}
 }
 
@@ -213,7 +253,7 @@ func (self *SymbolTableFrame) BindTo(symbol *Data, value *Data) (*Data, error) {
 		binding.Val = value
 	} else {
 		binding = BindingWithSymbolAndValue(symbol, value)
-		self.SetBindingAt(StringValue(symbol), binding)
+		self.setBindingOf(symbol, binding)
 	}
 	return binding.Val, nil
 }
@@ -225,7 +265,7 @@ func (self *SymbolTableFrame) BindToProtected(symbol *Data, value *Data) *Data {
 		binding.Protected = true
 	} else {
 		binding = ProtectedBindingWithSymbolAndValue(symbol, value)
-		self.SetBindingAt(StringValue(symbol), binding)
+		self.setBindingOf(symbol, binding)
 	}
 	return binding.Val
 }
@@ -261,7 +301,7 @@ func (self *SymbolTableFrame) SetTo(symbol *Data, value *Data) (result *Data, er
 }
 
 func (self *SymbolTableFrame) findBindingInLocalFrameFor(symbol *Data) (b *Binding, found bool) {
-	return self.BindingNamed(StringValue(symbol))
+	return self.bindingOf(symbol)
 }
 
 func (self *SymbolTableFrame) BindLocallyTo(symbol *Data, value *Data) (*Data, error) {
@@ -273,7 +313,7 @@ func (self *SymbolTableFrame) BindLocallyTo(symbol *Data, value *Data) (*Data, e
 		binding.Val = value
 	} else {
 		binding = BindingWithSymbolAndValue(symbol, value)
-		self.SetBindingAt(StringValue(symbol), binding)
+		self.setBindingOf(symbol, binding)
 	}
 	return binding.Val, nil
 }
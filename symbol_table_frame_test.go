@@ -8,6 +8,9 @@
 package golisp
 
 import (
+	"fmt"
+	"testing"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -38,7 +41,7 @@ func (s *SymbolTableFrameSuite) TestBinding(c *C) {
 	_, err := s.frame.BindTo(Intern("test"), IntegerWithValue(42))
 	c.Assert(err, IsNil)
 
-	binding, found := s.frame.Bindings["test"]
+	binding, found := s.frame.Bindings[Intern("test")]
 	c.Assert(found, Equals, true)
 	c.Assert(binding, NotNil)
 }
@@ -52,3 +55,20 @@ func (s *SymbolTableFrameSuite) TestSymbolValue(c *C) {
 	c.Assert(int(TypeOf(val)), Equals, IntegerType)
 	c.Assert(IntegerValue(val), Equals, int64(42))
 }
+
+// BenchmarkSymbolLookup looks up a global through a few nested frames, as
+// a variable reference in a function body does.
+func BenchmarkSymbolLookup(b *testing.B) {
+	InitLisp()
+	sym := Intern("benchmark-symbol-lookup-variable")
+	Global.BindTo(sym, IntegerWithValue(42))
+	env := Global
+	for i := 0; i < 4; i++ {
+		env = NewSymbolTableFrameBelow(env, "benchmark")
+		env.BindLocallyTo(Intern(fmt.Sprintf("local-%d", i)), IntegerWithValue(int64(i)))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		env.ValueOf(sym)
+	}
+}
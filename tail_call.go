@@ -0,0 +1,94 @@
+// Copyright 2014 SteelSeries ApS.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This package implements a basic LISP interpretor for embedding in a go program for scripting.
+// This file implements tail calls.
+
+package golisp
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// A TailCall is a call to a user function in tail position whose arguments
+// have been evaluated but whose body hasn't run yet. Special forms hand one
+// back up from their tail expression and the function whose body they
+// finish runs it in its own loop, so tail recursion doesn't grow the go
+// stack. Eval and Apply always run any tail call before returning, so lisp
+// code never sees one.
+type TailCall struct {
+	Function *Function
+	Args     *Data
+	Env      *SymbolTableFrame
+}
+
+func TailCallP(d *Data) bool {
+	return d != nil && TypeOf(d) == TailCallType
+}
+
+func TailCallValue(d *Data) *TailCall {
+	return (*TailCall)(d.Value)
+}
+
+func TailCallWithFunctionAndArgs(function *Function, args *Data, env *SymbolTableFrame) *Data {
+	return &Data{Type: TailCallType, Value: unsafe.Pointer(&TailCall{Function: function, Args: args, Env: env})}
+}
+
+// forceTailCall runs result if it is a tail call.
+func forceTailCall(result *Data, err error) (*Data, error) {
+	if err != nil || !TailCallP(result) {
+		return result, err
+	}
+	call := TailCallValue(result)
+	return call.Function.internalApply(call.Args, call.Env, nil, false)
+}
+
+// evalTail evaluates an expression in tail position, returning a tail call
+// rather than calling a user function. Calls anywhere else are made
+// directly, so only tail calls pay for building one.
+func evalTail(d *Data, env *SymbolTableFrame) (result *Data, err error) {
+	return evalUnforced(d, env, false, true)
+}
+
+// evaluateBodyTail evaluates a body, its last expression in tail position.
+func evaluateBodyTail(sexprs *Data, env *SymbolTableFrame) (result *Data, err error) {
+	for e := sexprs; NotNilP(e); e = Cdr(e) {
+		if NilP(Cdr(e)) {
+			return evalTail(Car(e), env)
+		}
+		result, err = Eval(Car(e), env)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// applyTail is Apply for the call being evaluated: user functions become
+// tail calls, and special forms may return one from their tail expression.
+func applyTail(function *Data, args *Data, env *SymbolTableFrame) (result *Data, err error) {
+	if NilP(function) || TypeOf(function) != FunctionType {
+		if PrimitiveP(function) {
+			return PrimitiveValue(function).applyUnforced(args, env)
+		}
+		return Apply(function, args, env)
+	}
+
+	f := FunctionValue(function)
+	if atomic.LoadInt32(&f.SlotFunction) == 1 && env.HasFrame() {
+		return f.ApplyWithFrame(args, env, env.Frame)
+	}
+
+	argValues := make([]*Data, 0, Length(args))
+	for a := args; NotNilP(a); a = Cdr(a) {
+		var argValue *Data
+		argValue, err = Eval(Car(a), env)
+		if err != nil {
+			return
+		}
+		argValues = append(argValues, SingleValue(argValue))
+	}
+	return TailCallWithFunctionAndArgs(f, ArrayToList(argValues), env), nil
+}
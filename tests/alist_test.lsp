@@ -31,8 +31,11 @@
                               '(a . 1))
                    (assert-eq (assoc 'b (alist '((a . 1) (b . 2) (c . 3))))
                               '(b . 2))
-                   (assert-eq (assoc 'c (alist '((a . 1) (b . 2))))
-                              '())
+                   (assert-false (assoc 'c (alist '((a . 1) (b . 2)))))
+                   (assert-eq (assoc "b" '(("a" . 1) ("b" . 2)))
+                              '("b" . 2))
+                   (assert-eq (assoc 4 '((1 . a) (2 . b)) (lambda (k x) (== k (* x 2))))
+                              '(2 . b))
 
                    (assert-error (assoc 'a '(a (b . 2))))) ;second arg must be an alist (i.e. list of pairs)
 
@@ -46,4 +49,49 @@
 
          (it "can remove"
                    (assert-eq (dissoc 'a (alist '((a . 1) (b . 2) (c . 3))))
-                              (alist '((b . 2) (c . 3))))))
+                              (alist '((b . 2) (c . 3)))))
+
+         (it "can lookup by identity"
+                   (define key "b")
+                   (assert-false (assq "b" '(("a" . 1) ("b" . 2))))
+                   (assert-eq (assq key (list '("a" . 1) (cons key 2)))
+                              '("b" . 2))
+                   (assert-eq (assq 'b '((a . 1) (b . 2)))
+                              '(b . 2))
+                   (assert-eq (assv 2 '((1 . a) (2 . b)))
+                              '(2 . b))
+                   (assert-false (assv 2.0 '((1 . a) (2 . b))))
+                   (assert-eq (assv 2.5 '((1.5 . a) (2.5 . b)))
+                              '(2.5 . b))
+                   (assert-error (assq 'a '((a . 1)) 5)))
+
+         (it "can convert to lists"
+                   (define al (alist '((a . 1) (b . 2))))
+                   (assert-eq (alist->list al)
+                              '((a . 1) (b . 2)))
+                   (assert-eq (alist-keys al)
+                              '(a b))
+                   (assert-eq (alist-values al)
+                              '(1 2))
+                   (assert-error (alist-keys '(1 2))))
+
+         (it "can update without changing the original"
+                   (define al (alist '((a . 1) (b . 2))))
+                   (assert-eq (alist-update 'b 20 al)
+                              (alist '((a . 1) (b . 20))))
+                   (assert-eq (alist-update 'c 3 al)
+                              (alist '((c . 3) (a . 1) (b . 2))))
+                   (assert-eq al
+                              (alist '((a . 1) (b . 2))))
+                   (assert-eq (alist-update "B" 20 '(("a" . 1) ("b" . 2)) string-ci=?)
+                              '(("a" . 1) ("b" . 20))))
+
+         (it "can delete"
+                   (define al (alist '((a . 1) (b . 2) (a . 3))))
+                   (assert-eq (alist-delete 'a al)
+                              (alist '((b . 2))))
+                   (assert-eq (alist-delete 'z al)
+                              al)
+                   (assert-eq (alist-delete 1 '((1 . a) (2 . b) (3 . c)) <)
+                              '((1 . a)))
+                   (assert-error (alist-delete 'a 5))))
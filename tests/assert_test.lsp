@@ -0,0 +1,52 @@
+;;; -*- mode: Scheme -*-
+
+(define (assertion-message thunk)
+  (handler-case (thunk)
+    (simple-error (c) (simple-error-message c))))
+
+(context "assert"
+
+         ()
+
+         (it "is the value of a true expression"
+             (assert-eq (assert (+ 1 2)) 3)
+             (assert-eq (assert (memq 'b '(a b c))) '(b c))
+             (assert-true (assert #t)))
+
+         (it "fails on false and nil"
+             (assert-error (assert #f))
+             (assert-error (assert nil))
+             (assert-error (assert (== 1 2))))
+
+         (it "evaluates each subform once"
+             (let ((count 0))
+               (assert (begin (set! count (+ count 1)) #t))
+               (assert (== (begin (set! count (+ count 1)) 2) 2))
+               (assert-eq count 2)))
+
+         (it "names the failing expression as written"
+             (let ((x 1))
+               (assert-eq (assertion-message (lambda () (assert (> x 5))))
+                          "Assertion (> x 5) failed: x is 1.")))
+
+         (it "shows the values of the subforms that aren't literals"
+             (let ((x 1)
+                   (y 2))
+               (assert-eq (assertion-message (lambda () (assert (== x (+ y 1)))))
+                          "Assertion (== x (+ y 1)) failed: x is 1, (+ y 1) is 3.")))
+
+         (it "shows nothing more for a non-call"
+             (let ((ok #f))
+               (assert-eq (assertion-message (lambda () (assert ok)))
+                          "Assertion ok failed.")
+               (assert-eq (assertion-message (lambda () (assert (and ok #t))))
+                          "Assertion (and ok #t) failed.")))
+
+         (it "puts a custom message first"
+             (let ((x 1)
+                   (y 2))
+               (assert-eq (assertion-message (lambda () (assert (== x y) "x and y differ")))
+                          "x and y differ. Assertion (== x y) failed: x is 1, y is 2.")))
+
+         (it "requires the message be a string"
+             (assert-error (assert #f 42))))
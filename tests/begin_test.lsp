@@ -8,4 +8,34 @@
              (assert-eq (begin 4)
                         4)
              (assert-eq (begin 1 2)
-                        2)))
+                        2))
+
+         (it "is nil when empty"
+             (assert-nil (begin)))
+
+         (it "evaluates in order"
+             (define log '())
+             (assert-eq (begin (set! log (cons 1 log))
+                               (set! log (cons 2 log))
+                               log)
+                        '(2 1)))
+
+         (it "sees earlier side effects"
+             (define x 1)
+             (assert-eq (begin (set! x (+ x 1)) (* x 10))
+                        20))
+
+         (it "puts several forms where one is expected"
+             (define y 0)
+             (assert-eq (if #t (begin (set! y 5) (+ y 1)) 'no)
+                        6)
+             (assert-eq y 5))
+
+         (it "stops at an error"
+             (define z 0)
+             (assert-error (begin (error "boom") (set! z 1)))
+             (assert-eq z 0))
+
+         (it "works when compiled"
+             (define (f x) (if x (begin (set! x 'changed) x) 'no))
+             (assert-eq ((compile f) #t) 'changed)))
@@ -0,0 +1,87 @@
+;;; -*- mode: Scheme -*-
+
+(context "bignum literals"
+
+         ()
+
+         (it "should read literals beyond int64 as bignums"
+             (assert-true (bignum? 123456789012345678901234567890))
+             (assert-eq (number->string 123456789012345678901234567890) "123456789012345678901234567890"))
+
+         (it "should read literals that fit as fixnums"
+             (assert-false (bignum? 9223372036854775807))
+             (assert-true (bignum? 9223372036854775808))
+             (assert-true (bignum? -9223372036854775809)))
+
+         (it "should be integers and numbers"
+             (assert-true (integer? 100000000000000000000))
+             (assert-true (number? 100000000000000000000))
+             (assert-false (float? 100000000000000000000))))
+
+(context "bignum arithmetic"
+
+         ()
+
+         (it "should compute expt exactly"
+             (assert-eq (expt 2 100) 1267650600228229401496703205376)
+             (assert-eq (pow 10 20) 100000000000000000000)
+             (assert-eq (expt 2 10) 1024))
+
+         (it "should still use floats for float exponents"
+             (assert-eq (expt 4.0 0.5) 2.0))
+
+         (it "should promote on addition overflow"
+             (assert-eq (+ 9223372036854775807 1) 9223372036854775808)
+             (assert-true (bignum? (+ 9223372036854775807 1))))
+
+         (it "should promote on subtraction overflow"
+             (assert-eq (- -9223372036854775808 1) -9223372036854775809))
+
+         (it "should promote on multiplication overflow"
+             (assert-eq (* 4294967296 4294967296) 18446744073709551616)
+             (assert-eq (* -1 -9223372036854775808) 9223372036854775808))
+
+         (it "should demote results that fit"
+             (assert-false (bignum? (- (expt 2 100) (expt 2 100) 5)))
+             (assert-eq (- (expt 2 64) (expt 2 64) 5) -5)
+             (assert-eq (quotient (expt 2 70) (expt 2 68)) 4))
+
+         (it "should support remainder and division"
+             (assert-eq (% (+ (expt 2 80) 7) (expt 2 80)) 7)
+             (assert-eq (/ (expt 2 80) (expt 2 79)) 2)
+             (assert-error (/ (expt 2 80) 0)))
+
+         (it "should mix with floats"
+             (assert-true (float? (+ (expt 2 70) 1.0))))
+
+         (it "should compare exactly"
+             (assert-true (< (expt 2 100) (+ (expt 2 100) 1)))
+             (assert-true (> (+ (expt 2 100) 1) (expt 2 100)))
+             (assert-true (>= (expt 2 100) (expt 2 100)))
+             (assert-false (== (expt 2 100) (+ (expt 2 100) 1)))
+             (assert-true (< 5 (expt 2 100))))
+
+         (it "should support the predicates"
+             (assert-true (even? (expt 2 100)))
+             (assert-true (odd? (+ (expt 2 100) 1)))
+             (assert-true (negative? (- 0 (expt 2 100))))
+             (assert-true (positive? (expt 2 100)))
+             (assert-eq (abs (- 0 (expt 2 100))) (expt 2 100))
+             (assert-eq (sign (- 0 (expt 2 100))) -1)))
+
+(context "bignum conversions"
+
+         ()
+
+         (it "should convert fixnums to bignums"
+             (assert-true (bignum? (->bignum 5)))
+             (assert-eq (->bignum 5) 5)
+             (assert-true (zero? (->bignum 0))))
+
+         (it "should convert bignums that fit to fixnums"
+             (assert-false (bignum? (->fixnum (->bignum 5))))
+             (assert-eq (->fixnum (->bignum 5)) 5))
+
+         (it "should refuse to truncate"
+             (assert-error (->fixnum (expt 2 100)))
+             (assert-error (->bignum 1.5))))
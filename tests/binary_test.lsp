@@ -72,3 +72,46 @@
              (assert-error (right-shift 2 'a))
              (assert-error (right-shift 2 '(a b))))
 )
+
+(context "bit operations"
+
+         ()
+
+         (it "should and, or and xor any number of integers"
+             (assert-eq (bit-and 12 10) 8)
+             (assert-eq (bit-and 15 7 3) 3)
+             (assert-eq (bit-or 12 10) 14)
+             (assert-eq (bit-xor 12 10) 6)
+             (assert-eq (bit-and) -1)
+             (assert-eq (bit-or) 0))
+
+         (it "should treat negative numbers as two's complement"
+             (assert-eq (bit-and -1 255) 255)
+             (assert-eq (bit-not 0) -1)
+             (assert-eq (bit-not 5) -6))
+
+         (it "should work on bignums"
+             (assert-eq (bit-or (expt 2 100) 1) (+ (expt 2 100) 1))
+             (assert-eq (bit-and (expt 2 100) 1) 0))
+
+         (it "should shift left for positive counts"
+             (assert-eq (arithmetic-shift 1 4) 16)
+             (assert-eq (arithmetic-shift 1 100) (expt 2 100)))
+
+         (it "should shift right preserving the sign for negative counts"
+             (assert-eq (arithmetic-shift 16 -2) 4)
+             (assert-eq (arithmetic-shift -16 -2) -4)
+             (assert-eq (arithmetic-shift -1 -10) -1))
+
+         (it "should count bits"
+             (assert-eq (bit-count 0) 0)
+             (assert-eq (bit-count 255) 8)
+             (assert-eq (bit-count -1) 0)
+             (assert-eq (bit-count (- (expt 2 100) 1)) 100))
+
+         (it "should reject non-integers"
+             (assert-error (bit-and 1 1.5))
+             (assert-error (bit-or 'a))
+             (assert-error (bit-not "1"))
+             (assert-error (arithmetic-shift 1 'a))
+             (assert-error (bit-count 1/2))))
@@ -76,5 +76,28 @@
                    (assert-eq (multi-test-func 8)
                               "some")
                    (assert-eq (multi-test-func 9)
-                              "many")))
+                              "many"))
 
+         (it case-evaluates-the-key-once
+                   (define calls 0)
+                   (assert-eq (case (begin (set! calls (+ calls 1)) 'b)
+                                ((a) 1)
+                                ((b) 2)
+                                ((c) 3))
+                              2)
+                   (assert-eq calls 1))
+
+         (it case-keys-are-literal
+                   (define a 'b)
+                   (assert-eq (case 'a ((a) 'literal) (else 'evaluated)) 'literal)
+                   (assert-eq (case 'b ((a) 'evaluated) (else 'no-match)) 'no-match))
+
+         (it case-without-else
+                   (assert-nil (case 'z ((a) 1) ((b) 2))))
+
+         (it case-first-match-wins
+                   (assert-eq (case 1 ((1 2) 'first) ((1) 'second)) 'first))
+
+         (it case-dispatches-on-symbols-and-booleans
+                   (assert-eq (case 'blue ((red green) 'warm) ((blue) 'cool)) 'cool)
+                   (assert-eq (case #f ((#t) 'yes) ((#f) 'no)) 'no)))
@@ -74,3 +74,82 @@
          (it "should not accept strings for shortcuts"
              (assert-error ("buffered<-" 1))
              (assert-error ("<-buffered"))))
+
+(context "channel aliases"
+
+         (
+             (define c (make-channel 1))
+             (define closed-channel (make-channel))
+             (channel-close closed-channel)
+         )
+
+         (it "should send and receive"
+             (assert-eq (begin
+                           (channel-send c 42)
+                           (channel-receive c))
+                         '(42 #t)))
+
+         (it "should signal closure on receive"
+             (assert-eq (channel-receive closed-channel) '(() #f)))
+
+         (it "should error sending to a closed channel"
+             (assert-error (channel-send closed-channel 1)))
+
+         (it "should error closing twice"
+             (assert-error (channel-close closed-channel)))
+
+         (it "should name the called primitive in errors"
+             (assert-true (on-error (channel-send 1 2)
+                                    (lambda (err) (substring? "channel-send" err))))
+             (assert-true (on-error (channel-receive 1)
+                                    (lambda (err) (substring? "channel-receive" err))))
+             (assert-true (on-error (channel-close closed-channel)
+                                    (lambda (err) (substring? "channel-close" err))))))
+
+(context "channel-select"
+
+         (
+             (define a (make-channel 1))
+             (define b (make-channel 1))
+             (define closed-channel (make-channel))
+             (close-channel closed-channel)
+         )
+
+         (it "should dispatch to the ready channel"
+             (assert-eq (begin
+                           (channel-write b 2)
+                           (channel-select (a (lambda (v) (list 'a v)))
+                                           (b (lambda (v) (list 'b v)))))
+                         '(b 2)))
+
+         (it "should block until a channel is ready"
+             (assert-eq (begin
+                           (fork (lambda (p) (channel-write a 1)))
+                           (channel-select (a (lambda (v) v))
+                                           (b (lambda (v) v))))
+                         1))
+
+         (it "should use the else clause when nothing is ready"
+             (assert-eq (channel-select (a (lambda (v) v))
+                                        (else (lambda () 'none)))
+                         'none))
+
+         (it "should pick one of several ready channels"
+             (channel-write a 1)
+             (channel-write b 2)
+             (let ((chosen (channel-select (a (lambda (v) v))
+                                           (b (lambda (v) v)))))
+               (assert-memq '(1 2) chosen)))
+
+         (it "should report closure to handlers that take more?"
+             (assert-eq (channel-select (closed-channel (lambda (v more) (list v more))))
+                        '(() #f))
+             (assert-eq (begin
+                          (channel-write a '())
+                          (channel-select (a (lambda (v more) (list v more)))))
+                        '(() #t)))
+
+         (it "should validate clauses"
+             (assert-error (channel-select (1 (lambda (v) v))))
+             (assert-error (channel-select (a 5)))
+             (assert-error (channel-select a))))
@@ -0,0 +1,66 @@
+;;; -*- mode: Scheme -*-
+
+(context "characters"
+
+         ()
+
+         (it "reads characters"
+             (assert-true (char? #\a))
+             (assert-false (char? "a"))
+             (assert-eq (char->integer #\a) 97)
+             (assert-eq (char->integer #\space) 32)
+             (assert-eq (char->integer #\newline) 10)
+             (assert-eq (char->integer #\x41) 65)
+             (assert-eq (char->integer #\() 40)
+             (assert-eq (list #\a #\b) (list (integer->char 97) (integer->char 98))))
+
+         (it "writes characters"
+             (assert-eq (with-output-to-string (lambda () (write (list #\a #\space #\newline #\x7)))) "(#\\a #\\space #\\newline #\\alarm)")
+             (assert-eq (with-output-to-string (lambda () (display #\a))) "a"))
+
+         (it "handles multi-byte characters"
+             (assert-eq (char->integer #\λ) 955)
+             (assert-eq (string-ref "aλb" 1) #\λ)
+             (assert-eq (string-ref "aλb" 2) #\b)
+             (assert-eq (integer->char 955) #\λ)
+             (assert-eq (char-upcase #\λ) #\Λ))
+
+         (it "converts case"
+             (assert-eq (char-upcase #\a) #\A)
+             (assert-eq (char-downcase #\A) #\a)
+             (assert-eq (char-upcase #\1) #\1))
+
+         (it "classifies characters"
+             (assert-true (char-alphabetic? #\a))
+             (assert-false (char-alphabetic? #\1))
+             (assert-true (char-numeric? #\7))
+             (assert-false (char-numeric? #\x))
+             (assert-true (char-whitespace? #\space))
+             (assert-true (char-whitespace? #\tab))
+             (assert-false (char-whitespace? #\a)))
+
+         (it "compares characters"
+             (assert-true (eqv? #\a #\a))
+             (assert-false (equal? #\a "a")))
+
+         (it "rejects bad arguments"
+             (assert-error (char->integer "a"))
+             (assert-error (integer->char -1))
+             (assert-error (integer->char 55296))
+             (assert-error (char-upcase 1))))
+
+(context "strings and characters"
+
+         ()
+
+         (it "assembles strings"
+             (assert-eq (string #\a #\b #\c) "abc")
+             (assert-eq (string #\λ "xy" #\z) "λxyz")
+             (assert-eq (string) "")
+             (assert-error (string 1)))
+
+         (it "indexes strings"
+             (assert-eq (string-ref "abc" 0) #\a)
+             (assert-error (string-ref "abc" 3))
+             (assert-error (string-ref "abc" -1))
+             (assert-error (string-ref 'abc 0))))
@@ -0,0 +1,45 @@
+;;; -*- mode: Scheme -*-
+
+(context "compile"
+
+         ((define (fact n) (if (< n 2) 1 (* n (fact (- n 1)))))
+          (define (count-down n) (cond ((== n 0) 'done) (else (count-down (- n 1)))))
+          (define (sum-all . xs) (apply + xs))
+          (define (with-let x) (let ((y (* x 2))) (+ x y)))
+          (define (bump x) (set! x (+ x 1)) x)
+          (define (adder n) (lambda (x) (+ x n)))
+          (define (twice x) (list x x))
+          (define (fails x) (+ x "a"))
+          (define compiled-fact (compile fact))
+          (set! count-down (compile count-down)))
+
+         (it "makes compiled functions"
+             (assert-true (compiled-function? compiled-fact))
+             (assert-false (compiled-function? fact))
+             (assert-false (compiled-function? car))
+             (assert-eq (compile compiled-fact) compiled-fact))
+
+         (it "computes what the interpreter does"
+             (assert-eq (compiled-fact 10) (fact 10))
+             (assert-eq ((compile sum-all) 1 2 3) 6)
+             (assert-eq ((compile with-let) 3) 9)
+             (assert-eq ((compile bump) 1) 2)
+             (assert-eq ((compile twice) '(a)) '((a) (a)))
+             (assert-eq (((compile adder) 5) 1) 6))
+
+         (it "runs recursion through its name in constant space"
+             (assert-eq (count-down 20000) 'done))
+
+         (it "compiles lambdas"
+             (assert-eq ((compile (lambda (a b) (when (> a b) (- a b)))) 5 3) 2)
+             (assert-nil ((compile (lambda (a b) (when (> a b) (- a b)))) 3 5)))
+
+         (it "compiles functions defined in frames"
+             (let ((f {a: 1 double: (lambda (x) (* x 2)) run: (lambda () (double a))}))
+               (set-slot! f run: (compile (get-slot f run:)))
+               (assert-eq (send f run:) 2)))
+
+         (it "raises the interpreter's errors"
+             (assert-error ((compile fails) 1))
+             (assert-error (compiled-fact))
+             (assert-error (compile 1))))
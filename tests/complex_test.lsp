@@ -0,0 +1,80 @@
+;;; -*- mode: Scheme -*-
+
+(context "complex numbers"
+
+         ()
+
+         (it "reads and writes complex numbers"
+             (assert-true (complex? 3+4i))
+             (assert-true (number? 3+4i))
+             (assert-true (complex? 3))
+             (assert-true (complex? 1/2))
+             (assert-false (complex? 'a))
+             (assert-true (real? 3))
+             (assert-true (real? 2.5))
+             (assert-false (real? 3+4i))
+             (assert-false (real? "3"))
+             (assert-eq +i (make-rectangular 0 1))
+             (assert-eq -i (make-rectangular 0 -1))
+             (assert-eq '(+i -i) (list (make-rectangular 0 1) (make-rectangular 0 -1)))
+             (assert-true (symbol? 'x+i))
+             (assert-eq (number->string +i) "0+1i")
+             (assert-eq (number->string 3+4i) "3+4i")
+             (assert-eq (number->string 1/2-i) "0.5-1i")
+             (assert-eq (number->string -2.5i) "0-2.5i")
+             (assert-eq (parse (number->string 1.5-2i)) 1.5-2i)
+             (assert-true (eqv? 1+2i (make-rectangular 1 2))))
+
+         (it "builds complex numbers"
+             (assert-eq (make-rectangular 3 4) 3+4i)
+             (assert-eq (make-rectangular 3 0) 3.0)
+             (assert-eq (make-polar 2 0) 2.0)
+             (assert-error (make-rectangular 1+i 2)))
+
+         (it "takes complex numbers apart"
+             (assert-eq (real-part 3+4i) 3.0)
+             (assert-eq (imag-part 3+4i) 4.0)
+             (assert-eq (magnitude 3+4i) 5.0)
+             (assert-eq (magnitude -3) 3)
+             (assert-eq (angle 5) 0)
+             (assert-eq (angle 1i) (/ pi 2))
+             (assert-eq (real-part 7) 7)
+             (assert-eq (imag-part 7) 0)
+             (assert-error (real-part "a")))
+
+         (it "does arithmetic"
+             (assert-eq (+ 1 2+3i) 3+3i)
+             (assert-eq (- 5+i 2+i) 3.0)
+             (assert-eq (* 1+i 1-i) 2.0)
+             (assert-eq (* 2i 2i) -4.0)
+             (assert-eq (/ 1+2i 2) 0.5+1i)
+             (assert-error (/ 1+i 0))
+             (assert-error (+ 1+i "a")))
+
+         (it "takes square roots and logarithms of negative numbers"
+             (assert-eq (sqrt -1) +i)
+             (assert-eq (sqrt -4) 2i)
+             (assert-eq (sqrt 2i) 1+i)
+             (assert-eq (* (sqrt -9) (sqrt -9)) -9.0)
+             (assert-eq (log -1) 0+3.141592653589793i)
+             (assert-true (< (abs (- (log -8 2) (make-rectangular 3 (/ 3.141592653589793 (log 2))))) 0.000001))
+             (assert-error (log +i 1)))
+
+         (it "applies transcendental functions to complex numbers"
+             (assert-true (< (abs (+ (exp (* +i 3.141592653589793)) 1)) 0.000001))
+             (assert-true (< (abs (- (sin +i) (* +i (sinh 1)))) 0.000001))
+             (assert-true (< (abs (- (cos +i) (cosh 1))) 0.000001))
+             (assert-true (< (abs (- (tan (atan 1+i)) 1+i)) 0.000001))
+             (assert-true (< (abs (- (sin (asin 2)) 2)) 0.000001))
+             (assert-error (gamma +i))
+             (assert-error (floor 1+i)))
+
+         (it "takes absolute values and powers of complex numbers"
+             (assert-eq (abs 3+4i) 5.0)
+             (assert-eq (abs -3-4i) 5.0)
+             (assert-eq (expt +i 2) -1.0)
+             (assert-eq (expt 1+i 2) 2i)
+             (assert-eq (expt 2i -1) -0.5i)
+             (assert-true (< (abs (- (expt -8 1/3) 1+1.7320508075688772i)) 0.000001))
+             (assert-true (< (abs (- (expt 2 +i) (exp (* +i (log 2))))) 0.000001))
+             (assert-error (expt +i 'a))))
@@ -22,12 +22,84 @@
                              (join f)
                              (join f))))
 
-         (it "shouldn't allow resetting or abandoning non-scheduled tasks"
+         (it "shouldn't allow resetting non-scheduled tasks"
              (assert-error (reset-timeout f))
-             (assert-error (abandon f))
              (assert-nerror (reset-timeout s))
              (assert-nerror (abandon s))))
 
+(context "fork arguments"
+
+         ()
+
+         (it "should fork zero-arg functions"
+             (assert-eq (proc-join (fork (lambda () 'done))) 'done))
+
+         (it "should prepend the process when the function has room for it"
+             (assert-true (proc-join (fork (lambda (proc a b) (and (not (nil? proc)) (== (+ a b) 3))) 1 2))))
+
+         (it "should pass the arguments alone when the function takes exactly those"
+             (assert-eq (proc-join (fork (lambda (a b) (+ a b)) 1 2)) 3))
+
+         (it "should schedule functions without the process"
+             (assert-eq (proc-join (schedule 0 (lambda (x) (* x 2)) 21)) 42))
+
+         (it "should reject mismatched arity"
+             (assert-error (fork (lambda (a b c) a) 1))
+             (assert-error (fork (lambda () 1) 1 2))))
+
+(context "proc-sleep-remaining"
+
+         ()
+
+         (it "should report no time remaining after a full sleep"
+             (assert-eq (receive all (proc-join (fork (lambda (self) (proc-sleep-remaining self 5))))
+                          all)
+                        '(#f 0)))
+
+         (it "should report the time remaining when woken"
+             (define p (fork (lambda (self) (proc-sleep-remaining self 1000))))
+             (proc-join-timeout p 20)
+             (wake p)
+             (receive (woken remaining) (proc-join p)
+               (assert-true woken)
+               (assert-true (> remaining 500))
+               (assert-true (< remaining 1000))))
+
+         (it "should be just woken? where one value is expected"
+             (assert-false (proc-join (fork (lambda (self) (proc-sleep-remaining self 5))))))
+
+         (it "should validate its arguments"
+             (assert-error (proc-sleep-remaining 5 10))
+             (assert-error (proc-join (fork (lambda (self) (proc-sleep-remaining self 'soon)))))))
+
+(context "proc-join"
+
+         (
+             (define f (fork (lambda (proc) (proc-sleep proc 10) 42)))
+             (define slow (fork (lambda (proc) (proc-sleep proc 1000) 'late)))
+             (define failing (fork (lambda (proc) (error "boom"))))
+         )
+
+         (it "should return the forked function's result"
+             (assert-eq (proc-join f) 42))
+
+         (it "should return the cached result on later joins"
+             (proc-join f)
+             (assert-eq (proc-join f) 42))
+
+         (it "should return nil when the timeout expires first"
+             (assert-nil (proc-join-timeout slow 5)))
+
+         (it "should return the result when the process finishes in time"
+             (assert-eq (proc-join-timeout f 1000) 42))
+
+         (it "should surface errors from the forked function"
+             (assert-error (proc-join failing)))
+
+         (it "should reject non-process arguments"
+             (assert-error (proc-join 5))
+             (assert-error (proc-join-timeout f 'soon))))
+
 (context "atomic"
 
          (
@@ -93,3 +165,322 @@
              (assert-error (atomic-add! 0 0))
              (assert-error (atomic-swap! 0 0))
              (assert-error (atomic-compare-and-swap! 0 0 0))))
+
+(context "atomic aliases"
+
+         (
+             (define counter (make-atomic))
+             (define sequence (make-atomic 10))
+         )
+
+         (it "should start at the initial value"
+             (assert-eq (atomic-get counter) 0)
+             (assert-eq (atomic-get sequence) 10))
+
+         (it "should return the new value from atomic-add"
+             (assert-eq (atomic-add sequence 5) 15)
+             (assert-eq (atomic-get sequence) 15))
+
+         (it "should report whether atomic-compare-and-swap succeeded"
+             (assert-true (atomic-compare-and-swap counter 0 1))
+             (assert-false (atomic-compare-and-swap counter 0 2))
+             (assert-eq (atomic-get counter) 1))
+
+         (it "should count race-free across processes"
+             (for-each proc-join
+                       (map (lambda (i) (fork (lambda () (atomic-add counter 1))))
+                            '(1 2 3 4 5 6 7 8 9 10)))
+             (assert-eq (atomic-get counter) 10))
+
+         (it "should name the called primitive in errors"
+             (assert-true (on-error (atomic-get 0)
+                                    (lambda (err) (substring? "atomic-get" err))))
+             (assert-true (on-error (make-atomic 1.5)
+                                    (lambda (err) (substring? "make-atomic" err))))
+             (assert-true (on-error (atomic-compare-and-swap counter 0.0 1)
+                                    (lambda (err) (substring? "atomic-compare-and-swap expects" err))))))
+
+(context "mutex"
+
+         (
+             (define m (make-mutex))
+             (define counter 0)
+         )
+
+         (it "should lock and unlock"
+             (assert-nerror (begin
+                              (mutex-lock m)
+                              (mutex-unlock m))))
+
+         (it "should error when unlocking an unlocked mutex"
+             (assert-error (mutex-unlock m)))
+
+         (it "should return the body's value from with-mutex"
+             (assert-eq (with-mutex m (set! counter 1) (+ counter 1)) 2))
+
+         (it "should unlock when the body errors"
+             (assert-error (with-mutex m (error "boom")))
+             (assert-eq (with-mutex m 'relocked) 'relocked))
+
+         (it "should serialize forked updates"
+             (define workers (map (lambda (i)
+                                    (fork (lambda (proc)
+                                            (with-mutex m
+                                                        (let ((old counter))
+                                                          (proc-sleep proc 1)
+                                                          (set! counter (+ old 1)))))))
+                                  '(1 2 3 4 5)))
+             (for-each proc-join workers)
+             (assert-eq counter 5))
+
+         (it "should validate its argument"
+             (assert-error (mutex-lock 5))
+             (assert-error (with-mutex 5 1))))
+
+(context "proc-status"
+
+         ()
+
+         (it "should report running and completed forks"
+             (define m (make-mutex))
+             (mutex-lock m)
+             (define p (fork (lambda () (with-mutex m 'done))))
+             (assert-eq (proc-status p) 'running)
+             (mutex-unlock m)
+             (proc-join p)
+             (assert-eq (proc-status p) 'completed))
+
+         (it "should report sleeping processes"
+             (define p (fork (lambda (proc) (proc-sleep proc 1000))))
+             (proc-join-timeout p 20)
+             (assert-eq (proc-status p) 'sleeping)
+             (wake p)
+             (proc-join p)
+             (assert-eq (proc-status p) 'completed))
+
+         (it "should report pending and abandoned scheduled tasks"
+             (define s (schedule 1000 (lambda () 'never)))
+             (assert-eq (proc-status s) 'pending)
+             (abandon s)
+             (proc-join s)
+             (assert-eq (proc-status s) 'abandoned))
+
+         (it "should report completed scheduled tasks"
+             (define s (schedule 0 (lambda () 'now)))
+             (proc-join s)
+             (assert-eq (proc-status s) 'completed))
+
+         (it "should validate its argument"
+             (assert-error (proc-status 5))))
+
+(context "waitgroup"
+
+         (
+             (define g (make-waitgroup))
+             (define total (atomic))
+         )
+
+         (it "should wait for all workers"
+             (waitgroup-add g 3)
+             (for-each (lambda (i)
+                         (fork (lambda (proc)
+                                 (proc-sleep proc 5)
+                                 (atomic-add! total i)
+                                 (waitgroup-done g))))
+                       '(1 2 3))
+             (waitgroup-wait g)
+             (assert-eq (atomic-load total) 6))
+
+         (it "should return immediately when the counter is zero"
+             (assert-nerror (waitgroup-wait g)))
+
+         (it "should reject negative counters"
+             (assert-error (waitgroup-done g))
+             (assert-error (waitgroup-add g -1))
+             (assert-nerror (begin
+                              (waitgroup-add g 1)
+                              (waitgroup-done g))))
+
+         (it "should validate its arguments"
+             (assert-error (waitgroup-add g 'one))
+             (assert-error (waitgroup-wait 5))))
+
+(context "parallel-map"
+
+         ()
+
+         (it "should return results in order"
+             (assert-eq (parallel-map (lambda (x) (* x x)) '(1 2 3 4)) '(1 4 9 16)))
+
+         (it "should handle empty lists"
+             (assert-nil (parallel-map (lambda (x) x) '())))
+
+         (it "should work with primitives"
+             (assert-eq (parallel-map car '((1 2) (3 4))) '(1 3)))
+
+         (it "should propagate errors"
+             (assert-error (parallel-map (lambda (x) (error "boom")) '(1 2))))
+
+         (it "should validate its arguments"
+             (assert-error (parallel-map 5 '(1)))
+             (assert-error (parallel-map car 5))))
+
+(context "proc-error"
+
+         (
+             (define ok (fork (lambda () 'fine)))
+             (define failing (fork (lambda () (error "worker failed"))))
+             (define crashing (fork (lambda () (panic! "worker crashed"))))
+         )
+
+         (it "should be nil for processes that succeed"
+             (assert-nil (proc-error ok)))
+
+         (it "should return an error object for failed processes"
+             (define e (proc-error failing))
+             (assert-true (error-object? e))
+             (assert-true (substring? "worker failed" (error-object-message e)))
+             (assert-eq (error-object-type e) 'error))
+
+         (it "should return the condition a process signalled"
+             (define c (make-condition 'simple-error message: "worker gave up"))
+             (define e (proc-error (fork (lambda () (signal c)))))
+             (assert-true (condition? e))
+             (assert-true (eq? e c))
+             (assert-eq (simple-error-message e) "worker gave up")
+             (assert-true (condition? (proc-error (fork (lambda () (error c)))))))
+
+         (it "should capture panics"
+             (define e (proc-error crashing))
+             (assert-eq (error-object-type e) 'panic)
+             (assert-true (substring? "worker crashed" (error-object-message e))))
+
+         (it "should validate its arguments"
+             (assert-error (proc-error 5))
+             (assert-false (error-object? "worker failed"))
+             (assert-error (error-object-message "worker failed"))))
+
+(context "supervise"
+
+         (
+             (define attempts (atomic))
+         )
+
+         (it "should return the result of a successful run"
+             (assert-eq (proc-join (supervise 0 (lambda () 'ok) 3)) 'ok))
+
+         (it "should retry until the function succeeds"
+             (define p (supervise 0 (lambda ()
+                                      (if (< (atomic-add! attempts 1) 3)
+                                          (error "not yet")
+                                          'succeeded))
+                                  5 1))
+             (assert-eq (proc-join p) 'succeeded)
+             (assert-eq (atomic-load attempts) 3)
+             (assert-eq (proc-status p) 'completed)
+             (assert-nil (proc-error p)))
+
+         (it "should give up after max-retries"
+             (define p (supervise 0 (lambda (proc)
+                                      (atomic-add! attempts 1)
+                                      (error "always"))
+                                  2))
+             (assert-true (error-object? (proc-error p)))
+             (assert-eq (atomic-load attempts) 3))
+
+         (it "should stop retrying when abandoned"
+             (define p (supervise 1000 (lambda () (error "never run")) 3))
+             (abandon p)
+             (assert-nil (proc-error p))
+             (assert-eq (proc-status p) 'abandoned))
+
+         (it "should validate its arguments"
+             (assert-error (supervise 'soon (lambda () 1) 1))
+             (assert-error (supervise 0 5 1))
+             (assert-error (supervise 0 (lambda () 1) -1))
+             (assert-error (supervise 0 (lambda () 1) 1 'slowly))
+             (assert-error (supervise 0 (lambda (a b) 1) 1))))
+
+(context "cancellation"
+
+         (
+             (define parent (schedule 1000 (lambda () 'never)))
+             (define worker (lambda (self)
+                              (do ((steps 0 (+ steps 1)))
+                                  ((proc-cancelled? self) 'stopped)
+                                (proc-sleep self 1))))
+         )
+
+         (it "should not be cancelled initially"
+             (assert-false (proc-cancelled? parent))
+             (abandon parent))
+
+         (it "should cascade abandon to children"
+             (define child (fork parent worker))
+             (define grandchild (fork child worker))
+             (abandon parent)
+             (assert-eq (proc-join child) 'stopped)
+             (assert-eq (proc-join grandchild) 'stopped)
+             (assert-true (proc-cancelled? parent)))
+
+         (it "should cascade abandoning a forked process to its children"
+             (define p (fork worker))
+             (define child (fork p worker))
+             (abandon p)
+             (assert-eq (proc-join p) 'stopped)
+             (assert-eq (proc-join child) 'stopped)
+             (assert-true (proc-cancelled? child))
+             (abandon parent))
+
+         (it "should cancel forked processes with proc-cancel"
+             (define p (fork worker))
+             (define child (fork p worker))
+             (proc-cancel p)
+             (assert-eq (proc-join p) 'stopped)
+             (assert-eq (proc-join child) 'stopped)
+             (abandon parent))
+
+         (it "should not cancel the parent when a child is cancelled"
+             (define child (fork parent worker))
+             (proc-cancel child)
+             (assert-eq (proc-join child) 'stopped)
+             (assert-false (proc-cancelled? parent))
+             (abandon parent))
+
+         (it "should pass arguments after the parent and function"
+             (assert-eq (proc-join (fork parent (lambda (a b) (+ a b)) 1 2)) 3)
+             (abandon parent))
+
+         (it "should validate its arguments"
+             (assert-error (proc-cancelled? 5))
+             (assert-error (proc-cancel 5))
+             (abandon parent)))
+
+(context "with-timeout"
+
+         ()
+
+         (it "should return the result when the thunk finishes in time"
+             (assert-eq (with-timeout 1000 (lambda () 42)) 42))
+
+         (it "should return the timeout object when the deadline passes"
+             (define r (with-timeout 10 (lambda (self) (proc-sleep self 1000) 'late)))
+             (assert-true (timeout-object? r))
+             (assert-false (timeout-object? 42))
+             (assert-false (timeout-object? '__TIMEOUT__)))
+
+         (it "should cancel the timed out thunk"
+             (define stopped (atomic))
+             (with-timeout 10 (lambda (self)
+                                (do () ((proc-cancelled? self) (atomic-store! stopped 1))
+                                  (proc-sleep self 1))))
+             (sleep 20)
+             (assert-eq (atomic-load stopped) 1))
+
+         (it "should propagate errors from the thunk"
+             (assert-error (with-timeout 1000 (lambda () (error "boom")))))
+
+         (it "should validate its arguments"
+             (assert-error (with-timeout 'soon (lambda () 1)))
+             (assert-error (with-timeout 10 5))
+             (assert-error (with-timeout 10 (lambda (a b) a)))))
@@ -31,4 +31,32 @@
              (assert-eq (cond (#f 1 2 3)
                               (#f 4 5 6)
                               (else 7 8 9))
-                        9)))
+                        9))
+
+         (it "is the test's value for a clause without a body"
+             (assert-eq (cond (#f) ((+ 1 2)) (else 4))
+                        3)
+             (assert-eq (cond ((memq 'b '(a b c))))
+                        '(b c)))
+
+         (it "passes the test's value to the function after =>"
+             (define table '((a . 1) (b . 2)))
+             (assert-eq (cond ((assq 'b table) => cdr)
+                              (else 'none))
+                        2)
+             (assert-eq (cond ((assq 'z table) => cdr)
+                              (else 'none))
+                        'none)
+             (assert-eq (cond ((+ 1 2) => (lambda (x) (* x x))))
+                        9))
+
+         (it "evaluates the test only once for =>"
+             (define calls 0)
+             (assert-eq (cond ((begin (set! calls (+ calls 1)) 5) => (lambda (x) x)))
+                        5)
+             (assert-eq calls 1))
+
+         (it "errors on a bad => clause"
+             (assert-error (cond (#t => 5)))
+             (assert-error (cond (#t => car cdr)))
+             (assert-error (cond (#t =>)))))
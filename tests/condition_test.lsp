@@ -0,0 +1,93 @@
+;;; -*- mode: Scheme -*-
+
+(define-condition app-error () (code))
+(define-condition not-found (app-error) (name))
+
+(context "conditions"
+
+         ()
+
+         (it "should make conditions with slots"
+             (let ((c (make-condition 'not-found code: 404 name: "x")))
+               (assert-true (condition? c))
+               (assert-eq (condition-type c) 'not-found)
+               (assert-eq (not-found-name c) "x")
+               (assert-eq (not-found-code c) 404)
+               (assert-eq (app-error-code c) 404)
+               (assert-eq (condition-slot c 'name) "x")))
+
+         (it "should leave missing slots nil"
+             (assert-nil (app-error-code (make-condition 'app-error))))
+
+         (it "should check slots and types"
+             (assert-error (make-condition 'no-such-condition))
+             (assert-error (make-condition 'app-error name: 1))
+             (assert-error (make-condition 'app-error code:))
+             (assert-error (not-found-name (make-condition 'app-error)))
+             (assert-error (app-error-code 5)))
+
+         (it "should catch a signalled condition by type"
+             (assert-eq (handler-case (signal (make-condition 'app-error code: 1))
+                          (app-error (c) (app-error-code c)))
+                        1))
+
+         (it "should raise conditions with error"
+             (assert-eq (handler-case (error (make-condition 'app-error code: 2))
+                          (app-error (c) (app-error-code c)))
+                        2))
+
+         (it "should catch conditions raised inside functions"
+             (define (f) (signal (make-condition 'not-found name: "deep")))
+             (assert-eq (handler-case (f)
+                          (not-found (c) (not-found-name c)))
+                        "deep"))
+
+         (it "should pick the most specific handler"
+             (assert-eq (handler-case (signal (make-condition 'not-found))
+                          (error () 'error)
+                          (app-error () 'app-error)
+                          (not-found () 'not-found))
+                        'not-found)
+             (assert-eq (handler-case (signal (make-condition 'app-error))
+                          (condition () 'condition)
+                          (not-found () 'not-found)
+                          (app-error () 'app-error))
+                        'app-error))
+
+         (it "should pass values through when nothing is raised"
+             (assert-eq (handler-case (+ 1 2)
+                          (error () 'error))
+                        3))
+
+         (it "should rethrow unmatched conditions"
+             (assert-eq (handler-case (handler-case (signal (make-condition 'app-error code: 3))
+                                        (not-found () 'inner))
+                          (app-error (c) (list 'outer (app-error-code c))))
+                        '(outer 3))
+             (assert-error (handler-case (signal (make-condition 'app-error))
+                             (not-found () 'inner))))
+
+         (it "should treat plain errors as simple-errors"
+             (assert-eq (handler-case (error 'boom)
+                          (simple-error (c) (simple-error-message c)))
+                        "boom")
+             (assert-eq (handler-case (car (list (error 'boom)))
+                          (simple-error (c) (simple-error-message c)))
+                        "boom")
+             (assert-true (substring? "Evaling (list (error 'boom))"
+                                      (handler-case (car (list (error 'boom)))
+                                        (simple-error (c) (simple-error-trace c)))))
+             (assert-eq (handler-case (error "boom")
+                          (app-error () 'app)
+                          (error () 'error))
+                        'error))
+
+         (it "should not catch plain errors with user types"
+             (assert-error (handler-case (error "boom")
+                             (app-error () 'app))))
+
+         (it "should check handler clauses"
+             (assert-error (handler-case (error "boom")
+                             (no-such-type () 1)))
+             (assert-error (handler-case (error "boom")
+                             5))))
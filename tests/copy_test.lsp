@@ -12,3 +12,61 @@
                           '(5 2 3))
                (assert-eq b
                           '(1 2 3)))))
+
+(define-structure deep-copy-box value)
+
+(context "deep-copy"
+
+         ()
+
+         (it "copies nested lists"
+             (let* ((a (list 1 (list 2 3) 4))
+                    (b (deep-copy a)))
+               (set-car! (cadr a) 5)
+               (assert-eq b '(1 (2 3) 4))
+               (assert-eq a '(1 (5 3) 4))))
+
+         (it "is equal to the original"
+             (let ((a (list 1 (vector 2 "three") (list 'four))))
+               (assert-eq (deep-copy a) a)))
+
+         (it "copies vectors"
+             (let* ((a (vector 1 (vector 2)))
+                    (b (deep-copy a)))
+               (vector-set! (vector-ref a 1) 0 5)
+               (assert-eq (vector-ref (vector-ref b 1) 0) 2)))
+
+         (it "copies hash tables"
+             (let* ((a (list (make-hash-table)))
+                    (b (deep-copy a)))
+               (hash-set! (car a) (quote k) 1)
+               (assert-eq (hash-count (car b)) 0)))
+
+         (it "copies structures"
+             (let* ((a (make-deep-copy-box (list 1 2)))
+                    (b (deep-copy a)))
+               (set-car! (deep-copy-box-value a) 5)
+               (set-deep-copy-box-value! a 'gone)
+               (assert-true (deep-copy-box? b))
+               (assert-eq (deep-copy-box-value b) '(1 2))))
+
+         (it "keeps shared structure shared"
+             (let* ((shared (list 1 2))
+                    (b (deep-copy (list shared shared))))
+               (assert-true (eqv? (car b) (cadr b)))
+               (assert-false (eqv? (car b) shared))))
+
+         (it "copies cyclic lists"
+             (let* ((a (list 1 2 3))
+                    (b (begin (set-cdr! (cddr a) a)
+                              (deep-copy a))))
+               (assert-true (eqv? (cdddr b) b))
+               (assert-false (eqv? b a))
+               (set-car! a 5)
+               (assert-eq (car b) 1)))
+
+         (it "leaves atoms alone"
+             (assert-eq (deep-copy 5) 5)
+             (assert-eq (deep-copy "str") "str")
+             (assert-eq (deep-copy 'sym) 'sym)
+             (assert-nil (deep-copy '()))))
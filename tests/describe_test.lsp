@@ -0,0 +1,54 @@
+;;; -*- mode: Scheme -*-
+
+(define (describing x)
+  (with-output-to-string (lambda () (describe x))))
+
+(define (square x) (* x x))
+
+(define-structure describe-point x y)
+
+(context "describe"
+
+         ()
+
+         (it "shows the type of atoms"
+             (assert-eq (describing 42) "42\n  type: Integer\n")
+             (assert-eq (describing 1.5) "1.5\n  type: Float\n")
+             (assert-eq (describing 'sym) "sym\n  type: Symbol\n")
+             (assert-eq (describing "hé") "\"hé\"\n  type: String\n  length: 2\n"))
+
+         (it "shows the shape of lists"
+             (assert-eq (describing '(1 2 3)) "(1 2 3)\n  type: List\n  length: 3\n")
+             (assert-eq (describing '(1 2 . 3)) "(1 2 . 3)\n  type: List\n  improper, ending in 3\n  cells: 2\n")
+             (assert-eq (describing '()) "()\n  type: Nil\n")
+             (let ((l (list 1 2)))
+               (set-cdr! (cdr l) l)
+               (assert-true (string-suffix? "cyclic\n  cells: 2\n" (describing l)))))
+
+         (it "shows user functions with their source"
+             (assert-eq (describing square)
+                        "<function: square>\n  type: Function\n  name: square\n  parameters: (x)\n  arity: 1\n  source: (lambda (x) (* x x))\n")
+             (assert-true (substring? "arity: >=1" (describing (lambda (a . rest) a)))))
+
+         (it "shows primitives"
+             (assert-eq (describing car) "<prim: car>\n  type: Primitive Function\n  name: car\n  arity: 1\n")
+             (assert-true (substring? "type: Special Form" (describing if)))
+             (assert-true (substring? "restricted" (describing load))))
+
+         (it "shows what is in containers"
+             (let ((h (make-hash-table)))
+               (hash-set! h 'b 2)
+               (hash-set! h 'a 1)
+               (assert-true (string-suffix? "type: Hash Table\n  entries: 2\n  a => 1\n  b => 2\n" (describing h))))
+             (assert-eq (describing (make-describe-point 1 "two"))
+                        "#[describe-point x: 1 y: \"two\"]\n  type: Structure describe-point\n  x: 1\n  y: \"two\"\n")
+             (assert-eq (describing (vector 1 2)) "#(1 2)\n  type: Vector\n  length: 2\n")
+             (assert-eq (describing (make-frame a: 1 b: 2)) "{a: 1 b: 2}\n  type: Frame\n  slots: 2\n  a: 1\n  b: 2\n"))
+
+         (it "samples large containers"
+             (let ((s (list->set (interval 10))))
+               (assert-true (substring? "elements: 10" (describing s)))
+               (assert-true (string-suffix? "and 5 more\n" (describing s)))))
+
+         (it "rejects anything but a port to write to"
+             (assert-error (describe 1 "not a port"))))
@@ -0,0 +1,40 @@
+;;; -*- mode: Scheme -*-
+
+(context "digests"
+
+         ()
+
+         (it "computes md5"
+             (assert-eq (md5 "hello") "5d41402abc4b2a76b9719d911017c592"))
+
+         (it "computes sha1"
+             (assert-eq (sha1 "hello") "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"))
+
+         (it "computes sha256"
+             (assert-eq (sha256 "hello") "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+             (assert-eq (sha256 "") "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+
+         (it "digests bytearrays like strings"
+             (assert-eq (md5 (string->bytes "hello")) (md5 "hello"))
+             (assert-eq (string-length (hex-decode (sha256 "x"))) 32))
+
+         (it "rejects what isn't bytes"
+             (assert-error (md5 42))
+             (assert-error (sha1 'hello))
+             (assert-error (sha256 '("hello")))))
+
+(context "hash"
+
+         ()
+
+         (it "hashes strings with fnv-1a"
+             (assert-eq (hash "hello") 2607821981565500683))
+
+         (it "hashes equal values the same"
+             (assert-eq (hash (list 1 "a" 'b)) (hash (list 1 "a" 'b)))
+             (assert-eq (hash 12) (hash 12))
+             (assert-false (== (hash "a") (hash "b"))))
+
+         (it "is a non-negative integer"
+             (assert-true (integer? (hash 'sym)))
+             (assert-true (>= (hash (vector 1 2)) 0))))
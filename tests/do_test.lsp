@@ -18,6 +18,33 @@
                             ((eq? a 5) (cons a b)))
                         '(5 . 0)))
 
+         (it "counts"
+             (assert-eq (do ((i 0 (+ i 1))
+                             (acc '() (cons i acc)))
+                            ((== i 4) acc))
+                        '(3 2 1 0)))
+
+         (it "steps every variable from the previous values"
+             (assert-eq (do ((a 1 b)
+                             (b 2 a)
+                             (n 0 (+ n 1)))
+                            ((== n 3) (list a b)))
+                        '(2 1)))
+
+         (it "returns nil without result expressions"
+             (assert-nil (do ((i 0 (+ i 1))) ((== i 3)) 'body)))
+
+         (it "returns the last result expression"
+             (assert-eq (do ((i 0 (+ i 1))) ((== i 2) 'first 'last)) 'last))
+
+         (it "runs long loops"
+             (assert-eq (do ((i 0 (+ i 1))) ((== i 100000) i)) 100000))
+
+         (it "raises errors from the body, test and steps"
+             (assert-error (do ((i 0 (+ i 1))) ((== i 3)) (error "body")))
+             (assert-error (do ((i 0 (+ i 1))) ((error "test"))))
+             (assert-error (do ((i 0 (error "step"))) ((== i 3)))))
+
          (it "rejects non-list bindings"
              (assert-error (do 4 (#t) (+ 1 2))))
 
@@ -0,0 +1,53 @@
+;;; -*- mode: Scheme -*-
+
+(context "dynamic-wind"
+
+         ((define log '())
+          (define (note x) (lambda () (set! log (append log (list x))))))
+
+         (it "should run before, during and after in order"
+             (assert-eq (dynamic-wind (note 'before)
+                                      (lambda () ((note 'during)) 42)
+                                      (note 'after))
+                        42)
+             (assert-eq log '(before during after)))
+
+         (it "should run after when during errors"
+             (assert-error (dynamic-wind (note 'before)
+                                         (lambda () (error "boom"))
+                                         (note 'after)))
+             (assert-eq log '(before after)))
+
+         (it "should keep during's error"
+             (assert-true (substring? "during" (on-error (dynamic-wind (lambda () 1)
+                                                                       (lambda () (error "during"))
+                                                                       (lambda () (error "after")))
+                                                         (lambda (e) e)))))
+
+         (it "should raise after's error following a normal return"
+             (assert-error (dynamic-wind (lambda () 1) (lambda () 2) (lambda () (error "after")))))
+
+         (it "should not run during or after when before fails"
+             (assert-error (dynamic-wind (lambda () (error "before")) (note 'during) (note 'after)))
+             (assert-eq log '()))
+
+         (it "should unwind nested extents innermost first"
+             (assert-error (dynamic-wind (note 'outer-before)
+                                         (lambda ()
+                                           (dynamic-wind (note 'inner-before)
+                                                         (lambda () (error "boom"))
+                                                         (note 'inner-after)))
+                                         (note 'outer-after)))
+             (assert-eq log '(outer-before inner-before inner-after outer-after)))
+
+         (it "should run after when a process panics"
+             (define done (make-atomic 0))
+             (assert-error (proc-join (fork (lambda ()
+                                              (dynamic-wind (lambda () 1)
+                                                            (lambda () (panic! "go down"))
+                                                            (lambda () (atomic-store! done 1)))))))
+             (assert-eq (atomic-get done) 1))
+
+         (it "should only take thunks"
+             (assert-error (dynamic-wind 1 (lambda () 2) (lambda () 3)))
+             (assert-error (dynamic-wind (lambda () 1) (lambda () 2) 'after))))
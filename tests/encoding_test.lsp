@@ -0,0 +1,40 @@
+;;; -*- mode: Scheme -*-
+
+(context "base64"
+
+         ()
+
+         (it "encodes strings"
+             (assert-eq (base64-encode "hello") "aGVsbG8=")
+             (assert-eq (base64-encode "") "")
+             (assert-eq (base64-encode "λ") "zrs=")
+             (assert-eq (base64-encode [0 255]) "AP8="))
+
+         (it "decodes what it encodes"
+             (assert-eq (base64-decode "aGVsbG8=") "hello")
+             (assert-eq (base64-decode (base64-encode "λx")) "λx"))
+
+         (it "rejects invalid input"
+             (assert-error (base64-decode "not base64!"))
+             (assert-error (base64-decode "aGVsbG8"))
+             (assert-error (base64-encode 42))
+             (assert-error (base64-decode 'aGk=))))
+
+(context "hex"
+
+         ()
+
+         (it "encodes strings"
+             (assert-eq (hex-encode "hi") "6869")
+             (assert-eq (hex-encode "") "")
+             (assert-eq (hex-encode [1 171]) "01ab"))
+
+         (it "decodes either case"
+             (assert-eq (hex-decode "6869") "hi")
+             (assert-eq (hex-decode "4A4b") "JK")
+             (assert-eq (hex-decode (hex-encode "λx")) "λx"))
+
+         (it "rejects invalid input"
+             (assert-error (hex-decode "abc"))
+             (assert-error (hex-decode "zz"))
+             (assert-error (hex-encode 42))))
@@ -61,3 +61,47 @@
              (assert-error (make-top-level-environment '(a b) '(1 2 3))) ;different length names & values
              (assert-error (make-top-level-environment '(3 4) '(1 2))) ;not symbol binding names
              (assert-error (procedure-environment +)))) ;not a user defined function
+
+(context "environment introspection"
+
+         ((environment-define (system-global-environment) 'introspection-global 1))
+
+         (it "lists the bindings of the current frame"
+             (assert-eq (let ((b 2) (a 1)) (environment-bindings))
+                        '((a . 1) (b . 2)))
+             (assert-eq (let ((a 1)) (let ((c 3)) (environment-bindings)))
+                        '((c . 3)))
+             (assert-eq ((lambda (x) (environment-bindings)) 5)
+                        '((x . 5))))
+
+         (it "tells what is bound"
+             (assert-true (bound? 'introspection-global))
+             (assert-true (bound? 'car))
+             (assert-false (bound? '____not-bound))
+             (assert-true (let ((local 1)) (bound? 'local)))
+             (assert-true (let ((outer 1)) (let () (bound? 'outer))))
+             (assert-false (bound? 'local))
+             (assert-true (environment-bound? (system-global-environment) 'introspection-global))
+             (assert-false (bound? 'local (system-global-environment))))
+
+         (it "follows lexical rather than dynamic scope"
+             (define (dynamic-check) (bound? 'caller-local))
+             (assert-false (let ((caller-local 1)) (dynamic-check))))
+
+         (it "sees frame slots in methods"
+             (let ((f {a: 1 check: (lambda () (bound? 'a))}))
+               (assert-true (send f check:))))
+
+         (it "works from compiled functions"
+             (assert-true ((compile (lambda (x) (bound? 'x))) 1))
+             (assert-eq ((compile (lambda (x) (environment-bindings))) 1) '((x . 1))))
+
+         (it "lists the global symbols"
+             (let ((symbols (global-symbols)))
+               (assert-true (memq 'car symbols))
+               (assert-true (memq 'introspection-global symbols))
+               (assert-false (memq '____not-bound symbols))))
+
+         (it "rejects bad arguments"
+             (assert-error (bound? "a"))
+             (assert-error (bound? 'a 5))))
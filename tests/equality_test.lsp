@@ -12,3 +12,118 @@
              (assert-false (eq? 42 "42"))
              (assert-false (eq? (alist '((a.1))) (alist '((a.1) (b.2)))))
              (assert-false (eq? '(1 2) '(1 2 3)))))
+
+(context "eq?"
+
+         ()
+
+         (it "is true of symbols with the same name"
+             (assert-true (eq? 'a 'a))
+             (assert-true (eq? 'a (string->symbol "a")))
+             (assert-true (eq? (car '(a b)) (cadr '(b a))))
+             (assert-false (eq? 'a 'b)))
+
+         (it "keeps uninterned symbols to themselves"
+             (let ((sym (string->uninterned-symbol "a")))
+               (assert-true (eq? sym sym))
+               (assert-false (eq? sym 'a))))
+
+         (it "compares fixnums by value"
+             (assert-true (eq? 5 5))
+             (assert-true (eq? (+ 2 3) (- 10 5)))
+             (assert-true (eq? -1 (- 0 1)))
+             (assert-true (eq? 9223372036854775807 9223372036854775807))
+             (assert-false (eq? 5 6))
+             (assert-false (eq? 5 5.0)))
+
+         (it "compares booleans, characters and empty lists by value"
+             (assert-true (eq? #t (== 1 1)))
+             (assert-true (eq? #f (< 2 1)))
+             (assert-true (eq? #\a (string-ref "abc" 0)))
+             (assert-true (eq? '() (list)))
+             (assert-true (eq? '() (cdr '(1)))))
+
+         (it "compares other numbers by identity"
+             (let ((big 100000000000000000000)
+                   (half 1/2)
+                   (x 1.5))
+               (assert-true (eq? big big))
+               (assert-true (eq? half half))
+               (assert-true (eq? x x))
+               (assert-false (eq? big (* 10 10000000000000000000)))
+               (assert-false (eq? half (* 1/4 2)))
+               (assert-false (eq? x (+ 1.0 0.5)))
+               (assert-true (eqv? big (* 10 10000000000000000000)))
+               (assert-true (eqv? half (* 1/4 2)))))
+
+         (it "compares strings and structures by identity"
+             (let ((s (str "a" "b"))
+                   (l (list 1 2))
+                   (v (vector 1 2)))
+               (assert-true (eq? s s))
+               (assert-true (eq? l l))
+               (assert-true (eq? v v))
+               (assert-true (eq? (cdr l) (cdr l)))
+               (assert-false (eq? s "ab"))
+               (assert-false (eq? l (list 1 2)))
+               (assert-false (eq? v (vector 1 2)))
+               (assert-true (equal? s "ab"))
+               (assert-true (equal? l (list 1 2)))))
+
+         (it "is the opposite of neq?"
+             (assert-true (neq? (list 1) (list 1)))
+             (assert-false (neq? 'a 'a))
+             (assert-false (neq? 7 7))))
+
+(context "equal?"
+
+         ()
+
+         (it "compares nested structures element by element"
+             (assert-true (equal? '(1 (2 "three") #(4 (5))) (list 1 (list 2 "three") (vector 4 (list 5)))))
+             (assert-false (equal? '(1 (2 3)) '(1 (2 4))))
+             (assert-false (equal? '(1 2) '(1 2 3)))
+             (assert-true (equal? '(1 . 2) '(1 . 2)))
+             (assert-false (equal? '(1 . 2) '(1 . 3)))
+             (assert-false (equal? '(1 2) '(1 . 2))))
+
+         (it "keeps exact and inexact numbers apart"
+             (assert-false (equal? 1 1.0))
+             (assert-true (equal? 1.5 1.5))
+             (assert-true (equal? (/ 1 2) (/ 2 4)))
+             (assert-true (equal? (* 4611686018427387904 4) (* 2 9223372036854775808))))
+
+         (it "terminates on circular lists"
+             (define (make-cycle)
+               (let ((l (list 1 2 3)))
+                 (set-cdr! (cddr l) l)
+                 l))
+             (define a (make-cycle))
+             (define b (make-cycle))
+             (assert-true (equal? a a))
+             (assert-true (equal? a b))
+             (define c (list 1 2 4))
+             (set-cdr! (cddr c) c)
+             (assert-false (equal? a c))))
+
+(context "eqv?"
+
+         ()
+
+         (it "compares atoms by value"
+             (assert-true (eqv? 1 1))
+             (assert-true (eqv? 2.5 2.5))
+             (assert-false (eqv? 1 1.0))
+             (assert-true (eqv? 'a 'a))
+             (assert-true (eqv? #t #t))
+             (assert-true (eqv? '() (list))))
+
+         (it "compares structures by identity"
+             (define l (list 1 2))
+             (define v (vector 1 2))
+             (assert-true (eqv? l l))
+             (assert-true (eqv? v v))
+             (assert-false (eqv? l (list 1 2)))
+             (assert-false (eqv? v (vector 1 2)))
+             (assert-false (eqv? "abc" (str "ab" "c")))
+             (assert-true (equal? l (list 1 2)))))
@@ -0,0 +1,48 @@
+;;; -*- mode: Scheme -*-
+
+(context "file ports"
+
+         ((define path "/tmp/golisp-file-port-test.txt")
+          (call-with-output-file path
+                                 (lambda (port)
+                                   (write-string "first line" port)
+                                   (newline port)
+                                   (write-string "λx" port))))
+
+         (it "should read lines until the eof object"
+             (let ((port (open-input-file path)))
+               (assert-eq (read-line port) "first line")
+               (assert-eq (read-line port) "λx")
+               (assert-true (eof-object? (read-line port)))
+               (close-port port)))
+
+         (it "should read characters until the eof object"
+             (let ((port (open-input-file path)))
+               (read-line port)
+               (assert-eq (read-char port) "λ")
+               (assert-eq (read-char port) "x")
+               (assert-true (eof-object? (read-char port)))
+               (close-port port)))
+
+         (it "should append to an output file"
+             (let ((port (open-output-file path #t)))
+               (write-string "!" port)
+               (close-port port))
+             (let ((port (open-input-file path)))
+               (read-line port)
+               (assert-eq (read-line port) "λx!")
+               (close-port port)))
+
+         (it "should close the port when the procedure fails"
+             (define saved '())
+             (assert-error (call-with-output-file path
+                                                  (lambda (port)
+                                                    (set! saved port)
+                                                    (error "failed"))))
+             (assert-error (write-string "late" saved)))
+
+         (it "should check the arguments"
+             (assert-error (read-line 1))
+             (assert-error (read-char "port"))
+             (assert-error (call-with-output-file 1 (lambda (port) port)))
+             (assert-error (call-with-output-file path 1))))
@@ -12,7 +12,14 @@
              (assert-eq (filter even? '(2 4 6))
                         '(2 4 6))
              (assert-eq (filter even? '(1 2 3 4 5 6))
-                        '(2 4 6))))
+                        '(2 4 6)))
+
+         (it "keeps order over a million elements"
+             (let ((evens (filter even? (interval 1 1000000))))
+               (assert-eq (length evens) 500000)
+               (assert-eq (car evens) 2)
+               (assert-eq (cadr evens) 4)
+               (assert-eq (car (last-pair evens)) 1000000))))
 
 (context filter-errors
 
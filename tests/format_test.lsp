@@ -0,0 +1,44 @@
+;;; -*- mode: Scheme -*-
+
+(context "format"
+
+         ()
+
+         (it "should return a string without a destination"
+             (assert-eq (format "~a has ~d items~%" "cart" 3) "cart has 3 items\n"))
+
+         (it "should return a string with #f as the destination"
+             (assert-eq (format #f "~a" 'x) "x"))
+
+         (it "should display with ~a and write with ~s"
+             (assert-eq (format "~a ~s" "hi" "hi") "hi \"hi\""))
+
+         (it "should format integers in decimal and hex"
+             (assert-eq (format "~d ~x" 255 255) "255 ff")
+             (assert-eq (format "~5d|" 42) "   42|")
+             (assert-eq (format "~d" (expt 2 70)) "1180591620717411303424"))
+
+         (it "should format floats with an optional precision"
+             (assert-eq (format "~,2f" 3.14159) "3.14")
+             (assert-eq (format "~8,3f|" 2.5) "   2.500|")
+             (assert-eq (format "~f" 1.5) "1.5"))
+
+         (it "should support newlines and literal tildes"
+             (assert-eq (format "a~%b") "a\nb")
+             (assert-eq (format "100~~") "100~"))
+
+         (it "should pad ~a"
+             (assert-eq (format "~5a|" "ab") "ab   |")
+             (assert-eq (format "~5@a|" "ab") "   ab|"))
+
+         (it "should reject unknown and unfinished directives"
+             (assert-error (format "~q" 1))
+             (assert-error (format "abc~"))
+             (assert-error (format "~,2" 1.0)))
+
+         (it "should reject mismatched arguments"
+             (assert-error (format "~d" "one"))
+             (assert-error (format "~f" 'a))
+             (assert-error (format "~a ~a" 1))
+             (assert-error (format "~a" 1 2))
+             (assert-error (format 5 "~a" 1))))
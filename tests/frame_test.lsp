@@ -165,7 +165,7 @@
                     (g {parent*: f  foo: (lambda () (+ 1 (apply-slot-super foo: '(1 2))))}))
                (assert-eq (send g foo:)
                           7))
-               (assert-error (apply-slot-super foo:)))) ;only usable in a frame
+               (assert-error (apply-slot-super foo:))) ;only usable in a frame
 
          (it calling-super-sugar
              (let* ((f {foo: (lambda () 42)})
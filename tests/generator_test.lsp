@@ -0,0 +1,76 @@
+;;; -*- mode: Scheme -*-
+
+(define (counting-from n)
+  (make-generator (lambda (yield)
+                    (let loop ((i n))
+                      (yield i)
+                      (loop (+ i 1))))))
+
+(define (generator-of l)
+  (make-generator (lambda (yield) (for-each yield l))))
+
+(context "generators"
+
+         ()
+
+         (it "produces what is yielded, in order"
+             (let ((g (make-generator (lambda (yield) (yield 'a) (yield 'b) (yield 'c)))))
+               (assert-eq (g) 'a)
+               (assert-eq (g) 'b)
+               (assert-eq (g) 'c)))
+
+         (it "produces the eof object once exhausted"
+             (let ((g (generator-of '(1))))
+               (assert-eq (g) 1)
+               (assert-true (eof-object? (g)))
+               (assert-true (eof-object? (g)))))
+
+         (it "runs only as far as it is asked"
+             (let* ((ran '())
+                    (g (make-generator (lambda (yield)
+                                         (set! ran (cons 1 ran))
+                                         (yield 1)
+                                         (set! ran (cons 2 ran))
+                                         (yield 2)))))
+               (assert-eq ran '())
+               (g)
+               (assert-eq ran '(1))
+               (g)
+               (assert-eq ran '(2 1))))
+
+         (it "can go on forever"
+             (assert-eq (generator->list (counting-from 10) 3) '(10 11 12)))
+
+         (it "keeps its state between calls"
+             (let ((g (counting-from 0)))
+               (g)
+               (g)
+               (assert-eq (generator->list g 2) '(2 3))))
+
+         (it "collects what is left"
+             (assert-eq (generator->list (generator-of '(1 2 3))) '(1 2 3))
+             (assert-eq (generator->list (generator-of '())) '()))
+
+         (it "can yield nil and false"
+             (assert-eq (generator->list (generator-of '(() #f 1))) '(() #f 1)))
+
+         (it "raises what its body raises, then is exhausted"
+             (let ((g (make-generator (lambda (yield) (yield 1) (error "broken")))))
+               (assert-eq (g) 1)
+               (assert-error (g))
+               (assert-true (eof-object? (g)))))
+
+         (it "sees the parameter bindings of its first call"
+             (define p (make-parameter 1))
+             (define g (make-generator (lambda (yield) (yield (p)) (yield (p)))))
+             (assert-eq (parameterize ((p 99)) (list (g) (g))) '(99 99)))
+
+         (it "is independent of other generators"
+             (let ((a (counting-from 0))
+                   (b (counting-from 100)))
+               (assert-eq (list (a) (b) (a) (b)) '(0 100 1 101))))
+
+         (it "rejects what isn't a function"
+             (assert-error (make-generator 5))
+             (assert-error (generator->list 5))
+             (assert-error (generator->list (counting-from 0) -1))))
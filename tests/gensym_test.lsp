@@ -27,6 +27,25 @@
                (assert-neq (gensym 'hi)
                           hi-sym)))
 
+         (it gensym-prints-distinctly
+             (let ((sym (gensym "tmp")))
+               (assert-true (string-prefix? "#:tmp-" (str sym)))
+               (assert-true (symbol? sym))))
+
+         (it gensym-is-unique-per-call
+             (let ((a (str (gensym "x")))
+                   (b (str (gensym "x"))))
+               (assert-neq a b)))
+
+         (it gensym-never-matches-a-source-symbol
+             (let ((sym (gensym "tmp")))
+               (assert-false (eq? sym (intern (substring (str sym) 2 (string-length (str sym))))))
+               (assert-eq sym sym)))
+
+         (it gensym-bindings-do-not-capture
+             (let ((sym (gensym "x")))
+               (assert-eq (eval `(let ((,sym 1)) (+ ,sym 1))) 2)))
+
          (it gensym-naked-with-default
              (let ((first-sym gensym-naked))
                (assert-eq first-sym (eval first-sym))
@@ -0,0 +1,79 @@
+;;; -*- mode: Scheme -*-
+
+(context "hash tables"
+
+         (
+             (define h (make-hash-table))
+             (hash-set! h 'a 1)
+             (hash-set! h "a" 2)
+             (hash-set! h 3 'three)
+         )
+
+         (it "should be a hash table"
+             (assert-true (hash-table? h))
+             (assert-false (hash-table? '((a . 1)))))
+
+         (it "should look up symbols, strings and integers separately"
+             (assert-eq (hash-ref h 'a) 1)
+             (assert-eq (hash-ref h "a") 2)
+             (assert-eq (hash-ref h 3) 'three))
+
+         (it "should error on a missing key without a default"
+             (assert-error (hash-ref h 'missing)))
+
+         (it "should return the default for a missing key"
+             (assert-eq (hash-ref h 'missing 0) 0)
+             (assert-nil (hash-ref h 'missing '())))
+
+         (it "should replace existing values"
+             (hash-set! h 'a 10)
+             (assert-eq (hash-ref h 'a) 10)
+             (assert-eq (hash-count h) 3))
+
+         (it "should treat bignums and fixnums that are equal as the same key"
+             (hash-set! h (->bignum 3) 'big)
+             (assert-eq (hash-ref h 3) 'big))
+
+         (it "should remove keys"
+             (assert-true (hash-remove! h 'a))
+             (assert-false (hash-remove! h 'a))
+             (assert-eq (hash-count h) 2)
+             (assert-error (hash-ref h 'a)))
+
+         (it "should list keys and values"
+             (assert-eq (length (hash-keys h)) 3)
+             (assert-true (memq 3 (hash-keys h)))
+             (assert-true (memq 'three (hash-values h))))
+
+         (it "should convert to an alist"
+             (define one (make-hash-table))
+             (hash-set! one 'k 'v)
+             (assert-eq (hash->alist one) (acons 'k 'v))
+             (assert-eq (cdr (assoc "a" (hash->alist h))) 2))
+
+         (it "should keep keys apart that print the same"
+             (define h (make-hash-table))
+             (parameterize ((*print-length* 2))
+               (hash-set! h '(1 2 3) 'a)
+               (hash-set! h '(1 2 4) 'b))
+             (hash-set! h (string->uninterned-symbol "k") 'uninterned)
+             (hash-set! h 'k 'interned)
+             (hash-set! h "k" 'string)
+             (assert-eq (hash-count h) 5)
+             (assert-eq (hash-ref h '(1 2 3)) 'a)
+             (assert-eq (hash-ref h '(1 2 4)) 'b)
+             (assert-eq (hash-ref h 'k) 'interned))
+
+         (it "should find keys that are equal? but not eq?"
+             (define h (make-hash-table))
+             (hash-set! h (list 1 "two" #(3)) 'list)
+             (hash-set! h [1 2] 'bytes)
+             (hash-set! h 100000000000000000000 'big)
+             (assert-eq (hash-ref h (list 1 "two" #(3))) 'list)
+             (assert-eq (hash-ref h [1 2]) 'bytes)
+             (assert-eq (hash-ref h (* 10 10000000000000000000)) 'big))
+
+         (it "should validate the table"
+             (assert-error (hash-ref '() 'a))
+             (assert-error (hash-set! 5 'a 1))
+             (assert-error (hash-count "h"))))
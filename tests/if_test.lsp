@@ -15,6 +15,18 @@
              (assert-eq (when (> 2 4) 1 2 3 4) nil))
 
 
+         (it when-returns-nil-without-a-body
+             (assert-nil (when #t))
+             (assert-nil (when #f))
+             (assert-nil (unless #t))
+             (assert-nil (unless #f)))
+
+         (it when-truthy-values
+             (assert-eq (when 0 'yes) 'yes)
+             (assert-nil (when '() 'yes))
+             (assert-nil (unless 0 'yes))
+             (assert-eq (when (values #t #f) 'first) 'first))
+
          (it when-dont-eval
              (set! when1 1)
              (assert-eq (when #f (set! when1 42) 1) nil)
@@ -0,0 +1,67 @@
+;;; -*- mode: Scheme -*-
+
+(context "json->lisp"
+
+         ()
+
+         (it "should read objects as frames by default"
+             (assert-eq (json->lisp "{\"a\": 1, \"b\": \"two\"}") {a: 1 b: "two"}))
+
+         (it "should read objects as alists in key order"
+             (assert-eq (json->lisp "{\"b\": 1, \"a\": {\"c\": 2}}" 'alist)
+                        '(("b" . 1) ("a" . (("c" . 2))))))
+
+         (it "should read objects as hash tables"
+             (let ((h (json->lisp "{\"a\": 1}" 'hash-table)))
+               (assert-true (hash-table? h))
+               (assert-eq (hash-ref h "a") 1)))
+
+         (it "should read arrays as lists"
+             (assert-eq (json->lisp "[1, \"x\", [2]]") '(1 "x" (2))))
+
+         (it "should read null and booleans"
+             (assert-eq (json->lisp "[null, true, false]") (list nil #t #f)))
+
+         (it "should keep integers exact"
+             (assert-true (integer? (json->lisp "42")))
+             (assert-true (float? (json->lisp "42.0")))
+             (assert-eq (json->lisp "123456789012345678901234567890") 123456789012345678901234567890))
+
+         (it "should report where badly formed json goes wrong"
+             (assert-true (regex-match? "byte offset 7" (on-error (json->lisp "{\"a\": }") (lambda (e) e))))
+             (assert-error (json->lisp "[1, 2"))
+             (assert-error (json->lisp "[1] 2"))
+             (assert-error (json->lisp 1))
+             (assert-error (json->lisp "1" 'vector))))
+
+(context "lisp->json"
+
+         ()
+
+         (it "should write frames as objects"
+             (assert-eq (lisp->json {a: 1}) "{\"a\":1}"))
+
+         (it "should write lists as arrays and nil as null"
+             (assert-eq (lisp->json '(1 "x" #t)) "[1,\"x\",true]")
+             (assert-eq (lisp->json nil) "null"))
+
+         (it "should write alists as objects when asked"
+             (assert-eq (lisp->json '(("a" . 1) (b . 2)) 'alist) "{\"a\":1,\"b\":2}")
+             (assert-error (lisp->json '(("a" . 1)) 'frame)))
+
+         (it "should write big integers exactly"
+             (assert-eq (lisp->json 123456789012345678901234567890) "123456789012345678901234567890"))
+
+         (it "should round trip"
+             (assert-eq (lisp->json (json->lisp "{\"a\":[1,2.5,null,true],\"b\":{\"c\":\"d\"}}" 'alist) 'alist)
+                        "{\"a\":[1,2.5,null,true],\"b\":{\"c\":\"d\"}}")
+             (assert-eq (lisp->json (json->lisp "{\"a\":[1,2.5,null,true]}" 'hash-table))
+                        "{\"a\":[1,2.5,null,true]}"))
+
+         (it "should write empty arrays back as null"
+             (assert-nil (json->lisp "[]"))
+             (assert-eq (lisp->json (json->lisp "[]")) "null")
+             (assert-eq (lisp->json (json->lisp "{\"a\":[]}" 'alist) 'alist) "{\"a\":null}"))
+
+         (it "should refuse data json can't represent"
+             (assert-error (lisp->json (lambda (x) x)))))
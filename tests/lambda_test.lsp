@@ -28,7 +28,34 @@
                    (assert-eq ((lambda (x) (* x x)) 6)
                               36)
 
-                   (assert-error (lambda x (+ 1 2))))
+                   (assert-error (lambda x (+ 1 2)))
+                   (assert-error (lambda (x 1) x))
+                   (assert-error (lambda (x . 1) x)))
+
+         (it rest-parameters
+                   (assert-eq ((lambda (a b . rest) (list a b rest)) 1 2 3 4)
+                              '(1 2 (3 4)))
+                   (assert-eq ((lambda (a b . rest) rest) 1 2)
+                              '())
+                   (assert-eq ((lambda (a . rest) (apply + a rest)) 1 2 3)
+                              6)
+                   (assert-eq ((named-lambda (f a . rest) (list a rest)) 1 2)
+                              '(1 (2)))
+                   (assert-error ((lambda (a b . rest) a) 1))
+                   (assert-error (named-lambda (f a . 5) a)))
+
+         (it rest-parameters-in-definitions
+                   (define (my-list . items) items)
+                   (define (my-plus . numbers) (apply + numbers))
+                   (define (at-least-one x . more) (cons x more))
+                   (assert-eq (my-list) '())
+                   (assert-eq (my-list 1 2 3) '(1 2 3))
+                   (assert-eq (my-plus) 0)
+                   (assert-eq (my-plus 1 2 3) 6)
+                   (assert-eq (at-least-one 1) '(1))
+                   (assert-eq (at-least-one 1 2 3) '(1 2 3))
+                   (assert-error (at-least-one))
+                   (assert-error (define (bad a "b") a)))
 
          (it named-lambda
                    (assert-eq (named-foo 0)
@@ -31,6 +31,35 @@
                           3)))
 
 
+         (it let*-sequential-bindings
+             (assert-eq (let* ((x 1) (y (+ x 1)) (z (* y 10))) (list x y z))
+                        '(1 2 20))
+             (assert-eq (let* ((x 1) (x (+ x 1))) x)
+                        2)
+             (assert-eq (let* ((x 1) (f (lambda () x)) (x 2)) (list (f) x))
+                        '(1 2))
+             (assert-nil (let* ()))
+             (assert-error (let* ((x 1) (5 2)) x))
+             (assert-error (let* (5) 1)))
+
+         (it let*-doesnt-leak
+             (define x 'outer)
+             (let* ((x 1) (y x)) y)
+             (assert-eq x 'outer))
+
+         (it letrec-mutual-recursion
+             (assert-eq (letrec ((is-even? (lambda (n) (if (== n 0) #t (is-odd? (- n 1)))))
+                                 (is-odd? (lambda (n) (if (== n 0) #f (is-even? (- n 1))))))
+                          (list (is-even? 10) (is-odd? 7) (is-even? 3)))
+                        '(#t #t #f))
+             (assert-eq (letrec ((fact (lambda (n) (if (< n 2) 1 (* n (fact (- n 1)))))))
+                          (fact 5))
+                        120))
+
+         (it letrec-binds-before-evaluating
+             (assert-eq (letrec ((f (lambda () g)) (g 2)) (f))
+                        2))
+
          (it let-binding-scope
              (assert-nil (begin (let ((zz 2)) zz)
                                 zz)))
@@ -55,4 +84,43 @@
              (assert-error (let 4 ((x 1)) (+ 1 2))) ;non-symbol name
              (assert-error (let name "hi" (+ 1 2))) ;non-list bindings
              (assert-error (let name ((4 1)) (+ 1 2)))) ;non-symbol binding name
+
+         (it named-let-accumulation
+             (assert-eq (let loop ((i 0) (acc '()))
+                          (if (== i 5)
+                              (reverse acc)
+                              (loop (+ i 1) (cons (* i i) acc))))
+                        '(0 1 4 9 16))
+             (assert-eq (let loop ((i 0) (sum 0))
+                          (if (> i 100000)
+                              sum
+                              (loop (+ i 1) (+ sum i))))
+                        5000050000))
+
+         (it named-let-early-termination
+             (assert-eq (let search ((l '(1 3 4 5 6)))
+                          (cond ((null? l) #f)
+                                ((even? (car l)) (car l))
+                                (else (search (cdr l)))))
+                        4)
+             (assert-false (let search ((l '(1 3 5)))
+                             (cond ((null? l) #f)
+                                   ((even? (car l)) (car l))
+                                   (else (search (cdr l)))))))
+
+         (it named-let-binding-is-local
+             (define loop 'outer)
+             (assert-eq (let loop ((i 0)) (if (< i 3) (loop (+ i 1)) i))
+                        3)
+             (assert-eq loop 'outer))
+
+         (it named-let-closes-over-its-environment
+             (let ((step 2))
+               (assert-eq (let loop ((i 0)) (if (>= i 10) i (loop (+ i step))))
+                          10)))
+
+         (it named-let-initials-are-evaluated-outside
+             (define i 7)
+             (assert-eq (let loop ((i 1) (j i)) j)
+                        7))
 )
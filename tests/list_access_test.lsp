@@ -221,3 +221,51 @@
              (assert-error (last-pair '())) ;needs non-empty list
              (assert-error (last-pair 5))) ;needs a list
 )
+
+(context "take, drop and slices"
+
+         ()
+
+         (it take
+             (assert-eq (take 2 '(1 2 3)) '(1 2))
+             (assert-eq (take 0 '(1 2 3)) '())
+             (assert-eq (take 5 '(1 2 3)) '(1 2 3))
+             (assert-error (take -1 '(1 2 3)))
+             (assert-error (take 'a '(1 2 3))))
+
+         (it drop
+             (assert-eq (drop 2 '(1 2 3)) '(3))
+             (assert-eq (drop 0 '(1 2 3)) '(1 2 3))
+             (assert-eq (drop 5 '(1 2 3)) '())
+             (assert-error (drop -1 '(1 2 3))))
+
+         (it take-while
+             (assert-eq (take-while odd? '(1 3 4 5)) '(1 3))
+             (assert-eq (take-while odd? '(2 3)) '())
+             (assert-eq (take-while odd? '()) '())
+             (assert-eq (take-while odd? '(1 3)) '(1 3))
+             (assert-error (take-while 1 '(1)))
+             (assert-error (take-while odd? 1)))
+
+         (it drop-while
+             (assert-eq (drop-while odd? '(1 3 4 5)) '(4 5))
+             (assert-eq (drop-while odd? '(1 3)) '())
+             (assert-eq (drop-while odd? '(2 3)) '(2 3))
+             (assert-error (drop-while (lambda (x) (error "bad")) '(1))))
+
+         (it list-slice
+             (assert-eq (list-slice '(a b c d) 1 3) '(b c))
+             (assert-eq (list-slice '(a b c d) 2) '(c d))
+             (assert-eq (list-slice '(a b c d) 4) '())
+             (assert-eq (list-slice '(a b c d) 1 1) '())
+             (assert-error (list-slice '(a b c d) -1 2))
+             (assert-error (list-slice '(a b c d) 3 2))
+             (assert-error (list-slice '(a b c d) 0 5))
+             (assert-error (list-slice 5 0 0)))
+
+         (it "handles long lists"
+             (define long (interval 0 19999))
+             (assert-eq (length (take 19999 long)) 19999)
+             (assert-eq (length (drop-while (lambda (x) (< x 19990)) long)) 10)
+             (assert-eq (length (take-while (lambda (x) #t) long)) 20000)
+             (assert-eq (length (list-slice long 10 19990)) 19980)))
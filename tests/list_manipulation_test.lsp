@@ -43,12 +43,58 @@
                    (assert-eq (flatten '(1 (2 3) 4))
                               '(1 2 3 4))
                    (assert-eq (flatten '(1 (2 (3 4) 5) 6))
-                              '(1 2 (3 4) 5 6))
+                              '(1 2 3 4 5 6))
                    (assert-eq (flatten (list))
                               '())
                    (assert-eq (flatten 42)
                               42))
 
+         (it flatten-to-depth
+                   (assert-eq (flatten '(1 (2 (3 4) 5) 6) 1)
+                              '(1 2 (3 4) 5 6))
+                   (assert-eq (flatten '(1 (2 (3 (7 8) 4) 5) 6) 2)
+                              '(1 2 3 (7 8) 4 5 6))
+                   (assert-eq (flatten '(1 (2 3)) 0)
+                              '(1 (2 3)))
+                   (assert-error (flatten '(1 2) -1))
+                   (assert-error (flatten '(1 2) 'a)))
+
+         (it flatten-improper-and-empty
+                   (assert-eq (flatten '(1 (2 . 3) 4))
+                              '(1 2 3 4))
+                   (assert-eq (flatten '(1 2 . 3))
+                              '(1 2 3))
+                   (assert-eq (flatten '(1 () (2 ()) 3))
+                              '(1 2 3)))
+
+         (it flatten-shared-structure
+                   (define shared (list 1 2))
+                   (assert-eq (flatten (list shared (list shared)))
+                              '(1 2 1 2)))
+
+         (it flatten-cycles
+                   (define cyclic (list 1 2 3))
+                   (set-cdr! (cddr cyclic) cyclic)
+                   (assert-error (flatten cyclic))
+                   (define nested (list 1 2))
+                   (set-car! (cdr nested) nested)
+                   (assert-error (flatten nested))
+                   (assert-error (flatten* nested)))
+
+         (it deep-reverse
+                   (assert-eq (deep-reverse '(1 (2 3) (4 (5 6))))
+                              '(((6 5) 4) (3 2) 1))
+                   (assert-eq (deep-reverse '(1 2 3))
+                              '(3 2 1))
+                   (assert-eq (deep-reverse '())
+                              '())
+                   (assert-eq (deep-reverse 42)
+                              42)
+                   (assert-error (deep-reverse '(1 2 . 3)))
+                   (define cyclic (list 1 2))
+                   (set-car! cyclic cyclic)
+                   (assert-error (deep-reverse cyclic)))
+
          (it flatten*
                    (assert-eq (flatten* '(1 2 3 4))
                               '(1 2 3 4))
@@ -70,10 +116,32 @@
                         '((1 2 3 4) (5 6 7 8))))
 
          (it partition-by-predicate
-             (assert-eq (partition odd? '(1 2 3 4 5 6 7 8 9))
-                        '((1 3 5 7 9) (2 4 6 8)))
-             (assert-eq (partition even? '(1 2 3 4 5 6 7 8 9))
-                        '((2 4 6 8) (1 3 5 7 9))))
+             (receive (odds evens) (partition odd? '(1 2 3 4 5 6 7 8 9))
+               (assert-eq odds '(1 3 5 7 9))
+               (assert-eq evens '(2 4 6 8)))
+             (receive (evens odds) (partition even? '(1 2 3 4 5 6 7 8 9))
+               (assert-eq evens '(2 4 6 8))
+               (assert-eq odds '(1 3 5 7 9)))
+             (receive (yes no) (partition odd? '())
+               (assert-nil yes)
+               (assert-nil no))
+             (assert-error (partition (lambda (x) (error "bad")) '(1))))
+
+         (it group-by
+             (assert-eq (group-by odd? '(1 2 3 4 5))
+                        (alist '((#t 1 3 5) (#f 2 4))))
+             (assert-eq (group-by string-length '("a" "bb" "c" "dd" "eee"))
+                        (alist '((1 "a" "c") (2 "bb" "dd") (3 "eee"))))
+             (assert-eq (map car (group-by car '((b 1) (a 2) (b 3))))
+                        '(b a))
+             (assert-eq (group-by car '((b 1) (a 2) (b 3)))
+                        (alist '((b (b 1) (b 3)) (a (a 2)))))
+             (define groups (parameterize ((*print-length* 2))
+                              (group-by (lambda (x) x) '((1 2 3) (1 2 4)))))
+             (assert-eq (length groups) 2)
+             (assert-nil (group-by odd? '()))
+             (assert-error (group-by 5 '(1)))
+             (assert-error (group-by odd? 5)))
 
          (it partition-errors
              (assert-error (partition -1 '(1 2))) ;1st arg has to be non -ive if it's an int
@@ -194,4 +262,78 @@
                         '(3 2 1))
              (assert-eq (sort '((3 a) (1 b) (2 c)) (lambda (a b) (< (first a) (first b))))
                         '((1 b) (2 c) (3 a))))
+
+         (it "sort should be stable"
+             (assert-eq (sort '((1 a) (0 b) (1 c) (0 d)) (lambda (a b) (< (first a) (first b))))
+                        '((0 b) (0 d) (1 a) (1 c))))
+
+         (it "sort should propagate comparator errors"
+             (assert-error (sort '(3 1 2) (lambda (a b) (error "bad compare"))))
+             (assert-error (sort '(3 a 2) <)))
+
+         (it sort-by
+             (assert-eq (sort-by '((3 a) (1 b) (3 c) (2 d)) first)
+                        '((1 b) (2 d) (3 a) (3 c)))
+             (assert-eq (sort-by '("ccc" "a" "bb") string-length >)
+                        '("ccc" "bb" "a"))
+             (assert-eq (sort-by '(("b" 1) ("a" 2)) first)
+                        '(("a" 2) ("b" 1))))
+
+         (it "sort-by should propagate errors"
+             (assert-error (sort-by '(1 2) (lambda (x) (error "bad key"))))
+             (assert-error (sort-by '(a b) (lambda (x) x)))
+             (assert-error (sort-by '(1 2) first 5)))
 )
+
+(context "non-destructive list operations"
+
+         ()
+
+         (it "append leaves its arguments unchanged"
+             (define a (list 1 2))
+             (define b (list 3 4))
+             (define ab (append a b))
+             (assert-eq ab '(1 2 3 4))
+             (assert-eq a '(1 2))
+             (assert-eq b '(3 4))
+             (set-car! ab 10)
+             (set-car! (cddr ab) 30)
+             (assert-eq a '(1 2))
+             (assert-eq b '(3 4)))
+
+         (it "append! changes its first argument"
+             (define a (list 1 2))
+             (define alias a)
+             (append! a (list 3))
+             (assert-eq alias '(1 2 3)))
+
+         (it "reverse, sort and sublist copy"
+             (define a (list 3 1 2))
+             (reverse a)
+             (sort a <)
+             (sort-by a (lambda (x) x))
+             (sublist a 1 2)
+             (flatten (list a))
+             (assert-eq a '(3 1 2)))
+
+         (it "set operations copy"
+             (define a (list 1 2 3))
+             (intersection a '(2))
+             (complement a '(2))
+             (union a '(4))
+             (assert-eq a '(1 2 3)))
+
+         (it replace-nth
+             (define a (list 1 2 3))
+             (assert-eq (replace-nth a 2 20) '(1 20 3))
+             (assert-eq a '(1 2 3))
+             (assert-error (replace-nth a 0 1))
+             (assert-error (replace-nth a 4 1))
+             (assert-error (replace-nth 5 1 1)))
+
+         (it "alist-update copies where acons changes in place"
+             (define al (acons 'a 1 (acons 'b 2)))
+             (alist-update 'a 10 al)
+             (assert-eq (cdr (assoc 'a al)) 1)
+             (acons 'a 10 al)
+             (assert-eq (cdr (assoc 'a al)) 10)))
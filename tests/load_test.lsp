@@ -0,0 +1,42 @@
+;;; -*- mode: Scheme -*-
+
+(context "load"
+
+         ((define (write-file path text)
+            (call-with-output-file path (lambda (port) (write-string text port))))
+          (write-file "/tmp/golisp-load-test-defs.lsp" "(define load-test-value 42) (define (load-test-double x) (* 2 x)) (load-test-double load-test-value)")
+          (write-file "/tmp/golisp-load-test-counter.lsp" "(set! load-test-count (+ load-test-count 1))")
+          (write-file "/tmp/golisp-load-test-bad.lsp" "(define load-test-ok 1)\n(car)")
+          (environment-define (system-global-environment) 'load-test-count 0)
+          (define original-load-path *load-path*))
+
+         (it "evaluates every form, returning the last value"
+             (assert-eq (load "/tmp/golisp-load-test-defs.lsp") 84)
+             (assert-eq load-test-value 42)
+             (assert-eq (load-test-double 2) 4))
+
+         (it "finds relative names in the load path"
+             (set! *load-path* '("/no/such/directory" "/tmp"))
+             (assert-eq (load "golisp-load-test-defs.lsp") 84)
+             (set! *load-path* original-load-path)
+             (assert-error (load "golisp-load-test-defs.lsp")))
+
+         (it "requires a file only once"
+             (set! *load-path* '("/tmp"))
+             (assert-true (require "golisp-load-test-counter"))
+             (assert-false (require "golisp-load-test-counter.lsp"))
+             (assert-false (require "/tmp/golisp-load-test-counter.lsp"))
+             (set! *load-path* original-load-path)
+             (assert-eq load-test-count 1)
+             (load "/tmp/golisp-load-test-counter.lsp")
+             (assert-eq load-test-count 2))
+
+         (it "names the file and form in errors"
+             (assert-true (regex-match? "In form 2 of /tmp/golisp-load-test-bad.lsp"
+                                        (on-error (load "/tmp/golisp-load-test-bad.lsp")
+                                                  (lambda (e) e)))))
+
+         (it "rejects bad arguments"
+             (assert-error (load 1))
+             (assert-error (load "/tmp/golisp-load-test-defs.lsp" 1))
+             (assert-error (require "/tmp/golisp-no-such-file"))))
@@ -3,6 +3,9 @@
 (defmacro (add x y)
   `(+ ,x ,@y))
 
+(defmacro (add-twice x y)
+  `(add ,x ,y))
+
 
 (context "macro"
 
@@ -50,6 +53,55 @@
              (assert-eq  `(a `(b ,(+ 1 2) ,(foo ,(+ 1 3) d) e) f) 
                          '(a `(b ,(+ 1 2) ,(foo 4 d) e) f)))
 
+         (it nested-depth
+             (let ((x 5)
+                   (l '(a b)))
+               (assert-eq `(1 `(2 ,(3 ,x)))
+                          '(1 `(2 ,(3 5))))
+               (assert-eq `(1 `(2 ,(3 ,@l)))
+                          '(1 `(2 ,(3 a b))))
+               (assert-eq `(1 `(2 ,,x))
+                          '(1 `(2 ,5)))))
+
+         (it dotted-templates
+             (let ((x 5)
+                   (l '(a b)))
+               (assert-eq `(1 . ,x)
+                          '(1 . 5))
+               (assert-eq `(,@l . rest)
+                          '(a b . rest))
+               (assert-eq `(1 ,@x)
+                          '(1 . 5))))
+
+         (it empty-and-nil-elements
+             (assert-eq `(1 ,@'() 2)
+                        '(1 2))
+             (assert-eq `(() ,(+ 1 1))
+                        '(() 2)))
+
+         (it splicing-a-non-list-errors
+             (assert-error `(1 ,@5 2)))
+
+         (it macroexpand-1
+             (assert-eq (macroexpand-1 '(add-twice 1 (2 3)))
+                        '(add 1 (2 3)))
+             (assert-eq (macroexpand-1 '(add 1 (2 3)))
+                        '(+ 1 2 3)))
+
+         (it macroexpand
+             (assert-eq (macroexpand '(add-twice 1 (2 3)))
+                        '(+ 1 2 3)))
+
+         (it macroexpand-non-macros
+             (assert-eq (macroexpand-1 '(+ 1 2)) '(+ 1 2))
+             (assert-eq (macroexpand '(+ 1 2)) '(+ 1 2))
+             (assert-eq (macroexpand 'add) 'add)
+             (assert-eq (macroexpand 5) 5)
+             (assert-nil (macroexpand '())))
+
+         (it macroexpand-errors
+             (assert-error (macroexpand '(add 1))))
+
          (it defmacro-errors
              (assert-error (defmacro "x" 1))
              (assert-error (defmacro ("x") 1)))
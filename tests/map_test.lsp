@@ -23,6 +23,20 @@
              (assert-eq (map + '(1 2 3) '(4 5 6))
                         '(5 7 9)))
 
+         (it map-stops-at-the-shortest-list
+             (assert-eq (map + '(1 2 3) '(4 5))
+                        '(5 7))
+             (assert-eq (map list '(1 2) '(a b c) '("x" "y" "z"))
+                        '((1 a "x") (2 b "y")))
+             (assert-eq (map + '(1 2) '())
+                        '()))
+
+         (it map-over-a-million-elements
+             (let ((mapped (map succ (interval 1 1000000))))
+               (assert-eq (length mapped) 1000000)
+               (assert-eq (car mapped) 2)
+               (assert-eq (car (last-pair mapped)) 1000001)))
+
          (it map-errors
              (assert-error (map 5 '( 1 2 3)))
              (assert-error (map + 4))
@@ -35,6 +49,19 @@
                (assert-eq count
                           10)))
 
+         (it for-each-with-multiple-lists
+             (let ((pairs '()))
+               (assert-nil (for-each (lambda (x y) (set! pairs (cons (list x y) pairs)))
+                                     '(1 2 3)
+                                     '(a b)))
+               (assert-eq (reverse pairs)
+                          '((1 a) (2 b)))))
+
+         (it for-each-over-nothing
+             (let ((count 0))
+               (for-each (lambda (x) (set! count (+ count 1))) '())
+               (assert-eq count 0)))
+
          (it for-each-errors
              (assert-error (for-each 5 '( 1 2 3))) ;1st arg must be a function
              (assert-error (for-each + 4)) ;remainign args must be lists
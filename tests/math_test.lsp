@@ -24,6 +24,39 @@
              (assert-eq (modulo 7 5)
                         2))
 
+         (it integer-division-signs
+             (assert-eq (list (quotient 7 2) (quotient -7 2) (quotient 7 -2) (quotient -7 -2)) '(3 -3 -3 3))
+             (assert-eq (list (remainder 7 2) (remainder -7 2) (remainder 7 -2) (remainder -7 -2)) '(1 -1 1 -1))
+             (assert-eq (list (modulo 7 2) (modulo -7 2) (modulo 7 -2) (modulo -7 -2)) '(1 1 -1 -1))
+             (assert-eq (modulo -6 3) 0)
+             (assert-eq (% -7 2) -1))
+
+         (it integer-division-of-bignums
+             (assert-eq (quotient (- 0 (expt 2 80)) (expt 2 79)) -2)
+             (assert-eq (remainder (- 0 (+ (expt 2 80) 1)) (expt 2 80)) -1)
+             (assert-eq (modulo (- 0 (expt 2 80)) 3) 2)
+             (assert-eq (quotient (- -9223372036854775807 1) -1) 9223372036854775808))
+
+         (it integer-division-errors
+             (assert-error (quotient 1 0))
+             (assert-error (remainder 1 0))
+             (assert-error (modulo (expt 2 80) 0))
+             (assert-error (quotient 7.0 2))
+             (assert-error (modulo 7 'a)))
+
+         (it gcd-and-lcm
+             (assert-eq (gcd) 0)
+             (assert-eq (gcd 12 18) 6)
+             (assert-eq (gcd -12 18 8) 2)
+             (assert-eq (gcd 0 5) 5)
+             (assert-eq (gcd (expt 2 80) (expt 6 3)) 8)
+             (assert-eq (lcm) 1)
+             (assert-eq (lcm 4 6) 12)
+             (assert-eq (lcm -4 6 10) 60)
+             (assert-eq (lcm 3 0) 0)
+             (assert-error (gcd 1.5 2))
+             (assert-error (lcm 'a)))
+
          (it subtraction-going-negative
              (assert-eq (- 5 9)
                         -4))
@@ -73,6 +106,30 @@
              (assert-eq #t (or (> 4 2) (+ 4 1)))
              (assert-eq 5 (or (< 4 2) (+ 4 1))))
 
+         (it "and/or return the deciding value"
+             (assert-eq (and 1 2 3) 3)
+             (assert-eq (and 1 #f 3) #f)
+             (assert-nil (and 1 '() 3))
+             (assert-eq (or #f 2 3) 2)
+             (assert-eq (or #f '() 'last) 'last)
+             (assert-eq (or #f #f) #f)
+             (assert-eq (and) #t)
+             (assert-eq (or) #f))
+
+         (it "and/or don't evaluate past the deciding form"
+             (define calls 0)
+             (define (bump v) (set! calls (+ calls 1)) v)
+             (assert-eq (or (bump 1) (bump 2)) 1)
+             (assert-eq calls 1)
+             (assert-eq (and (bump #f) (bump 2)) #f)
+             (assert-eq calls 2)
+             (assert-eq (and (bump 1) (bump 2)) 2)
+             (assert-eq calls 4)
+             (define x '())
+             (assert-nil (and (pair? x) (car x)))
+             (assert-eq (or 'first (error "never")) 'first)
+             (assert-false (and #f (error "never"))))
+
          (it int-min
              (assert-eq (min '(1 2))
                         1)
@@ -97,13 +154,49 @@
              (assert-eq (max '(3 4.8 2 8 8.3 6 1))
                         8.3))
 
+         (it min-max-of-arguments
+             (assert-eq (min 3 1 2) 1)
+             (assert-eq (max 3 1 2) 3)
+             (assert-eq (min 7) 7)
+             (assert-eq (max -1/2 -1/3) -1/3)
+             (assert-eq (min (expt 2 80) (expt 2 70)) (expt 2 70))
+             (assert-eq (max (expt 2 80) 1) (expt 2 80)))
+
+         (it min-max-contagion
+             (assert-true (float? (max 5 4.5)))
+             (assert-eq (max 5 4.5) 5.0)
+             (assert-eq (min 1 2.5) 1.0)
+             (assert-eq (min 1/2 0.75) 0.5)
+             (assert-false (float? (min 1/2 3))))
+
+         (it min-max-errors
+             (assert-error (min))
+             (assert-error (max 1 "2"))
+             (assert-error (min 'a 1))
+             (assert-error (max 1+2i 3)))
+
+         (it abs
+             (assert-eq (abs -5) 5)
+             (assert-eq (abs 5) 5)
+             (assert-eq (abs -2.5) 2.5)
+             (assert-eq (abs (- 0 (expt 2 80))) (expt 2 80))
+             (assert-eq (abs -9223372036854775808) 9223372036854775808)
+             (assert-true (bignum? (abs -9223372036854775808)))
+             (assert-eq (abs 3+4i) 5.0)
+             (assert-error (abs 'x)))
+
          (it floor
              (assert-eq (floor 3.4)
                         3.0)
              (assert-eq (floor -3.4)
                         -4.0)
              (assert-eq (floor 3)
-                        3.0))
+                        3)
+             (assert-true (exact? (floor 3)))
+             (assert-eq (floor 7/2) 3)
+             (assert-eq (floor -7/2) -4)
+             (assert-eq (floor (expt 2 70)) (expt 2 70))
+             (assert-true (exact? (floor (expt 2 70)))))
 
          (it ceiling
              (assert-eq (ceiling 3.4)
@@ -111,7 +204,61 @@
              (assert-eq (ceiling -3.4)
                         -3.0)
              (assert-eq (ceiling 3)
-                        3.0))
+                        3)
+             (assert-eq (ceiling 7/2) 4)
+             (assert-eq (ceiling -7/2) -3))
+
+         (it round-and-truncate
+             (assert-eq (round 3.4) 3.0)
+             (assert-eq (round 3.6) 4.0)
+             (assert-eq (round 2.5) 2.0)
+             (assert-eq (round -3.5) -4.0)
+             (assert-eq (truncate 3.7) 3.0)
+             (assert-eq (truncate -3.7) -3.0)
+             (assert-eq (truncate 3) 3)
+             (assert-eq (round 7/2) 4)
+             (assert-eq (round 5/2) 2)
+             (assert-eq (round -7/2) -4)
+             (assert-eq (round 7/3) 2)
+             (assert-eq (round 8/3) 3)
+             (assert-eq (truncate -7/2) -3)
+             (assert-eq (truncate 7/2) 3)
+             (assert-true (exact? (round 7/2)))
+             (assert-true (float? (round 3.5)))
+             (assert-error (round 'd))
+             (assert-error (truncate 'd)))
+
+         (it exact-sqrt
+             (assert-eq (sqrt 4) 2)
+             (assert-eq (sqrt 0) 0)
+             (assert-eq (sqrt 9/16) 3/4)
+             (assert-eq (sqrt 100000000000000000000000000000000000000) 10000000000000000000)
+             (assert-eq (sqrt 2.25) 1.5)
+             (assert-true (float? (sqrt 2)))
+             (assert-eq (sqrt -1) +i)
+             (assert-eq (sqrt -1/4) 0.5i)
+             (assert-error (sqrt 'x)))
+
+         (it atan-and-log
+             (assert-eq (atan 1 1) (atan2 1 1))
+             (assert-true (> (atan 1 -1) 2.0))
+             (assert-eq (log 1) 0.0)
+             (assert-eq (log 8 2) 3.0)
+             (assert-eq (log 100 10) 2.0)
+             (assert-eq (log -1) 0+3.141592653589793i)
+             (assert-error (log 0))
+             (assert-error (log 8 1))
+             (assert-error (log 8 'b))
+             (assert-error (atan2 1 'x)))
+
+         (it domain-errors
+             (assert-true (complex? (asin 2)))
+             (assert-false (real? (asin 2)))
+             (assert-false (real? (acos -2)))
+             (assert-false (real? (acosh 0.5)))
+             (assert-error (log2 -1))
+             (assert-error (log1p -2))
+             (assert-eq (asin 0) 0.0))
 
          (it general-math-errors
              (assert-error (/ 3 0))
@@ -0,0 +1,59 @@
+;;; -*- mode: Scheme -*-
+
+(context "memoize"
+
+         ((define calls 0)
+          (define (slow-square x) (set! calls (+ calls 1)) (* x x))
+          (define square (memoize slow-square))
+          (define small (memoize slow-square 2))
+          (define fib (memoize (lambda (n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2))))))))
+
+         (it "skips calls with arguments it has seen"
+             (set! calls 0)
+             (assert-eq (square 3) 9)
+             (assert-eq (square 3) 9)
+             (assert-eq calls 1)
+             (assert-eq (square 4) 16)
+             (assert-eq calls 2))
+
+         (it "keys on the value of the arguments"
+             (let ((count 0))
+               (define f (memoize (lambda (x . rest) (set! count (+ count 1)) x)))
+               (f '(1 "a" b) 2.5)
+               (f (list 1 "a" 'b) 2.5)
+               (assert-eq count 1)
+               (f '(1 "a" c) 2.5)
+               (f "1")
+               (f 1)
+               (assert-eq count 4)))
+
+         (it "memoizes recursive calls through its name"
+             (assert-eq (fib 80) 23416728348467685))
+
+         (it "evicts the least recently used result"
+             (set! calls 0)
+             (small 1)
+             (small 2)
+             (small 1)
+             (small 3)
+             (assert-eq calls 3)
+             (small 1)
+             (assert-eq calls 3)
+             (small 2)
+             (assert-eq calls 4))
+
+         (it "clears its cache"
+             (set! calls 0)
+             (square 5)
+             (memoize-clear! square)
+             (square 5)
+             (assert-eq calls 2))
+
+         (it "doesn't cache errors"
+             (assert-error (square "a"))
+             (assert-error (square "a")))
+
+         (it "rejects bad arguments"
+             (assert-error (memoize 1))
+             (assert-error (memoize slow-square 0))
+             (assert-error (memoize-clear! slow-square))))
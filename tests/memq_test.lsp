@@ -20,6 +20,22 @@
                         '(2 3))
              (assert-false (memq 4 '(1 2 3))))
 
+         (it memq-compares-with-eq
+             (define s "b")
+             (assert-false (memq "b" '("a" "b")))
+             (assert-eq (memq s (list "a" s)) (list s))
+             (assert-false (memq (list 1) '((1) (2)))))
+
+         (it memv-compares-with-eqv
+             (assert-eq (memv 2.5 '(1.5 2.5)) '(2.5))
+             (assert-false (memv 2 '(1.0 2.0)))
+             (assert-false (memv "b" '("a" "b"))))
+
+         (it member-compares-with-equal
+             (assert-eq (member "b" '("a" "b")) '("b"))
+             (assert-eq (member (list 1) '((1) (2))) '((1) (2)))
+             (assert-false (member 3 '(1 2))))
+
          (it find
              (assert-eq (find even? '(3 1 4 1 5 9))
                         4)
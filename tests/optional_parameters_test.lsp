@@ -0,0 +1,99 @@
+;;; -*- mode: Scheme -*-
+
+(context "optional parameters"
+
+         ((define (f a #!optional (b 10) #!key c)
+            (list a b c))
+          (define (g a #!optional b c)
+            (list a b c))
+          (define (h #!optional (x 1) (y (+ x 1)))
+            (list x y)))
+
+         (it "are bound to what is passed"
+             (assert-eq (f 1 2) '(1 2 ()))
+             (assert-eq (g 1 2 3) '(1 2 3)))
+
+         (it "take their defaults when left out"
+             (assert-eq (f 1) '(1 10 ()))
+             (assert-eq (g 1) '(1 () ()))
+             (assert-eq (g 1 2) '(1 2 ())))
+
+         (it "evaluate defaults after the parameters before them"
+             (assert-eq (h) '(1 2))
+             (assert-eq (h 5) '(5 6))
+             (assert-eq (h 5 0) '(5 0)))
+
+         (it "only evaluate defaults when needed"
+             (define count 0)
+             (define (counted #!optional (x (begin (set! count (+ count 1)) count))) x)
+             (assert-eq (counted 'given) 'given)
+             (assert-eq count 0)
+             (assert-eq (counted) 1)
+             (assert-eq (counted) 2))
+
+         (it "check the number of arguments"
+             (assert-error (f))
+             (assert-error (g 1 2 3 4))
+             (assert-error (h 1 2 3)))
+
+         (it "work with rest parameters"
+             (define (r a #!optional b #!rest more) (list a b more))
+             (define (d a #!optional b . more) (list a b more))
+             (assert-eq (r 1) '(1 () ()))
+             (assert-eq (r 1 2 3 4) '(1 2 (3 4)))
+             (assert-eq (d 1 2 3) '(1 2 (3))))
+
+         (it "work in lambdas"
+             (assert-eq ((lambda (#!optional (x 3)) (* x x))) 9)
+             (assert-eq ((named-lambda (sq #!optional (x 3)) (* x x)) 4) 16))
+
+         (it "work when compiled"
+             (define cf (compile f))
+             (define cg (compile g))
+             (assert-eq (cf 1) '(1 10 ()))
+             (assert-eq (cf 1 2 c: 3) '(1 2 3))
+             (assert-eq (cg 1 2) '(1 2 ()))))
+
+(context "keyword parameters"
+
+         ((define (f a #!optional (b 10) #!key c)
+            (list a b c))
+          (define (k #!key (width 80) (height (* width 2)))
+            (list width height)))
+
+         (it "are passed by name"
+             (assert-eq (f 1 2 c: 3) '(1 2 3))
+             (assert-eq (k height: 1 width: 2) '(2 1))
+             (assert-eq (k width: 10) '(10 20)))
+
+         (it "end the positional arguments"
+             (assert-eq (f 1 c: 3) '(1 10 3)))
+
+         (it "take their defaults when left out"
+             (assert-eq (k) '(80 160))
+             (assert-eq (f 1 2) '(1 2 ())))
+
+         (it "reject unknown keywords"
+             (assert-error (f 1 2 d: 3))
+             (assert-error (k size: 3)))
+
+         (it "reject keywords without values and repeated keywords"
+             (assert-error (f 1 2 c:))
+             (assert-error (k width: 1 width: 2)))
+
+         (it "reject extra positional arguments"
+             (assert-error (f 1 2 3))
+             (assert-error (k 1))))
+
+(context "malformed parameter lists"
+
+         ()
+
+         (it "are errors"
+             (assert-error (lambda (#!optional 5) 1))
+             (assert-error (lambda (#!optional (a 1 2)) 1))
+             (assert-error (lambda (#!key a #!optional b) 1))
+             (assert-error (lambda (#!rest) 1))
+             (assert-error (lambda (#!rest a b) 1))
+             (assert-error (lambda (#!key a . b) 1))
+             (assert-error (parse "#!bogus"))))
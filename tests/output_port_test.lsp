@@ -0,0 +1,79 @@
+;;; -*- mode: Scheme -*-
+
+(context "with-output-to-string"
+
+         ()
+
+         (it "captures display and write"
+             (assert-eq (with-output-to-string (lambda () (display "a") (write "b") (newline))) "a\"b\"\n")
+             (assert-eq (with-output-to-string (lambda () 42)) ""))
+
+         (it "captures format to stdout and pretty-print"
+             (assert-eq (with-output-to-string (lambda () (format #t "~A-~A" 1 2))) "1-2")
+             (assert-eq (with-output-to-string (lambda () (pretty-print '(a b)))) "(a b)\n"))
+
+         (it "makes the buffer the current output port"
+             (assert-eq (with-output-to-string (lambda () (write-string "x" (current-output-port)))) "x"))
+
+         (it "nests"
+             (assert-eq (with-output-to-string
+                         (lambda ()
+                           (display "outer ")
+                           (display (with-output-to-string (lambda () (display "inner"))))))
+                        "outer inner"))
+
+         (it "keeps the output of other processes apart"
+             (define other (fork (lambda ()
+                                   (with-output-to-string (lambda ()
+                                                            (display "b")
+                                                            (sleep 40)
+                                                            (display "b"))))))
+             (define mine (with-output-to-string (lambda ()
+                                                   (sleep 20)
+                                                   (display "a")
+                                                   (sleep 40)
+                                                   (display "a"))))
+             (assert-eq mine "aa")
+             (assert-eq (proc-join other) "bb"))
+
+         (it "hands the buffer to processes forked in it"
+             (assert-eq (with-output-to-string (lambda () (proc-join (fork (lambda () (display "x"))))))
+                        "x"))
+
+         (it "makes a port that works wherever a port does"
+             (assert-eq (with-output-to-string
+                         (lambda ()
+                           (define port (current-output-port))
+                           (assert-true (port? port))
+                           (assert-eq (str port) "<port: string>")
+                           (display 1 port)
+                           (format port "~A" 2)
+                           (newline port)))
+                        "12\n"))
+
+         (it "restores the output port after an error"
+             (define before (current-output-port))
+             (assert-error (with-output-to-string (lambda () (display "x") (car))))
+             (assert-eq (current-output-port) before)
+             (assert-eq (with-output-to-string (lambda () (display "y"))) "y"))
+
+         (it "expects a function"
+             (assert-error (with-output-to-string "abc"))))
+
+(context "current-output-port"
+
+         ()
+
+         (it "is a parameter"
+             (assert-true (parameter? current-output-port))
+             (assert-eq (with-output-to-string
+                         (lambda ()
+                           (define buffer (current-output-port))
+                           (with-output-to-string
+                            (lambda ()
+                              (parameterize ((current-output-port buffer))
+                                (display "out"))))))
+                        "out"))
+
+         (it "only takes ports"
+             (assert-error (parameterize ((current-output-port 5)) 1))))
@@ -0,0 +1,85 @@
+;;; -*- mode: Scheme -*-
+
+(context "parameters"
+
+         ((define width (make-parameter 80))
+          (define (current-width) (width)))
+
+         (it "should return their value when called"
+             (assert-eq (width) 80)
+             (assert-true (parameter? width))
+             (assert-false (parameter? car))
+             (assert-false (parameter? 80)))
+
+         (it "should convert their values"
+             (define p (make-parameter 5 (lambda (x) (* x 2))))
+             (assert-eq (p) 10)
+             (parameterize ((p 1))
+               (assert-eq (p) 2))
+             (assert-error (make-parameter 1 2)))
+
+         (it "should take no arguments"
+             (assert-error (width 10))))
+
+(context "parameterize"
+
+         ((define width (make-parameter 80))
+          (define height (make-parameter 24))
+          (define (current-width) (width)))
+
+         (it "should rebind for the dynamic extent of the body"
+             (assert-eq (parameterize ((width 100)) (current-width)) 100)
+             (assert-eq (width) 80))
+
+         (it "should rebind several parameters"
+             (assert-eq (parameterize ((width 1) (height 2)) (list (width) (height)))
+                        '(1 2)))
+
+         (it "should nest"
+             (parameterize ((width 1))
+               (parameterize ((width 2))
+                 (assert-eq (width) 2))
+               (assert-eq (width) 1))
+             (assert-eq (width) 80))
+
+         (it "should evaluate every value before binding any"
+             (assert-eq (parameterize ((width 1) (height (width))) (height)) 80))
+
+         (it "should restore on errors"
+             (assert-error (parameterize ((width 100)) (error "boom")))
+             (assert-eq (width) 80))
+
+         (it "should restore when unwinding"
+             (assert-error (dynamic-wind (lambda () 1)
+                                         (lambda () (parameterize ((width 5)) (error "boom")))
+                                         (lambda () (assert-eq (width) 80)))))
+
+         (it "should reach compiled functions"
+             (define f (compile (lambda (x) (+ x (current-width)))))
+             (assert-eq (parameterize ((width 1)) (f 1)) 2)
+             (assert-eq (f 1) 81))
+
+         (it "should not reach functions made in its body once it returns"
+             (define g (parameterize ((width 5)) (lambda () (width))))
+             (assert-eq (g) 80)
+             (assert-eq ((compile g)) 80))
+
+         (it "should hand its bindings to processes it forks"
+             (assert-eq (parameterize ((width 7)) (proc-join (fork (lambda () (width)))))
+                        7)
+             (assert-eq (parameterize ((width 8)) (parallel-map (lambda (x) (+ x (width))) '(1 2)))
+                        '(9 10)))
+
+         (it "should not change what other processes see"
+             (define seen (make-atomic 0))
+             (define p (fork (lambda ()
+                               (sleep 50)
+                               (atomic-store! seen (width)))))
+             (parameterize ((width 3))
+               (proc-join p))
+             (assert-eq (atomic-get seen) 80))
+
+         (it "should check its bindings"
+             (assert-error (parameterize ((car 1)) 1))
+             (assert-error (parameterize (width 1) 1))
+             (assert-error (parameterize 5 1))))
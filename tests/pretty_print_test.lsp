@@ -0,0 +1,28 @@
+;;; -*- mode: Scheme -*-
+
+(context "pretty-print"
+
+         ()
+
+         (it "leaves forms that fit on one line"
+             (assert-eq (pretty-print-to-string '(a (b c) "d")) "(a (b c) \"d\")"))
+
+         (it "breaks long forms at *pretty-print-width*"
+             (let ((*pretty-print-width* 20))
+               (assert-eq (string-split (pretty-print-to-string '(list 'alpha 'beta 'gamma)) (format #f "~%"))
+                          '("(list 'alpha" "      'beta" "      'gamma)"))))
+
+         (it "indents siblings instead of aligning them when *pretty-print-align* is false"
+             (let ((*pretty-print-width* 20)
+                   (*pretty-print-align* #f))
+               (assert-eq (string-split (pretty-print-to-string '(list 'alpha 'beta 'gamma)) (format #f "~%"))
+                          '("(list" "  'alpha" "  'beta" "  'gamma)"))))
+
+         (it "indents the bodies of special forms"
+             (let ((*pretty-print-width* 24))
+               (assert-eq (string-split (pretty-print-to-string '(define (f x) (display x) (+ x 1))) (format #f "~%"))
+                          '("(define (f x)" "  (display x)" "  (+ x 1))"))))
+
+         (it "rejects a bad width"
+             (let ((*pretty-print-width* "wide"))
+               (assert-error (pretty-print-to-string '(a b))))))
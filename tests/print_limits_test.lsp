@@ -0,0 +1,54 @@
+;;; -*- mode: Scheme -*-
+
+(context "print limits"
+
+         ()
+
+         (it "abbreviates lists and vectors longer than *print-length*"
+             (parameterize ((*print-length* 2))
+               (assert-eq (str '(1 2 3 4)) "(1 2 ...)")
+               (assert-eq (str '(1 2)) "(1 2)")
+               (assert-eq (str #(1 2 3)) "#(1 2 ...)")
+               (assert-eq (with-output-to-string (lambda () (display '(1 2 3)))) "(1 2 ...)")
+               (assert-eq (format #f "~A" '(1 2 3)) "(1 2 ...)")))
+
+         (it "abbreviates structure nested deeper than *print-depth*"
+             (parameterize ((*print-depth* 2))
+               (assert-eq (str '(1 (2 (3 (4))))) "(1 (2 ...))")
+               (assert-eq (pretty-print-to-string '(1 (2 (3 (4))))) "(1 (2 ...))")))
+
+         (it "are parameters, so limits only hold inside parameterize"
+             (assert-true (parameter? *print-length*))
+             (assert-true (parameter? *print-depth*))
+             (parameterize ((*print-length* 1))
+               (assert-eq (*print-length*) 1))
+             (assert-nil (*print-length*))
+             (assert-eq (str '(1 2 3)) "(1 2 3)")
+             (assert-error (set! *print-length* 2)))
+
+         (it "prints everything when the limits are not integers"
+             (assert-eq (str '(1 (2 (3 (4))) 5 6)) "(1 (2 (3 (4))) 5 6)")))
+
+(context "datum labels"
+
+         ()
+
+         (it "labels circular lists"
+             (define l (list 1 2 3))
+             (set-cdr! (cddr l) l)
+             (assert-eq (str l) "#0=(1 2 3 . #0#)")
+             (assert-eq (pretty-print-to-string l) "#0=(1 2 3 . #0#)"))
+
+         (it "labels lists that contain themselves"
+             (define l (list 1 2))
+             (set-car! l l)
+             (assert-eq (str l) "#0=(#0# 2)"))
+
+         (it "labels vectors that contain themselves"
+             (define v (vector 1 2))
+             (vector-set! v 1 v)
+             (assert-eq (str v) "#0=#(1 #0#)"))
+
+         (it "leaves shared structure that isn't circular unlabelled"
+             (define s (list 9))
+             (assert-eq (str (list s s)) "((9) (9))")))
@@ -0,0 +1,115 @@
+;;; -*- mode: Scheme -*-
+
+(define (integers-from n)
+  (stream-cons n (integers-from (+ n 1))))
+
+(define (stream-map-squares s)
+  (stream-cons (* (stream-car s) (stream-car s))
+               (stream-map-squares (stream-cdr s))))
+
+(context "delay and force"
+
+         ()
+
+         (it "doesn't evaluate until forced"
+             (let* ((evaluated #f)
+                    (p (delay (begin (set! evaluated #t) 42))))
+               (assert-false evaluated)
+               (assert-false (promise-forced? p))
+               (assert-eq (force p) 42)
+               (assert-true evaluated)
+               (assert-true (promise-forced? p))))
+
+         (it "evaluates once"
+             (let* ((count 0)
+                    (p (delay (begin (set! count (+ count 1)) count))))
+               (force p)
+               (force p)
+               (assert-eq (force p) 1)
+               (assert-eq count 1)))
+
+         (it "evaluates in the environment it was made in"
+             (let ((p (let ((x 10)) (delay (* x 2)))))
+               (assert-eq (force p) 20)))
+
+         (it "leaves values that aren't promises alone"
+             (assert-eq (force 5) 5)
+             (assert-eq (force '(1 2)) '(1 2)))
+
+         (it "makes forced promises"
+             (let ((p (make-promise 'ready)))
+               (assert-true (promise? p))
+               (assert-true (promise-forced? p))
+               (assert-eq (force p) 'ready)
+               (assert-true (eqv? (make-promise p) p))))
+
+         (it "is described by whether it was forced"
+             (let ((p (delay (+ 1 2))))
+               (assert-eq (with-output-to-string (lambda () (describe p)))
+                          "<promise>\n  type: Promise\n  not yet forced\n")
+               (force p)
+               (assert-eq (with-output-to-string (lambda () (describe p)))
+                          "<promise>\n  type: Promise\n  forced to 3\n")))
+
+         (it "recognizes promises"
+             (assert-true (promise? (delay 1)))
+             (assert-false (promise? 1))
+             (assert-error (promise-forced? 1)))
+
+         (it "raises errors each time until it succeeds"
+             (let* ((tries 0)
+                    (p (delay (begin (set! tries (+ tries 1))
+                                     (if (< tries 2) (error "not yet") tries)))))
+               (assert-error (force p))
+               (assert-false (promise-forced? p))
+               (assert-eq (force p) 2)))
+
+         (it "is a promise whose expression forces itself"
+             (define count 0)
+             (define p (delay (begin (set! count (+ count 1))
+                                     (if (> count 1) count (force p)))))
+             (assert-eq (force p) 2)
+             (assert-eq (force p) 2))
+
+         (it "gives every process the same value"
+             (let* ((p (delay (list 'shared)))
+                    (procs (map (lambda (i) (fork (lambda () (force p)))) '(1 2 3 4 5 6 7 8)))
+                    (results (map proc-join procs)))
+               (for-each (lambda (v) (assert-true (eqv? v (force p)))) results))))
+
+(context "streams"
+
+         ()
+
+         (it "takes from an infinite stream"
+             (assert-eq (stream-take (integers-from 1) 5) '(1 2 3 4 5))
+             (assert-eq (stream-take (stream-map-squares (integers-from 1)) 4) '(1 4 9 16))
+             (assert-eq (stream-take (integers-from 1) 0) '()))
+
+         (it "takes all of a short stream"
+             (assert-eq (stream-take (stream-cons 1 (stream-cons 2 the-empty-stream)) 5) '(1 2)))
+
+         (it "drops from the front"
+             (assert-eq (stream-car (stream-tail (integers-from 1) 100)) 101))
+
+         (it "only evaluates what is needed"
+             (let* ((forced '())
+                    (s (stream-cons 1 (begin (set! forced (cons 2 forced))
+                                             (stream-cons 2 (begin (set! forced (cons 3 forced))
+                                                                   the-empty-stream))))))
+               (assert-eq (stream-take s 2) '(1 2))
+               (assert-eq forced '(2))
+               (assert-eq (stream-cdr (stream-cdr s)) '())
+               (assert-eq forced '(3 2))))
+
+         (it "recognizes streams"
+             (assert-true (stream-pair? (integers-from 1)))
+             (assert-false (stream-pair? '(1 2)))
+             (assert-true (stream-null? the-empty-stream))
+             (assert-false (stream-null? (integers-from 1))))
+
+         (it "rejects what isn't a stream"
+             (assert-error (stream-car '()))
+             (assert-error (stream-cdr '(1 2)))
+             (assert-error (stream-take (integers-from 1) -1))
+             (assert-error (stream-tail (stream-cons 1 the-empty-stream) 2))))
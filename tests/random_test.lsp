@@ -0,0 +1,35 @@
+;;; -*- mode: Scheme -*-
+
+(context "random"
+
+         ((define (draws r) (list (random-int r 1000) (random-int r 1000) (random-float r) (random-choice r '(a b c d e)))))
+
+         (it "repeats what a seed gives"
+             (assert-eq (draws (make-random 42)) (draws (make-random 42)))
+             (assert-true (random? (make-random)))
+             (assert-false (random? 42)))
+
+         (it "keeps numbers in range"
+             (let ((r (make-random 7)))
+               (do ((i 0 (+ i 1))) ((== i 50))
+                 (let ((n (random-int r 10))
+                       (f (random-float r)))
+                   (assert-true (and (>= n 0) (< n 10)))
+                   (assert-true (and (>= f 0.0) (< f 1.0)))))))
+
+         (it "chooses from lists and vectors"
+             (assert-true (memq (random-choice (make-random 1) '(x y z)) '(x y z)))
+             (assert-eq (random-choice (make-random 1) #(only)) 'only))
+
+         (it "has seedless versions"
+             (assert-true (< (random-int 5) 5))
+             (assert-true (< (random-float) 1.0))
+             (assert-true (memq (random-choice '(p q)) '(p q))))
+
+         (it "rejects bad arguments"
+             (assert-error (make-random "seed"))
+             (assert-error (random-int 0))
+             (assert-error (random-int (make-random 1) "10"))
+             (assert-error (random-float 1))
+             (assert-error (random-choice '()))
+             (assert-error (random-choice 5))))
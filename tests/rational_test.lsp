@@ -0,0 +1,129 @@
+;;; -*- mode: Scheme -*-
+
+(context "rational literals"
+
+         ()
+
+         (it "should read n/d as a rational"
+             (assert-true (rational? 3/4))
+             (assert-eq (numerator 3/4) 3)
+             (assert-eq (denominator 3/4) 4))
+
+         (it "should reduce to lowest terms"
+             (assert-eq 2/4 1/2)
+             (assert-eq (denominator 6/8) 4))
+
+         (it "should read whole rationals as integers"
+             (assert-true (integer? 4/2))
+             (assert-eq 4/2 2))
+
+         (it "should read negative rationals"
+             (assert-eq (numerator -1/3) -1))
+
+         (it "should print as n/d"
+             (assert-eq (number->string 3/4) "3/4")
+             (assert-eq (str 3/4) "3/4"))
+
+         (it "should leave the / symbol alone"
+             (assert-eq (/ 6 3) 2)))
+
+(context "rational arithmetic"
+
+         ()
+
+         (it "should add exactly"
+             (assert-eq (+ 1/3 1/6) 1/2)
+             (assert-eq (+ 1/2 1/2) 1))
+
+         (it "should subtract and multiply exactly"
+             (assert-eq (- 1/2 1/3) 1/6)
+             (assert-eq (* 2/3 3/4) 1/2)
+             (assert-eq (* 1/3 3) 1))
+
+         (it "should divide exactly when a rational is involved"
+             (assert-eq (/ 1/2 2) 1/4)
+             (assert-error (/ 1/2 0)))
+
+         (it "should produce rationals from exact/"
+             (assert-eq (exact/ 1 3) 1/3)
+             (assert-eq (exact/ 6 3) 2)
+             (assert-eq (exact/ 1 2 2) 1/4)
+             (assert-error (exact/ 1 0)))
+
+         (it "should contaminate to float"
+             (assert-true (float? (+ 1/2 0.5)))
+             (assert-eq (+ 1/2 0.5) 1.0)
+             (assert-eq (exact/ 1 2.0) 0.5))
+
+         (it "should raise rationals to integer powers"
+             (assert-eq (expt 2/3 2) 4/9)
+             (assert-eq (expt 2 -2) 1/4)
+             (assert-error (expt 0 -1)))
+
+         (it "should compare exactly"
+             (assert-true (< 1/3 1/2))
+             (assert-true (> 1/2 1/3))
+             (assert-true (<= 1/2 1/2))
+             (assert-true (< 1/3 1))
+             (assert-false (== 1/3 1/2)))
+
+         (it "should support the numeric predicates"
+             (assert-true (positive? 1/2))
+             (assert-true (negative? -1/2))
+             (assert-eq (abs -1/2) 1/2)
+             (assert-eq (sign -1/2) -1)
+             (assert-false (float? 1/2))))
+
+(context "numerator, denominator and rationalize"
+
+         ()
+
+         (it "should treat integers as n/1"
+             (assert-eq (numerator 5) 5)
+             (assert-eq (denominator 5) 1))
+
+         (it "should find the simplest rational within a tolerance"
+             (assert-eq (rationalize 3/10 1/10) 1/3)
+             (assert-eq (rationalize -3/10 1/10) -1/3)
+             (assert-eq (rationalize 1/4 1/4) 0))
+
+         (it "should return floats for inexact arguments"
+             (assert-true (float? (rationalize 0.3 1/10))))
+
+         (it "should reject inexact numbers for numerator and denominator"
+             (assert-error (numerator 0.5))
+             (assert-error (denominator 'a))))
+
+(context "exactness"
+
+         ()
+
+         (it "should tell exact numbers from inexact ones"
+             (assert-true (exact? 3))
+             (assert-true (exact? 1/3))
+             (assert-true (exact? (expt 2 80)))
+             (assert-false (exact? 0.5))
+             (assert-true (inexact? 0.5))
+             (assert-true (inexact? 3+4i))
+             (assert-false (inexact? 3))
+             (assert-error (exact? 'a))
+             (assert-error (inexact? "1.5")))
+
+         (it "should convert exact numbers to floats"
+             (assert-eq (exact->inexact 1/2) 0.5)
+             (assert-true (float? (exact->inexact 3)))
+             (assert-eq (exact->inexact 0.25) 0.25)
+             (assert-error (exact->inexact 'a)))
+
+         (it "should convert floats to the exact value they hold"
+             (assert-eq (inexact->exact 0.5) 1/2)
+             (assert-eq (inexact->exact 2.0) 2)
+             (assert-true (integer? (inexact->exact -3.0)))
+             (assert-eq (inexact->exact 0.1) 13421773/134217728)
+             (assert-eq (inexact->exact 7/8) 7/8)
+             (assert-eq (exact->inexact (inexact->exact 0.1)) 0.1))
+
+         (it "should reject floats with no exact value"
+             (assert-error (inexact->exact (bits->float 2139095040)))
+             (assert-error (inexact->exact 3+4i))
+             (assert-error (inexact->exact "0.5"))))
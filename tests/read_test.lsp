@@ -0,0 +1,55 @@
+;;; -*- mode: Scheme -*-
+
+(context "read-from-string"
+
+         ()
+
+         (it "reads the first expression and where it stopped"
+             (receive (form next) (read-from-string "(a b) 42 \"s\"")
+               (assert-eq form '(a b))
+               (assert-eq next 6)))
+
+         (it "reads successive expressions from a start index"
+             (define source "(a b) 42 \"s\"")
+             (receive (form next) (read-from-string source 6)
+               (assert-eq form 42)
+               (assert-eq next 9)
+               (receive (form next) (read-from-string source next)
+                 (assert-eq form "s")
+                 (assert-eq next 12)
+                 (assert-true (eof-object? (read-from-string source next))))))
+
+         (it "returns data, not code"
+             (assert-eq (read-from-string "(+ 1 2)") '(+ 1 2))
+             (assert-eq (read-from-string "#(1 2)") #(1 2)))
+
+         (it "skips comments"
+             (assert-eq (read-from-string "; note\n sym") 'sym))
+
+         (it "reads the eof object from a blank string"
+             (assert-true (eof-object? (read-from-string "   "))))
+
+         (it "reports parse errors with their position"
+             (assert-true (substring? "position 4" (on-error (read-from-string "(a b") (lambda (e) e))))
+             (assert-true (substring? "position 3" (on-error (read-from-string "(a #q b)") (lambda (e) e))))
+             (assert-true (substring? "Unexpected )" (on-error (read-from-string ")") (lambda (e) e)))))
+
+         (it "checks its arguments"
+             (assert-error (read-from-string 'a))
+             (assert-error (read-from-string "abc" 4))
+             (assert-error (read-from-string "abc" -1))))
+
+(context "read-all-from-string"
+
+         ()
+
+         (it "reads every expression"
+             (assert-eq (read-all-from-string "(a b) 42 ; c\n \"s\" () #(1 2)")
+                        '((a b) 42 "s" () #(1 2))))
+
+         (it "reads nothing from a blank string"
+             (assert-nil (read-all-from-string "")))
+
+         (it "reports parse errors with their position"
+             (assert-true (substring? "position 2" (on-error (read-all-from-string "a ) b") (lambda (e) e))))
+             (assert-error (read-all-from-string 42))))
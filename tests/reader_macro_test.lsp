@@ -0,0 +1,63 @@
+;;; -*- mode: Scheme -*-
+
+(context "reader macros"
+
+         ((define (read-until-bang stream)
+            (let loop ((chars '()))
+              (let ((ch (reader-read-char stream)))
+                (if (or (eof-object? ch) (string=? ch "!"))
+                    (apply str (reverse chars))
+                    (loop (cons ch chars)))))))
+
+         (it "calls the function for its character"
+             (unwind-protect
+              (begin
+                (set-reader-macro "!" read-until-bang)
+                (assert-eq (read-from-string "!hello world!") "hello world")
+                (assert-eq (read-all-from-string "(a !b c! d)") '((a "b c" d))))
+              (set-reader-macro "!" nil)))
+
+         (it "can read characters and data from the stream"
+             (unwind-protect
+              (begin
+                (set-reader-macro "~" (lambda (s)
+                                        (list (reader-read s) (reader-peek-char s) (reader-read-char s) (reader-read s))))
+                (assert-eq (read-from-string "~(1 2) zq") '((1 2) " " " " zq))
+                (assert-eq (read-from-string "~~1 2 3 4 5 6 7") '((1 " " " " 2) " " " " 3)))
+              (set-reader-macro "~" nil)))
+
+         (it "returns the eof object at the end of the input"
+             (unwind-protect
+              (begin
+                (set-reader-macro "~" (lambda (s) (list (eof-object? (reader-read-char s)) (eof-object? (reader-read s)))))
+                (assert-eq (read-from-string "~") '(#t #t)))
+              (set-reader-macro "~" nil)))
+
+         (it "stop applying once removed"
+             (set-reader-macro "!" read-until-bang)
+             (set-reader-macro "!" nil)
+             (assert-eq (read-from-string "!abc!") '!abc!))
+
+         (it "can replace the built-in quote syntax"
+             (unwind-protect
+              (begin
+                (set-reader-macro "'" (lambda (s) (list 'literally (reader-read s))))
+                (assert-eq (read-from-string "'a") '(literally a)))
+              (set-reader-macro "'" nil))
+             (assert-eq (read-from-string "'a") ''a))
+
+         (it "can replace the built-in # syntax"
+             (unwind-protect
+              (begin
+                (set-reader-macro "#" (lambda (s) (list 'hash (reader-read-char s))))
+                (assert-eq (read-from-string "#t") '(hash "t")))
+              (set-reader-macro "#" nil))
+             (assert-eq (read-from-string "#t") #t)
+             (assert-eq (read-from-string "#(1 2)") #(1 2)))
+
+         (it "checks its arguments"
+             (assert-error (set-reader-macro "!!" read-until-bang))
+             (assert-error (set-reader-macro 'a read-until-bang))
+             (assert-error (set-reader-macro "(" read-until-bang))
+             (assert-error (set-reader-macro "!" 42))
+             (assert-error (reader-read-char "not a stream"))))
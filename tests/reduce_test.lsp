@@ -43,4 +43,20 @@
 
             (assert-eq (fold-right list '() '(1 2 3 4))
                        '(1 (2 (3 (4 ()))))))
-)
+
+        (it "fold over several lists"
+            (assert-eq (fold-left list '() '(1 2 3) '(a b c))
+                       '(((() 1 a) 2 b) 3 c))
+            (assert-eq (fold-right list '() '(1 2 3) '(a b c))
+                       '(1 a (2 b (3 c ()))))
+            (assert-eq (fold-left + 0 '(1 2 3) '(10 20 30)) 66))
+
+        (it "fold stops at the shortest list"
+            (assert-eq (fold-left list 'x '(1 2 3) '(a)) '(x 1 a))
+            (assert-eq (fold-right cons* '() '(1 2 3) '(a b)) '(1 a 2 b))
+            (assert-eq (fold-left + 0 '(1 2) '()) 0))
+
+        (it "fold checks its arguments"
+            (assert-error (fold-left 1 0 '(1 2)))
+            (assert-error (fold-right + 0 '(1 2) 5))
+            (assert-error (fold-left (lambda (acc x) (error "bad")) 0 '(1)))))
@@ -0,0 +1,35 @@
+;;; -*- mode: Scheme -*-
+
+(context "regular expressions"
+
+         (
+             (define digits (regex-compile "[0-9]+"))
+         )
+
+         (it "should test for a match"
+             (assert-true (regex-match? digits "abc 123"))
+             (assert-false (regex-match? digits "abc"))
+             (assert-true (regex-match? "^a.c$" "abc")))
+
+         (it "should find the first match with its groups"
+             (assert-eq (regex-find "([a-z]+)@([a-z]+)" "mail bob@example now") '("bob@example" "bob" "example"))
+             (assert-eq (regex-find digits "a 12 b 34") '("12")))
+
+         (it "should return nil when nothing matches"
+             (assert-nil (regex-find digits "none")))
+
+         (it "should find all matches"
+             (assert-eq (regex-find-all digits "a 12 b 34 c 5") '("12" "34" "5"))
+             (assert-nil (regex-find-all digits "none")))
+
+         (it "should replace with group references"
+             (assert-eq (regex-replace "(\\w+)@(\\w+)" "bob@example" "$2 at ${1}") "example at bob")
+             (assert-eq (regex-replace digits "a1b22" "#") "a#b#"))
+
+         (it "should report bad patterns and arguments"
+             (assert-error (regex-compile "("))
+             (assert-error (regex-match? "(" "x"))
+             (assert-error (regex-match? 5 "x"))
+             (assert-error (regex-find digits 5))
+             (assert-error (regex-replace digits "x" 5))
+             (assert-error (regex-compile digits))))
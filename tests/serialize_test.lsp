@@ -0,0 +1,70 @@
+;;; -*- mode: Scheme -*-
+
+(define (round-trip x)
+  (deserialize (serialize x)))
+
+(context "serialize"
+
+         ()
+
+         (it "makes a bytearray"
+             (assert-true (bytearray? (serialize '(1 2 3)))))
+
+         (it "round-trips atoms"
+             (assert-eq (round-trip 42) 42)
+             (assert-eq (round-trip -7) -7)
+             (assert-eq (round-trip 1.5) 1.5)
+             (assert-eq (round-trip 123456789012345678901234567890) 123456789012345678901234567890)
+             (assert-eq (round-trip 3/4) 3/4)
+             (assert-eq (round-trip 3+4i) 3+4i)
+             (assert-eq (round-trip #\a) #\a)
+             (assert-eq (round-trip "λx") "λx")
+             (assert-eq (round-trip 'sym) 'sym)
+             (assert-true (round-trip #t))
+             (assert-false (round-trip #f))
+             (assert-nil (round-trip '())))
+
+         (it "round-trips containers"
+             (let ((h (make-hash-table))
+                   (s (make-set 1 "two" 'three)))
+               (hash-set! h 'a (list 1 2))
+               (hash-set! h "b" (vector 3 4))
+               (let ((value (list s (vector 1 (list 2)) [1 2 3] (make-frame a: 1 b: "c") (acons 'x 1 '())))
+                     (table (round-trip h)))
+                 (assert-eq (round-trip value) value)
+                 (assert-eq (hash-count table) 2)
+                 (assert-eq (hash-ref table 'a) '(1 2))
+                 (assert-eq (hash-ref table "b") (vector 3 4)))))
+
+         (it "preserves sharing"
+             (let* ((shared (list 1 2))
+                    (copy (round-trip (vector shared shared))))
+               (assert-true (eqv? (vector-ref copy 0) (vector-ref copy 1)))))
+
+         (it "preserves cycles"
+             (let* ((l (list 1 2 3))
+                    (v (vector 'a l)))
+               (set-cdr! (cddr l) l)
+               (vector-set! v 0 v)
+               (let ((copy (round-trip v)))
+                 (assert-true (eqv? (vector-ref copy 0) copy))
+                 (assert-true (eqv? (cdddr (vector-ref copy 1)) (vector-ref copy 1)))
+                 (assert-eq (car (vector-ref copy 1)) 1)
+                 (assert-eq (caddr (vector-ref copy 1)) 3))))
+
+         (it "writes to and reads from ports"
+             (let ((path "/tmp/golisp-serialize-test.bin"))
+               (call-with-output-file path
+                                      (lambda (port)
+                                        (serialize '(1 "a") port)
+                                        (serialize 'second port)))
+               (let ((port (open-input-file path)))
+                 (assert-eq (deserialize port) '(1 "a"))
+                 (assert-eq (deserialize port) 'second)
+                 (close-port port))))
+
+         (it "rejects what it can't serialize"
+             (assert-error (serialize car))
+             (assert-error (serialize (lambda (x) x)))
+             (assert-error (deserialize "not bytes"))
+             (assert-error (deserialize [1 2 3]))))
@@ -1,48 +1,60 @@
 ;;; -*- mode: Scheme -*-
 
-(define x 4)
-
-(define y 5)
-
-(context "setting"
+(context "sets"
 
          ()
-         
-         (it set!-in-global-context
-             (assert-eq x
-                        4)
-             (assert-eq (begin
-                          (set! x 10)
-                          x)
-                        10)
-             (assert-eq x
-                        10))
-
-         (it set!-in-local-context
-             (assert-eq y
-                        5)
-             (assert-eq (let ((y 2))
-                          (set! y 15)
-                          y)
-                        15)
-             (assert-eq y
-                        5))
-
-         (it set-car!
-             (assert-eq (let ((pair '(a b)))
-                          (set-car! pair 1)
-                          (car pair))
-                        1))
-
-         (it set-cdr!
-             (assert-eq (let ((pair '(a b)))
-                          (set-cdr! pair 1)
-                          (cdr pair))
-                        1))
-
-         (it set-nth!
-             (assert-eq (let ((l '(a b c d)))
-                          (set-nth! l 3 1)
-                          (nth l 3))
-                        1))
-)
+
+         (it "makes sets"
+             (assert-true (set? (make-set)))
+             (assert-false (set? '(1 2)))
+             (assert-eq (set-count (make-set)) 0)
+             (assert-eq (set-count (make-set 1 2 2 3 1)) 3)
+             (assert-eq (set-count (list->set '("a" "b" "a"))) 2))
+
+         (it "adds and removes elements"
+             (define s (make-set))
+             (assert-eq (set-add! s 1 "one" 'one) s)
+             (assert-true (set-member? s 1))
+             (assert-true (set-member? s "one"))
+             (assert-true (set-member? s 'one))
+             (assert-false (set-member? s 2))
+             (assert-true (set-remove! s "one"))
+             (assert-false (set-remove! s "one"))
+             (assert-false (set-member? s "one"))
+             (assert-eq (set-count s) 2))
+
+         (it "compares elements with equal?"
+             (define s (make-set '(1 2) "x"))
+             (assert-true (set-member? s (list 1 2)))
+             (assert-true (set-member? s (string #\x)))
+             (assert-false (set-member? s "X")))
+
+         (it "lists elements"
+             (assert-eq (sort (set->list (make-set 3 1 2 3)) <) '(1 2 3))
+             (assert-eq (set->list (make-set)) '()))
+
+         (it "combines sets without changing them"
+             (define a (make-set 1 2 3))
+             (define b (make-set 2 3 4))
+             (assert-eq (set-union a b) (make-set 1 2 3 4))
+             (assert-eq (set-intersection a b) (make-set 2 3))
+             (assert-eq (set-difference a b) (make-set 1))
+             (assert-eq (set-difference a b (make-set 1)) (make-set))
+             (assert-eq (set-union) (make-set))
+             (assert-eq a (make-set 3 2 1))
+             (assert-eq b (make-set 2 3 4)))
+
+         (it "writes its elements"
+             (assert-eq (with-output-to-string (lambda () (write (make-set "b" "a")))) "<set: \"a\" \"b\">"))
+
+         (it "keeps elements apart that print the same"
+             (define s (parameterize ((*print-length* 2))
+                         (make-set '(1 2 3) '(1 2 4))))
+             (assert-eq (set-count s) 2)
+             (assert-eq (set-count (make-set 'k (string->uninterned-symbol "k"))) 2))
+
+         (it "rejects things that aren't sets"
+             (assert-error (set-add! '(1) 2))
+             (assert-error (set-union (make-set) '(1)))
+             (assert-error (set-count 5))
+             (assert-error (list->set 5))))
@@ -0,0 +1,31 @@
+;;; -*- mode: Scheme -*-
+
+(context "string builders"
+
+         ()
+
+         (it "accumulates strings"
+             (define sb (make-string-builder))
+             (assert-true (string-builder? sb))
+             (assert-false (string-builder? "abc"))
+             (assert-eq (sb->string sb) "")
+             (sb-append! sb "abc")
+             (sb-append! sb 42 " " 'sym " " (list 1 "two"))
+             (assert-eq (sb->string sb) "abc42 sym (1 two)")
+             (assert-eq (sb-length sb) 17))
+
+         (it "builds in a loop"
+             (define sb (make-string-builder))
+             (do ((i 0 (+ i 1)))
+                 ((== i 5))
+               (sb-append! sb i))
+             (assert-eq (sb->string sb) "01234"))
+
+         (it "returns the builder from sb-append!"
+             (define sb (make-string-builder))
+             (assert-eq (sb->string (sb-append! (sb-append! sb "a") "b")) "ab"))
+
+         (it "rejects things that aren't builders"
+             (assert-error (sb-append! "abc" "d"))
+             (assert-error (sb-length 1))
+             (assert-error (sb->string '()))))
@@ -37,8 +37,42 @@
                         10)
              (assert-eq (string->number "10" 16)
                         16)
-             (assert-eq (string->number "10" 20)
-                        0))
+             (assert-error (string->number "10" 20)))
+
+         (it string->number-reads-all-kinds-of-numbers
+             (assert-eq (string->number "-42") -42)
+             (assert-eq (string->number "+7") 7)
+             (assert-eq (string->number "2.5") 2.5)
+             (assert-eq (string->number "-.5") -0.5)
+             (assert-eq (string->number "1e2") 100.0)
+             (assert-eq (string->number "3/4") 3/4)
+             (assert-eq (string->number "-6/4") -3/2)
+             (assert-eq (string->number "4/2") 2)
+             (assert-eq (string->number "123456789012345678901234567890")
+                        123456789012345678901234567890)
+             (assert-eq (string->number "3+4i") 3+4i)
+             (assert-eq (string->number "ff" 16) 255)
+             (assert-eq (string->number "-FF" 16) -255)
+             (assert-eq (string->number "777" 8) 511)
+             (assert-eq (string->number "101/11" 2) 5/3)
+             (assert-eq (string->number "ffffffffffffffffffff" 16)
+                        1208925819614629174706175))
+
+         (it string->number-is-false-for-non-numbers
+             (assert-false (string->number ""))
+             (assert-false (string->number "abc"))
+             (assert-false (string->number "12abc"))
+             (assert-false (string->number " 12"))
+             (assert-false (string->number "1_000"))
+             (assert-false (string->number "0x10"))
+             (assert-false (string->number "1/0"))
+             (assert-false (string->number "1/-2"))
+             (assert-false (string->number "inf"))
+             (assert-false (string->number "2" 2))
+             (assert-false (string->number "1.5" 16))
+             (assert-false (string->number "i"))
+             (assert-error (string->number 10))
+             (assert-error (string->number "10" 'a)))
 
          (it number->string
              (assert-eq (number->string 10)
@@ -51,8 +85,18 @@
                         "10")
              (assert-eq (number->string 16 16)
                         "10")
-             (assert-eq (number->string 20 20)
-                        "Unsupported base: 20"))
+             (assert-error (number->string 20 20)))
+
+         (it number->string-writes-all-kinds-of-numbers
+             (assert-eq (number->string 255 16) "ff")
+             (assert-eq (number->string -5 2) "-101")
+             (assert-eq (number->string 2.5) "2.5")
+             (assert-eq (number->string 3/4 2) "11/100")
+             (assert-eq (number->string 1208925819614629174706175 16)
+                        "ffffffffffffffffffff")
+             (assert-eq (string->number (number->string 12345 8) 8) 12345)
+             (assert-error (number->string 2.5 2))
+             (assert-error (number->string "10")))
 
          (it string-split
              (assert-eq (string-split "1-2" "-")
@@ -62,6 +106,29 @@
              (assert-error (string-split 3 ""))
              (assert-error (string-split "" 3)))
 
+         (it "string-split edge cases"
+             (assert-eq (string-split "abc" "")
+                        '("a" "b" "c"))
+             (assert-eq (string-split "abc" ",")
+                        '("abc"))
+             (assert-eq (string-split "a,,b," ",")
+                        '("a" "" "b" ""))
+             (assert-eq (string-split "a,,b," "," #t)
+                        '("a" "b"))
+             (assert-eq (string-split "a,,b," "," #f)
+                        '("a" "" "b" "")))
+
+         (it "string-join with separators"
+             (assert-eq (string-join '("a" "b" "c") ", ")
+                        "a, b, c")
+             (assert-eq (string-join '("a" "b"))
+                        "ab")
+             (assert-eq (string-join '("solo") "-")
+                        "solo")
+             (assert-eq (string-join (string-split "a-b" "-") "+")
+                        "a+b")
+             (assert-error (string-join '("a" 1) ",")))
+
          (it string-trim
              (assert-eq (string-trim "  hello ")
                         "hello")
@@ -151,6 +218,10 @@
                         1)
              (assert-eq (string-length "12345")
                         5)
+             (assert-eq (string-length "héllo")
+                        5)
+             (assert-eq (string-length "日本語")
+                        3)
              (assert-error (string-length 5)))
 
 
@@ -168,7 +239,23 @@
              (assert-error (substring "hello" "a" 5))
              (assert-error (substring "hello" 1 "5"))
              (assert-error (substring "hello" 10 2))
-             (assert-error (substring "hello" 1 10)))
+             (assert-error (substring "hello" 1 10))
+             (assert-error (substring "hello" -1 2))
+             (assert-eq (substring "日本語です" 1 3)
+                        "本語")
+             (assert-eq (substring "héllo" 1 5)
+                        "éllo")
+             (assert-error (substring "日本語" 1 4)))
+
+         (it string->bytes
+             (assert-eq (bytearray->list (string->bytes "aé"))
+                        '(97 195 169))
+             (assert-eq (bytes->string (list->bytearray '(97 195 169)))
+                        "aé")
+             (assert-eq (string-length (bytes->string (string->bytes "日本")))
+                        2)
+             (assert-error (string->bytes 5))
+             (assert-error (bytes->string "abc")))
 
 
          (it substring?
@@ -227,4 +314,13 @@
              (assert-false (string>=? "a" "b"))
              (assert-true (string>=? "a" "a"))
              (assert-true (string>=? "a" "A"))
-             (assert-true (string-ci>=? "a" "A"))))
+             (assert-true (string-ci>=? "a" "A")))
+
+         (it "indexes a string repeatedly"
+             (define s "añb日c")
+             (define (collect i acc)
+               (if (== i (string-length s))
+                   acc
+                   (collect (+ i 1) (cons (string-ref s i) acc))))
+             (assert-eq (apply string (reverse (collect 0 '())))
+                        s)))
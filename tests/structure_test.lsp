@@ -0,0 +1,48 @@
+;;; -*- mode: Scheme -*-
+
+(context "define-structure"
+
+         ((define-structure point x y)
+          (define-structure empty))
+
+         (it "makes a constructor and accessors"
+             (define p (make-point 1 2))
+             (assert-eq (point-x p) 1)
+             (assert-eq (point-y p) 2))
+
+         (it "makes a predicate"
+             (assert-true (point? (make-point 1 2)))
+             (assert-false (point? '((x . 1) (y . 2))))
+             (assert-false (point? (make-empty)))
+             (assert-true (structure? (make-empty)))
+             (assert-false (structure? 5)))
+
+         (it "makes setters"
+             (define p (make-point 1 2))
+             (assert-eq (set-point-x! p 10) 10)
+             (assert-eq (point-x p) 10)
+             (assert-eq (point-y p) 2))
+
+         (it "compares field by field with equal?"
+             (assert-true (equal? (make-point 1 '(a)) (make-point 1 '(a))))
+             (assert-false (equal? (make-point 1 2) (make-point 1 3)))
+             (assert-false (eqv? (make-point 1 2) (make-point 1 2))))
+
+         (it "keeps types apart"
+             (define-structure pair-of x y)
+             (assert-false (equal? (make-point 1 2) (make-pair-of 1 2)))
+             (assert-false (point? (make-pair-of 1 2)))
+             (assert-error (point-x (make-pair-of 1 2))))
+
+         (it "prints its fields"
+             (assert-eq (str (make-point 1 "a")) "#[point x: 1 y: \"a\"]")
+             (assert-eq (str (make-empty)) "#[empty]"))
+
+         (it "checks its arguments"
+             (assert-error (make-point 1))
+             (assert-error (make-point 1 2 3))
+             (assert-error (point-x 5))
+             (assert-error (set-point-y! '(1 2) 3))
+             (assert-error (define-structure 5 x))
+             (assert-error (define-structure bad "x"))
+             (assert-error (define-structure bad x x))))
@@ -0,0 +1,48 @@
+;;; -*- mode: Scheme -*-
+
+(context "run-process"
+
+         ()
+
+         (it "returns the exit code and output"
+             (receive (code out err) (run-process "echo" '("hello" "world"))
+                      (assert-eq code 0)
+                      (assert-eq out "hello world\n")
+                      (assert-eq err "")))
+
+         (it "captures error output and exit codes"
+             (receive (code out err) (run-process "sh" '("-c" "echo oops >&2; exit 3"))
+                      (assert-eq code 3)
+                      (assert-eq out "")
+                      (assert-eq err "oops\n")))
+
+         (it "gives the process its input"
+             (receive (code out err) (run-process "cat" '() "piped in")
+                      (assert-eq out "piped in")))
+
+         (it "rejects what it can't run"
+             (assert-error (run-process "no-such-command-for-golisp" '()))
+             (assert-error (run-process 'echo '()))
+             (assert-error (run-process "echo" "hello"))
+             (assert-error (run-process "cat" '() 5))))
+
+(context "start-process"
+
+         ()
+
+         (it "can be joined"
+             (let ((p (start-process "sh" '("-c" "echo started"))))
+               (receive (code out err) (proc-join p)
+                        (assert-eq code 0)
+                        (assert-eq out "started\n"))
+               (assert-eq (proc-status p) 'completed)))
+
+         (it "is killed when cancelled"
+             (let ((p (start-process "sleep" '("10"))))
+               (assert-nil (proc-join-timeout p 50))
+               (proc-cancel p)
+               (assert-error (proc-join p))))
+
+         (it "is killed with its parent"
+             (assert-true (timeout-object? (with-timeout 100 (lambda (self)
+                                                                 (run-process "sleep" '("10"))))))))
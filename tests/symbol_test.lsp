@@ -0,0 +1,30 @@
+;;; -*- mode: Scheme -*-
+
+(context "symbols"
+
+         ()
+
+         (it "interns symbols read with the same name"
+             (assert-true (eq? 'abc 'abc))
+             (assert-true (eq? 'abc (string->symbol "abc")))
+             (assert-true (eq? (intern "abc") (string->symbol "abc"))))
+
+         (it "converts symbols to strings"
+             (assert-eq (symbol->string 'abc) "abc")
+             (assert-eq (symbol->string (string->symbol "a b")) "a b")
+             (assert-error (symbol->string "abc"))
+             (assert-error (string->symbol 'abc)))
+
+         (it "keeps uninterned symbols distinct"
+             (let ((sym (string->uninterned-symbol "abc")))
+               (assert-true (symbol? sym))
+               (assert-eq (symbol->string sym) "abc")
+               (assert-false (eq? sym 'abc))
+               (assert-false (eq? sym (string->uninterned-symbol "abc")))
+               (assert-true (eq? sym sym))
+               (assert-true (memq sym (list 'abc sym)))
+               (assert-error (string->uninterned-symbol 'abc))))
+
+         (it "binds uninterned symbols apart from interned ones"
+             (let ((sym (string->uninterned-symbol "abc")))
+               (assert-eq (eval `(let ((,sym 1) (abc 2)) (list ,sym abc))) '(1 2)))))
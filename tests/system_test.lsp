@@ -11,10 +11,40 @@
          (it apply
              (assert-eq (apply + '(1 2)) 3)
              (assert-eq (apply + 1 2 '(3)) 6)
+             (assert-eq (apply + '()) 0)
+             (assert-eq (apply list '()) '())
              (assert-error (apply 5 '(1 2))) ;1st arg must be a function
-             (assert-error (apply + 1 2))) ;last are must be a list
+             (assert-error (apply + 1 2)) ;last are must be a list
+             (assert-error (apply + 1 '(2 . 3))) ;and a proper one
+             (assert-error (apply +))) ;and there must be one
+
+         (it apply-user-functions
+             (define (add a b) (+ a b))
+             (define (all . xs) xs)
+             (define (first-and-rest a . xs) (list a xs))
+             (assert-eq (apply add '(1 2)) 3)
+             (assert-eq (apply add 1 '(2)) 3)
+             (assert-eq (apply all '()) '())
+             (assert-eq (apply all 1 2 '(3 4)) '(1 2 3 4))
+             (assert-eq (apply first-and-rest 1 2 '(3)) '(1 (2 3)))
+             (assert-eq (apply first-and-rest '(1)) '(1 ()))
+             (assert-error (apply add '(1 2 3))) ;arity is checked
+             (assert-error (apply first-and-rest '()))
+             (assert-error (apply car '(1 2)))
+             (assert-error (apply (lambda (x) (error "failed")) '(1))))
 
          (it eval
              (assert-eq (+ 1 2) 3)
              (assert-error (5 1 2))
-             (assert-error ('list 1 2))))
+             (assert-error ('list 1 2)))
+
+         (it environment-variables
+             (assert-eq (setenv "GOLISP_SYSTEM_TEST" "set") "set")
+             (assert-eq (getenv "GOLISP_SYSTEM_TEST") "set")
+             (assert-false (getenv "GOLISP_SYSTEM_TEST_UNSET"))
+             (assert-error (getenv 'name))
+             (assert-error (setenv "GOLISP_SYSTEM_TEST" 1)))
+
+         (it command-line-args
+             (assert-true (list? (command-line-args)))
+             (assert-true (string? (car (command-line-args))))))
@@ -0,0 +1,49 @@
+;;; -*- mode: Scheme -*-
+
+(context "tail calls"
+
+         ((define (count-down n) (if (== n 0) 'done (count-down (- n 1))))
+          (define (count-cond n) (cond ((== n 0) 'done) (else (count-cond (- n 1)))))
+          (define (count-case n) (case n ((0) 'done) (else (count-case (- n 1)))))
+          (define (count-when n) (when (> n 0) (count-when (- n 1))))
+          (define (count-let n) (let ((m (- n 1))) (if (< m 0) 'done (count-let m))))
+          (define (count-begin n) (begin (if (== n 0) 'done (count-begin (- n 1)))))
+          (define (count-and n) (and #t (or (== n 0) (count-and (- n 1)))))
+          (define (ping n) (if (== n 0) 'ping (pong (- n 1))))
+          (define (pong n) (if (== n 0) 'pong (ping (- n 1)))))
+
+         (it "should loop through if"
+             (assert-eq (count-down 20000) 'done))
+
+         (it "should loop through cond and case"
+             (assert-eq (count-cond 20000) 'done)
+             (assert-eq (count-case 20000) 'done))
+
+         (it "should loop through when, let and begin"
+             (assert-nil (count-when 20000))
+             (assert-eq (count-let 20000) 'done)
+             (assert-eq (count-begin 20000) 'done))
+
+         (it "should loop through and and or"
+             (assert-true (count-and 20000)))
+
+         (it "should loop through mutual recursion"
+             (assert-eq (ping 20001) 'pong))
+
+         (it "should loop in a named let"
+             (assert-eq (let loop ((i 0) (acc 0))
+                          (if (== i 20000) acc (loop (+ i 1) (+ acc 2))))
+                        40000))
+
+         (it "should still return values from non-tail calls"
+             (assert-eq (+ 1 (length (list (count-down 10) (count-down 0)))) 3)
+             (assert-eq (list (count-down 3)) '(done)))
+
+         (it "should raise errors from tail called functions"
+             (define (fail n) (if (== n 0) (error "bottom") (fail (- n 1))))
+             (assert-error (fail 100))
+             (assert-true (substring? "bottom" (on-error (fail 100) (lambda (e) e)))))
+
+         (it "should check arity of tail calls"
+             (define (wrong) (count-down 1 2))
+             (assert-error (wrong))))
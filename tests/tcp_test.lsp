@@ -0,0 +1,48 @@
+;;; -*- mode: Scheme -*-
+
+(context "tcp sockets"
+
+         ((define listener (tcp-listen 0 "127.0.0.1"))
+          (define port-number (tcp-listener-port listener)))
+
+         (it "should make a listener"
+             (assert-true (tcp-listener? listener))
+             (assert-false (tcp-listener? port-number))
+             (assert-true (> port-number 0)))
+
+         (it "should connect and exchange lines"
+             (define server (fork (lambda ()
+                                    (let* ((conn (tcp-accept listener))
+                                           (line (read-line conn)))
+                                      (write-string (string-upcase line) conn)
+                                      (newline conn)
+                                      (close-port conn)
+                                      line))))
+             (define client (tcp-connect "127.0.0.1" port-number))
+             (write-string "hello" client)
+             (newline client)
+             (assert-eq (read-line client) "HELLO")
+             (assert-true (eof-object? (read-line client)))
+             (close-port client)
+             (assert-eq (proc-join server) "hello"))
+
+         (it "should fork a handler for each accepted connection"
+             (define acceptor (fork (lambda ()
+                                      (tcp-accept listener
+                                                  (lambda (conn)
+                                                    (write-string (read-line conn) conn)
+                                                    (close-port conn))))))
+             (define client (tcp-connect "127.0.0.1" port-number))
+             (write-string "echo" client)
+             (newline client)
+             (assert-eq (read-line client) "echo")
+             (close-port client)
+             (assert-eq (proc-status (proc-join acceptor)) 'completed))
+
+         (it "should report connection errors"
+             (close-port listener)
+             (assert-error (tcp-accept listener))
+             (assert-error (tcp-connect "127.0.0.1" port-number))
+             (assert-error (tcp-connect "127.0.0.1" 70000))
+             (assert-error (tcp-connect 1 80))
+             (assert-error (tcp-accept 1))))
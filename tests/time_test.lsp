@@ -0,0 +1,33 @@
+;;; -*- mode: Scheme -*-
+
+(context "time"
+
+         ()
+
+         (it "reads the clock"
+             (assert-true (integer? (current-time)))
+             (assert-true (<= (current-milliseconds) (millis)))
+             (assert-true (<= (quotient (current-time) 1000000) (current-milliseconds))))
+
+         (it "reads the clock in a forked process"
+             (assert-true (integer? (proc-join (fork (lambda () (current-time)))))))
+
+         (it "parses times"
+             (assert-eq (time-parse "1970-01-02" "2006-01-02") 86400000000000)
+             (assert-eq (time-parse "1970-01-01T00:00:01+01:00" "2006-01-02T15:04:05Z07:00") -3599000000000))
+
+         (it "formats what it parses"
+             (let ((now (current-time))
+                   (layout "2006-01-02T15:04:05.999999999Z07:00"))
+               (assert-eq (time-parse (time-format now layout) layout) now)))
+
+         (it "rejects bad times"
+             (assert-error (time-parse "yesterday" "2006-01-02"))
+             (assert-error (time-format "now" "2006-01-02"))
+             (assert-error (time-format 0 'layout)))
+
+         (it "times an expression"
+             (receive (elapsed value) (time (begin (sleep 10) 'done))
+                      (assert-true (>= elapsed 10))
+                      (assert-eq value 'done))
+             (assert-true (integer? (time 1)))))
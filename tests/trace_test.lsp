@@ -0,0 +1,46 @@
+;;; -*- mode: Scheme -*-
+
+(context "trace"
+
+         ((define path "/tmp/golisp-trace-test.txt")
+          (define (fact n) (if (< n 2) 1 (* n (fact (- n 1)))))
+          (define (read-lines port)
+            (let ((line (read-line port)))
+              (if (eof-object? line)
+                  '()
+                  (cons line (read-lines port)))))
+          (define (traced-output thunk)
+            (call-with-output-file path
+                                   (lambda (port)
+                                     (trace fact port)
+                                     (thunk)
+                                     (untrace fact)))
+            (let* ((port (open-input-file path))
+                   (lines (read-lines port)))
+              (close-port port)
+              lines)))
+
+         (it "prints calls and results indented by depth"
+             (assert-eq (traced-output (lambda () (fact 3)))
+                        '("(fact 3)"
+                          "  (fact 2)"
+                          "    (fact 1)"
+                          "    fact => 1"
+                          "  fact => 2"
+                          "fact => 6")))
+
+         (it "returns the function's result"
+             (let ((result 0))
+               (traced-output (lambda () (set! result (fact 5))))
+               (assert-eq result 120)))
+
+         (it "restores the function on untrace"
+             (traced-output (lambda () (fact 1)))
+             (assert-true (function? fact))
+             (assert-eq (fact 4) 24))
+
+         (it "rejects what it can't trace"
+             (assert-error (trace 1))
+             (assert-error (trace car))
+             (assert-error (trace no-such-function))
+             (assert-error (untrace fact))))
@@ -0,0 +1,75 @@
+;;; -*- mode: Scheme -*-
+
+(define test-result '())
+
+(define (run-tests-reporting)
+  (with-output-to-string (lambda () (set! test-result (run-tests)))))
+
+(context "unit testing"
+
+         ((clear-tests))
+
+         (it "passes checks that hold"
+             (assert-true (check-equal (+ 1 2) 3))
+             (assert-true (check-equal '(a b) (list 'a 'b)))
+             (assert-true (check-true (memq 'b '(a b c))))
+             (assert-true (check-error (error "boom"))))
+
+         (it "signals a check-failure for checks that don't"
+             (assert-error (check-equal (+ 1 2) 4))
+             (assert-error (check-true (== 1 2)))
+             (assert-error (check-error (+ 1 2)))
+             (assert-eq (handler-case (check-equal (+ 1 2) 4)
+                          (check-failure (c) (check-failure-message c)))
+                        "(+ 1 2) is 3 but 4 was expected."))
+
+         (it "checks the type of condition raised"
+             (define-condition bad-input () ())
+             (assert-true (check-error (signal (make-condition 'bad-input)) bad-input))
+             (assert-true (check-error (signal (make-condition 'bad-input)) error))
+             (assert-true (check-error (error "boom") simple-error))
+             (assert-eq (handler-case (check-error (error "boom") bad-input)
+                          (check-failure (c) (check-failure-message c)))
+                        "(error \"boom\") raised a simple-error but a bad-input was expected.")
+             (assert-error (check-error (error "boom") no-such-condition)))
+
+         (it "reports all passing"
+             (define-test arithmetic (check-equal (* 2 3) 6))
+             (define-test lists (check-true (pair? '(1))))
+             (assert-eq (run-tests-reporting) "Ran 2 tests: 2 passed, 0 failed, 0 errors.\n")
+             (assert-true test-result))
+
+         (it "runs the rest after a failure or an error"
+             (define-test first (check-equal 1 2))
+             (define-test second (error "oops"))
+             (define-test third (check-true #t))
+             (let ((report (run-tests-reporting)))
+               (assert-false test-result)
+               (assert-true (string-prefix? "FAIL first: 1 is 1 but 2 was expected.\nERROR second: " report))
+               (assert-true (string-suffix? "Ran 3 tests: 1 passed, 1 failed, 1 errors.\n" report))))
+
+         (it "stops a test at its first failing check"
+             (define reached #f)
+             (define-test stops (check-true #f) (set! reached #t))
+             (run-tests-reporting)
+             (assert-false reached))
+
+         (it "replaces a test defined again"
+             (define-test again (check-true #f))
+             (define-test again (check-true #t))
+             (assert-eq (run-tests-reporting) "Ran 1 tests: 1 passed, 0 failed, 0 errors.\n"))
+
+         (it "keeps a test's definitions to itself"
+             (define-test defines (define only-here 1) (check-equal only-here 1))
+             (run-tests-reporting)
+             (assert-true test-result)
+             (assert-false (bound? 'only-here)))
+
+         (it "forgets tests when cleared"
+             (define-test forgotten (check-true #f))
+             (clear-tests)
+             (assert-eq (run-tests-reporting) "Ran 0 tests: 0 passed, 0 failed, 0 errors.\n"))
+
+         (it "requires a name"
+             (assert-error (define-test (check-true #t)))))
+(clear-tests)
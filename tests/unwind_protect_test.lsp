@@ -0,0 +1,48 @@
+;;; -*- mode: Scheme -*-
+
+(context "unwind-protect"
+
+         ((define log '())
+          (define (note x) (set! log (append log (list x)))))
+
+         (it "should return the protected value after cleaning up"
+             (assert-eq (unwind-protect (+ 1 2) (note 'cleanup)) 3)
+             (assert-eq log '(cleanup)))
+
+         (it "should run every cleanup form"
+             (unwind-protect 'body (note 'one) (note 'two))
+             (assert-eq log '(one two)))
+
+         (it "should clean up when the body errors"
+             (assert-error (unwind-protect (error "boom") (note 'cleanup)))
+             (assert-eq log '(cleanup)))
+
+         (it "should keep the body's error"
+             (assert-true (substring? "body" (on-error (unwind-protect (error "body") (error "cleanup"))
+                                                       (lambda (e) e)))))
+
+         (it "should raise cleanup errors after a normal body"
+             (assert-error (unwind-protect 1 (error "cleanup"))))
+
+         (it "should clean up innermost first"
+             (assert-error (unwind-protect (unwind-protect (error "boom")
+                                             (note 'inner))
+                             (note 'outer)))
+             (assert-eq log '(inner outer)))
+
+         (it "should clean up when a process panics"
+             (define done (make-atomic 0))
+             (assert-error (proc-join (fork (lambda ()
+                                              (unwind-protect (panic! "go down")
+                                                (atomic-store! done 1))))))
+             (assert-eq (atomic-get done) 1))
+
+         (it "should clean up when a cancelled process returns early"
+             (define done (make-atomic 0))
+             (define p (fork (lambda (self)
+                               (unwind-protect (do () ((proc-cancelled? self) 'stopped)
+                                                 (sleep 1))
+                                 (atomic-store! done 1)))))
+             (proc-cancel p)
+             (assert-eq (proc-join p) 'stopped)
+             (assert-eq (atomic-get done) 1)))
@@ -0,0 +1,55 @@
+;;; -*- mode: Scheme -*-
+
+(context "values"
+
+         ((define (two) (values 1 2))
+          (define (none) (values)))
+
+         (it "should pass a single value through"
+             (assert-eq (values 5) 5)
+             (assert-eq (call-with-values (lambda () 5) list) '(5)))
+
+         (it "should hand values to call-with-values consumers"
+             (assert-eq (call-with-values two list) '(1 2))
+             (assert-eq (call-with-values two +) 3)
+             (assert-eq (call-with-values none list) '()))
+
+         (it "should use the first value where one is expected"
+             (assert-eq (+ (two) 10) 11)
+             (assert-eq (car (list (two))) 1)
+             (assert-nil (car (list (none))))
+             (define x (two))
+             (assert-eq x 1)
+             (let ((y (two)))
+               (assert-eq y 1))
+             (assert-eq (if (values #f #t) 'yes 'no) 'no))
+
+         (it "should receive values"
+             (assert-eq (receive (a b) (two) (list b a)) '(2 1))
+             (assert-eq (receive (a . rest) (values 1 2 3) rest) '(2 3))
+             (assert-eq (receive all (two) all) '(1 2))
+             (assert-eq (receive (a) 7 a) 7))
+
+         (it "should bind let-values"
+             (assert-eq (let-values (((a b) (two))
+                                     ((c) (values 3)))
+                          (list a b c))
+                        '(1 2 3)))
+
+         (it "should evaluate let-values expressions outside the new bindings"
+             (define a 10)
+             (assert-eq (let-values (((a b) (two))
+                                     ((c) a))
+                          c)
+                        10))
+
+         (it "should check the number of values"
+             (assert-error (receive (a b c) (two) a))
+             (assert-error (receive (a) (two) a))
+             (assert-error (let-values (((a) (two))) a)))
+
+         (it "should check arguments"
+             (assert-error (call-with-values 1 list))
+             (assert-error (call-with-values two 1))
+             (assert-error (let-values (a) a))
+             (assert-error (receive (1) 1 1))))
@@ -0,0 +1,54 @@
+;;; -*- mode: Scheme -*-
+
+(context "vectors"
+
+         ((define v (make-vector 3 0)))
+
+         (it "should make vectors with a fill value"
+             (assert-eq (vector->list v) '(0 0 0))
+             (assert-eq (vector-length (make-vector 2)) 2)
+             (assert-nil (vector-ref (make-vector 2) 1)))
+
+         (it "should recognize vectors"
+             (assert-true (vector? v))
+             (assert-false (vector? '(0 0 0))))
+
+         (it "should set and get elements"
+             (vector-set! v 1 'b)
+             (assert-eq (vector-ref v 1) 'b)
+             (assert-eq (vector->list v) '(0 b 0)))
+
+         (it "should bounds check indices"
+             (assert-error (vector-ref v 3))
+             (assert-error (vector-ref v -1))
+             (assert-error (vector-set! v 5 'x))
+             (assert-error (vector-ref v 'a)))
+
+         (it "should name the index and length in bounds errors"
+             (assert-true (substring? "index 3" (on-error (vector-ref v 3) (lambda (e) e))))
+             (assert-true (substring? "length 3" (on-error (vector-ref v 3) (lambda (e) e)))))
+
+         (it "should convert to and from lists"
+             (assert-eq (vector->list (list->vector '(1 2 3))) '(1 2 3))
+             (assert-eq (vector->list (vector 1 2)) '(1 2))
+             (assert-error (list->vector 5)))
+
+         (it "should fill"
+             (vector-fill! v 'z)
+             (assert-eq (vector->list v) '(z z z)))
+
+         (it "should compare by contents"
+             (assert-eq (vector 1 2) (vector 1 2))
+             (assert-false (equal? (vector 1 2) (vector 1 2 3))))
+
+         (it "should read literals"
+             (assert-eq (vector-ref #(1 2 3) 2) 3)
+             (assert-eq (vector->list #(a (b c))) '(a (b c)))
+             (assert-eq (vector-length #()) 0))
+
+         (it "should not allow changing literals"
+             (assert-error (vector-set! #(1 2) 0 5))
+             (assert-error (vector-fill! #(1 2) 0)))
+
+         (it "should print as a literal"
+             (assert-eq (str (vector 1 "a" 'b)) "#(1 \"a\" b)")))
@@ -0,0 +1,32 @@
+;;; -*- mode: Scheme -*-
+
+(context "weak references"
+
+         ()
+
+         (it "refers to its value"
+             (let* ((value (list 1 2 3))
+                    (ref (make-weak-ref value)))
+               (assert-true (eqv? (weak-ref-value ref) value))))
+
+         (it "can be one of several to the same value"
+             (let* ((value (vector 1 2))
+                    (a (make-weak-ref value))
+                    (b (make-weak-ref value)))
+               (assert-true (eqv? (weak-ref-value a) value))
+               (assert-true (eqv? (weak-ref-value b) value))))
+
+         (it "is recognized"
+             (assert-true (weak-ref? (make-weak-ref "x")))
+             (assert-false (weak-ref? "x")))
+
+         (it "prints without its value"
+             (assert-eq (format #f "~A" (make-weak-ref 'x)) "<weak ref>"))
+
+         (it "is described with its value"
+             (let ((value (list 1 2)))
+               (assert-eq (with-output-to-string (lambda () (describe (make-weak-ref value))))
+                          "<weak ref>\n  type: Weak Reference\n  value: (1 2)\n")))
+
+         (it "requires a weak reference for its value"
+             (assert-error (weak-ref-value 'x))))
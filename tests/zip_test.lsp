@@ -0,0 +1,66 @@
+;;; -*- mode: Scheme -*-
+
+(context zip
+
+         ()
+
+         (it pairs-corresponding-elements
+             (assert-eq (zip '(1 2 3) '(a b c))
+                        '((1 a) (2 b) (3 c)))
+             (assert-eq (zip '(1 2) '(a b) '("x" "y"))
+                        '((1 a "x") (2 b "y"))))
+
+         (it stops-at-the-shortest-list
+             (assert-eq (zip '(1 2 3) '(a))
+                        '((1 a)))
+             (assert-eq (zip '(1 2 3) '())
+                        '()))
+
+         (it handles-a-single-list
+             (assert-eq (zip '(1 2 3))
+                        '((1) (2) (3))))
+
+         (it errors
+             (assert-error (zip '(1 2) 3))))
+
+(context zip-with
+
+         ()
+
+         (it combines-corresponding-elements
+             (assert-eq (zip-with + '(1 2 3) '(10 20 30))
+                        '(11 22 33))
+             (assert-eq (zip-with list '(1 2 3) '(a b))
+                        '((1 a) (2 b)))
+             (assert-eq (zip-with (lambda (x) (* x x)) '(1 2 3))
+                        '(1 4 9))
+             (assert-eq (zip-with + '() '(1 2))
+                        '()))
+
+         (it errors
+             (assert-error (zip-with 5 '(1 2)))
+             (assert-error (zip-with + '(1 2) 'a))))
+
+(context unzip
+
+         ()
+
+         (it undoes-zip
+             (assert-eq (unzip '((1 a) (2 b) (3 c)))
+                        '((1 2 3) (a b c)))
+             (assert-eq (apply zip (unzip '((1 a "x") (2 b "y"))))
+                        '((1 a "x") (2 b "y"))))
+
+         (it truncates-uneven-tuples
+             (assert-eq (unzip '((1 a x) (2 b)))
+                        '((1 2) (a b))))
+
+         (it handles-empty-lists
+             (assert-eq (unzip '())
+                        '())
+             (assert-eq (unzip '(() (1)))
+                        '()))
+
+         (it errors
+             (assert-error (unzip 5))
+             (assert-error (unzip '((1 2) 3)))))
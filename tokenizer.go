@@ -14,6 +14,7 @@ import (
 	"os"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -23,6 +24,9 @@ const (
 	HEXNUMBER
 	BINARYNUMBER
 	FLOAT
+	RATIONAL
+	COMPLEX
+	CHARACTER
 	STRING
 	QUOTE
 	BACKQUOTE
@@ -34,28 +38,45 @@ const (
 	RBRACKET
 	LBRACE
 	RBRACE
+	HASHLPAREN
 	PERIOD
 	TRUE
 	FALSE
+	READERMACRO
 	COMMENT
 	EOF
 )
 
 type Tokenizer struct {
-	LookaheadToken int
-	LookaheadLit   string
-	Source         *bufrr.Reader
-	CurrentCh      rune
-	NextCh         rune
-	Eof            bool
-	AlmostEof      bool
+	LookaheadToken    int
+	LookaheadLit      string
+	LookaheadPosition int
+	LookaheadLine     int
+	LookaheadColumn   int
+	File              string
+	Source            *bufrr.Reader
+	CurrentCh         rune
+	NextCh            rune
+	Position          int
+	nextPosition      int
+	Line              int
+	Column            int
+	Eof               bool
+	AlmostEof         bool
+	recording         bool
+	tokenText         []rune
+	textPosition      int
+	textLine          int
+	textColumn        int
+	pushback          []rune
+	readTableVersion  int64
 }
 
 var mostRecentFileTokenizer *Tokenizer
 var mostRecentlyUsedFile *os.File
 
 func NewTokenizer(scanner *bufrr.Reader) *Tokenizer {
-	t := &Tokenizer{Source: scanner}
+	t := &Tokenizer{Source: scanner, Line: 1}
 	t.Advance()
 	t.ConsumeToken()
 	return t
@@ -70,25 +91,87 @@ func NewTokenizerFromFile(src *os.File) *Tokenizer {
 		return mostRecentFileTokenizer
 	} else {
 		t := NewTokenizer(bufrr.NewReader(src))
+		t.File = src.Name()
 		mostRecentFileTokenizer = t
 		mostRecentlyUsedFile = src
 		return t
 	}
 }
 
+// Advance moves to the next character. Position is the byte offset of the
+// current character, or the length of the source at the end, and Line and
+// Column are where it is in the source counting from 1.
 func (self *Tokenizer) Advance() {
 	var err error
-	self.CurrentCh, _, err = self.Source.ReadRune()
+	var size int
+	if self.recording && !self.Eof {
+		self.tokenText = append(self.tokenText, self.CurrentCh)
+	}
+	if self.CurrentCh == '\n' {
+		self.Line++
+		self.Column = 1
+	} else {
+		self.Column++
+	}
+	self.Position = self.nextPosition
+	if len(self.pushback) > 0 {
+		self.CurrentCh = self.pushback[0]
+		self.pushback = self.pushback[1:]
+		self.nextPosition += utf8.RuneLen(self.CurrentCh)
+		self.NextCh = self.peekRune()
+		return
+	}
+	self.CurrentCh, size, err = self.Source.ReadRune()
 	if err == io.EOF || self.CurrentCh == -1 {
 		self.Eof = true
 		self.NextCh = 0
 	} else {
-		self.NextCh, _, err = self.Source.ReadRune()
-		if err == io.EOF || self.NextCh == -1 {
-			self.AlmostEof = true
-		} else {
-			self.Source.UnreadRune()
-		}
+		self.nextPosition += size
+		self.NextCh = self.peekRune()
+	}
+}
+
+func (self *Tokenizer) peekRune() rune {
+	if len(self.pushback) > 0 {
+		return self.pushback[0]
+	}
+	ch, _, err := self.Source.ReadRune()
+	if err == io.EOF || ch == -1 {
+		self.AlmostEof = true
+		return 0
+	}
+	self.Source.UnreadRune()
+	return ch
+}
+
+// unreadLookahead puts the text read for the lookahead token back in front
+// of the current character so that it can be read again.
+func (self *Tokenizer) unreadLookahead() {
+	if len(self.tokenText) == 0 {
+		return
+	}
+	pending := append([]rune{}, self.tokenText[1:]...)
+	if !self.Eof {
+		pending = append(pending, self.CurrentCh)
+	}
+	self.pushback = append(pending, self.pushback...)
+	self.CurrentCh = self.tokenText[0]
+	self.tokenText = self.tokenText[:0]
+	self.Eof = false
+	self.AlmostEof = false
+	self.Position = self.textPosition
+	self.nextPosition = self.Position + utf8.RuneLen(self.CurrentCh)
+	self.Line = self.textLine
+	self.Column = self.textColumn
+	self.NextCh = self.peekRune()
+}
+
+// Relex reads the lookahead token again, for when the read table has
+// changed since it was read.
+func (self *Tokenizer) Relex() {
+	if self.readTableVersion != currentReadTableVersion() {
+		self.unreadLookahead()
+		self.ConsumeToken()
 	}
 }
 
@@ -106,7 +189,11 @@ func (self *Tokenizer) readSymbol() (token int, lit string) {
 		buffer = append(buffer, self.CurrentCh)
 		self.Advance()
 	}
-	return SYMBOL, string(buffer)
+	// +i and -i start like symbols, but are the imaginary unit and its negation.
+	if lit = string(buffer); lit == "+i" || lit == "-i" {
+		return COMPLEX, lit
+	}
+	return SYMBOL, lit
 }
 
 func isHexChar(ch rune) bool {
@@ -162,15 +249,33 @@ func (self *Tokenizer) readBinaryNumber() (token int, lit string) {
 func (self *Tokenizer) readNumber() (token int, lit string) {
 	buffer := make([]rune, 0, 1)
 	isFloat := false
+	isRational := false
+	isComplex := false
 	sawDecimal := false
 	firstChar := true
 	for !self.isEof() {
 		ch := rune(self.CurrentCh)
-		if ch == '.' && !sawDecimal {
+		if ch == 'i' && !firstChar && !self.isSymbolCharacter(self.NextCh) {
+			isComplex = true
+			buffer = append(buffer, self.CurrentCh)
+			self.Advance()
+			break
+		} else if (ch == '+' || ch == '-') && !firstChar && !isComplex && (unicode.IsNumber(self.NextCh) || self.NextCh == 'i') {
+			isComplex = true
+			isFloat = false
+			isRational = false
+			sawDecimal = false
+			buffer = append(buffer, self.CurrentCh)
+			self.Advance()
+		} else if ch == '.' && !sawDecimal && !isRational {
 			isFloat = true
 			sawDecimal = true
 			buffer = append(buffer, self.CurrentCh)
 			self.Advance()
+		} else if ch == '/' && !isFloat && !isRational && !firstChar && unicode.IsNumber(rune(self.NextCh)) {
+			isRational = true
+			buffer = append(buffer, self.CurrentCh)
+			self.Advance()
 		} else if firstChar && ch == '-' {
 			buffer = append(buffer, self.CurrentCh)
 			self.Advance()
@@ -184,14 +289,33 @@ func (self *Tokenizer) readNumber() (token int, lit string) {
 	}
 
 	lit = string(buffer)
-	if isFloat {
+	if isComplex {
+		token = COMPLEX
+	} else if isFloat {
 		token = FLOAT
+	} else if isRational {
+		token = RATIONAL
 	} else {
 		token = NUMBER
 	}
 	return
 }
 
+// readCharacter reads what follows #\: a single character, which may be a
+// delimiter, or the name of one such as space or x41.
+func (self *Tokenizer) readCharacter() (token int, lit string) {
+	if self.isEof() {
+		return ILLEGAL, "#\\"
+	}
+	buffer := []rune{self.CurrentCh}
+	self.Advance()
+	for !self.isEof() && unicode.IsLetter(buffer[0]) && self.isSymbolCharacter(self.CurrentCh) {
+		buffer = append(buffer, self.CurrentCh)
+		self.Advance()
+	}
+	return CHARACTER, string(buffer)
+}
+
 func (self *Tokenizer) readString() (token int, lit string) {
 	buffer := make([]rune, 0, 10)
 	self.Advance()
@@ -226,16 +350,25 @@ func (self *Tokenizer) isAlmostEof() bool {
 
 func (self *Tokenizer) readNextToken() (token int, lit string) {
 	if self.isEof() {
+		self.LookaheadPosition = self.Position
 		return EOF, ""
 	}
 	for unicode.IsSpace(self.CurrentCh) {
 		self.Advance()
 		if self.isEof() {
+			self.LookaheadPosition = self.Position
 			return EOF, ""
 		}
 	}
+	self.LookaheadPosition = self.Position
+	self.LookaheadLine = self.Line
+	self.LookaheadColumn = self.Column
 
-	if self.CurrentCh == '0' && self.NextCh == 'x' {
+	if customReaderMacroP(self.CurrentCh) {
+		ch := self.CurrentCh
+		self.Advance()
+		return READERMACRO, string(ch)
+	} else if self.CurrentCh == '0' && self.NextCh == 'x' {
 		self.Advance()
 		self.Advance()
 		return self.readHexNumber()
@@ -295,6 +428,20 @@ func (self *Tokenizer) readNextToken() (token int, lit string) {
 		} else if self.CurrentCh == 'b' {
 			self.Advance()
 			return self.readBinaryNumber()
+		} else if self.CurrentCh == '(' {
+			self.Advance()
+			return HASHLPAREN, "#("
+		} else if self.CurrentCh == '\\' {
+			self.Advance()
+			return self.readCharacter()
+		} else if self.CurrentCh == '!' {
+			self.Advance()
+			_, name := self.readSymbol()
+			switch name {
+			case "optional", "rest", "key":
+				return SYMBOL, "#!" + name
+			}
+			return ILLEGAL, "#!" + name
 		} else {
 			return ILLEGAL, fmt.Sprintf("#%c", self.NextCh)
 		}
@@ -315,9 +462,49 @@ func (self *Tokenizer) readNextToken() (token int, lit string) {
 	}
 }
 
+// ConsumeToken reads the next token into the lookahead, skipping comments.
+// The text it reads, including any whitespace and comments before the
+// token, is kept so that unreadLookahead can put it back.
 func (self *Tokenizer) ConsumeToken() {
-	self.LookaheadToken, self.LookaheadLit = self.readNextToken()
-	if self.LookaheadToken == COMMENT { // skip comments
-		self.ConsumeToken()
+	self.tokenText = self.tokenText[:0]
+	self.textPosition, self.textLine, self.textColumn = self.Position, self.Line, self.Column
+	self.readTableVersion = currentReadTableVersion()
+	self.recording = true
+	for {
+		self.LookaheadToken, self.LookaheadLit = self.readNextToken()
+		if self.LookaheadToken != COMMENT {
+			break
+		}
+	}
+	self.recording = false
+}
+
+// InputComplete is whether src holds whole forms: every list, vector,
+// bytearray, frame and string it opens is closed, and it doesn't end with a
+// quote waiting for the form it quotes. Extra closers count as complete so
+// that the parser gets to report them.
+func InputComplete(src string) bool {
+	t := NewTokenizerFromString(src)
+	depth := 0
+	last := EOF
+	for {
+		token, _ := t.NextToken()
+		switch token {
+		case EOF:
+			if strings.TrimSpace(src[t.LookaheadPosition:]) != "" {
+				return false // an unterminated string
+			}
+			switch last {
+			case QUOTE, BACKQUOTE, COMMA, COMMAAT:
+				return false
+			}
+			return depth <= 0
+		case LPAREN, LBRACKET, LBRACE, HASHLPAREN:
+			depth++
+		case RPAREN, RBRACKET, RBRACE:
+			depth--
+		}
+		last = token
+		t.ConsumeToken()
 	}
 }
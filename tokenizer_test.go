@@ -149,6 +149,20 @@ func (s *TokenizerSuite) TestNegativeFloat(c *C) {
 	c.Assert(lit, Equals, "-12.345")
 }
 
+func (s *TokenizerSuite) TestRational(c *C) {
+	t := NewTokenizerFromString("-3/4 a")
+	tok, lit := t.NextToken()
+	c.Assert(tok, Equals, RATIONAL)
+	c.Assert(lit, Equals, "-3/4")
+}
+
+func (s *TokenizerSuite) TestIntegerBeforeSlashSymbol(c *C) {
+	t := NewTokenizerFromString("3/a")
+	tok, lit := t.NextToken()
+	c.Assert(tok, Equals, NUMBER)
+	c.Assert(lit, Equals, "3")
+}
+
 func (s *TokenizerSuite) TestString(c *C) {
 	t := NewTokenizerFromString(`"hi" a`)
 	tok, lit := t.NextToken()
@@ -219,6 +233,13 @@ func (s *TokenizerSuite) TestRBracket(c *C) {
 	c.Assert(lit, Equals, `]`)
 }
 
+func (s *TokenizerSuite) TestHashLParen(c *C) {
+	t := NewTokenizerFromString(`#(1 2)`)
+	tok, lit := t.NextToken()
+	c.Assert(tok, Equals, HASHLPAREN)
+	c.Assert(lit, Equals, `#(`)
+}
+
 func (s *TokenizerSuite) TestPeriod(c *C) {
 	t := NewTokenizerFromString(`. a`)
 	tok, lit := t.NextToken()
@@ -337,3 +358,84 @@ func (s *TokenizerSuite) TestTrue(c *C) {
 	c.Assert(tok, Equals, TRUE)
 	c.Assert(lit, Equals, `#t`)
 }
+
+func (s *TokenizerSuite) TestLookaheadPosition(c *C) {
+	t := NewTokenizerFromString("  (héllo) x")
+	c.Assert(t.LookaheadPosition, Equals, 2)
+	t.ConsumeToken()
+	c.Assert(t.LookaheadPosition, Equals, 3)
+	t.ConsumeToken()
+	c.Assert(t.LookaheadPosition, Equals, 9)
+	t.ConsumeToken()
+	c.Assert(t.LookaheadPosition, Equals, 11)
+	t.ConsumeToken()
+	tok, _ := t.NextToken()
+	c.Assert(tok, Equals, EOF)
+	c.Assert(t.LookaheadPosition, Equals, 12)
+}
+
+func (s *TokenizerSuite) TestComplex(c *C) {
+	t := NewTokenizerFromString("3-4.5i 1+ a")
+	tok, lit := t.NextToken()
+	c.Assert(tok, Equals, COMPLEX)
+	c.Assert(lit, Equals, "3-4.5i")
+	t.ConsumeToken()
+	tok, lit = t.NextToken()
+	c.Assert(tok, Equals, NUMBER)
+	c.Assert(lit, Equals, "1")
+}
+
+func (s *TokenizerSuite) TestImaginaryUnit(c *C) {
+	t := NewTokenizerFromString("+i -i +in")
+	tok, lit := t.NextToken()
+	c.Assert(tok, Equals, COMPLEX)
+	c.Assert(lit, Equals, "+i")
+	t.ConsumeToken()
+	tok, lit = t.NextToken()
+	c.Assert(tok, Equals, COMPLEX)
+	c.Assert(lit, Equals, "-i")
+	t.ConsumeToken()
+	tok, lit = t.NextToken()
+	c.Assert(tok, Equals, SYMBOL)
+	c.Assert(lit, Equals, "+in")
+}
+
+func (s *TokenizerSuite) TestCharacter(c *C) {
+	t := NewTokenizerFromString(`#\space #\)`)
+	tok, lit := t.NextToken()
+	c.Assert(tok, Equals, CHARACTER)
+	c.Assert(lit, Equals, "space")
+	t.ConsumeToken()
+	tok, lit = t.NextToken()
+	c.Assert(tok, Equals, CHARACTER)
+	c.Assert(lit, Equals, ")")
+}
+
+func (s *TokenizerSuite) TestLambdaListMarker(c *C) {
+	t := NewTokenizerFromString(`#!optional #!bogus`)
+	tok, lit := t.NextToken()
+	c.Assert(tok, Equals, SYMBOL)
+	c.Assert(lit, Equals, "#!optional")
+	t.ConsumeToken()
+	tok, _ = t.NextToken()
+	c.Assert(tok, Equals, ILLEGAL)
+}
+
+func (s *TokenizerSuite) TestInputComplete(c *C) {
+	c.Assert(InputComplete(""), Equals, true)
+	c.Assert(InputComplete("42"), Equals, true)
+	c.Assert(InputComplete("(+ 1 2)"), Equals, true)
+	c.Assert(InputComplete("(define (f x)\n  (* x x))"), Equals, true)
+	c.Assert(InputComplete("(+ 1 2))"), Equals, true)
+	c.Assert(InputComplete(`(display ")")`), Equals, true)
+	c.Assert(InputComplete("(+ 1 2) ; (unclosed"), Equals, true)
+
+	c.Assert(InputComplete("(+ 1"), Equals, false)
+	c.Assert(InputComplete("(define (f x)\n"), Equals, false)
+	c.Assert(InputComplete("#(1 2"), Equals, false)
+	c.Assert(InputComplete("{a: 1"), Equals, false)
+	c.Assert(InputComplete("[1 2"), Equals, false)
+	c.Assert(InputComplete(`(display "unfinished`), Equals, false)
+	c.Assert(InputComplete(`"a (`), Equals, false)
+	c.Assert(InputComplete("'"), Equals, false)
+}
@@ -8,17 +8,18 @@
 package golisp
 
 func ArrayToList(sexprs []*Data) *Data {
-	head := Cons(nil, EmptyCons())
-	lastCell := head
-	for _, element := range sexprs {
+	if len(sexprs) == 0 {
+		return EmptyCons()
+	}
+	var list *Data
+	for i := len(sexprs) - 1; i >= 0; i-- {
+		element := sexprs[i]
 		if element == nil {
 			element = EmptyCons()
 		}
-		newCell := Cons(element, nil)
-		ConsValue(lastCell).Cdr = newCell
-		lastCell = newCell
+		list = Cons(element, list)
 	}
-	return Cdr(head)
+	return list
 }
 
 func ArrayToListWithTail(sexprs []*Data, tail *Data) *Data {